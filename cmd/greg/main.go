@@ -20,6 +20,7 @@ import (
 	"github.com/justchokingaround/greg/internal/database"
 	"github.com/justchokingaround/greg/internal/downloader"
 	"github.com/justchokingaround/greg/internal/providers"
+	_ "github.com/justchokingaround/greg/internal/providers/all"
 	"github.com/justchokingaround/greg/internal/registry"
 	"github.com/justchokingaround/greg/internal/tracker"
 	"github.com/justchokingaround/greg/internal/tracker/anilist"
@@ -130,6 +131,8 @@ and concurrent downloads.`,
 				logger.Debug("registered provider", "name", name)
 			}
 		}
+		providers.GetRegistry().SetCacheDir(cfg.Cache.Path)
+		providers.ConfigureAll(cfg, logger)
 
 		// Setup hot reload
 		v.WatchConfig()
@@ -153,6 +156,7 @@ and concurrent downloads.`,
 					logger.Warn("failed to register provider", "name", name, "error", err)
 				}
 			}
+			providers.ConfigureAll(cfg, logger)
 			logger.Info("Providers reloaded")
 		})
 
@@ -191,7 +195,7 @@ and concurrent downloads.`,
 		}
 		if animeProvider != nil {
 			providerMap[providers.MediaTypeAnime] = animeProvider
-			logger.Info("using anime provider", "provider", animeProvider.Name())
+			logger.Info("using anime provider", "provider", animeProvider.Name(), "provider_version", providers.VersionOf(animeProvider))
 		}
 
 		// Get movie provider - use configured default
@@ -210,14 +214,14 @@ and concurrent downloads.`,
 			providerMap[providers.MediaTypeMovieTV] = movieProvider
 			providerMap[providers.MediaTypeMovie] = movieProvider
 			providerMap[providers.MediaTypeTV] = movieProvider
-			logger.Info("using movie provider", "provider", movieProvider.Name())
+			logger.Info("using movie provider", "provider", movieProvider.Name(), "provider_version", providers.VersionOf(movieProvider))
 		}
 
 		// Get manga provider
 		mangaProviders := providers.GetByType(providers.MediaTypeManga)
 		if len(mangaProviders) > 0 {
 			providerMap[providers.MediaTypeManga] = mangaProviders[0]
-			logger.Info("using manga provider", "provider", mangaProviders[0].Name())
+			logger.Info("using manga provider", "provider", mangaProviders[0].Name(), "provider_version", providers.VersionOf(mangaProviders[0]))
 		}
 
 		if len(providerMap) == 0 {
@@ -226,6 +230,7 @@ and concurrent downloads.`,
 
 		// Initialize tracker manager
 		trackerMgr := tracker.NewManager(cfg, database.DB)
+		trackerMgr.SetLogger(logger)
 
 		// Initialize AniList if enabled
 		if cfg.Tracker.AniList.Enabled {
@@ -240,6 +245,19 @@ and concurrent downloads.`,
 
 			if anilistClient.IsAuthenticated() {
 				logger.Info("AniList authenticated")
+				if cfg.Tracker.AniList.AutoSync {
+					syncCtx, stopSync := context.WithCancel(context.Background())
+					syncDone := make(chan struct{})
+					go func() {
+						defer close(syncDone)
+						trackerMgr.RunSync(syncCtx)
+					}()
+					// Stop the loop on exit; RunSync does a final flush first
+					defer func() {
+						stopSync()
+						<-syncDone
+					}()
+				}
 			} else {
 				logger.Info("AniList not authenticated (run 'greg auth anilist' to authenticate)")
 			}
@@ -324,6 +342,7 @@ func init() {
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(searchCmd)
 	rootCmd.AddCommand(providersCmd)
+	rootCmd.AddCommand(cacheCmd)
 	rootCmd.AddCommand(authCmd)
 	rootCmd.AddCommand(downloadCmd)
 	rootCmd.AddCommand(watchpartyCmd)
@@ -607,14 +626,15 @@ var downloadCmd = &cobra.Command{
 			}
 
 			// Get the stream URL
-			parsedQuality := providers.Quality1080p
+			var requestedQuality providers.Quality
 			if quality != "" {
 				if q, err := providers.ParseQuality(quality); err == nil {
-					parsedQuality = q
+					requestedQuality = q
 				} else {
-					fmt.Fprintf(os.Stderr, "Invalid quality %s, using default 1080p\n", quality)
+					fmt.Fprintf(os.Stderr, "Invalid quality %s, using configured default\n", quality)
 				}
 			}
+			parsedQuality := providers.SelectQuality(requestedQuality, provider.Name(), cfg)
 
 			stream, err := provider.GetStreamURL(ctx, episodeID, parsedQuality)
 			if err != nil {
@@ -691,28 +711,43 @@ var downloadCmd = &cobra.Command{
 			return nil
 		} else {
 			// This is TV/anime with episodes
-			// For now, just download the first season
 			if len(mediaDetails.Seasons) == 0 {
 				return fmt.Errorf("no seasons found for %s", mediaDetails.Title)
 			}
 
-			// Get episodes for the first season
-			episodes, err := provider.GetEpisodes(ctx, mediaDetails.Seasons[0].ID)
-			if err != nil {
-				return fmt.Errorf("failed to get episodes: %w", err)
-			}
-
 			// Determine which episodes to download based on range
 			var targetEpisodes []providers.Episode
-			if episodeRange != "" {
-				// Parse episode range (e.g., "1-5", "1,3,5", "1-5,7,9")
-				targetEpisodes, err = providers.ParseEpisodeRange(episodes, episodeRange)
+			if downloader.IsSeasonSpec(episodeRange) {
+				// Season-aware selection (e.g., "S01E03-E08", "S02", "latest")
+				selectors, err := downloader.ParseEpisodeRange(episodeRange)
 				if err != nil {
 					return fmt.Errorf("failed to parse episode range: %w", err)
 				}
+				seasons, err := downloader.GetSeasonEpisodes(ctx, provider, mediaID, selectors)
+				if err != nil {
+					return err
+				}
+				targetEpisodes, err = downloader.SelectEpisodes(selectors, seasons)
+				if err != nil {
+					return err
+				}
 			} else {
-				// Default to all episodes if no range specified
-				targetEpisodes = episodes
+				// Plain episode numbers refer to the first season
+				episodes, err := provider.GetEpisodes(ctx, mediaDetails.Seasons[0].ID)
+				if err != nil {
+					return fmt.Errorf("failed to get episodes: %w", err)
+				}
+
+				if episodeRange != "" {
+					// Parse episode range (e.g., "1-5", "1,3,5", "1-5,7,9")
+					targetEpisodes, err = providers.ParseEpisodeRange(episodes, episodeRange)
+					if err != nil {
+						return fmt.Errorf("failed to parse episode range: %w", err)
+					}
+				} else {
+					// Default to all episodes if no range specified
+					targetEpisodes = episodes
+				}
 			}
 
 			// Initialize download manager
@@ -733,14 +768,15 @@ var downloadCmd = &cobra.Command{
 			}
 
 			// Download each episode
-			parsedQuality := providers.Quality1080p
+			var requestedQuality providers.Quality
 			if quality != "" {
 				if q, err := providers.ParseQuality(quality); err == nil {
-					parsedQuality = q
+					requestedQuality = q
 				} else {
-					fmt.Fprintf(os.Stderr, "Invalid quality %s, using default 1080p\n", quality)
+					fmt.Fprintf(os.Stderr, "Invalid quality %s, using configured default\n", quality)
 				}
 			}
+			parsedQuality := providers.SelectQuality(requestedQuality, provider.Name(), cfg)
 
 			for _, episode := range targetEpisodes {
 				fmt.Printf("Getting stream for %s - Episode %d...\n", mediaDetails.Title, episode.Number)
@@ -758,7 +794,7 @@ var downloadCmd = &cobra.Command{
 					MediaTitle: mediaDetails.Title,
 					MediaType:  mediaDetails.Type,
 					Episode:    episode.Number,
-					Season:     0, // We can extract this from episode data if needed
+					Season:     episode.Season,
 					Quality:    parsedQuality,
 					Provider:   provider.Name(),
 					StreamURL:  stream.URL,
@@ -882,9 +918,63 @@ var providersInfoCmd = &cobra.Command{
 	},
 }
 
+var providersSelfTestCmd = &cobra.Command{
+	Use:   "selftest <provider-name>",
+	Short: "Run an end-to-end search, info, episodes and stream test against a provider",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
+
+		result, err := providers.SelfTest(ctx, name)
+		if result.Query != "" {
+			fmt.Printf("Self-test for %s (query: %q)\n\n", result.Provider, result.Query)
+		}
+		for i, step := range result.Steps {
+			if step.Err != nil {
+				fmt.Printf("%d. %-8s ✗ %s (%v)\n", i+1, step.Name, step.Duration.Round(time.Millisecond), step.Err)
+				continue
+			}
+			fmt.Printf("%d. %-8s ✓ %s %s\n", i+1, step.Name, step.Duration.Round(time.Millisecond), step.Detail)
+		}
+		if err != nil {
+			return fmt.Errorf("self-test failed: %w", err)
+		}
+
+		fmt.Printf("\nAll steps passed in %s\n", result.Duration.Round(time.Millisecond))
+		return nil
+	},
+}
+
 func init() {
 	providersCmd.AddCommand(providersListCmd)
 	providersCmd.AddCommand(providersInfoCmd)
+	providersCmd.AddCommand(providersSelfTestCmd)
+}
+
+// cacheCmd manages cached provider data
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage cached provider data",
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Clear cached search results, media info and images",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := providers.ClearCache(); err != nil {
+			return fmt.Errorf("failed to clear cache: %w", err)
+		}
+
+		fmt.Printf("Cache cleared: %s\n", cfg.Cache.Path)
+		return nil
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheClearCmd)
 }
 
 // debugCmd provides debugging utilities
@@ -1148,10 +1238,14 @@ var authAniListCmd = &cobra.Command{
 		case "1":
 			// Option 1: Browser Authentication (Automatic Callback)
 			fmt.Println("Starting browser-based authentication...")
+			redirectURI := cfg.Tracker.AniList.RedirectURI
+			if redirectURI == "" {
+				redirectURI = anilist.AuthBrowserRedirectURI
+			}
 			authConfig := anilist.BrowserAuthConfig{
 				ClientID:     anilist.AuthBrowserClientID,
 				ClientSecret: anilist.AuthBrowserClientSecret,
-				RedirectURI:  anilist.AuthBrowserRedirectURI,
+				RedirectURI:  redirectURI,
 				ServerPort:   cfg.Tracker.AniList.ServerPort,
 			}
 			token, err := anilist.AuthenticateWithBrowser(context.Background(), authConfig, tokenStorage.SaveToken)
@@ -1300,7 +1394,7 @@ func init() {
 	// Add download command flags
 	downloadCmd.Flags().StringP("provider", "p", "", "provider to use (default: auto-detect by type)")
 	downloadCmd.Flags().StringP("type", "t", "anime", "media type: anime, movie, movies, tv, shows (default: anime)")
-	downloadCmd.Flags().StringP("episode", "e", "", "episode range (e.g., 1-5, 7, 9-12) - TV/anime only")
+	downloadCmd.Flags().StringP("episode", "e", "", "episode range (e.g., 1-5, 7, 9-12, S01E03-E08, S02, latest) - TV/anime only")
 	downloadCmd.Flags().StringP("quality", "q", "1080p", "video quality (360p, 480p, 720p, 1080p, etc.)")
 	downloadCmd.Flags().StringP("output", "o", "", "output directory (default: config setting)")
 
@@ -28,6 +28,7 @@ require (
 	github.com/diniamo/gopv v0.0.0-20251028165920-b71b8f821a6c
 	github.com/fsnotify/fsnotify v1.9.0
 	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c
+	golang.org/x/sync v0.19.0
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
@@ -47,6 +48,17 @@ type PlayerConfig struct {
 	AudioPreference string        `mapstructure:"audio_preference"`
 	LoadUserConfig  bool          `mapstructure:"load_user_config"`
 	IPCTimeout      time.Duration `mapstructure:"ipc_timeout"`
+	AutoplayNext    bool          `mapstructure:"autoplay_next"`
+
+	// QualityLadder overrides, by media type ("movie", "tv", "anime"), the
+	// qualities tried in order when the requested one isn't available
+	QualityLadder map[string][]string `mapstructure:"quality_ladder"`
+
+	// OpenSubtitles fills in subtitles a stream lacks in SubtitleLang. The
+	// login is optional and only raises the daily download quota.
+	OpenSubtitlesAPIKey   string `mapstructure:"opensubtitles_api_key"`
+	OpenSubtitlesUsername string `mapstructure:"opensubtitles_username"`
+	OpenSubtitlesPassword string `mapstructure:"opensubtitles_password"`
 }
 
 // ProvidersConfig contains provider settings
@@ -55,6 +67,11 @@ type ProvidersConfig struct {
 	Priority            PriorityProviders `mapstructure:"priority" yaml:"priority"`
 	HealthCheckInterval time.Duration     `mapstructure:"health_check_interval" yaml:"health_check_interval"`
 	AutoFailover        bool              `mapstructure:"auto_failover" yaml:"auto_failover"`
+	EpisodeNumbering    string            `mapstructure:"episode_numbering" yaml:"episode_numbering"`       // "season" or "absolute"
+	ExtractorKeySource  string            `mapstructure:"extractor_key_source" yaml:"extractor_key_source"` // URL serving the current megacloud keys
+	TMDBAPIKey          string            `mapstructure:"tmdb_api_key" yaml:"tmdb_api_key"`                 // Fills missing movie/TV details from TMDB when set
+	ExtractorAliases    map[string]string `mapstructure:"extractor_aliases" yaml:"extractor_aliases"`       // Server name -> extractor key (megacloud, vidcloud)
+	LanguagePreference  string            `mapstructure:"language_preference" yaml:"language_preference"`   // "sub", "dub" or "" to keep the site's order
 	AllAnime            ProviderSettings  `mapstructure:"allanime" yaml:"allanime"`
 	HiAnime             ProviderSettings  `mapstructure:"hianime" yaml:"hianime"`
 	SFlix               ProviderSettings  `mapstructure:"sflix" yaml:"sflix"`
@@ -78,14 +95,62 @@ type PriorityProviders struct {
 
 // ProviderSettings contains provider-specific settings
 type ProviderSettings struct {
-	Mode       string        `mapstructure:"mode"`       // "local" or "remote" (Default: "local")
-	RemoteURL  string        `mapstructure:"remote_url"` // Target API URL if mode is remote
-	Enabled    bool          `mapstructure:"enabled"`
-	BaseURL    string        `mapstructure:"base_url"`
-	APIURL     string        `mapstructure:"api_url"`
-	Timeout    time.Duration `mapstructure:"timeout"`
-	MaxRetries int           `mapstructure:"max_retries"`
-	RateLimit  int           `mapstructure:"rate_limit"`
+	Mode           string            `mapstructure:"mode"`       // "local" or "remote" (Default: "local")
+	RemoteURL      string            `mapstructure:"remote_url"` // Target API URL if mode is remote
+	Enabled        bool              `mapstructure:"enabled"`
+	BaseURL        string            `mapstructure:"base_url"`
+	APIURL         string            `mapstructure:"api_url"`
+	Timeout        time.Duration     `mapstructure:"timeout"`
+	MaxRetries     int               `mapstructure:"max_retries"`
+	RateLimit      int               `mapstructure:"rate_limit"`
+	Mirrors        []string          `mapstructure:"mirrors"`         // Alternative base URLs tried when the site blocks requests
+	RequestDelay   time.Duration     `mapstructure:"request_delay"`   // Minimum gap between requests, plus random jitter
+	DefaultQuality string            `mapstructure:"default_quality"` // Overrides player.quality for this provider
+	Headers        map[string]string `mapstructure:"headers"`         // Extra headers sent with every request, replacing the provider's own
+	CacheEnabled   bool              `mapstructure:"cache_enabled"`   // Cache search, info and source results in memory (default true)
+}
+
+// Settings returns the settings block for the named provider
+func (p ProvidersConfig) Settings(name string) (ProviderSettings, bool) {
+	switch strings.ToLower(name) {
+	case "allanime":
+		return p.AllAnime, true
+	case "hianime":
+		return p.HiAnime, true
+	case "sflix":
+		return p.SFlix, true
+	case "flixhq":
+		return p.FlixHQ, true
+	case "hdrezka", "hdrezka_anime":
+		return p.HDRezka, true
+	case "comix":
+		return p.Comix, true
+	}
+	return ProviderSettings{}, false
+}
+
+// SetEnabled sets the enabled flag of the named provider, reporting whether
+// the name is known. "hdrezka" and "hdrezka_anime" share one setting.
+func (p *ProvidersConfig) SetEnabled(name string, enabled bool) bool {
+	var settings *ProviderSettings
+	switch strings.ToLower(name) {
+	case "allanime":
+		settings = &p.AllAnime
+	case "hianime":
+		settings = &p.HiAnime
+	case "sflix":
+		settings = &p.SFlix
+	case "flixhq":
+		settings = &p.FlixHQ
+	case "hdrezka", "hdrezka_anime":
+		settings = &p.HDRezka
+	case "comix":
+		settings = &p.Comix
+	default:
+		return false
+	}
+	settings.Enabled = enabled
+	return true
 }
 
 // TrackerConfig contains tracker settings
@@ -118,6 +183,9 @@ type DownloadsConfig struct {
 	MovieFilenameTemplate string   `mapstructure:"movie_filename_template"`
 	MaxSpeed              int64    `mapstructure:"max_speed"`
 	MinFreeSpace          int      `mapstructure:"min_free_space"`
+	FFmpegBinary          string   `mapstructure:"ffmpeg_binary"`
+	SanitizeReplacement   string   `mapstructure:"sanitize_replacement"` // Replaces characters illegal in filenames; empty for readable defaults
+	NativeFilenames       bool     `mapstructure:"native_filenames"`     // Only replace characters the current OS forbids
 }
 
 // UIConfig contains UI settings
@@ -135,6 +203,7 @@ type UIConfig struct {
 	FuzzyFinder      string            `mapstructure:"fuzzy_finder"`
 	ShowLoading      bool              `mapstructure:"show_loading"`
 	DefaultMediaType string            `mapstructure:"default_media_type"` // movie_tv, anime, or manga
+	SafeSearch       bool              `mapstructure:"safe_search"`        // Hide adult titles from search results
 }
 
 // PreviewSize contains preview image dimensions
@@ -191,14 +260,16 @@ type LoggingConfig struct {
 
 // NetworkConfig contains network settings
 type NetworkConfig struct {
-	Timeout         time.Duration `mapstructure:"timeout"`
-	HTTP2           bool          `mapstructure:"http2"`
-	MaxIdleConns    int           `mapstructure:"max_idle_conns"`
-	IdleConnTimeout time.Duration `mapstructure:"idle_conn_timeout"`
-	UserAgent       string        `mapstructure:"user_agent"`
-	Proxy           string        `mapstructure:"proxy"`
-	VerifyTLS       bool          `mapstructure:"verify_tls"`
-	DNSServers      []string      `mapstructure:"dns_servers"`
+	Timeout             time.Duration `mapstructure:"timeout"`
+	HTTP2               bool          `mapstructure:"http2"`
+	MaxIdleConns        int           `mapstructure:"max_idle_conns"`
+	MaxIdleConnsPerHost int           `mapstructure:"max_idle_conns_per_host"` // Kept-alive connections per site; concurrent fetches beyond this reconnect
+	IdleConnTimeout     time.Duration `mapstructure:"idle_conn_timeout"`
+	DisableKeepAlives   bool          `mapstructure:"disable_keep_alives"` // Open a new connection per request, for networks that drop idle ones
+	UserAgent           string        `mapstructure:"user_agent"`
+	Proxy               string        `mapstructure:"proxy"`
+	VerifyTLS           bool          `mapstructure:"verify_tls"`
+	DNSServers          []string      `mapstructure:"dns_servers"`
 }
 
 // AdvancedConfig contains advanced settings
@@ -314,36 +385,50 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("player.audio_preference", "sub")
 	v.SetDefault("player.load_user_config", true)
 	v.SetDefault("player.ipc_timeout", 5*time.Second)
+	v.SetDefault("player.autoplay_next", false)
+	v.SetDefault("player.opensubtitles_api_key", "")
+	v.SetDefault("player.opensubtitles_username", "")
+	v.SetDefault("player.opensubtitles_password", "")
 
 	// Provider defaults
 	v.SetDefault("providers.default.anime", "hianime")
 	v.SetDefault("providers.default.movies_and_tv", "sflix") // Combined default for movies and TV
 	v.SetDefault("providers.health_check_interval", 5*time.Minute)
 	v.SetDefault("providers.auto_failover", true)
+	v.SetDefault("providers.episode_numbering", "season")
+	v.SetDefault("providers.extractor_key_source", "")
+	v.SetDefault("providers.tmdb_api_key", "")
+	v.SetDefault("providers.language_preference", "")
 
 	// AllAnime defaults (API-based)
 	v.SetDefault("providers.allanime.enabled", true)
 	v.SetDefault("providers.allanime.mode", "local")
+	v.SetDefault("providers.allanime.cache_enabled", true)
 
 	// HiAnime defaults (API-based)
 	v.SetDefault("providers.hianime.enabled", true)
 	v.SetDefault("providers.hianime.mode", "local")
+	v.SetDefault("providers.hianime.cache_enabled", true)
 
 	// SFlix defaults (API-based)
 	v.SetDefault("providers.sflix.enabled", true)
 	v.SetDefault("providers.sflix.mode", "local")
+	v.SetDefault("providers.sflix.cache_enabled", true)
 
 	// FlixHQ defaults (API-based)
 	v.SetDefault("providers.flixhq.enabled", true)
 	v.SetDefault("providers.flixhq.mode", "local")
+	v.SetDefault("providers.flixhq.cache_enabled", true)
 
 	// HDRezka defaults (API-based)
 	v.SetDefault("providers.hdrezka.enabled", true)
 	v.SetDefault("providers.hdrezka.mode", "local")
+	v.SetDefault("providers.hdrezka.cache_enabled", true)
 
 	// Comix defaults (API-based)
 	v.SetDefault("providers.comix.enabled", true)
 	v.SetDefault("providers.comix.mode", "local")
+	v.SetDefault("providers.comix.cache_enabled", true)
 
 	// Tracker defaults
 	v.SetDefault("tracker.anilist.enabled", true)
@@ -367,6 +452,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("downloads.movie_filename_template", "{title} ({year}) [{quality}]")
 	v.SetDefault("downloads.max_speed", 0)
 	v.SetDefault("downloads.min_free_space", 5)
+	v.SetDefault("downloads.ffmpeg_binary", "ffmpeg")
+	v.SetDefault("downloads.sanitize_replacement", "")
+	v.SetDefault("downloads.native_filenames", false)
 
 	// UI defaults
 	v.SetDefault("ui.theme", "default")
@@ -381,6 +469,7 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("ui.time_format", "15:04")
 	v.SetDefault("ui.fuzzy_finder", "builtin")
 	v.SetDefault("ui.show_loading", false)
+	v.SetDefault("ui.safe_search", true)
 
 	// WatchParty defaults
 	v.SetDefault("watchparty.enabled", true)
@@ -419,7 +508,9 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("network.timeout", 30*time.Second)
 	v.SetDefault("network.http2", true)
 	v.SetDefault("network.max_idle_conns", 100)
+	v.SetDefault("network.max_idle_conns_per_host", 10)
 	v.SetDefault("network.idle_conn_timeout", 90*time.Second)
+	v.SetDefault("network.disable_keep_alives", false)
 	v.SetDefault("network.user_agent", "greg/1.0.0")
 	v.SetDefault("network.verify_tls", true)
 
@@ -30,6 +30,21 @@ func (History) TableName() string {
 	return "history"
 }
 
+// WatchedEpisode marks an episode as watched on a provider
+type WatchedEpisode struct {
+	ID           uint      `gorm:"primaryKey"`
+	ProviderName string    `gorm:"not null;uniqueIndex:idx_watched_episode"`
+	MediaID      string    `gorm:"not null;uniqueIndex:idx_watched_episode"`
+	Season       int       `gorm:"not null;default:0;uniqueIndex:idx_watched_episode"`
+	Episode      int       `gorm:"not null;uniqueIndex:idx_watched_episode"`
+	WatchedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+// TableName overrides the table name
+func (WatchedEpisode) TableName() string {
+	return "watched_episodes"
+}
+
 // Statistic represents aggregate viewing statistics for a media item
 type Statistic struct {
 	ID             uint      `gorm:"primaryKey"`
@@ -140,6 +155,7 @@ func (AudioPreference) TableName() string {
 func Migrate(db *gorm.DB) error {
 	return db.AutoMigrate(
 		&History{},
+		&WatchedEpisode{},
 		&Statistic{},
 		&SyncQueue{},
 		&Setting{},
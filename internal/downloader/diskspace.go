@@ -0,0 +1,102 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+// ErrInsufficientSpace is returned when the downloads filesystem does not
+// have room for a download plus the configured min_free_space reserve
+var ErrInsufficientSpace = errors.New("insufficient disk space")
+
+const bytesPerGB = 1024 * 1024 * 1024
+
+// freeSpaceFunc reports the bytes available on the filesystem holding path.
+// Platform-specific implementations of freeSpace are in diskspace_*.go;
+// replaced in tests.
+var freeSpaceFunc = freeSpace
+
+// estimatedEpisodeSizes are rough sizes of a ~24 minute episode per quality
+var estimatedEpisodeSizes = map[providers.Quality]int64{
+	providers.Quality360p:  150 * 1024 * 1024,
+	providers.Quality480p:  250 * 1024 * 1024,
+	providers.Quality720p:  500 * 1024 * 1024,
+	providers.Quality1080p: 1 * bytesPerGB,
+	providers.Quality1440p: 2 * bytesPerGB,
+	providers.Quality4K:    4 * bytesPerGB,
+}
+
+// estimateDownloadSize estimates how many bytes a task will write. The known
+// total is used when available; otherwise it is guessed from the quality and
+// media type, erring on the large side.
+func estimateDownloadSize(task *DownloadTask) int64 {
+	if task.TotalBytes > 0 && task.StreamType != providers.StreamTypeHLS {
+		return task.TotalBytes - task.BytesDownloaded
+	}
+
+	if task.MediaType == providers.MediaTypeManga {
+		return 50 * 1024 * 1024
+	}
+
+	size, ok := estimatedEpisodeSizes[providers.NormalizeQuality(string(task.Quality))]
+	if !ok {
+		size = estimatedEpisodeSizes[providers.Quality1080p]
+	}
+
+	if task.MediaType == providers.MediaTypeMovie {
+		// Movies run roughly four times as long as an episode
+		size *= 4
+	}
+
+	return size
+}
+
+// checkDiskSpace returns ErrInsufficientSpace if the downloads filesystem has
+// less free space than min_free_space plus the estimated size of task. The
+// check is skipped when min_free_space is unset or the platform can't report
+// free space.
+func (m *Manager) checkDiskSpace(task *DownloadTask) error {
+	if m.config.MinFreeSpace <= 0 || m.config.Path == "" {
+		return nil
+	}
+
+	path := existingParent(m.config.Path)
+	available, err := freeSpaceFunc(path)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check disk space on %s: %w", path, err)
+	}
+
+	estimate := estimateDownloadSize(task)
+	required := uint64(m.config.MinFreeSpace)*bytesPerGB + uint64(estimate)
+
+	if available < required {
+		return fmt.Errorf("%w on %s: %.1f GB free, %.1f GB required (%d GB reserve + ~%.1f GB download)",
+			ErrInsufficientSpace, path,
+			float64(available)/bytesPerGB, float64(required)/bytesPerGB,
+			m.config.MinFreeSpace, float64(estimate)/bytesPerGB)
+	}
+
+	return nil
+}
+
+// existingParent returns path or its closest existing ancestor, since the
+// downloads directory may not have been created yet
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,101 @@
+package downloader
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeFreeSpace replaces the free-space lookup for the duration of a test
+func fakeFreeSpace(t *testing.T, available uint64, err error) *string {
+	t.Helper()
+
+	var checked string
+	original := freeSpaceFunc
+	freeSpaceFunc = func(path string) (uint64, error) {
+		checked = path
+		return available, err
+	}
+	t.Cleanup(func() { freeSpaceFunc = original })
+
+	return &checked
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	task := &DownloadTask{
+		MediaType:  providers.MediaTypeAnime,
+		Quality:    providers.Quality1080p,
+		StreamType: providers.StreamTypeHLS,
+	}
+
+	t.Run("fails below reserve plus estimate", func(t *testing.T) {
+		dir := t.TempDir()
+		m := &Manager{config: &config.DownloadsConfig{Path: dir, MinFreeSpace: 5}}
+
+		// Enough for the reserve but not the estimated episode on top of it
+		checked := fakeFreeSpace(t, 5*bytesPerGB+100*1024*1024, nil)
+
+		err := m.checkDiskSpace(task)
+		require.Error(t, err)
+		assert.ErrorIs(t, err, ErrInsufficientSpace)
+		assert.Equal(t, dir, *checked)
+	})
+
+	t.Run("passes with enough space", func(t *testing.T) {
+		m := &Manager{config: &config.DownloadsConfig{Path: t.TempDir(), MinFreeSpace: 5}}
+		fakeFreeSpace(t, 7*bytesPerGB, nil)
+
+		assert.NoError(t, m.checkDiskSpace(task))
+	})
+
+	t.Run("checks the closest existing directory", func(t *testing.T) {
+		dir := t.TempDir()
+		m := &Manager{config: &config.DownloadsConfig{Path: filepath.Join(dir, "not", "created"), MinFreeSpace: 1}}
+		checked := fakeFreeSpace(t, 10*bytesPerGB, nil)
+
+		require.NoError(t, m.checkDiskSpace(task))
+		assert.Equal(t, dir, *checked)
+	})
+
+	t.Run("skipped when unset or unsupported", func(t *testing.T) {
+		fakeFreeSpace(t, 0, nil)
+		m := &Manager{config: &config.DownloadsConfig{Path: t.TempDir()}}
+		assert.NoError(t, m.checkDiskSpace(task))
+
+		fakeFreeSpace(t, 0, errors.ErrUnsupported)
+		m.config.MinFreeSpace = 5
+		assert.NoError(t, m.checkDiskSpace(task))
+	})
+
+	t.Run("real filesystem", func(t *testing.T) {
+		available, err := freeSpace(t.TempDir())
+		if errors.Is(err, errors.ErrUnsupported) {
+			t.Skip("free space not supported on this platform")
+		}
+		require.NoError(t, err)
+		assert.Greater(t, available, uint64(0))
+	})
+}
+
+func TestEstimateDownloadSize(t *testing.T) {
+	episode := estimateDownloadSize(&DownloadTask{MediaType: providers.MediaTypeTV, Quality: providers.Quality720p})
+	movie := estimateDownloadSize(&DownloadTask{MediaType: providers.MediaTypeMovie, Quality: providers.Quality720p})
+	assert.Greater(t, movie, episode)
+
+	// Unknown qualities assume 1080p
+	assert.Equal(t,
+		estimateDownloadSize(&DownloadTask{Quality: providers.Quality1080p}),
+		estimateDownloadSize(&DownloadTask{Quality: providers.QualityAuto}))
+
+	// Known sizes of direct downloads are used as-is
+	assert.Equal(t, int64(300), estimateDownloadSize(&DownloadTask{
+		StreamType:      providers.StreamTypeMP4,
+		TotalBytes:      1000,
+		BytesDownloaded: 700,
+	}))
+}
@@ -3,13 +3,13 @@
 package downloader
 
 import (
-	"fmt"
 	"syscall"
 	"unsafe"
 )
 
-// checkDiskSpace checks if there's enough free disk space (Windows)
-func (m *Manager) checkDiskSpace() error {
+// freeSpace returns the bytes available to the current user on the volume
+// holding path (Windows)
+func freeSpace(path string) (uint64, error) {
 	kernel32 := syscall.NewLazyDLL("kernel32.dll")
 	getDiskFreeSpaceEx := kernel32.NewProc("GetDiskFreeSpaceExW")
 
@@ -18,29 +18,20 @@ func (m *Manager) checkDiskSpace() error {
 	var availBytes uint64
 
 	// Convert path to UTF16
-	pathPtr, err := syscall.UTF16PtrFromString(m.config.Path)
+	pathPtr, err := syscall.UTF16PtrFromString(path)
 	if err != nil {
-		return fmt.Errorf("failed to convert path: %w", err)
+		return 0, err
 	}
 
 	ret, _, err := getDiskFreeSpaceEx.Call(
 		uintptr(unsafe.Pointer(pathPtr)),
-		uintptr(unsafe.Pointer(&freeBytes)),
-		uintptr(unsafe.Pointer(&totalBytes)),
 		uintptr(unsafe.Pointer(&availBytes)),
+		uintptr(unsafe.Pointer(&totalBytes)),
+		uintptr(unsafe.Pointer(&freeBytes)),
 	)
-
 	if ret == 0 {
-		return fmt.Errorf("failed to check disk space: %w", err)
-	}
-
-	// Calculate free space in GB
-	freeSpaceGB := freeBytes / (1024 * 1024 * 1024)
-
-	if int(freeSpaceGB) < m.config.MinFreeSpace {
-		return fmt.Errorf("insufficient disk space: %d GB free, %d GB required",
-			freeSpaceGB, m.config.MinFreeSpace)
+		return 0, err
 	}
 
-	return nil
+	return availBytes, nil
 }
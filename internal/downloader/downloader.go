@@ -11,6 +11,7 @@ import (
 type Downloader interface {
 	// Queue management
 	AddToQueue(ctx context.Context, task DownloadTask) error
+	Enqueue(ctx context.Context, task DownloadTask) (string, error)
 	RemoveFromQueue(ctx context.Context, id string) error
 	GetQueue(ctx context.Context) ([]DownloadTask, error)
 	ClearQueue(ctx context.Context) error
@@ -29,6 +30,8 @@ type Downloader interface {
 	OnProgressUpdate(callback func(task DownloadTask))
 	OnDownloadComplete(callback func(task DownloadTask))
 	OnDownloadError(callback func(task DownloadTask, err error))
+	Job(id string) (DownloadTask, bool)
+	Jobs() []DownloadTask
 
 	// Settings
 	SetConcurrency(workers int)
@@ -3,7 +3,11 @@ package downloader
 import (
 	"context"
 	"log/slog"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -234,3 +238,94 @@ func TestProcessTaskWithRetry(t *testing.T) {
 	require.Len(t, tasks, 1)
 	require.Equal(t, "retry-test-task", tasks[0].ID)
 }
+
+func TestManagerConcurrencyLimit(t *testing.T) {
+	const (
+		concurrent = 2
+		episodes   = 6
+	)
+
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			return
+		}
+
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if n <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, n) {
+				break
+			}
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write([]byte("video"))
+	}))
+	defer server.Close()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+
+	cfg := &config.DownloadsConfig{
+		Path:             t.TempDir(),
+		Concurrent:       concurrent,
+		FilenameTemplate: "{title} - S{season:02d}E{episode:02d}",
+	}
+	manager, err := NewManager(db, cfg, slog.Default())
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	wg.Add(episodes)
+	manager.OnDownloadComplete(func(task DownloadTask) { wg.Done() })
+	manager.OnDownloadError(func(task DownloadTask, err error) {
+		t.Errorf("download %s failed: %v", task.ID, err)
+		wg.Done()
+	})
+
+	require.NoError(t, manager.Start(context.Background()))
+	defer func() { _ = manager.Stop() }()
+
+	ids := make([]string, 0, episodes)
+	for ep := 1; ep <= episodes; ep++ {
+		id, err := manager.Enqueue(context.Background(), DownloadTask{
+			MediaID:    "season",
+			MediaTitle: "Season",
+			MediaType:  providers.MediaTypeTV,
+			Season:     1,
+			Episode:    ep,
+			StreamURL:  server.URL + "/video.mp4",
+			StreamType: providers.StreamTypeMP4,
+		})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// Every job can be followed while it waits or downloads
+	for _, id := range ids {
+		job, ok := manager.Job(id)
+		require.True(t, ok)
+		assert.Equal(t, id, job.ID)
+	}
+	assert.LessOrEqual(t, len(manager.Jobs()), episodes)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("downloads did not finish")
+	}
+
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(concurrent))
+	assert.Greater(t, atomic.LoadInt32(&maxInFlight), int32(0))
+
+	job, ok := manager.Job(ids[0])
+	require.True(t, ok)
+	assert.Equal(t, StatusCompleted, job.Status)
+}
@@ -0,0 +1,263 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+// ErrEpisodeOutOfRange is returned when a selector names a season or episode
+// the show doesn't have
+var ErrEpisodeOutOfRange = errors.New("episode out of range")
+
+// EpisodeSelector picks episodes of a show to download
+type EpisodeSelector struct {
+	Latest bool // The newest episode of the show; the other fields are unused
+	Season int
+	First  int // First episode, zero for the whole season
+	Last   int // Last episode, equal to First for a single episode
+}
+
+// String formats the selector the way ParseEpisodeRange accepts it
+func (s EpisodeSelector) String() string {
+	switch {
+	case s.Latest:
+		return "latest"
+	case s.First == 0:
+		return fmt.Sprintf("S%02d", s.Season)
+	case s.First == s.Last:
+		return fmt.Sprintf("S%02dE%02d", s.Season, s.First)
+	default:
+		return fmt.Sprintf("S%02dE%02d-E%02d", s.Season, s.First, s.Last)
+	}
+}
+
+var selectorPattern = regexp.MustCompile(`^s(\d+)(?:e(\d+)(?:-(?:s(\d+))?e?(\d+))?)?$`)
+
+// ParseEpisodeRange parses a comma-separated list of episode selectors:
+//
+//	S01E03-E08 - episodes 3 to 8 of season 1 (also S01E03-08 or S01E03-S01E08)
+//	S02        - all of season 2
+//	S01E05     - a single episode
+//	latest     - the newest episode
+//
+// Matching is case-insensitive. Ranges can't span seasons.
+func ParseEpisodeRange(spec string) ([]EpisodeSelector, error) {
+	var selectors []EpisodeSelector
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.ToLower(strings.Join(strings.Fields(part), ""))
+		if part == "" {
+			continue
+		}
+		if part == "latest" {
+			selectors = append(selectors, EpisodeSelector{Latest: true})
+			continue
+		}
+
+		m := selectorPattern.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("invalid episode selector %q (expected e.g. S01E03-E08, S02, S01E05 or latest)", part)
+		}
+
+		sel := EpisodeSelector{Season: atoi(m[1])}
+		if m[2] != "" {
+			sel.First = atoi(m[2])
+			sel.Last = sel.First
+			if sel.First == 0 {
+				return nil, fmt.Errorf("invalid episode selector %q: episodes start at 1", part)
+			}
+		}
+		if m[4] != "" {
+			if m[3] != "" && atoi(m[3]) != sel.Season {
+				return nil, fmt.Errorf("invalid episode selector %q: ranges can't span seasons", part)
+			}
+			sel.Last = atoi(m[4])
+			if sel.Last < sel.First {
+				return nil, fmt.Errorf("invalid episode selector %q: range ends before it starts", part)
+			}
+		}
+		selectors = append(selectors, sel)
+	}
+
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("empty episode selection")
+	}
+	return selectors, nil
+}
+
+// IsSeasonSpec reports whether spec uses the season-aware selectors
+// ParseEpisodeRange understands, as opposed to plain episode numbers such as
+// "1-5,7"
+func IsSeasonSpec(spec string) bool {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	return strings.HasPrefix(spec, "s") || strings.HasPrefix(spec, "latest")
+}
+
+// atoi parses digits already matched by a pattern
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// GetSeasonEpisodes returns the episodes of each of the show's seasons by
+// season number, fetching only the seasons the selectors need
+func GetSeasonEpisodes(ctx context.Context, p providers.Provider, mediaID string, selectors []EpisodeSelector) (map[int][]providers.Episode, error) {
+	seasons, err := p.GetSeasons(ctx, mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seasons: %w", err)
+	}
+
+	// Specials only count as the latest season when there is nothing else
+	latest := providers.SeasonSpecials
+	for _, season := range seasons {
+		if season.Number > latest {
+			latest = season.Number
+		}
+	}
+
+	wanted := make(map[int]bool)
+	for _, sel := range selectors {
+		if sel.Latest {
+			wanted[latest] = true
+		} else {
+			wanted[sel.Season] = true
+		}
+	}
+
+	bySeason := make(map[int][]providers.Episode)
+	for _, season := range seasons {
+		if !wanted[season.Number] {
+			bySeason[season.Number] = nil
+			continue
+		}
+		episodes, err := p.GetEpisodes(ctx, season.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get episodes for %s: %w", providers.SeasonTitle(season.Number), err)
+		}
+		bySeason[season.Number] = episodes
+	}
+	return bySeason, nil
+}
+
+// SelectEpisodes resolves selectors against a show's episodes by season
+// number, as returned by GetSeasonEpisodes. Episodes are returned in the
+// order selected, each once. Seasons or episodes the show doesn't have are
+// reported as ErrEpisodeOutOfRange.
+func SelectEpisodes(selectors []EpisodeSelector, seasons map[int][]providers.Episode) ([]providers.Episode, error) {
+	var selected []providers.Episode
+	seen := make(map[string]bool)
+	add := func(ep providers.Episode) {
+		key := ep.ID
+		if key == "" {
+			key = fmt.Sprintf("%d/%d", ep.Season, ep.Number)
+		}
+		if !seen[key] {
+			seen[key] = true
+			selected = append(selected, ep)
+		}
+	}
+
+	for _, sel := range selectors {
+		if sel.Latest {
+			ep, ok := latestEpisode(seasons)
+			if !ok {
+				return nil, fmt.Errorf("latest: %w: no episodes available", ErrEpisodeOutOfRange)
+			}
+			add(ep)
+			continue
+		}
+
+		episodes, ok := seasons[sel.Season]
+		if !ok {
+			return nil, fmt.Errorf("%s: %w: season %d not found (available: %s)", sel, ErrEpisodeOutOfRange, sel.Season, seasonList(seasons))
+		}
+		if sel.First == 0 {
+			for _, ep := range episodes {
+				add(ep)
+			}
+			continue
+		}
+
+		first, last := episodeBounds(episodes)
+		if len(episodes) == 0 || sel.First < first || sel.Last > last {
+			return nil, fmt.Errorf("%s: %w: %s has episodes %d-%d", sel, ErrEpisodeOutOfRange, providers.SeasonTitle(sel.Season), first, last)
+		}
+		for _, ep := range episodes {
+			if n := seasonEpisodeNumber(ep); n >= sel.First && n <= sel.Last {
+				add(ep)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// seasonEpisodeNumber returns an episode's number within its season, which
+// differs from Number when episodes are numbered across seasons
+func seasonEpisodeNumber(ep providers.Episode) int {
+	if ep.SeasonNumber != 0 {
+		return ep.SeasonNumber
+	}
+	return ep.Number
+}
+
+// episodeBounds returns the lowest and highest episode numbers of a season
+func episodeBounds(episodes []providers.Episode) (int, int) {
+	if len(episodes) == 0 {
+		return 0, 0
+	}
+	first, last := seasonEpisodeNumber(episodes[0]), seasonEpisodeNumber(episodes[0])
+	for _, ep := range episodes[1:] {
+		first = min(first, seasonEpisodeNumber(ep))
+		last = max(last, seasonEpisodeNumber(ep))
+	}
+	return first, last
+}
+
+// latestEpisode returns the highest-numbered episode of the last regular
+// season, or of the specials if the show has nothing else
+func latestEpisode(seasons map[int][]providers.Episode) (providers.Episode, bool) {
+	best := -1
+	for number, episodes := range seasons {
+		if len(episodes) == 0 {
+			continue
+		}
+		if best == -1 || (number != providers.SeasonSpecials && (best == providers.SeasonSpecials || number > best)) {
+			best = number
+		}
+	}
+	if best == -1 {
+		return providers.Episode{}, false
+	}
+
+	episodes := seasons[best]
+	latest := episodes[0]
+	for _, ep := range episodes[1:] {
+		if seasonEpisodeNumber(ep) > seasonEpisodeNumber(latest) {
+			latest = ep
+		}
+	}
+	return latest, true
+}
+
+// seasonList formats the available season numbers for error messages
+func seasonList(seasons map[int][]providers.Episode) string {
+	if len(seasons) == 0 {
+		return "none"
+	}
+	numbers := make([]int, 0, len(seasons))
+	for number := range seasons {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	parts := make([]string, len(numbers))
+	for i, number := range numbers {
+		parts[i] = strconv.Itoa(number)
+	}
+	return strings.Join(parts, ", ")
+}
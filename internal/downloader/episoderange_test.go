@@ -0,0 +1,212 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEpisodeRange(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []EpisodeSelector
+	}{
+		{"S01E03-E08", []EpisodeSelector{{Season: 1, First: 3, Last: 8}}},
+		{"s01e03-08", []EpisodeSelector{{Season: 1, First: 3, Last: 8}}},
+		{"S01E03-S01E08", []EpisodeSelector{{Season: 1, First: 3, Last: 8}}},
+		{"S02", []EpisodeSelector{{Season: 2}}},
+		{"S00", []EpisodeSelector{{Season: 0}}},
+		{"S1E5", []EpisodeSelector{{Season: 1, First: 5, Last: 5}}},
+		{"S01E05,S01E07", []EpisodeSelector{{Season: 1, First: 5, Last: 5}, {Season: 1, First: 7, Last: 7}}},
+		{" S01E05 , latest ", []EpisodeSelector{{Season: 1, First: 5, Last: 5}, {Latest: true}}},
+		{"LATEST", []EpisodeSelector{{Latest: true}}},
+		{"S01E03-E03", []EpisodeSelector{{Season: 1, First: 3, Last: 3}}},
+		{"S01E05,", []EpisodeSelector{{Season: 1, First: 5, Last: 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseEpisodeRange(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseEpisodeRangeErrors(t *testing.T) {
+	tests := []struct {
+		spec    string
+		message string
+	}{
+		{"", "empty"},
+		{" , ", "empty"},
+		{"E05", "invalid episode selector"},
+		{"3-8", "invalid episode selector"},
+		{"S01E", "invalid episode selector"},
+		{"S01E03-", "invalid episode selector"},
+		{"S01E03-E08-E09", "invalid episode selector"},
+		{"S01E00", "episodes start at 1"},
+		{"S01E08-E03", "ends before it starts"},
+		{"S01E08-S02E03", "can't span seasons"},
+		{"newest", "invalid episode selector"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseEpisodeRange(tt.spec)
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+}
+
+func TestEpisodeSelectorString(t *testing.T) {
+	for _, spec := range []string{"S01E03-E08", "S02", "S01E05", "latest"} {
+		selectors, err := ParseEpisodeRange(spec)
+		require.NoError(t, err)
+		assert.Equal(t, spec, selectors[0].String())
+	}
+}
+
+func TestIsSeasonSpec(t *testing.T) {
+	assert.True(t, IsSeasonSpec("S01E03-E08"))
+	assert.True(t, IsSeasonSpec(" latest"))
+	assert.False(t, IsSeasonSpec("1-5,7"))
+	assert.False(t, IsSeasonSpec(""))
+}
+
+// makeSeason builds episodes 1..n of a season
+func makeSeason(season, n int) []providers.Episode {
+	episodes := make([]providers.Episode, n)
+	for i := range episodes {
+		episodes[i] = providers.Episode{ID: fmt.Sprintf("s%de%d", season, i+1), Season: season, Number: i + 1}
+	}
+	return episodes
+}
+
+func ids(episodes []providers.Episode) []string {
+	var out []string
+	for _, ep := range episodes {
+		out = append(out, ep.ID)
+	}
+	return out
+}
+
+func TestSelectEpisodes(t *testing.T) {
+	seasons := map[int][]providers.Episode{
+		0: makeSeason(0, 2),
+		1: makeSeason(1, 8),
+		2: makeSeason(2, 3),
+	}
+
+	tests := []struct {
+		spec string
+		want []string
+	}{
+		{"S01E03-E05", []string{"s1e3", "s1e4", "s1e5"}},
+		{"S02", []string{"s2e1", "s2e2", "s2e3"}},
+		{"S01E05,S01E07", []string{"s1e5", "s1e7"}},
+		{"latest", []string{"s2e3"}},
+		{"S01E07-E08,S01E08,S02E01", []string{"s1e7", "s1e8", "s2e1"}},
+		{"S00E01", []string{"s0e1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			selectors, err := ParseEpisodeRange(tt.spec)
+			require.NoError(t, err)
+			got, err := SelectEpisodes(selectors, seasons)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ids(got))
+		})
+	}
+
+	t.Run("absolute numbering selects by season number", func(t *testing.T) {
+		absolute := map[int][]providers.Episode{
+			2: {
+				{ID: "a", Season: 2, Number: 9, SeasonNumber: 1},
+				{ID: "b", Season: 2, Number: 10, SeasonNumber: 2},
+			},
+		}
+		got, err := SelectEpisodes([]EpisodeSelector{{Season: 2, First: 2, Last: 2}}, absolute)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"b"}, ids(got))
+	})
+
+	t.Run("latest falls back to specials", func(t *testing.T) {
+		got, err := SelectEpisodes([]EpisodeSelector{{Latest: true}}, map[int][]providers.Episode{0: makeSeason(0, 2)})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"s0e2"}, ids(got))
+	})
+}
+
+func TestSelectEpisodesOutOfRange(t *testing.T) {
+	seasons := map[int][]providers.Episode{1: makeSeason(1, 8), 2: makeSeason(2, 3)}
+
+	tests := []struct {
+		spec    string
+		message string
+	}{
+		{"S03", "season 3 not found (available: 1, 2)"},
+		{"S01E07-E10", "Season 1 has episodes 1-8"},
+		{"S02E04", "Season 2 has episodes 1-3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			selectors, err := ParseEpisodeRange(tt.spec)
+			require.NoError(t, err)
+			_, err = SelectEpisodes(selectors, seasons)
+			assert.ErrorIs(t, err, ErrEpisodeOutOfRange)
+			assert.ErrorContains(t, err, tt.message)
+		})
+	}
+
+	t.Run("latest without episodes", func(t *testing.T) {
+		_, err := SelectEpisodes([]EpisodeSelector{{Latest: true}}, map[int][]providers.Episode{})
+		assert.ErrorIs(t, err, ErrEpisodeOutOfRange)
+	})
+}
+
+// seasonsProvider serves fixed seasons and counts episode fetches
+type seasonsProvider struct {
+	providers.Provider
+	seasons  []providers.Season
+	episodes map[string][]providers.Episode
+	fetched  []string
+}
+
+func (p *seasonsProvider) GetSeasons(ctx context.Context, mediaID string) ([]providers.Season, error) {
+	return p.seasons, nil
+}
+
+func (p *seasonsProvider) GetEpisodes(ctx context.Context, seasonID string) ([]providers.Episode, error) {
+	p.fetched = append(p.fetched, seasonID)
+	return p.episodes[seasonID], nil
+}
+
+func TestGetSeasonEpisodes(t *testing.T) {
+	p := &seasonsProvider{
+		seasons: []providers.Season{{ID: "sp", Number: 0}, {ID: "one", Number: 1}, {ID: "two", Number: 2}},
+		episodes: map[string][]providers.Episode{
+			"sp": makeSeason(0, 1), "one": makeSeason(1, 8), "two": makeSeason(2, 3),
+		},
+	}
+
+	selectors, err := ParseEpisodeRange("S01E02,latest")
+	require.NoError(t, err)
+	seasons, err := GetSeasonEpisodes(context.Background(), p, "tv/dark", selectors)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, p.fetched, "only the selected seasons are fetched")
+
+	got, err := SelectEpisodes(selectors, seasons)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"s1e2", "s2e3"}, ids(got))
+
+	// Seasons that weren't fetched still exist for validation
+	_, err = SelectEpisodes([]EpisodeSelector{{Season: 0}}, seasons)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,180 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Key describes how a segment is encrypted (#EXT-X-KEY)
+type Key struct {
+	Method string // "AES-128"
+	URI    string // Absolute URL of the key
+	IV     []byte // Explicit IV, nil to derive it from the media sequence number
+}
+
+// parseAttributes parses an HLS attribute list such as
+// METHOD=AES-128,URI="https://example.com/key",IV=0x1234
+func parseAttributes(list string) map[string]string {
+	attrs := make(map[string]string)
+	for len(list) > 0 {
+		eq := strings.IndexByte(list, '=')
+		if eq < 0 {
+			break
+		}
+		name := strings.TrimSpace(list[:eq])
+		list = list[eq+1:]
+
+		var value string
+		if strings.HasPrefix(list, `"`) {
+			end := strings.IndexByte(list[1:], '"')
+			if end < 0 {
+				value, list = list[1:], ""
+			} else {
+				value, list = list[1:end+1], list[end+2:]
+			}
+		} else if comma := strings.IndexByte(list, ','); comma >= 0 {
+			value, list = list[:comma], list[comma:]
+		} else {
+			value, list = list, ""
+		}
+		attrs[name] = value
+
+		list = strings.TrimPrefix(strings.TrimSpace(list), ",")
+	}
+	return attrs
+}
+
+// parseKeyTag parses an #EXT-X-KEY tag, resolving the key URI against the
+// playlist URL. It returns nil for METHOD=NONE.
+func parseKeyTag(line, playlistURL string) (*Key, error) {
+	attrs := parseAttributes(strings.TrimPrefix(line, "#EXT-X-KEY:"))
+
+	method := attrs["METHOD"]
+	switch method {
+	case "", "NONE":
+		return nil, nil
+	case "AES-128":
+	default:
+		return nil, fmt.Errorf("unsupported HLS encryption method %q", method)
+	}
+
+	if attrs["URI"] == "" {
+		return nil, fmt.Errorf("HLS key is missing a URI")
+	}
+
+	key := &Key{Method: method, URI: resolveURL(playlistURL, attrs["URI"])}
+
+	if iv := attrs["IV"]; iv != "" {
+		raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimPrefix(iv, "0x"), "0X"))
+		if err != nil || len(raw) != aes.BlockSize {
+			return nil, fmt.Errorf("invalid HLS key IV %q", iv)
+		}
+		key.IV = raw
+	}
+
+	return key, nil
+}
+
+// resolveURL resolves ref against base, returning ref unchanged if either
+// cannot be parsed
+func resolveURL(base, ref string) string {
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// fetchKey downloads an encryption key, caching it for later segments
+func (d *Downloader) fetchKey(ctx context.Context, uri string, headers map[string]string) ([]byte, error) {
+	d.keysMu.Lock()
+	defer d.keysMu.Unlock()
+
+	if key, ok := d.keys[uri]; ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch HLS key: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch HLS key: HTTP %d", resp.StatusCode)
+	}
+
+	key, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HLS key: %w", err)
+	}
+	if len(key) != 16 {
+		return nil, fmt.Errorf("invalid HLS key length %d", len(key))
+	}
+
+	if d.keys == nil {
+		d.keys = make(map[string][]byte)
+	}
+	d.keys[uri] = key
+	return key, nil
+}
+
+// decryptSegment decrypts an AES-128 segment. Unencrypted segments are
+// returned unchanged.
+func (d *Downloader) decryptSegment(ctx context.Context, segment Segment, data []byte, headers map[string]string) ([]byte, error) {
+	if segment.Key == nil {
+		return data, nil
+	}
+
+	key, err := d.fetchKey(ctx, segment.Key.URI, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := segment.Key.IV
+	if iv == nil {
+		// Without an explicit IV the media sequence number is used
+		iv = make([]byte, aes.BlockSize)
+		binary.BigEndian.PutUint64(iv[8:], uint64(segment.Sequence))
+	}
+
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("segment %d is not a whole number of AES blocks", segment.Index)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, data)
+
+	// Strip PKCS#7 padding
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize || !bytes.Equal(plain[len(plain)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) {
+		return nil, fmt.Errorf("segment %d has invalid padding", segment.Index)
+	}
+	return plain[:len(plain)-pad], nil
+}
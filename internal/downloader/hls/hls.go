@@ -15,6 +15,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/justchokingaround/greg/internal/downloader/ratelimit"
 )
 
 // Segment represents a single HLS segment
@@ -23,6 +25,8 @@ type Segment struct {
 	Index    int
 	Duration float64
 	Title    string
+	Sequence int  // Media sequence number
+	Key      *Key // Encryption key, nil if unencrypted
 }
 
 // M3U8Playlist represents the HLS playlist structure
@@ -35,9 +39,18 @@ type M3U8Playlist struct {
 	PlaylistType   string
 }
 
+// defaultConcurrency is the number of segments downloaded at once when no
+// concurrency has been configured
+const defaultConcurrency = 8
+
 // Downloader handles HLS downloads
 type Downloader struct {
-	client *http.Client
+	client      *http.Client
+	concurrency int
+	limiter     *ratelimit.Limiter
+
+	keysMu sync.Mutex
+	keys   map[string][]byte
 }
 
 // NewDownloader creates a new HLS downloader
@@ -49,6 +62,17 @@ func NewDownloader() *Downloader {
 	}
 }
 
+// SetConcurrency sets how many segments are downloaded at once
+func (d *Downloader) SetConcurrency(n int) {
+	d.concurrency = n
+}
+
+// SetRateLimiter throttles segment downloads through a limiter shared with
+// other downloads; nil means unlimited
+func (d *Downloader) SetRateLimiter(l *ratelimit.Limiter) {
+	d.limiter = l
+}
+
 // Download downloads an HLS stream to the specified output file with concurrent segment downloads
 func (d *Downloader) Download(ctx context.Context, url, output string, headers map[string]string) error {
 	// Use DownloadWithProgress with a no-op callback for consistency and performance
@@ -215,6 +239,7 @@ func (d *Downloader) parseMediaPlaylistLines(lines []string, url string, headers
 	}
 
 	segmentIndex := 0
+	var key *Key
 	for i, line := range lines {
 		if strings.HasPrefix(line, "#EXTM3U") {
 			continue // Header
@@ -234,6 +259,12 @@ func (d *Downloader) parseMediaPlaylistLines(lines []string, url string, headers
 			}
 		} else if strings.HasPrefix(line, "#EXT-X-PLAYLIST-TYPE:") {
 			playlist.PlaylistType = strings.TrimPrefix(line, "#EXT-X-PLAYLIST-TYPE:")
+		} else if strings.HasPrefix(line, "#EXT-X-KEY:") {
+			k, err := parseKeyTag(line, url)
+			if err != nil {
+				return nil, err
+			}
+			key = k
 		} else if strings.HasPrefix(line, "#EXT-X-ENDLIST") {
 			playlist.EndList = true
 		} else if strings.HasPrefix(line, "#EXTINF:") {
@@ -271,6 +302,8 @@ func (d *Downloader) parseMediaPlaylistLines(lines []string, url string, headers
 						Index:    segmentIndex,
 						Duration: duration,
 						Title:    title,
+						Sequence: playlist.MediaSequence + segmentIndex,
+						Key:      key,
 					})
 					segmentIndex++
 				}
@@ -311,7 +344,7 @@ func (d *Downloader) downloadSegment(ctx context.Context, url string, headers ma
 		}
 
 		// Process the response
-		body, err := io.ReadAll(resp.Body)
+		body, err := io.ReadAll(ratelimit.NewReader(ctx, resp.Body, d.limiter))
 		_ = resp.Body.Close() // Close immediately after reading
 
 		if err != nil {
@@ -388,24 +421,46 @@ func (d *Downloader) DownloadWithProgress(ctx context.Context, url, output strin
 	}
 	defer func() { _ = outFile.Close() }()
 
+	return d.fetchSegments(ctx, url, playlist.Segments, 0, headers, func(index int, data []byte) error {
+		if _, err := outFile.Write(data); err != nil {
+			return fmt.Errorf("failed to write segment %d: %w", index, err)
+		}
+		return nil
+	}, progressCallback)
+}
+
+// fetchSegments downloads segments[start:] concurrently and passes them to
+// write strictly in playlist order
+func (d *Downloader) fetchSegments(ctx context.Context, url string, segments []Segment, start int, headers map[string]string, write func(index int, data []byte) error, progressCallback ProgressCallback) error {
 	// Set up progress tracking
-	totalSegments := len(playlist.Segments)
-	var downloadedSegments int32
+	totalSegments := len(segments)
+	pending := totalSegments - start
+	downloadedSegments := int32(start)
 
 	// Report initial progress
 	if progressCallback != nil {
-		progressCallback(0, totalSegments)
+		progressCallback(start, totalSegments)
+	}
+
+	if pending <= 0 {
+		return nil
 	}
 
 	// Concurrent download configuration
-	const maxWorkers = 8
+	maxWorkers := d.concurrency
+	if maxWorkers <= 0 {
+		maxWorkers = defaultConcurrency
+	}
+	if maxWorkers > pending {
+		maxWorkers = pending
+	}
 
 	// Channel for segments to download
 	type job struct {
 		index   int
 		segment Segment
 	}
-	jobs := make(chan job, totalSegments)
+	jobs := make(chan job, pending)
 
 	// Channel for results
 	type result struct {
@@ -413,7 +468,7 @@ func (d *Downloader) DownloadWithProgress(ctx context.Context, url, output strin
 		data  []byte
 		err   error
 	}
-	results := make(chan result, totalSegments)
+	results := make(chan result, pending)
 
 	// Start workers
 	var wg sync.WaitGroup
@@ -442,25 +497,28 @@ func (d *Downloader) DownloadWithProgress(ctx context.Context, url, output strin
 				}
 
 				data, err := d.downloadSegment(ctx, segmentURL, headers)
+				if err == nil {
+					data, err = d.decryptSegment(ctx, j.segment, data, headers)
+				}
 				results <- result{index: j.index, data: data, err: err}
 			}
 		}()
 	}
 
 	// Fill job queue
-	for i, segment := range playlist.Segments {
-		jobs <- job{index: i, segment: segment}
+	for i := start; i < totalSegments; i++ {
+		jobs <- job{index: i, segment: segments[i]}
 	}
 	close(jobs)
 
 	// Collect results and write in order
 	// We need to buffer results because they might arrive out of order
 	segmentBuffer := make(map[int][]byte)
-	nextIndex := 0
+	nextIndex := start
 	var firstErr error
 
 	// Process results
-	for i := 0; i < totalSegments; i++ {
+	for i := 0; i < pending; i++ {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -476,16 +534,14 @@ func (d *Downloader) DownloadWithProgress(ctx context.Context, url, output strin
 			atomic.AddInt32(&downloadedSegments, 1)
 
 			// Write available sequential segments
-			for {
+			for firstErr == nil {
 				data, ok := segmentBuffer[nextIndex]
 				if !ok {
 					break
 				}
 
-				if _, err := outFile.Write(data); err != nil {
-					if firstErr == nil {
-						firstErr = fmt.Errorf("failed to write segment %d: %w", nextIndex, err)
-					}
+				if err := write(nextIndex, data); err != nil {
+					firstErr = err
 				}
 
 				// Free memory
@@ -495,7 +551,7 @@ func (d *Downloader) DownloadWithProgress(ctx context.Context, url, output strin
 
 			// Report progress
 			if progressCallback != nil {
-				progressCallback(int(downloadedSegments), totalSegments)
+				progressCallback(int(atomic.LoadInt32(&downloadedSegments)), totalSegments)
 			}
 		}
 	}
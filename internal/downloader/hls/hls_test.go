@@ -0,0 +1,332 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encryptSegment encrypts data the way an HLS packager would
+func encryptSegment(t *testing.T, key, iv, data []byte) []byte {
+	t.Helper()
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+
+	pad := aes.BlockSize - len(data)%aes.BlockSize
+	padded := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(pad)}, pad)...)
+	out := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(out, padded)
+	return out
+}
+
+func sequenceIV(seq int) []byte {
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint64(iv[8:], uint64(seq))
+	return iv
+}
+
+func TestDownloader_EncryptedPlaylist(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	const segmentCount = 6
+	const mediaSequence = 10
+
+	var inFlight, maxInFlight, keyRequests int32
+	var badHeaders int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/media.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXT-X-MEDIA-SEQUENCE:%d\n", mediaSequence)
+		b.WriteString("#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\"\n")
+		for i := 0; i < segmentCount; i++ {
+			fmt.Fprintf(&b, "#EXTINF:4.0,\nseg%d.ts\n", i)
+		}
+		b.WriteString("#EXT-X-ENDLIST\n")
+		_, _ = w.Write([]byte(b.String()))
+	})
+	mux.HandleFunc("/key.bin", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&keyRequests, 1)
+		_, _ = w.Write(key)
+	})
+	for i := 0; i < segmentCount; i++ {
+		i := i
+		mux.HandleFunc(fmt.Sprintf("/seg%d.ts", i), func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Referer") != "https://embed.example.com/e/1" || r.Header.Get("Origin") != "https://embed.example.com" {
+				atomic.AddInt32(&badHeaders, 1)
+			}
+
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				m := atomic.LoadInt32(&maxInFlight)
+				if n <= m || atomic.CompareAndSwapInt32(&maxInFlight, m, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+
+			plain := []byte(fmt.Sprintf("segment-%d;", i))
+			_, _ = w.Write(encryptSegment(t, key, sequenceIV(mediaSequence+i), plain))
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "out.ts")
+	headers := RequestHeaders(nil, "https://embed.example.com/e/1")
+
+	downloader := NewDownloader()
+	downloader.SetConcurrency(2)
+	require.NoError(t, downloader.Download(context.Background(), server.URL+"/media.m3u8", dest, headers))
+
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+
+	var want strings.Builder
+	for i := 0; i < segmentCount; i++ {
+		fmt.Fprintf(&want, "segment-%d;", i)
+	}
+	assert.Equal(t, want.String(), string(data))
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&keyRequests))
+	assert.Zero(t, atomic.LoadInt32(&badHeaders))
+}
+
+func TestParseKeyTag(t *testing.T) {
+	t.Run("explicit IV and relative URI", func(t *testing.T) {
+		key, err := parseKeyTag(`#EXT-X-KEY:METHOD=AES-128,URI="/keys/1?a=b,c",IV=0x000102030405060708090a0b0c0d0e0f`, "https://cdn.example.com/hls/media.m3u8")
+		require.NoError(t, err)
+		require.NotNil(t, key)
+		assert.Equal(t, "https://cdn.example.com/keys/1?a=b,c", key.URI)
+		assert.Equal(t, []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}, key.IV)
+	})
+
+	t.Run("method none", func(t *testing.T) {
+		key, err := parseKeyTag("#EXT-X-KEY:METHOD=NONE", "https://cdn.example.com/media.m3u8")
+		require.NoError(t, err)
+		assert.Nil(t, key)
+	})
+
+	t.Run("unsupported method", func(t *testing.T) {
+		_, err := parseKeyTag(`#EXT-X-KEY:METHOD=SAMPLE-AES,URI="k"`, "https://cdn.example.com/media.m3u8")
+		assert.Error(t, err)
+	})
+}
+
+// newSegmentServer serves a playlist of n plain segments, counting requests
+// per segment
+func newSegmentServer(t *testing.T, n int) (*httptest.Server, []int32) {
+	t.Helper()
+
+	hits := make([]int32, n)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		b.WriteString("#EXTM3U\n#EXT-X-TARGETDURATION:10\n")
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&b, "#EXTINF:10.0,\nseg%d.ts\n", i)
+		}
+		b.WriteString("#EXT-X-ENDLIST\n")
+		_, _ = w.Write([]byte(b.String()))
+	})
+	for i := 0; i < n; i++ {
+		i := i
+		mux.HandleFunc(fmt.Sprintf("/seg%d.ts", i), func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits[i], 1)
+			_, _ = fmt.Fprintf(w, "segment-%02d;", i)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+func TestDownloader_DownloadResumable(t *testing.T) {
+	const segments = 10
+
+	var want strings.Builder
+	for i := 0; i < segments; i++ {
+		fmt.Fprintf(&want, "segment-%02d;", i)
+	}
+
+	t.Run("resumes after interruption without refetching", func(t *testing.T) {
+		server, hits := newSegmentServer(t, segments)
+		dest := filepath.Join(t.TempDir(), "episode.ts")
+		opts := ResumeOptions{Resume: true, KeepPartial: true}
+
+		// Interrupt the first attempt halfway through
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		d := NewDownloader()
+		d.SetConcurrency(1)
+		err := d.DownloadResumable(ctx, server.URL+"/index.m3u8?token=old", dest, nil, opts, func(downloaded, total int) {
+			if downloaded >= segments/2 {
+				cancel()
+			}
+		})
+		require.ErrorIs(t, err, context.Canceled)
+
+		_, err = os.Stat(dest)
+		assert.True(t, os.IsNotExist(err), "destination should not exist until the download completes")
+
+		index := readPartIndex(dest)
+		require.NotNil(t, index)
+		assert.GreaterOrEqual(t, index.Completed, segments/2)
+		assert.Less(t, index.Completed, segments)
+		completed := index.Completed
+
+		before := make([]int32, segments)
+		for i := range hits {
+			before[i] = atomic.LoadInt32(&hits[i])
+		}
+
+		// Resume and finish with a refreshed token
+		firstProgress := -1
+		d = NewDownloader()
+		d.SetConcurrency(1)
+		err = d.DownloadResumable(context.Background(), server.URL+"/index.m3u8?token=new", dest, nil, opts, func(downloaded, total int) {
+			if firstProgress < 0 {
+				firstProgress = downloaded
+			}
+		})
+		require.NoError(t, err)
+		assert.Equal(t, completed, firstProgress)
+
+		for i := 0; i < completed; i++ {
+			assert.Equal(t, before[i], atomic.LoadInt32(&hits[i]), "segment %d was fetched again", i)
+		}
+
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, want.String(), string(data))
+
+		_, err = os.Stat(PartPath(dest))
+		assert.True(t, os.IsNotExist(err))
+		_, err = os.Stat(indexPath(dest))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("keeps paused progress without keep_partial", func(t *testing.T) {
+		server, hits := newSegmentServer(t, segments)
+		dest := filepath.Join(t.TempDir(), "episode.ts")
+		opts := ResumeOptions{Resume: true, KeepPartial: false}
+
+		// Pausing cancels the download's context
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		d := NewDownloader()
+		d.SetConcurrency(1)
+		err := d.DownloadResumable(ctx, server.URL+"/index.m3u8", dest, nil, opts, func(downloaded, total int) {
+			if downloaded >= segments/2 {
+				cancel()
+			}
+		})
+		require.ErrorIs(t, err, context.Canceled)
+
+		index := readPartIndex(dest)
+		require.NotNil(t, index, "pausing should keep the partial file and index")
+		completed := index.Completed
+
+		before := make([]int32, segments)
+		for i := range hits {
+			before[i] = atomic.LoadInt32(&hits[i])
+		}
+
+		d = NewDownloader()
+		d.SetConcurrency(1)
+		require.NoError(t, d.DownloadResumable(context.Background(), server.URL+"/index.m3u8", dest, nil, opts, nil))
+
+		for i := 0; i < completed; i++ {
+			assert.Equal(t, before[i], atomic.LoadInt32(&hits[i]), "segment %d was fetched again", i)
+		}
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, want.String(), string(data))
+	})
+
+	t.Run("starts over when resume is disabled", func(t *testing.T) {
+		server, hits := newSegmentServer(t, segments)
+		dest := filepath.Join(t.TempDir(), "episode.ts")
+
+		// Leave a stale partial file behind
+		require.NoError(t, os.WriteFile(PartPath(dest), []byte("segment-00;segment-01;"), 0644))
+		require.NoError(t, (&partIndex{Segments: segments, Completed: 2, Offset: 22}).save(dest))
+
+		err := NewDownloader().DownloadResumable(context.Background(), server.URL+"/index.m3u8", dest, nil, ResumeOptions{}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits[0]))
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, want.String(), string(data))
+	})
+
+	t.Run("discards partial data of a different stream", func(t *testing.T) {
+		server, hits := newSegmentServer(t, segments)
+		dest := filepath.Join(t.TempDir(), "episode.ts")
+
+		// A partial file from another episode with the same segment count
+		require.NoError(t, os.WriteFile(PartPath(dest), []byte("other-00;other-01;"), 0644))
+		stale := &partIndex{
+			Playlist:     "https://other.example/index.m3u8",
+			FirstSegment: "other0.ts",
+			LastSegment:  "other9.ts",
+			Segments:     segments,
+			Completed:    2,
+			Offset:       18,
+		}
+		require.NoError(t, stale.save(dest))
+
+		d := NewDownloader()
+		d.SetConcurrency(1)
+		err := d.DownloadResumable(context.Background(), server.URL+"/index.m3u8", dest, nil, ResumeOptions{Resume: true}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits[0]))
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, want.String(), string(data))
+	})
+
+	t.Run("removes partial data on failure unless keep_partial", func(t *testing.T) {
+		for _, keep := range []bool{false, true} {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("#EXTM3U\n#EXTINF:10.0,\nok.ts\n#EXTINF:10.0,\nmissing.ts\n#EXT-X-ENDLIST\n"))
+			})
+			mux.HandleFunc("/ok.ts", func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("ok"))
+			})
+			server := httptest.NewServer(mux)
+
+			dest := filepath.Join(t.TempDir(), "episode.ts")
+			d := NewDownloader()
+			d.SetConcurrency(1)
+			err := d.DownloadResumable(context.Background(), server.URL+"/index.m3u8", dest, nil, ResumeOptions{Resume: true, KeepPartial: keep}, nil)
+			server.Close()
+			require.Error(t, err)
+
+			_, err = os.Stat(PartPath(dest))
+			assert.Equal(t, keep, err == nil, "keep_partial=%v", keep)
+			_, err = os.Stat(indexPath(dest))
+			assert.Equal(t, keep, err == nil, "keep_partial=%v", keep)
+		}
+	})
+}
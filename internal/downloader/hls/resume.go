@@ -0,0 +1,224 @@
+package hls
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ResumeOptions controls how interrupted downloads are handled
+type ResumeOptions struct {
+	// Resume continues from the partial file of an earlier attempt instead
+	// of starting over
+	Resume bool
+	// KeepPartial keeps the partial file and its index when a download fails.
+	// A cancelled download always keeps them, since pausing cancels it.
+	KeepPartial bool
+}
+
+// partIndex records how much of a download has been written to its .part
+// file. Segments are written in playlist order, so the completed segments
+// are always segments[0:Completed] and occupy the first Offset bytes.
+//
+// Stream URLs carry expiring tokens, so a stream is identified by its
+// playlist URL without the query plus the names of its first and last
+// segments rather than by the full URL.
+type partIndex struct {
+	URL          string    `json:"url"`
+	Playlist     string    `json:"playlist"`
+	FirstSegment string    `json:"first_segment"`
+	LastSegment  string    `json:"last_segment"`
+	Segments     int       `json:"segments"`
+	Completed    int       `json:"completed"`
+	Offset       int64     `json:"offset"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// newPartIndex returns an empty index for the playlist at playlistURL
+func newPartIndex(playlistURL string, segments []Segment) *partIndex {
+	index := &partIndex{
+		URL:      playlistURL,
+		Playlist: stripQuery(playlistURL),
+		Segments: len(segments),
+	}
+	if len(segments) > 0 {
+		index.FirstSegment = segmentName(segments[0].URL)
+		index.LastSegment = segmentName(segments[len(segments)-1].URL)
+	}
+	return index
+}
+
+// sameStream reports whether p and other describe the same stream
+func (p *partIndex) sameStream(other *partIndex) bool {
+	return p.Playlist == other.Playlist &&
+		p.FirstSegment == other.FirstSegment &&
+		p.LastSegment == other.LastSegment &&
+		p.Segments == other.Segments
+}
+
+// stripQuery drops the query and fragment, which hold the expiring tokens,
+// from rawURL
+func stripQuery(rawURL string) string {
+	if i := strings.IndexAny(rawURL, "?#"); i >= 0 {
+		return rawURL[:i]
+	}
+	return rawURL
+}
+
+// segmentName returns the file name of a segment URL
+func segmentName(rawURL string) string {
+	return path.Base(stripQuery(rawURL))
+}
+
+// PartPath returns the path of the partial file for dest
+func PartPath(dest string) string {
+	return dest + ".part"
+}
+
+// indexPath returns the path of the segment index for dest
+func indexPath(dest string) string {
+	return dest + ".part.json"
+}
+
+// readPartIndex reads the segment index of a previous attempt, or returns nil
+// if there is none
+func readPartIndex(dest string) *partIndex {
+	data, err := os.ReadFile(indexPath(dest))
+	if err != nil {
+		return nil
+	}
+
+	var index partIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil
+	}
+	return &index
+}
+
+// loadPartIndex reads the segment index of a previous attempt at the stream
+// described by current. It returns nil if there is none or it does not match,
+// and discards the partial data of a different stream.
+func loadPartIndex(dest string, current *partIndex) *partIndex {
+	index := readPartIndex(dest)
+	if index == nil {
+		return nil
+	}
+
+	if !index.sameStream(current) || index.Completed <= 0 || index.Completed > index.Segments {
+		RemovePartial(dest)
+		return nil
+	}
+
+	info, err := os.Stat(PartPath(dest))
+	if err != nil || info.Size() < index.Offset {
+		return nil
+	}
+
+	return index
+}
+
+// save writes the index next to the partial file
+func (p *partIndex) save(dest string) error {
+	p.UpdatedAt = time.Now()
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	tmp := indexPath(dest) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, indexPath(dest))
+}
+
+// RemovePartial deletes the partial file and segment index for dest
+func RemovePartial(dest string) {
+	_ = os.Remove(PartPath(dest))
+	_ = os.Remove(indexPath(dest))
+}
+
+// DownloadResumable downloads an HLS stream into dest via a .part file and a
+// segment index, so an interrupted download can resume without fetching the
+// segments it already completed. On success the .part file is renamed to
+// dest. On failure the partial data is kept only if opts.KeepPartial is set;
+// when ctx is cancelled it is always kept so a paused download can resume.
+func (d *Downloader) DownloadResumable(ctx context.Context, url, dest string, headers map[string]string, opts ResumeOptions, progressCallback ProgressCallback) (err error) {
+	playlist, err := d.parsePlaylist(ctx, url, headers)
+	if err != nil {
+		return fmt.Errorf("failed to parse playlist: %w", err)
+	}
+
+	if len(playlist.Segments) == 0 {
+		return fmt.Errorf("playlist has no segments to download")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	index := newPartIndex(url, playlist.Segments)
+	if opts.Resume {
+		if previous := loadPartIndex(dest, index); previous != nil {
+			index.Completed = previous.Completed
+			index.Offset = previous.Offset
+		}
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY
+	if index.Completed == 0 {
+		flags |= os.O_TRUNC
+	}
+	outFile, err := os.OpenFile(PartPath(dest), flags, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create/open partial file: %w", err)
+	}
+
+	defer func() {
+		if err != nil && !opts.KeepPartial && !errors.Is(err, context.Canceled) {
+			RemovePartial(dest)
+		}
+	}()
+
+	// Drop anything written after the last recorded segment
+	if err := outFile.Truncate(index.Offset); err != nil {
+		_ = outFile.Close()
+		return fmt.Errorf("failed to truncate partial file: %w", err)
+	}
+	if _, err := outFile.Seek(index.Offset, 0); err != nil {
+		_ = outFile.Close()
+		return fmt.Errorf("failed to seek partial file: %w", err)
+	}
+
+	err = d.fetchSegments(ctx, url, playlist.Segments, index.Completed, headers, func(i int, data []byte) error {
+		if _, err := outFile.Write(data); err != nil {
+			return fmt.Errorf("failed to write segment %d: %w", i, err)
+		}
+		index.Completed = i + 1
+		index.Offset += int64(len(data))
+		if err := index.save(dest); err != nil {
+			return fmt.Errorf("failed to save segment index: %w", err)
+		}
+		return nil
+	}, progressCallback)
+
+	if closeErr := outFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close partial file: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(PartPath(dest), dest); err != nil {
+		return fmt.Errorf("failed to finalize download: %w", err)
+	}
+	_ = os.Remove(indexPath(dest))
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package hls
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+// RequestHeaders merges a stream's headers with its Referer and derives an
+// Origin from the Referer when none is set, as some CDNs reject segment
+// requests without them
+func RequestHeaders(headers map[string]string, referer string) map[string]string {
+	merged := make(map[string]string, len(headers)+2)
+	for key, value := range headers {
+		merged[key] = value
+	}
+
+	if referer != "" {
+		merged["Referer"] = referer
+	}
+	if _, ok := merged["Origin"]; !ok {
+		if u, err := url.Parse(merged["Referer"]); err == nil && u.Scheme != "" && u.Host != "" {
+			merged["Origin"] = u.Scheme + "://" + u.Host
+		}
+	}
+
+	return merged
+}
+
+// DownloadHLS downloads the HLS stream at playlist.URL into dest, fetching up
+// to segments segments concurrently, decrypting AES-128 segments and
+// concatenating them in order. The stream's Referer and Origin are sent with
+// every playlist, key and segment request.
+func DownloadHLS(ctx context.Context, playlist providers.StreamURL, dest string, segments int) error {
+	downloader := NewDownloader()
+	downloader.SetConcurrency(segments)
+
+	headers := RequestHeaders(playlist.Headers, playlist.Referer)
+
+	// Write to a temporary file so dest never holds a partial download
+	tempPath := dest + ".tmp"
+	if err := downloader.Download(ctx, playlist.URL, tempPath, headers); err != nil {
+		_ = os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, dest); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to rename download: %w", err)
+	}
+
+	return nil
+}
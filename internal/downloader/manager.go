@@ -12,6 +12,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/database"
+	"github.com/justchokingaround/greg/internal/downloader/ratelimit"
 	"github.com/justchokingaround/greg/internal/downloader/tools"
 	"github.com/justchokingaround/greg/internal/providers"
 	"gorm.io/gorm"
@@ -23,10 +24,14 @@ type Manager struct {
 
 	// Worker pool
 	workers  []*worker
-	queue    chan *DownloadTask
 	active   map[string]*activeDownload // task ID -> active download info
 	workerWg sync.WaitGroup             // Wait group for workers
 
+	// Tasks waiting for a worker, see queue.go
+	queueMu sync.Mutex
+	pending []*DownloadTask
+	wake    chan struct{}
+
 	// State
 	running bool
 	ctx     context.Context
@@ -46,6 +51,9 @@ type Manager struct {
 	// Database
 	db *gorm.DB
 
+	// Shared speed limit for all workers (downloads.max_speed)
+	limiter *ratelimit.Limiter
+
 	// Tools (still maintained for backward compatibility)
 	ytdlp  *tools.ToolInfo
 	ffmpeg *tools.ToolInfo
@@ -82,6 +90,14 @@ func NewManager(db *gorm.DB, cfg *config.DownloadsConfig, logger *slog.Logger) (
 		ffmpeg = &tools.ToolInfo{Type: tools.ToolFFmpeg, Available: false}
 	}
 
+	// A configured ffmpeg binary takes precedence over the one in PATH
+	if cfg.FFmpegBinary != "" && cfg.FFmpegBinary != "ffmpeg" {
+		ffmpeg = tools.DetectFFmpeg(cfg.FFmpegBinary)
+		if !ffmpeg.Available {
+			logger.Warn("configured ffmpeg binary not found", "ffmpeg_binary", cfg.FFmpegBinary)
+		}
+	}
+
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(cfg.Path, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create output directory: %w", err)
@@ -90,15 +106,16 @@ func NewManager(db *gorm.DB, cfg *config.DownloadsConfig, logger *slog.Logger) (
 	ctx, cancel := context.WithCancel(context.Background())
 
 	m := &Manager{
-		queue:  make(chan *DownloadTask, 100), // Buffered queue
-		active: make(map[string]*activeDownload),
-		config: cfg,
-		logger: logger,
-		db:     db,
-		ytdlp:  ytdlp,
-		ffmpeg: ffmpeg,
-		ctx:    ctx,
-		cancel: cancel,
+		active:  make(map[string]*activeDownload),
+		wake:    make(chan struct{}, 1),
+		limiter: ratelimit.New(cfg.MaxSpeed),
+		config:  cfg,
+		logger:  logger,
+		db:      db,
+		ytdlp:   ytdlp,
+		ffmpeg:  ffmpeg,
+		ctx:     ctx,
+		cancel:  cancel,
 	}
 
 	// Load existing queued/paused downloads from database
@@ -147,9 +164,6 @@ func (m *Manager) Stop() error {
 		m.cancel()
 	}
 
-	// Close queue channel
-	close(m.queue)
-
 	// Wait for all workers to finish
 	m.workerWg.Wait()
 
@@ -207,12 +221,11 @@ func (m *Manager) AddToQueue(ctx context.Context, task DownloadTask) error {
 	if task.StreamURL == "" {
 		return fmt.Errorf("stream URL is empty for episode %d", task.Episode)
 	}
+	task.StreamURL = providers.CleanStreamURL(task.StreamURL)
 
 	// Check disk space
-	if m.config.MinFreeSpace > 0 {
-		if err := m.checkDiskSpace(); err != nil {
-			return fmt.Errorf("insufficient disk space: %w", err)
-		}
+	if err := m.checkDiskSpace(&task); err != nil {
+		return err
 	}
 
 	// Generate output path from template
@@ -223,7 +236,8 @@ func (m *Manager) AddToQueue(ctx context.Context, task DownloadTask) error {
 		m.config.FilenameTemplate,
 	)
 
-	filename, err := ParseTemplate(template, task)
+	sanitizer := SanitizerFor(m.config)
+	filename, err := ParseTemplateWith(template, task, sanitizer)
 	if err != nil {
 		return fmt.Errorf("failed to parse filename template: %w", err)
 	}
@@ -233,7 +247,7 @@ func (m *Manager) AddToQueue(ctx context.Context, task DownloadTask) error {
 	switch task.MediaType {
 	case providers.MediaTypeAnime:
 		// anime/Title/Episode.mkv
-		showDir := SanitizeFilename(task.MediaTitle)
+		showDir := sanitizer.Sanitize(task.MediaTitle)
 		outputPath = filepath.Join(m.config.Path, "anime", showDir, filename)
 
 	case providers.MediaTypeMovie:
@@ -242,7 +256,7 @@ func (m *Manager) AddToQueue(ctx context.Context, task DownloadTask) error {
 
 	case providers.MediaTypeTV:
 		// tv/Title/Season NN/Episode.mkv
-		showDir := SanitizeFilename(task.MediaTitle)
+		showDir := sanitizer.Sanitize(task.MediaTitle)
 		if task.Season > 0 {
 			seasonDir := fmt.Sprintf("Season %02d", task.Season)
 			outputPath = filepath.Join(m.config.Path, "tv", showDir, seasonDir, filename)
@@ -253,7 +267,7 @@ func (m *Manager) AddToQueue(ctx context.Context, task DownloadTask) error {
 
 	case providers.MediaTypeManga:
 		// manga/Title/Chapter.cbz
-		showDir := SanitizeFilename(task.MediaTitle)
+		showDir := sanitizer.Sanitize(task.MediaTitle)
 		outputPath = filepath.Join(m.config.Path, "manga", showDir, filename)
 
 	default:
@@ -278,18 +292,8 @@ func (m *Manager) AddToQueue(ctx context.Context, task DownloadTask) error {
 		return fmt.Errorf("failed to save task to database: %w", err)
 	}
 
-	// Add to queue if manager is running
-	if m.running {
-		select {
-		case m.queue <- &task:
-			// Task added successfully
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-			// Queue is full, but task is saved to database
-			// It will be picked up when queue has space
-		}
-	}
+	// Workers pick it up as soon as one is free
+	m.push(&task)
 
 	return nil
 }
@@ -299,6 +303,8 @@ func (m *Manager) RemoveFromQueue(ctx context.Context, id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.drop(id)
+
 	// Check if task is active
 	if ad, exists := m.active[id]; exists {
 		// Cancel the download
@@ -368,6 +374,7 @@ func (m *Manager) ClearQueue(ctx context.Context) error {
 			// Only delete queued, completed, failed, or cancelled tasks
 			status := DownloadStatus(d.Status)
 			if status == StatusQueued || status.IsComplete() {
+				m.drop(d.ID)
 				if err := m.db.Delete(&d).Error; err != nil {
 					return fmt.Errorf("failed to delete task %s: %w", d.ID, err)
 				}
@@ -420,16 +427,8 @@ func (m *Manager) Resume(ctx context.Context, id string) error {
 		return err
 	}
 
-	// Add back to queue if running
-	if m.running {
-		select {
-		case m.queue <- &task:
-			// Task re-queued successfully
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-	}
+	// Add back to queue
+	m.push(&task)
 
 	return nil
 }
@@ -463,14 +462,7 @@ func (m *Manager) ResumeAll(ctx context.Context) error {
 		task.Status = StatusQueued
 		_ = m.updateTaskInDB(task)
 
-		if m.running {
-			select {
-			case m.queue <- &task:
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
-		}
+		m.push(&task)
 	}
 
 	return nil
@@ -481,6 +473,8 @@ func (m *Manager) Cancel(ctx context.Context, id string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	m.drop(id)
+
 	// Remove from active if present
 	if ad, exists := m.active[id]; exists {
 		if ad.cancel != nil {
@@ -527,15 +521,8 @@ func (m *Manager) Retry(ctx context.Context, id string) error {
 
 	task := m.downloadToTask(download)
 
-	// Add back to queue if running
-	if m.running {
-		select {
-		case m.queue <- &task:
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-	}
+	// Add back to queue
+	m.push(&task)
 
 	return nil
 }
@@ -630,6 +617,7 @@ func (m *Manager) SetMaxSpeed(bytesPerSecond int64) {
 	defer m.mu.Unlock()
 
 	m.config.MaxSpeed = bytesPerSecond
+	m.limiter.SetRate(bytesPerSecond)
 }
 
 // startWorkerPool starts the worker goroutines
@@ -647,7 +635,7 @@ func (m *Manager) startWorkerPool() {
 		m.workerWg.Add(1)
 		go func() {
 			defer m.workerWg.Done()
-			w.run(m.ctx, m.queue)
+			w.run(m.ctx)
 		}()
 	}
 }
@@ -679,11 +667,7 @@ func (m *Manager) loadQueueFromDB() error {
 		// Add to queue if status is queued and auto-resume is enabled
 		if m.config.AutoResume && task.Status == StatusQueued {
 			// Will be picked up by workers when started
-			select {
-			case m.queue <- &task:
-			default:
-				// Queue is full, task will remain in database
-			}
+			m.push(&task)
 		}
 	}
 
@@ -765,9 +749,6 @@ func (m *Manager) downloadToTask(download database.Download) DownloadTask {
 	}
 }
 
-// checkDiskSpace checks if there's enough free disk space
-// Platform-specific implementations in diskspace_*.go files
-
 // triggerProgressCallback safely triggers the progress callback
 func (m *Manager) triggerProgressCallback(task DownloadTask) {
 	m.mu.RLock()
@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 
@@ -279,7 +278,7 @@ func (m *Manager) AddMangaToQueue(ctx context.Context, provider providers.MangaP
 
 	// Generate output path if not set
 	if task.OutputPath == "" {
-		sanitizedTitle := sanitizeFilename(task.MediaTitle)
+		sanitizedTitle := SanitizerFor(m.config).Sanitize(task.MediaTitle)
 		filename := fmt.Sprintf("%s - Chapter %d.cbz", sanitizedTitle, task.ChapterNum)
 		task.OutputPath = filepath.Join(m.config.Path, "manga", sanitizedTitle, filename)
 	}
@@ -317,20 +316,3 @@ func (m *Manager) AddMangaToQueue(ctx context.Context, provider providers.MangaP
 	m.logger.Info("added manga chapter to queue", "chapter", task.ChapterTitle)
 	return nil
 }
-
-// sanitizeFilename removes invalid characters from filenames
-func sanitizeFilename(name string) string {
-	// Replace invalid characters
-	replacer := strings.NewReplacer(
-		"/", "-",
-		"\\", "-",
-		":", "-",
-		"*", "",
-		"?", "",
-		"\"", "",
-		"<", "",
-		">", "",
-		"|", "",
-	)
-	return replacer.Replace(name)
-}
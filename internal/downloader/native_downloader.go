@@ -16,6 +16,7 @@ import (
 	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/database"
 	"github.com/justchokingaround/greg/internal/downloader/hls"
+	"github.com/justchokingaround/greg/internal/downloader/ratelimit"
 	"gorm.io/gorm"
 )
 
@@ -25,6 +26,10 @@ type NativeDownloader struct {
 	config *config.DownloadsConfig
 	logger *slog.Logger
 
+	// limiter caps the combined speed of all downloads (downloads.max_speed);
+	// shared by every worker of a manager, nil for unlimited
+	limiter *ratelimit.Limiter
+
 	// Progress and callbacks
 	onProgress func(DownloadTask)
 	onComplete func(DownloadTask)
@@ -83,22 +88,21 @@ func (d *NativeDownloader) downloadHLS(ctx context.Context, task *DownloadTask)
 	downloadCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	// Prepare headers including referer if provided
-	requestHeaders := make(map[string]string)
-	for k, v := range task.Headers {
-		requestHeaders[k] = v
-	}
-
-	// Add referer if provided separately
-	if task.Referer != "" {
-		requestHeaders["Referer"] = task.Referer
-	}
+	// Prepare headers including referer and origin if provided
+	requestHeaders := hls.RequestHeaders(task.Headers, task.Referer)
 
 	// Create HLS downloader with progress reporting
 	hlsDownloader := hls.NewDownloader()
+	hlsDownloader.SetConcurrency(d.config.ConcurrentSegments)
+	hlsDownloader.SetRateLimiter(d.limiter)
 
-	// Download the HLS stream with progress reporting
-	if err := hlsDownloader.DownloadWithProgress(downloadCtx, task.StreamURL, task.OutputPath, requestHeaders, func(downloaded, total int) {
+	// Download into a .part file with a segment index so an interrupted
+	// download can pick up where it left off
+	resume := hls.ResumeOptions{
+		Resume:      d.config.AutoResume,
+		KeepPartial: d.config.KeepPartial,
+	}
+	if err := hlsDownloader.DownloadResumable(downloadCtx, task.StreamURL, task.OutputPath, requestHeaders, resume, func(downloaded, total int) {
 		if total > 0 {
 			progress := float64(downloaded) / float64(total) * 100.0
 			task.Progress = progress
@@ -284,11 +288,13 @@ func (d *NativeDownloader) downloadDirectConcurrent(ctx context.Context, task *D
 				return
 			}
 
+			// All parts draw from the manager's shared speed limit
+			body := ratelimit.NewReader(partCtx, resp.Body, d.limiter)
 			buf := make([]byte, 32*1024)
 			offset := start
 
 			for {
-				n, err := resp.Body.Read(buf)
+				n, err := body.Read(buf)
 				if n > 0 {
 					// WriteAt is thread-safe
 					if _, wErr := f.WriteAt(buf[:n], offset); wErr != nil {
@@ -382,7 +388,8 @@ func (d *NativeDownloader) downloadDirectSingle(ctx context.Context, task *Downl
 	}
 	defer func() { _ = out.Close() }()
 
-	// Download with progress tracking
+	// Download with progress tracking, throttled by the shared speed limit
+	body := ratelimit.NewReader(ctx, resp.Body, d.limiter)
 	buffer := make([]byte, 32*1024) // 32KB buffer
 	var downloaded int64
 	lastUpdate := time.Now()
@@ -395,7 +402,7 @@ func (d *NativeDownloader) downloadDirectSingle(ctx context.Context, task *Downl
 		default:
 		}
 
-		n, err := resp.Body.Read(buffer)
+		n, err := body.Read(buffer)
 		if n > 0 {
 			if _, writeErr := out.Write(buffer[:n]); writeErr != nil {
 				return fmt.Errorf("failed to write to file: %w", writeErr)
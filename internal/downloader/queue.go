@@ -0,0 +1,126 @@
+package downloader
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/justchokingaround/greg/internal/database"
+)
+
+// The pending queue holds tasks waiting for a free worker. It is unbounded so
+// queuing a whole season never drops episodes; the worker pool, sized by
+// downloads.concurrent, caps how many of them download at once.
+
+// push appends a task to the pending queue and wakes an idle worker
+func (m *Manager) push(task *DownloadTask) {
+	m.queueMu.Lock()
+	m.pending = append(m.pending, task)
+	m.queueMu.Unlock()
+
+	m.signal()
+}
+
+// signal wakes one idle worker without blocking
+func (m *Manager) signal() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// drop removes a task from the pending queue, if it is still waiting
+func (m *Manager) drop(id string) {
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+
+	for i, task := range m.pending {
+		if task.ID == id {
+			m.pending = append(m.pending[:i], m.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// next blocks until a pending task is available or ctx is done
+func (m *Manager) next(ctx context.Context) (*DownloadTask, bool) {
+	for {
+		m.queueMu.Lock()
+		if len(m.pending) > 0 {
+			task := m.pending[0]
+			m.pending = m.pending[1:]
+			more := len(m.pending) > 0
+			m.queueMu.Unlock()
+
+			// Hand the wake-up on so other idle workers see the rest
+			if more {
+				m.signal()
+			}
+			return task, true
+		}
+		m.queueMu.Unlock()
+
+		select {
+		case <-m.wake:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// Enqueue adds a task to the download queue like AddToQueue and returns its
+// ID, which can be passed to Job to follow its progress. At most
+// downloads.concurrent tasks download at once; the rest wait their turn.
+func (m *Manager) Enqueue(ctx context.Context, task DownloadTask) (string, error) {
+	if task.ID == "" {
+		task.ID = uuid.New().String()
+	}
+	if err := m.AddToQueue(ctx, task); err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
+
+// Job returns the current state of a queued or downloading task, including
+// its progress. Tasks that have finished are looked up in the database.
+func (m *Manager) Job(id string) (DownloadTask, bool) {
+	m.mu.RLock()
+	ad, active := m.active[id]
+	m.mu.RUnlock()
+	if active {
+		return *ad.task, true
+	}
+
+	m.queueMu.Lock()
+	for _, task := range m.pending {
+		if task.ID == id {
+			m.queueMu.Unlock()
+			return *task, true
+		}
+	}
+	m.queueMu.Unlock()
+
+	var download database.Download
+	if err := m.db.First(&download, "id = ?", id).Error; err != nil {
+		return DownloadTask{}, false
+	}
+	return m.downloadToTask(download), true
+}
+
+// Jobs returns the tasks currently downloading, in no particular order,
+// followed by those waiting for a worker, in queue order
+func (m *Manager) Jobs() []DownloadTask {
+	m.mu.RLock()
+	jobs := make([]DownloadTask, 0, len(m.active))
+	for _, ad := range m.active {
+		jobs = append(jobs, *ad.task)
+	}
+	m.mu.RUnlock()
+
+	m.queueMu.Lock()
+	for _, task := range m.pending {
+		jobs = append(jobs, *task)
+	}
+	m.queueMu.Unlock()
+
+	return jobs
+}
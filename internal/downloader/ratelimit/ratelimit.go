@@ -0,0 +1,144 @@
+// Package ratelimit provides a token bucket shared by concurrent downloads so
+// their combined throughput stays under a configured speed
+package ratelimit
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// maxChunk bounds how much a single read may take from the bucket, so one
+// reader can't starve the others with a huge buffer
+const maxChunk = 32 * 1024
+
+// Limiter is a token bucket measured in bytes. A single Limiter is shared by
+// every reader of a download manager, so the cap applies to the aggregate
+// throughput rather than to each connection. A nil Limiter or a rate of 0
+// means unlimited.
+type Limiter struct {
+	mu     sync.Mutex
+	rate   int64   // bytes per second
+	tokens float64 // may go negative while readers wait for their reservation
+	last   time.Time
+}
+
+// New creates a limiter allowing bytesPerSecond, 0 for unlimited
+func New(bytesPerSecond int64) *Limiter {
+	l := &Limiter{}
+	l.SetRate(bytesPerSecond)
+	return l
+}
+
+// SetRate changes the limit; it applies to reads from now on
+func (l *Limiter) SetRate(bytesPerSecond int64) {
+	if bytesPerSecond < 0 {
+		bytesPerSecond = 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = bytesPerSecond
+	l.tokens = 0
+	l.last = time.Now()
+}
+
+// Rate returns the current limit in bytes per second, 0 if unlimited
+func (l *Limiter) Rate() int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// WaitN takes n bytes from the bucket, blocking until they are available or
+// ctx is done
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if l == nil || n <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.rate <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+
+	// Refill, allowing at most one second of burst
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+	if burst := float64(l.rate); l.tokens > burst {
+		l.tokens = burst
+	}
+	l.last = now
+
+	// Reserve the bytes now and wait until the bucket has paid them back,
+	// so concurrent readers queue up behind each other
+	l.tokens -= float64(n)
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// chunk returns how many bytes a single read should take
+func (l *Limiter) chunk(n int) int {
+	if n > maxChunk {
+		n = maxChunk
+	}
+	if rate := l.Rate(); rate > 0 && int64(n) > rate {
+		n = int(rate)
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// reader throttles reads through a shared Limiter
+type reader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *Limiter
+}
+
+// NewReader returns a reader that draws every byte read from r from limiter.
+// It returns r unchanged if limiter is nil.
+func NewReader(ctx context.Context, r io.Reader, limiter *Limiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	return &reader{ctx: ctx, r: r, limiter: limiter}
+}
+
+// Read implements io.Reader
+func (r *reader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	n, err := r.r.Read(p[:r.limiter.chunk(len(p))])
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
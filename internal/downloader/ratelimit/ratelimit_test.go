@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_AggregateThroughput(t *testing.T) {
+	const (
+		rate    = 200 * 1024 // bytes per second
+		readers = 3
+		size    = 40 * 1024 // per reader
+	)
+
+	limiter := New(rate)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := NewReader(context.Background(), bytes.NewReader(make([]byte, size)), limiter)
+			n, err := io.Copy(io.Discard, r)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(size), n)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	// Three readers share one bucket, so together they can't beat the cap
+	throughput := float64(readers*size) / elapsed.Seconds()
+	assert.LessOrEqual(t, throughput, rate*1.1, "aggregate throughput %.0f B/s exceeds cap", throughput)
+	assert.Greater(t, throughput, rate*0.5, "limiter is throttling far below the cap")
+}
+
+func TestLimiter_Unlimited(t *testing.T) {
+	var nilLimiter *Limiter
+	assert.NoError(t, nilLimiter.WaitN(context.Background(), 1<<30))
+
+	limiter := New(0)
+	start := time.Now()
+	require.NoError(t, limiter.WaitN(context.Background(), 1<<30))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	r := bytes.NewReader(nil)
+	assert.Equal(t, io.Reader(r), NewReader(context.Background(), r, nil))
+}
+
+func TestLimiter_Cancel(t *testing.T) {
+	limiter := New(1024)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := limiter.WaitN(ctx, 10*1024)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
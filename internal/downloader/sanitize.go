@@ -0,0 +1,150 @@
+package downloader
+
+import (
+	"regexp"
+	"runtime"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/justchokingaround/greg/internal/config"
+)
+
+// maxComponentLength caps a sanitized file or directory name in bytes,
+// leaving room for an extension and the " (1)" EnsureUniqueFilename adds
+// within the usual 255 byte limit
+const maxComponentLength = 200
+
+// defaultReplacements are the readable substitutes used for illegal
+// characters when no replacement is configured
+var defaultReplacements = map[rune]string{
+	'/':  "-",
+	'\\': "-",
+	':':  " -",
+	'*':  "",
+	'?':  "",
+	'"':  "'",
+	'<':  "",
+	'>':  "",
+	'|':  "-",
+}
+
+// windowsReserved matches names Windows reserves for devices, with or
+// without an extension
+var windowsReserved = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9]|lpt[0-9])(\..*)?$`)
+
+var multipleSpaces = regexp.MustCompile(`\s+`)
+
+// Sanitizer makes a rendered template safe to use as a single file or
+// directory name. By default names are kept portable, so a library copied
+// to another operating system keeps its file names.
+type Sanitizer struct {
+	// OS is the runtime.GOOS value whose filename rules apply
+	OS string
+
+	// Native only replaces the characters OS itself forbids instead of
+	// every character some common OS forbids
+	Native bool
+
+	// Replacement replaces each illegal character. When empty, readable
+	// substitutes are used instead, e.g. ": " becomes " - ".
+	Replacement string
+
+	// MaxLength caps the name's length in bytes; zero means no limit
+	MaxLength int
+}
+
+// NewSanitizer returns a sanitizer for the current OS that replaces illegal
+// characters with replacement
+func NewSanitizer(replacement string) Sanitizer {
+	return Sanitizer{OS: runtime.GOOS, Replacement: replacement, MaxLength: maxComponentLength}
+}
+
+// SanitizerFor returns a sanitizer for the current OS set up by the
+// downloads config
+func SanitizerFor(cfg *config.DownloadsConfig) Sanitizer {
+	s := NewSanitizer(cfg.SanitizeReplacement)
+	s.Native = cfg.NativeFilenames
+	return s
+}
+
+// illegal reports whether ch can't appear in a filename. Unless s.Native is
+// set that is every character Windows forbids, the strictest of the common
+// systems. Native names follow s.OS alone: Windows forbids the most, macOS
+// only '/' and ':' (which Finder shows as '/'). Backslashes are illegal
+// everywhere since they separate paths on Windows.
+func (s Sanitizer) illegal(ch rune) bool {
+	switch ch {
+	case '/', '\\':
+		return true
+	case ':':
+		return !s.Native || s.OS == "windows" || s.OS == "darwin"
+	case '*', '?', '"', '<', '>', '|':
+		return !s.Native || s.OS == "windows"
+	}
+	return false
+}
+
+// replacement returns what illegal characters are replaced with. A
+// configured replacement that is itself illegal falls back to the defaults.
+func (s Sanitizer) replacement(ch rune) string {
+	if s.Replacement != "" && !strings.ContainsFunc(s.Replacement, s.illegal) {
+		return s.Replacement
+	}
+	return defaultReplacements[ch]
+}
+
+// Sanitize replaces illegal characters, drops control characters,
+// collapses whitespace and caps the length. On Windows trailing dots and
+// spaces are trimmed and reserved device names such as "CON" get a "_"
+// appended to the device name. An empty result becomes "download".
+func (s Sanitizer) Sanitize(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for _, ch := range name {
+		switch {
+		case s.illegal(ch):
+			b.WriteString(s.replacement(ch))
+		case ch == '\n' || ch == '\r' || ch == '\t':
+			b.WriteByte(' ')
+		case !unicode.IsPrint(ch):
+			// Skip non-printable characters
+		default:
+			b.WriteRune(ch)
+		}
+	}
+
+	cleaned := multipleSpaces.ReplaceAllString(b.String(), " ")
+	cleaned = strings.Trim(cleaned, " ")
+	cleaned = strings.TrimLeft(cleaned, ".")
+	if s.OS == "windows" {
+		cleaned = strings.TrimRight(cleaned, " .")
+	}
+	cleaned = s.truncate(cleaned)
+
+	if cleaned == "" {
+		return "download"
+	}
+	if s.OS == "windows" {
+		cleaned = windowsReserved.ReplaceAllString(cleaned, "${1}_${2}")
+	}
+	return cleaned
+}
+
+// truncate cuts name to MaxLength bytes without splitting a character,
+// preferring to cut at a word boundary
+func (s Sanitizer) truncate(name string) string {
+	if s.MaxLength <= 0 || len(name) <= s.MaxLength {
+		return name
+	}
+
+	cut := s.MaxLength
+	for cut > 0 && !utf8.RuneStart(name[cut]) {
+		cut--
+	}
+	name = name[:cut]
+	if lastSpace := strings.LastIndex(name, " "); lastSpace > s.MaxLength*3/4 {
+		name = name[:lastSpace]
+	}
+	return strings.TrimRight(name, " .-")
+}
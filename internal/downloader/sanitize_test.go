@@ -0,0 +1,112 @@
+package downloader
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizerPerOS(t *testing.T) {
+	name := `Re:Zero - What? <Part "1"> *|* AC/DC\Live.`
+
+	tests := []struct {
+		os          string
+		native      bool
+		replacement string
+		want        string
+	}{
+		// Portable by default, whatever the OS
+		{"linux", false, "", `Re -Zero - What Part '1' - AC-DC-Live.`},
+		{"darwin", false, "", `Re -Zero - What Part '1' - AC-DC-Live.`},
+		{"windows", false, "", `Re -Zero - What Part '1' - AC-DC-Live`},
+		{"linux", false, "_", `Re_Zero - What_ _Part _1__ ___ AC_DC_Live.`},
+		{"windows", false, "_", `Re_Zero - What_ _Part _1__ ___ AC_DC_Live`},
+		// A replacement that is itself illegal falls back to the defaults
+		{"linux", false, "?", `Re -Zero - What Part '1' - AC-DC-Live.`},
+		// Native names only avoid what the OS forbids
+		{"linux", true, "", `Re:Zero - What? <Part "1"> *|* AC-DC-Live.`},
+		{"darwin", true, "", `Re -Zero - What? <Part "1"> *|* AC-DC-Live.`},
+		{"windows", true, "", `Re -Zero - What Part '1' - AC-DC-Live`},
+		{"linux", true, "_", `Re:Zero - What? <Part "1"> *|* AC_DC_Live.`},
+		{"linux", true, "?", `Re:Zero - What? <Part "1"> *|* AC?DC?Live.`},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/native=%v/%s", tt.os, tt.native, tt.replacement), func(t *testing.T) {
+			s := Sanitizer{OS: tt.os, Native: tt.native, Replacement: tt.replacement, MaxLength: maxComponentLength}
+			assert.Equal(t, tt.want, s.Sanitize(name))
+		})
+	}
+}
+
+func TestSanitizerFor(t *testing.T) {
+	name := `Who? What: "Why"`
+
+	s := SanitizerFor(&config.DownloadsConfig{})
+	assert.Equal(t, `Who What - 'Why'`, s.Sanitize(name))
+	assert.Equal(t, `Who What - 'Why'`, SanitizeFilename(name))
+
+	s = SanitizerFor(&config.DownloadsConfig{SanitizeReplacement: "_", NativeFilenames: true})
+	s.OS = "linux"
+	assert.Equal(t, name, s.Sanitize(name))
+}
+
+func TestSanitizerCleanup(t *testing.T) {
+	linux := Sanitizer{OS: "linux"}
+	windows := Sanitizer{OS: "windows"}
+
+	assert.Equal(t, "Line one Line two", linux.Sanitize("Line one\n\tLine  two"))
+	assert.Equal(t, "Bell", linux.Sanitize("Be\x07ll"))
+	assert.Equal(t, "hidden", linux.Sanitize("..hidden"), "leading dots would hide the file")
+	assert.Equal(t, "Ends with dots...", linux.Sanitize(" Ends with dots... "))
+	assert.Equal(t, "Ends with dots", windows.Sanitize("Ends with dots. . "))
+	assert.Equal(t, "download", linux.Sanitize(" \x00 "))
+	assert.Equal(t, "download", windows.Sanitize("..."))
+
+	// Reserved device names
+	assert.Equal(t, "CON_", windows.Sanitize("CON"))
+	assert.Equal(t, "com1_.mkv", windows.Sanitize("com1.mkv"))
+	assert.Equal(t, "CON", linux.Sanitize("CON"))
+	assert.Equal(t, "Console", windows.Sanitize("Console"))
+}
+
+func TestSanitizerMaxLength(t *testing.T) {
+	s := Sanitizer{OS: "linux", MaxLength: 20}
+
+	// Cut at a word boundary near the limit
+	assert.Equal(t, "The quick brown fox", s.Sanitize("The quick brown fox jumps over"))
+
+	// Multi-byte characters are never split
+	got := s.Sanitize(strings.Repeat("é", 30))
+	assert.True(t, utf8.ValidString(got))
+	assert.LessOrEqual(t, len(got), 20)
+	assert.Equal(t, strings.Repeat("é", 10), got)
+
+	assert.Len(t, NewSanitizer("").Sanitize(strings.Repeat("a", 300)), maxComponentLength)
+}
+
+func TestParseTemplateWithSanitizer(t *testing.T) {
+	task := DownloadTask{
+		MediaTitle: "Mission: Impossible (1996)",
+		MediaType:  providers.MediaTypeMovie,
+		Quality:    "1080p",
+	}
+
+	got, err := ParseTemplateWith("{title} ({year}) [{quality}]", task, Sanitizer{OS: "windows", Replacement: "_"})
+	require.NoError(t, err)
+	assert.Equal(t, "Mission_ Impossible (1996) [1080p].mp4", got)
+
+	got, err = ParseTemplateWith("{title} ({year}) [{quality}]", task, Sanitizer{OS: "linux", Replacement: "_"})
+	require.NoError(t, err)
+	assert.Equal(t, "Mission_ Impossible (1996) [1080p].mp4", got)
+
+	got, err = ParseTemplateWith("{title} ({year}) [{quality}]", task, Sanitizer{OS: "linux", Native: true, Replacement: "_"})
+	require.NoError(t, err)
+	assert.Equal(t, "Mission: Impossible (1996) [1080p].mp4", got)
+}
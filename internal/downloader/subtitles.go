@@ -0,0 +1,68 @@
+package downloader
+
+import (
+	"fmt"
+
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/subtitles"
+)
+
+// filterSubtitles returns the subtitles whose language is in languages,
+// ordered by the preference in languages. An empty list keeps everything.
+func filterSubtitles(subs []providers.Subtitle, languages []string) []providers.Subtitle {
+	if len(languages) == 0 {
+		return subs
+	}
+
+	var filtered []providers.Subtitle
+	picked := make([]bool, len(subs))
+	for _, wanted := range languages {
+		for i, sub := range subs {
+			if picked[i] {
+				continue
+			}
+			if subtitles.MatchesLanguage(sub.Language, wanted) {
+				filtered = append(filtered, sub)
+				picked[i] = true
+			}
+		}
+	}
+	return filtered
+}
+
+// subtitleMetadataLanguage returns the ISO 639-2 code written into the
+// container for a subtitle label, or "und" if it is not recognised
+func subtitleMetadataLanguage(label string) string {
+	if lang, ok := subtitles.LookupLanguage(label); ok {
+		return lang.ISO2
+	}
+	return "und"
+}
+
+// subtitleMuxArgs builds the ffmpeg arguments that copy the video and audio of
+// input into output and add each subtitle file as a track with its language
+// and title set
+func subtitleMuxArgs(input, output string, subFiles []string, subs []providers.Subtitle) []string {
+	args := []string{"-i", input}
+	for _, subFile := range subFiles {
+		args = append(args, "-i", subFile)
+	}
+
+	// Keep every video and audio stream of the original; audio may be absent
+	args = append(args, "-map", "0:v", "-map", "0:a?")
+
+	for i := range subFiles {
+		args = append(args, "-map", fmt.Sprintf("%d:0", i+1))
+
+		label := subs[i].Language
+		args = append(args, fmt.Sprintf("-metadata:s:s:%d", i), "language="+subtitleMetadataLanguage(label))
+		if label != "" {
+			args = append(args, fmt.Sprintf("-metadata:s:s:%d", i), "title="+label)
+		}
+	}
+
+	// Copy video/audio, but convert subtitles to SRT format for MKV compatibility
+	args = append(args, "-c:v", "copy", "-c:a", "copy", "-c:s", "srt")
+
+	return append(args, "-y", output)
+}
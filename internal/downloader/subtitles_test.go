@@ -0,0 +1,71 @@
+package downloader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterSubtitles(t *testing.T) {
+	subs := []providers.Subtitle{
+		{Language: "English", URL: "en.vtt"},
+		{Language: "Spanish - Latin America", URL: "es.vtt"},
+		{Language: "Japanese", URL: "ja.vtt"},
+		{Language: "Malayalam", URL: "ml.vtt"},
+		{Language: "en-US", URL: "en-us.vtt"},
+	}
+
+	urls := func(subs []providers.Subtitle) []string {
+		var out []string
+		for _, s := range subs {
+			out = append(out, s.URL)
+		}
+		return out
+	}
+
+	t.Run("keeps configured languages in preference order", func(t *testing.T) {
+		assert.Equal(t, []string{"ja.vtt", "en.vtt", "en-us.vtt"}, urls(filterSubtitles(subs, []string{"ja", "en"})))
+	})
+
+	t.Run("does not match a language by a shorter name", func(t *testing.T) {
+		assert.Empty(t, filterSubtitles(subs, []string{"ms"}))
+	})
+
+	t.Run("no duplicates for overlapping codes", func(t *testing.T) {
+		assert.Equal(t, []string{"es.vtt"}, urls(filterSubtitles(subs, []string{"es", "spa"})))
+	})
+
+	t.Run("empty list keeps everything", func(t *testing.T) {
+		assert.Len(t, filterSubtitles(subs, nil), len(subs))
+	})
+}
+
+func TestSubtitleMetadataLanguage(t *testing.T) {
+	tests := map[string]string{
+		"English":              "eng",
+		"en":                   "eng",
+		"pt_BR":                "por",
+		"Portuguese (Brazil)":  "por",
+		"Chinese - Simplified": "zho",
+		"Klingon":              "und",
+		"":                     "und",
+	}
+	for label, want := range tests {
+		assert.Equal(t, want, subtitleMetadataLanguage(label), label)
+	}
+}
+
+func TestSubtitleMuxArgs(t *testing.T) {
+	args := subtitleMuxArgs("in.mp4", "out.mkv",
+		[]string{"a.vtt", "b.srt"},
+		[]providers.Subtitle{{Language: "Japanese"}, {Language: "English - SDH"}})
+	joined := strings.Join(args, " ")
+
+	assert.True(t, strings.HasPrefix(joined, "-i in.mp4 -i a.vtt -i b.srt "))
+	assert.Contains(t, joined, "-map 0:v -map 0:a? -map 1:0")
+	assert.Contains(t, joined, "-metadata:s:s:0 language=jpn -metadata:s:s:0 title=Japanese")
+	assert.Contains(t, joined, "-map 2:0 -metadata:s:s:1 language=eng -metadata:s:s:1 title=English - SDH")
+	assert.True(t, strings.HasSuffix(joined, "-c:s srt -y out.mkv"))
+}
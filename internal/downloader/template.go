@@ -7,7 +7,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"unicode"
 
 	"github.com/justchokingaround/greg/internal/providers"
 )
@@ -22,6 +21,12 @@ import (
 //	{provider} - Provider name
 //	{year} - Year (for movies, if available in title)
 func ParseTemplate(template string, task DownloadTask) (string, error) {
+	return ParseTemplateWith(template, task, NewSanitizer(""))
+}
+
+// ParseTemplateWith is ParseTemplate with the rendered name cleaned by
+// sanitizer instead of the defaults
+func ParseTemplateWith(template string, task DownloadTask, sanitizer Sanitizer) (string, error) {
 	if template == "" {
 		return "", fmt.Errorf("template cannot be empty")
 	}
@@ -68,7 +73,7 @@ func ParseTemplate(template string, task DownloadTask) (string, error) {
 	result = replaceNumberTemplate(result, "season", task.Season)
 
 	// Sanitize the filename
-	result = SanitizeFilename(result)
+	result = sanitizer.Sanitize(result)
 
 	// Add file extension based on quality/type
 	// Most streaming sources are MP4 or MKV
@@ -124,63 +129,10 @@ func extractYear(title string) string {
 	return ""
 }
 
-// SanitizeFilename removes or replaces invalid characters from a filename
-// Replaces filesystem-unsafe characters with safe alternatives
+// SanitizeFilename makes filename safe to use on the current OS with the
+// default replacements. See Sanitizer for the rules.
 func SanitizeFilename(filename string) string {
-	// Replace common problematic characters
-	replacements := map[rune]string{
-		'/':  "-",  // Path separator
-		'\\': "-",  // Windows path separator
-		':':  " -", // Colon (problematic on Windows)
-		'*':  "",   // Wildcard
-		'?':  "",   // Wildcard
-		'"':  "'",  // Quote
-		'<':  "",   // Redirect
-		'>':  "",   // Redirect
-		'|':  "-",  // Pipe
-		'\n': " ",  // Newline
-		'\r': " ",  // Carriage return
-		'\t': " ",  // Tab
-	}
-
-	var result strings.Builder
-	result.Grow(len(filename))
-
-	for _, ch := range filename {
-		if replacement, exists := replacements[ch]; exists {
-			result.WriteString(replacement)
-		} else if !unicode.IsPrint(ch) {
-			// Skip non-printable characters
-			continue
-		} else {
-			result.WriteRune(ch)
-		}
-	}
-
-	cleaned := result.String()
-
-	// Collapse multiple spaces
-	cleaned = regexp.MustCompile(`\s+`).ReplaceAllString(cleaned, " ")
-
-	// Trim spaces and dots from start/end (problematic on Windows)
-	cleaned = strings.Trim(cleaned, " .")
-
-	// Ensure filename is not empty
-	if cleaned == "" {
-		cleaned = "download"
-	}
-
-	// Limit filename length to 200 characters (leaving room for extension and path)
-	if len(cleaned) > 200 {
-		// Try to cut at a word boundary
-		cleaned = cleaned[:200]
-		if lastSpace := strings.LastIndex(cleaned, " "); lastSpace > 150 {
-			cleaned = cleaned[:lastSpace]
-		}
-		cleaned = strings.TrimRight(cleaned, " .-")
-	}
-
-	return cleaned
+	return NewSanitizer("").Sanitize(filename)
 }
 
 // EnsureUniqueFilename ensures the filename is unique by appending a number if necessary
@@ -67,6 +67,18 @@ func DetectTools() (ytdlp *ToolInfo, ffmpeg *ToolInfo, err error) {
 	return ytdlp, ffmpeg, nil
 }
 
+// DetectFFmpeg detects ffmpeg at a configured binary name or path
+func DetectFFmpeg(binary string) *ToolInfo {
+	ffmpeg := &ToolInfo{Type: ToolFFmpeg}
+	path, err := FindTool(binary)
+	if err == nil {
+		ffmpeg.Binary = path
+		ffmpeg.Available = true
+		ffmpeg.Version, _ = GetVersion(path)
+	}
+	return ffmpeg
+}
+
 // FindTool searches for a tool in the system PATH
 // Returns the full path to the binary or an error if not found
 func FindTool(name string) (string, error) {
@@ -14,6 +14,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/justchokingaround/greg/internal/providers"
 )
 
 // worker represents a download worker
@@ -35,6 +37,9 @@ func newWorker(id int, manager *Manager) *worker {
 	if err != nil {
 		logger.Error("failed to create native downloader", "error", err)
 		nativeDownloader = nil
+	} else {
+		// All workers share one limiter so max_speed caps their total
+		nativeDownloader.limiter = manager.limiter
 	}
 
 	return &worker{
@@ -46,27 +51,22 @@ func newWorker(id int, manager *Manager) *worker {
 }
 
 // run starts the worker loop
-func (w *worker) run(ctx context.Context, queue <-chan *DownloadTask) {
+func (w *worker) run(ctx context.Context) {
 	for {
-		select {
-		case <-ctx.Done():
+		task, ok := w.manager.next(ctx)
+		if !ok {
 			return
-		case task, ok := <-queue:
-			if !ok {
-				// Queue closed
-				return
-			}
+		}
 
-			// Process the task
-			w.currentTask = task
-			if err := w.processTask(ctx, task); err != nil {
-				task.Status = StatusFailed
-				task.Error = err.Error()
-				_ = w.manager.updateTaskInDB(*task)
-				w.manager.triggerErrorCallback(*task, err)
-			}
-			w.currentTask = nil
+		// Process the task
+		w.currentTask = task
+		if err := w.processTask(ctx, task); err != nil {
+			task.Status = StatusFailed
+			task.Error = err.Error()
+			_ = w.manager.updateTaskInDB(*task)
+			w.manager.triggerErrorCallback(*task, err)
 		}
+		w.currentTask = nil
 	}
 }
 
@@ -99,6 +99,11 @@ func (w *worker) processTask(ctx context.Context, task *DownloadTask) error {
 	_ = w.manager.updateTaskInDB(*task)
 	w.manager.triggerProgressCallback(*task)
 
+	// Space may have run out while the task was queued
+	if err := w.manager.checkDiskSpace(task); err != nil {
+		return err
+	}
+
 	// Attempt the download with retries for network-related failures
 	maxRetries := 3
 	var lastErr error
@@ -191,8 +196,8 @@ func (w *worker) processTask(ctx context.Context, task *DownloadTask) error {
 		return lastErr
 	}
 
-	// Embed subtitles if requested and available
-	if task.EmbedSubs && len(task.Subtitles) > 0 {
+	// Embed subtitles if requested and enabled in downloads.embed_subtitles
+	if task.EmbedSubs && w.manager.config.EmbedSubtitles && len(task.Subtitles) > 0 {
 		task.Status = StatusProcessing
 		_ = w.manager.updateTaskInDB(*task)
 		w.manager.triggerProgressCallback(*task)
@@ -255,7 +260,7 @@ func (w *worker) downloadWithYTDLP(ctx context.Context, task *DownloadTask) erro
 		args = append(args, "--user-agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
 	}
 
-	w.logger.Debug("invoking yt-dlp", "stream_url", task.StreamURL, "args", args)
+	w.logger.Debug("invoking yt-dlp", "stream_url", providers.RedactedStreamURL(task.StreamURL), "args", redactedArgs(args, task.StreamURL))
 
 	cmd := exec.CommandContext(ctx, w.manager.ytdlp.Binary, args...)
 
@@ -463,8 +468,8 @@ func (w *worker) downloadHLSWithFFmpeg(ctx context.Context, task *DownloadTask)
 	// Log the command for debugging
 	w.logger.Debug("invoking ffmpeg",
 		"binary", w.manager.ffmpeg.Binary,
-		"args", args,
-		"stream_url", task.StreamURL,
+		"args", redactedArgs(args, task.StreamURL),
+		"stream_url", providers.RedactedStreamURL(task.StreamURL),
 		"output", outputPath)
 
 	cmd := exec.CommandContext(ctx, w.manager.ffmpeg.Binary, args...)
@@ -752,7 +757,7 @@ func (w *worker) downloadWithMPV(ctx context.Context, task *DownloadTask) error
 	// Add the stream URL
 	args = append(args, task.StreamURL)
 
-	w.logger.Debug("invoking mpv download", "args", args, "stream_url", task.StreamURL)
+	w.logger.Debug("invoking mpv download", "args", redactedArgs(args, task.StreamURL), "stream_url", providers.RedactedStreamURL(task.StreamURL))
 
 	cmd := exec.CommandContext(ctx, "mpv", args...)
 
@@ -831,20 +836,29 @@ func (w *worker) downloadWithMPV(ctx context.Context, task *DownloadTask) error
 	return nil
 }
 
-// embedSubtitles embeds subtitles into the video file using ffmpeg
+// embedSubtitles muxes the task's subtitles in downloads.subtitle_languages
+// into the video file using ffmpeg. It is skipped with a warning when ffmpeg
+// isn't installed.
 func (w *worker) embedSubtitles(ctx context.Context, task *DownloadTask) error {
-	w.logger.Info("EMBEDDING SUBTITLES STARTED",
-		"subtitle_count", len(task.Subtitles),
-		"output_path", task.OutputPath,
-		"embed_subs", task.EmbedSubs)
+	if w.manager.ffmpeg == nil || !w.manager.ffmpeg.Available {
+		w.logger.Warn("ffmpeg not found, skipping subtitle embedding", "output_path", task.OutputPath)
+		return nil
+	}
 
-	if !w.manager.ffmpeg.Available {
-		w.logger.Warn("ffmpeg not available for subtitle embedding")
-		return fmt.Errorf("ffmpeg not available for subtitle embedding")
+	subs := filterSubtitles(task.Subtitles, w.manager.config.SubtitleLanguages)
+	if len(subs) == 0 {
+		w.logger.Info("no subtitles match subtitle_languages, skipping embedding",
+			"available", len(task.Subtitles),
+			"languages", w.manager.config.SubtitleLanguages)
+		return nil
 	}
 
-	// Download subtitle files
-	subFiles := make([]string, 0, len(task.Subtitles))
+	w.logger.Info("embedding subtitles", "subtitle_count", len(subs), "output_path", task.OutputPath)
+
+	// Download subtitle files, keeping each file paired with its subtitle so
+	// the language metadata stays correct when a download fails
+	subFiles := make([]string, 0, len(subs))
+	embedded := make([]providers.Subtitle, 0, len(subs))
 	defer func() {
 		// Cleanup subtitle files
 		for _, f := range subFiles {
@@ -852,88 +866,43 @@ func (w *worker) embedSubtitles(ctx context.Context, task *DownloadTask) error {
 		}
 	}()
 
-	for i, sub := range task.Subtitles {
-		// Download subtitle file
+	for i, sub := range subs {
 		subPath := filepath.Join(os.TempDir(), fmt.Sprintf("sub_%s_%d.%s", task.ID, i, getSubtitleExtension(sub.URL)))
-		w.logger.Info("downloading subtitle",
-			"index", i,
-			"language", sub.Language,
-			"url", sub.URL,
-			"path", subPath)
 		if err := w.downloadSubtitle(ctx, sub.URL, subPath); err != nil {
-			w.logger.Warn("failed to download subtitle", "error", err, "subtitle_index", i, "url", sub.URL)
+			w.logger.Warn("failed to download subtitle", "error", err, "language", sub.Language, "url", sub.URL)
 			continue
 		}
-		w.logger.Info("subtitle downloaded successfully", "index", i, "path", subPath)
 		subFiles = append(subFiles, subPath)
+		embedded = append(embedded, sub)
 	}
 
 	if len(subFiles) == 0 {
-		w.logger.Error("NO SUBTITLES DOWNLOADED - all subtitle downloads failed")
 		return fmt.Errorf("no subtitles downloaded")
 	}
 
-	w.logger.Info("downloaded subtitle files", "count", len(subFiles))
-
-	// Build ffmpeg command to embed subtitles
 	tempOutput := task.OutputPath + ".temp.mkv"
-
-	args := []string{
-		"-i", task.OutputPath, // Input video
-	}
-
-	// Add subtitle inputs
-	for _, subFile := range subFiles {
-		args = append(args, "-i", subFile)
-	}
-
-	// Map video and audio streams from first input
-	args = append(args, "-map", "0:v", "-map", "0:a")
-
-	// Map subtitle files (they're separate text files, not streams)
-	for i, subFile := range subFiles {
-		args = append(args, "-map", fmt.Sprintf("%d:0", i+1))
-		// Add metadata for subtitle language if available
-		if i < len(task.Subtitles) {
-			lang := task.Subtitles[i].Language
-			if lang == "" {
-				lang = "eng" // Default to English
-			}
-			args = append(args, fmt.Sprintf("-metadata:s:s:%d", i), fmt.Sprintf("language=%s", lang))
-			args = append(args, fmt.Sprintf("-metadata:s:s:%d", i), fmt.Sprintf("title=%s", task.Subtitles[i].Language))
-		}
-		_ = subFile // Keep for reference in loop
-	}
-
-	// Copy video/audio, but convert subtitles to SRT format for MKV compatibility
-	args = append(args, "-c:v", "copy", "-c:a", "copy", "-c:s", "srt")
-
-	// Output
-	args = append(args, "-y", tempOutput)
+	args := subtitleMuxArgs(task.OutputPath, tempOutput, subFiles, embedded)
 
 	cmd := exec.CommandContext(ctx, w.manager.ffmpeg.Binary, args...)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		w.logger.Error("FFMPEG SUBTITLE EMBEDDING FAILED",
+		_ = os.Remove(tempOutput)
+		w.logger.Error("ffmpeg subtitle embedding failed",
 			"error", err,
 			"output", string(output),
 			"command", fmt.Sprintf("%s %v", w.manager.ffmpeg.Binary, args))
 		return fmt.Errorf("ffmpeg subtitle embedding failed: %w, output: %s", err, string(output))
 	}
 
-	w.logger.Info("FFMPEG SUBTITLE EMBEDDING SUCCESS")
-
 	// Replace original file with new file
 	if err := os.Remove(task.OutputPath); err != nil {
-		w.logger.Error("failed to remove original file", "error", err)
 		return fmt.Errorf("failed to remove original file: %w", err)
 	}
 	if err := os.Rename(tempOutput, task.OutputPath); err != nil {
-		w.logger.Error("failed to rename temp file", "error", err)
 		return fmt.Errorf("failed to rename temp file: %w", err)
 	}
 
-	w.logger.Info("SUBTITLE EMBEDDING COMPLETE", "output_path", task.OutputPath)
+	w.logger.Info("subtitles embedded", "count", len(subFiles), "output_path", task.OutputPath)
 
 	return nil
 }
@@ -975,3 +944,16 @@ func getSubtitleExtension(url string) string {
 	}
 	return "srt"
 }
+
+// redactedArgs returns a copy of a command's arguments with the stream URL
+// redacted, for logging
+func redactedArgs(args []string, streamURL string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == streamURL {
+			arg = providers.RedactedStreamURL(arg)
+		}
+		redacted[i] = arg
+	}
+	return redacted
+}
@@ -1,6 +1,7 @@
 package history
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -66,6 +67,21 @@ type Stats struct {
 	CompletedCount int64
 }
 
+// Entry is an unfinished item that can be resumed from where it was left
+type Entry struct {
+	ProviderName    string
+	MediaID         string
+	MediaTitle      string
+	MediaType       string
+	Season          int
+	Episode         int
+	ProgressSeconds int
+	TotalSeconds    int
+	ProgressPercent float64
+	WatchedAt       time.Time
+	AniListID       *int
+}
+
 // NewService creates a new history service
 func NewService(db *gorm.DB) *Service {
 	return &Service{db: db}
@@ -194,6 +210,59 @@ func (s *Service) GetHistory(filter FilterOptions) ([]HistoryItem, error) {
 	return items, nil
 }
 
+// ContinueWatching returns up to limit unfinished items across all
+// providers, most recently watched first. Each media appears once, at its
+// latest watched episode, and is left out if that episode was completed.
+// Manga reading progress is not included. A limit of 0 or less returns
+// every unfinished item.
+func (s *Service) ContinueWatching(ctx context.Context, limit int) ([]Entry, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	var records []database.History
+	err := s.db.WithContext(ctx).
+		Where("media_type <> ?", "manga").
+		Order("watched_at DESC").
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch history: %w", err)
+	}
+
+	var entries []Entry
+	seen := make(map[string]bool)
+	for _, record := range records {
+		key := record.ProviderName + "\x00" + record.MediaID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if record.Completed {
+			continue
+		}
+
+		entries = append(entries, Entry{
+			ProviderName:    record.ProviderName,
+			MediaID:         record.MediaID,
+			MediaTitle:      record.MediaTitle,
+			MediaType:       record.MediaType,
+			Season:          record.Season,
+			Episode:         record.Episode,
+			ProgressSeconds: record.ProgressSeconds,
+			TotalSeconds:    record.TotalSeconds,
+			ProgressPercent: record.ProgressPercent,
+			WatchedAt:       record.WatchedAt,
+			AniListID:       record.AniListID,
+		})
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
 // GetByID retrieves a specific history item by ID
 func (s *Service) GetByID(id uint) (*HistoryItem, error) {
 	if s.db == nil {
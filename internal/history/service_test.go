@@ -0,0 +1,56 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestService(t *testing.T) (*Service, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+
+	return NewService(db), db
+}
+
+func TestContinueWatching(t *testing.T) {
+	service, db := newTestService(t)
+	now := time.Now()
+
+	records := []database.History{
+		// Resumable: latest episode is unfinished
+		{ProviderName: "sflix", MediaID: "tv/dark", MediaTitle: "Dark", MediaType: "tv", Season: 1, Episode: 2, ProgressSeconds: 600, TotalSeconds: 3000, WatchedAt: now.Add(-time.Hour)},
+		{ProviderName: "sflix", MediaID: "tv/dark", MediaTitle: "Dark", MediaType: "tv", Season: 1, Episode: 1, ProgressSeconds: 3000, TotalSeconds: 3000, Completed: true, WatchedAt: now.Add(-2 * time.Hour)},
+		// Finished most recently, so nothing to resume
+		{ProviderName: "flixhq", MediaID: "movie/inception", MediaTitle: "Inception", MediaType: "movie", Episode: 1, ProgressSeconds: 9000, TotalSeconds: 9000, Completed: true, WatchedAt: now.Add(-time.Minute)},
+		{ProviderName: "flixhq", MediaID: "movie/inception", MediaTitle: "Inception", MediaType: "movie", Episode: 1, ProgressSeconds: 100, TotalSeconds: 9000, WatchedAt: now.Add(-3 * time.Hour)},
+		// Same media ID on another provider is a separate item
+		{ProviderName: "allanime", MediaID: "tv/dark", MediaTitle: "Dark", MediaType: "anime", Episode: 5, ProgressSeconds: 30, TotalSeconds: 1400, WatchedAt: now.Add(-30 * time.Minute)},
+		{ProviderName: "allanime", MediaID: "frieren", MediaTitle: "Frieren", MediaType: "anime", Episode: 3, ProgressSeconds: 200, TotalSeconds: 1400, WatchedAt: now.Add(-4 * time.Hour)},
+	}
+	require.NoError(t, db.Create(&records).Error)
+
+	entries, err := service.ContinueWatching(context.Background(), 0)
+	require.NoError(t, err)
+
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.ProviderName+":"+entry.MediaID)
+	}
+	assert.Equal(t, []string{"allanime:tv/dark", "sflix:tv/dark", "allanime:frieren"}, got)
+	assert.Equal(t, 2, entries[1].Episode)
+	assert.Equal(t, 600, entries[1].ProgressSeconds)
+
+	limited, err := service.ContinueWatching(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Len(t, limited, 2)
+}
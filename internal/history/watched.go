@@ -0,0 +1,120 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/justchokingaround/greg/internal/database"
+)
+
+// EpisodeRef identifies an episode within a media item. Movies use
+// season 0, episode 1.
+type EpisodeRef struct {
+	Season  int
+	Episode int
+}
+
+// MarkWatched records an episode as watched. Marking it again only
+// refreshes the watched time.
+func (s *Service) MarkWatched(ctx context.Context, provider, mediaID string, ref EpisodeRef) error {
+	if s.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+
+	watched := database.WatchedEpisode{
+		ProviderName: provider,
+		MediaID:      mediaID,
+		Season:       ref.Season,
+		Episode:      ref.Episode,
+		WatchedAt:    time.Now(),
+	}
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider_name"}, {Name: "media_id"}, {Name: "season"}, {Name: "episode"}},
+		DoUpdates: clause.AssignmentColumns([]string{"watched_at"}),
+	}).Create(&watched).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark episode watched: %w", err)
+	}
+	return nil
+}
+
+// UnmarkWatched removes an episode's watched mark
+func (s *Service) UnmarkWatched(ctx context.Context, provider, mediaID string, ref EpisodeRef) error {
+	if s.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+
+	return s.db.WithContext(ctx).
+		Where("provider_name = ? AND media_id = ? AND season = ? AND episode = ?", provider, mediaID, ref.Season, ref.Episode).
+		Delete(&database.WatchedEpisode{}).Error
+}
+
+// IsWatched reports whether an episode has been marked watched
+func (s *Service) IsWatched(ctx context.Context, provider, mediaID string, ref EpisodeRef) (bool, error) {
+	if s.db == nil {
+		return false, fmt.Errorf("database connection is nil")
+	}
+
+	var count int64
+	err := s.db.WithContext(ctx).Model(&database.WatchedEpisode{}).
+		Where("provider_name = ? AND media_id = ? AND season = ? AND episode = ?", provider, mediaID, ref.Season, ref.Episode).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check watched episode: %w", err)
+	}
+	return count > 0, nil
+}
+
+// WatchedEpisodes returns every watched episode of a media item, so a
+// whole episode list can be checked with one query
+func (s *Service) WatchedEpisodes(ctx context.Context, provider, mediaID string) (map[EpisodeRef]bool, error) {
+	if s.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	var records []database.WatchedEpisode
+	err := s.db.WithContext(ctx).
+		Where("provider_name = ? AND media_id = ?", provider, mediaID).
+		Find(&records).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch watched episodes: %w", err)
+	}
+
+	watched := make(map[EpisodeRef]bool, len(records))
+	for _, record := range records {
+		watched[EpisodeRef{Season: record.Season, Episode: record.Episode}] = true
+	}
+	return watched, nil
+}
+
+// CompletionPercent returns how much of a series with totalEpisodes
+// episodes has been watched, from 0 to 100
+func (s *Service) CompletionPercent(ctx context.Context, provider, mediaID string, totalEpisodes int) (float64, error) {
+	if totalEpisodes <= 0 {
+		return 0, nil
+	}
+
+	watched, err := s.WatchedEpisodes(ctx, provider, mediaID)
+	if err != nil {
+		return 0, err
+	}
+
+	percent := float64(len(watched)) / float64(totalEpisodes) * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return percent, nil
+}
+
+// PassedThreshold reports whether playback got far enough to count an
+// episode as watched. threshold is a fraction of the duration, the same
+// as tracker.anilist.sync_threshold.
+func PassedThreshold(position, duration time.Duration, threshold float64) bool {
+	if duration <= 0 {
+		return false
+	}
+	return position.Seconds()/duration.Seconds() >= threshold
+}
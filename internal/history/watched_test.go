@@ -0,0 +1,69 @@
+package history
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkWatched(t *testing.T) {
+	service, _ := newTestService(t)
+	ctx := context.Background()
+	ep := EpisodeRef{Season: 1, Episode: 2}
+
+	watched, err := service.IsWatched(ctx, "sflix", "tv/dark", ep)
+	require.NoError(t, err)
+	assert.False(t, watched)
+
+	require.NoError(t, service.MarkWatched(ctx, "sflix", "tv/dark", ep))
+	// Marking twice must not fail on the unique index
+	require.NoError(t, service.MarkWatched(ctx, "sflix", "tv/dark", ep))
+
+	watched, err = service.IsWatched(ctx, "sflix", "tv/dark", ep)
+	require.NoError(t, err)
+	assert.True(t, watched)
+
+	// Watched marks are per provider and per season
+	watched, err = service.IsWatched(ctx, "flixhq", "tv/dark", ep)
+	require.NoError(t, err)
+	assert.False(t, watched)
+	watched, err = service.IsWatched(ctx, "sflix", "tv/dark", EpisodeRef{Season: 2, Episode: 2})
+	require.NoError(t, err)
+	assert.False(t, watched)
+
+	require.NoError(t, service.UnmarkWatched(ctx, "sflix", "tv/dark", ep))
+	watched, err = service.IsWatched(ctx, "sflix", "tv/dark", ep)
+	require.NoError(t, err)
+	assert.False(t, watched)
+}
+
+func TestCompletionPercent(t *testing.T) {
+	service, _ := newTestService(t)
+	ctx := context.Background()
+
+	for episode := 1; episode <= 3; episode++ {
+		require.NoError(t, service.MarkWatched(ctx, "sflix", "tv/dark", EpisodeRef{Season: 1, Episode: episode}))
+	}
+
+	watched, err := service.WatchedEpisodes(ctx, "sflix", "tv/dark")
+	require.NoError(t, err)
+	assert.Len(t, watched, 3)
+	assert.True(t, watched[EpisodeRef{Season: 1, Episode: 2}])
+
+	percent, err := service.CompletionPercent(ctx, "sflix", "tv/dark", 12)
+	require.NoError(t, err)
+	assert.InDelta(t, 25.0, percent, 0.001)
+
+	percent, err = service.CompletionPercent(ctx, "sflix", "tv/dark", 0)
+	require.NoError(t, err)
+	assert.Zero(t, percent)
+}
+
+func TestPassedThreshold(t *testing.T) {
+	assert.True(t, PassedThreshold(85*time.Second, 100*time.Second, 0.85))
+	assert.False(t, PassedThreshold(84*time.Second, 100*time.Second, 0.85))
+	assert.False(t, PassedThreshold(10*time.Second, 0, 0.85))
+}
@@ -0,0 +1,186 @@
+package imagecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/clock"
+)
+
+// DirName is the directory under the cache path that images are stored in
+const DirName = "images"
+
+// Cache stores poster, thumbnail and page images on disk and revalidates
+// them with conditional GETs once their TTL has expired
+type Cache struct {
+	dir    string
+	ttl    time.Duration
+	client *http.Client
+	clock  clock.Clock
+}
+
+// entryMeta is stored next to each cached image
+type entryMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// New creates an image cache rooted at dir/images
+func New(dir string, ttl time.Duration) *Cache {
+	return &Cache{
+		dir: filepath.Join(dir, DirName),
+		ttl: ttl,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		clock: clock.Real{},
+	}
+}
+
+// SetTransport sets the transport images are downloaded through, so the cache
+// follows the same network settings as the rest of the app
+func (c *Cache) SetTransport(t http.RoundTripper) {
+	c.client.Transport = t
+}
+
+// Fetch returns the path of a local copy of the image at url.
+// Fresh entries are served from disk; stale entries are revalidated with
+// If-None-Match/If-Modified-Since and only re-downloaded when the server
+// reports a change.
+func (c *Cache) Fetch(ctx context.Context, url string, headers map[string]string) (string, error) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create image cache directory: %w", err)
+	}
+
+	imagePath, metaPath := c.paths(url)
+	meta, hasEntry := c.loadMeta(metaPath, imagePath)
+
+	if hasEntry && clock.Since(c.clock, meta.FetchedAt) < c.ttl {
+		return imagePath, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create image request: %w", err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if hasEntry {
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download image: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && hasEntry:
+		// Image unchanged, just extend its lifetime
+		meta.FetchedAt = c.clock.Now()
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			meta.ETag = etag
+		}
+		if err := c.saveMeta(metaPath, meta); err != nil {
+			return "", err
+		}
+		return imagePath, nil
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("image request returned status code %d", resp.StatusCode)
+	}
+
+	if err := writeFileAtomic(imagePath, resp.Body); err != nil {
+		return "", err
+	}
+
+	meta = entryMeta{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    c.clock.Now(),
+	}
+	if err := c.saveMeta(metaPath, meta); err != nil {
+		return "", err
+	}
+
+	return imagePath, nil
+}
+
+// paths returns the image and metadata file paths for a URL
+func (c *Cache) paths(url string) (string, string) {
+	sum := sha256.Sum256([]byte(url))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key+".img"), filepath.Join(c.dir, key+".json")
+}
+
+// loadMeta reads the metadata for an entry, reporting false if either the
+// metadata or the image itself is missing
+func (c *Cache) loadMeta(metaPath, imagePath string) (entryMeta, bool) {
+	var meta entryMeta
+
+	if _, err := os.Stat(imagePath); err != nil {
+		return meta, false
+	}
+
+	data, err := os.ReadFile(metaPath)
+	if err != nil {
+		return meta, false
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, false
+	}
+
+	return meta, true
+}
+
+// saveMeta writes the metadata for an entry
+func (c *Cache) saveMeta(metaPath string, meta entryMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode image metadata: %w", err)
+	}
+	if err := os.WriteFile(metaPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write image metadata: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes r to path via a temp file so readers never see a
+// partially written image
+func writeFileAtomic(path string, r io.Reader) error {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+
+	if _, err := io.Copy(tmpFile, r); err != nil {
+		_ = tmpFile.Close()
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package imagecache
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_Fetch(t *testing.T) {
+	t.Run("serves fresh entries from disk", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte("image-v1"))
+		}))
+		defer server.Close()
+
+		cache := New(t.TempDir(), time.Hour)
+
+		path, err := cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+		_, err = cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, requests)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "image-v1", string(data))
+	})
+
+	t.Run("revalidates stale entries with conditional GET", func(t *testing.T) {
+		var conditional []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conditional = append(conditional, r.Header.Get("If-None-Match"))
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+			_, _ = w.Write([]byte("image-v1"))
+		}))
+		defer server.Close()
+
+		// Zero TTL forces revalidation on every fetch
+		cache := New(t.TempDir(), 0)
+
+		_, err := cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+		path, err := cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"", `"v1"`}, conditional)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "image-v1", string(data))
+	})
+
+	t.Run("re-downloads changed images", func(t *testing.T) {
+		version := "v1"
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == `"`+version+`"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"`+version+`"`)
+			_, _ = w.Write([]byte("image-" + version))
+		}))
+		defer server.Close()
+
+		cache := New(t.TempDir(), 0)
+
+		_, err := cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+
+		version = "v2"
+		path, err := cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "image-v2", string(data))
+	})
+
+	t.Run("entries expire once the TTL has passed", func(t *testing.T) {
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			_, _ = w.Write([]byte("image-v1"))
+		}))
+		defer server.Close()
+
+		fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		cache := New(t.TempDir(), time.Hour)
+		cache.clock = fake
+
+		_, err := cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+
+		fake.Advance(59 * time.Minute)
+		_, err = cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 1, requests)
+
+		fake.Advance(time.Minute)
+		_, err = cache.Fetch(context.Background(), server.URL+"/poster.jpg", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("returns error on failed download", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		cache := New(t.TempDir(), time.Hour)
+
+		_, err := cache.Fetch(context.Background(), server.URL+"/missing.jpg", nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,77 @@
+package player
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+// BuildArgs assembles the mpv arguments for playing stream, for use with
+// player.binary. The stream's Referer, Origin and any other headers go into
+// a single --http-header-fields option, which protected HLS streams need on
+// every segment request. Each subtitle becomes a --sub-file entry when
+// auto_subtitles is on, and start is passed as --start when resume is on
+// (the position usually comes from history). player.mpv_args follow, and
+// the stream URL is always the last argument.
+func BuildArgs(stream providers.StreamURL, cfg config.PlayerConfig, start time.Duration) []string {
+	var args []string
+
+	headers := make(map[string]string, len(stream.Headers)+1)
+	for key, value := range stream.Headers {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	if stream.Referer != "" {
+		headers["Referer"] = stream.Referer
+	}
+	if userAgent, ok := headers["User-Agent"]; ok {
+		args = append(args, "--user-agent="+userAgent)
+		delete(headers, "User-Agent")
+	}
+	if fields := headerFields(headers); len(fields) > 0 {
+		args = append(args, "--http-header-fields="+strings.Join(fields, ","))
+	}
+
+	if cfg.AutoSubtitles {
+		for _, sub := range stream.Subtitles {
+			if sub.URL != "" {
+				args = append(args, "--sub-file="+sub.URL)
+			}
+		}
+		if cfg.SubtitleLang != "" {
+			args = append(args, "--slang="+cfg.SubtitleLang)
+		}
+	}
+
+	if cfg.Resume && start > 0 {
+		args = append(args, fmt.Sprintf("--start=%d", int(start.Seconds())))
+	}
+
+	args = append(args, cfg.MPVArgs...)
+	return append(args, stream.URL)
+}
+
+// headerFields formats headers as "Key: value" with Referer and Origin
+// first and the rest sorted, so the arguments are stable
+func headerFields(headers map[string]string) []string {
+	var fields []string
+	for _, key := range []string{"Referer", "Origin"} {
+		if value, ok := headers[key]; ok && value != "" {
+			fields = append(fields, key+": "+value)
+		}
+	}
+
+	var rest []string
+	for key, value := range headers {
+		if key != "Referer" && key != "Origin" && value != "" {
+			rest = append(rest, key+": "+value)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(fields, rest...)
+}
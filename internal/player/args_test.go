@@ -0,0 +1,71 @@
+package player
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+func TestBuildArgs(t *testing.T) {
+	stream := providers.StreamURL{
+		URL:     "https://cdn.example.com/master.m3u8",
+		Referer: "https://megacloud.tv/",
+		Headers: map[string]string{
+			"origin":     "https://megacloud.tv",
+			"User-Agent": "greg-test",
+			"X-Token":    "abc",
+		},
+		Subtitles: []providers.Subtitle{
+			{URL: "https://cdn.example.com/en.vtt", Language: "English"},
+			{URL: "https://cdn.example.com/es.vtt", Language: "Spanish"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		cfg   config.PlayerConfig
+		start time.Duration
+		want  []string
+	}{
+		{
+			name:  "headers, subtitles and resume",
+			cfg:   config.PlayerConfig{AutoSubtitles: true, SubtitleLang: "en", Resume: true, MPVArgs: []string{"--hwdec=auto"}},
+			start: 754 * time.Second,
+			want: []string{
+				"--user-agent=greg-test",
+				"--http-header-fields=Referer: https://megacloud.tv/,Origin: https://megacloud.tv,X-Token: abc",
+				"--sub-file=https://cdn.example.com/en.vtt",
+				"--sub-file=https://cdn.example.com/es.vtt",
+				"--slang=en",
+				"--start=754",
+				"--hwdec=auto",
+				"https://cdn.example.com/master.m3u8",
+			},
+		},
+		{
+			name:  "resume and subtitles disabled",
+			cfg:   config.PlayerConfig{},
+			start: 754 * time.Second,
+			want: []string{
+				"--user-agent=greg-test",
+				"--http-header-fields=Referer: https://megacloud.tv/,Origin: https://megacloud.tv,X-Token: abc",
+				"https://cdn.example.com/master.m3u8",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, BuildArgs(stream, tt.cfg, tt.start))
+		})
+	}
+}
+
+func TestBuildArgsWithoutHeaders(t *testing.T) {
+	args := BuildArgs(providers.StreamURL{URL: "https://cdn.example.com/a.mp4"}, config.PlayerConfig{Resume: true}, 0)
+	assert.Equal(t, []string{"https://cdn.example.com/a.mp4"}, args)
+}
@@ -0,0 +1,193 @@
+//go:build !windows
+
+package mpv
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diniamo/gopv"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/justchokingaround/greg/internal/player"
+)
+
+// fakeMPV answers get_property and set_property over a unix socket the way
+// mpv's JSON IPC does. Properties missing from props never get a reply.
+type fakeMPV struct {
+	mu    sync.Mutex
+	props map[string]any
+}
+
+func (f *fakeMPV) get(name string) (any, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.props[name]
+	return value, ok
+}
+
+func (f *fakeMPV) set(name string, value any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.props[name] = value
+}
+
+func (f *fakeMPV) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var req struct {
+			Command   []any `json:"command"`
+			RequestID int   `json:"request_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || len(req.Command) < 2 {
+			continue
+		}
+
+		name, _ := req.Command[1].(string)
+		var data any
+		switch req.Command[0] {
+		case "get_property":
+			value, ok := f.get(name)
+			if !ok {
+				continue
+			}
+			data = value
+		case "set_property":
+			f.set(name, req.Command[2])
+		}
+
+		reply, _ := json.Marshal(map[string]any{"data": data, "error": "success", "request_id": req.RequestID})
+		if _, err := conn.Write(append(reply, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+// newFakeMPVPlayer connects an MPVPlayer to a fakeMPV
+func newFakeMPVPlayer(t *testing.T, props map[string]any) (*MPVPlayer, *fakeMPV) {
+	t.Helper()
+
+	// Unix socket paths are length limited, so keep the directory short
+	dir, err := os.MkdirTemp("", "mpv")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "ipc.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	fake := &fakeMPV{props: props}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fake.serve(conn)
+		}
+	}()
+
+	client, err := gopv.Connect(path, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return &MPVPlayer{
+		client:     client,
+		state:      player.StatePlaying,
+		ctx:        ctx,
+		cancel:     cancel,
+		ipcTimeout: time.Second,
+	}, fake
+}
+
+func TestIPCProgressAndControl(t *testing.T) {
+	p, fake := newFakeMPVPlayer(t, map[string]any{
+		"time-pos":    30.0,
+		"duration":    120.0,
+		"pause":       false,
+		"eof-reached": false,
+		"volume":      80.0,
+		"speed":       1.0,
+	})
+	ctx := context.Background()
+
+	progress, err := p.GetProgress(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, progress.CurrentTime)
+	assert.Equal(t, 2*time.Minute, progress.Duration)
+	assert.InDelta(t, 25.0, progress.Percentage, 0.001)
+	assert.Equal(t, 80, progress.Volume)
+
+	require.NoError(t, p.Seek(ctx, 90*time.Second))
+	pos, _ := fake.get("time-pos")
+	assert.Equal(t, 90.0, pos)
+
+	require.NoError(t, p.SetPaused(ctx, true))
+	paused, _ := fake.get("pause")
+	assert.Equal(t, true, paused)
+	assert.True(t, p.IsPaused())
+
+	require.NoError(t, p.SetPaused(ctx, false))
+	assert.True(t, p.IsPlaying())
+}
+
+func TestIPCPlaybackEnd(t *testing.T) {
+	p, fake := newFakeMPVPlayer(t, map[string]any{
+		"time-pos":    119.0,
+		"duration":    120.0,
+		"pause":       false,
+		"eof-reached": false,
+		"volume":      100.0,
+		"speed":       1.0,
+	})
+
+	ended := make(chan struct{})
+	p.OnPlaybackEnd(func() { close(ended) })
+	go p.monitorProgress()
+
+	fake.set("eof-reached", true)
+	select {
+	case <-ended:
+	case <-time.After(5 * time.Second):
+		t.Fatal("playback end was not detected")
+	}
+}
+
+func TestIPCRequestTimeout(t *testing.T) {
+	p, fake := newFakeMPVPlayer(t, map[string]any{})
+	p.ipcTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := p.request("get_property", "time-pos")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+	assert.Less(t, time.Since(start), time.Second)
+
+	// The abandoned request is still waiting, so further requests fail at
+	// once instead of leaking another blocked goroutine each
+	fake.set("volume", 100.0)
+	start = time.Now()
+	_, err = p.request("get_property", "volume")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "has not answered")
+	assert.Less(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestIPCTimeoutFromConfig(t *testing.T) {
+	assert.Equal(t, 5*time.Second, ipcTimeoutFromConfig(5))
+	assert.Equal(t, 2*time.Second, ipcTimeoutFromConfig(2*time.Second))
+	assert.Equal(t, time.Duration(0), ipcTimeoutFromConfig(0))
+}
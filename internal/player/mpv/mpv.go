@@ -14,6 +14,7 @@ import (
 	"github.com/diniamo/gopv"
 	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/player"
+	"github.com/justchokingaround/greg/internal/providers"
 	"github.com/spf13/viper"
 )
 
@@ -42,12 +43,20 @@ type MPVPlayer struct {
 	cancel       context.CancelFunc
 	done         chan struct{}
 	clientClosed bool
+	// ipcStuck is closed once a timed out IPC request finally returns, and is
+	// nil while none is outstanding
+	ipcStuck chan struct{}
 
 	// Configuration
 	debug          bool
 	loadUserConfig bool
+	ipcTimeout     time.Duration // player.ipc_timeout
+	mpvArgs        []string      // player.mpv_args
 }
 
+// defaultIPCTimeout is used when player.ipc_timeout is not set
+const defaultIPCTimeout = 5 * time.Second
+
 // NewMPVPlayer creates a new mpv player instance
 func NewMPVPlayer() (*MPVPlayer, error) {
 	return NewMPVPlayerWithDebug(false)
@@ -69,12 +78,15 @@ func NewMPVPlayerWithConfig(cfg *config.Config, debug bool) (*MPVPlayer, error)
 		platform:       platform,
 		debug:          debug,
 		loadUserConfig: cfg.Player.LoadUserConfig,
+		ipcTimeout:     ipcTimeoutFromConfig(cfg.Player.IPCTimeout),
+		mpvArgs:        cfg.Player.MPVArgs,
 	}
 
 	return player, nil
 }
 
 // NewMPVPlayerWithDebug creates a new mpv player instance with debug flag
+// and the default configuration
 func NewMPVPlayerWithDebug(debug bool) (*MPVPlayer, error) {
 	// Create a default config to use defaults
 	defaultConfig := &config.Config{}
@@ -83,25 +95,11 @@ func NewMPVPlayerWithDebug(debug bool) (*MPVPlayer, error) {
 	config.SetDefaults(v) // Use the public function instead
 	// Unmarshal the defaults into the config struct
 	if err := v.Unmarshal(defaultConfig); err != nil {
-		// Fallback to false if there's an error
-		defaultConfig.Player.LoadUserConfig = false
-	}
-
-	// Detect platform
-	platform := DetectPlatform()
-
-	// Verify mpv executable is available
-	if _, err := FindMPVExecutable(platform); err != nil {
-		return nil, fmt.Errorf("mpv not found: %w", err)
+		// Fall back to zero settings, which keep the built-in defaults
+		defaultConfig = &config.Config{}
 	}
 
-	return &MPVPlayer{
-		state:          player.StateStopped,
-		done:           make(chan struct{}),
-		platform:       platform,
-		debug:          debug,
-		loadUserConfig: defaultConfig.Player.LoadUserConfig,
-	}, nil
+	return NewMPVPlayerWithConfig(defaultConfig, debug)
 }
 
 // Play starts playback of the given URL with options
@@ -176,8 +174,9 @@ func (p *MPVPlayer) Play(ctx context.Context, url string, options player.PlayOpt
 // asyncInitialize handles the async parts of player initialization
 // Reports errors via OnError callback and updates state when ready
 func (p *MPVPlayer) asyncInitialize(ctx context.Context, ipcConfig *IPCConfig) {
-	// Create a timeout context for the initialization
-	initCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	// Create a timeout context for the initialization, leaving room for the
+	// IPC wait plus connecting
+	initCtx, cancel := context.WithTimeout(ctx, max(15*time.Second, 3*p.getIPCTimeout()))
 	defer cancel()
 
 	// Wait for IPC to be ready
@@ -247,6 +246,7 @@ func (p *MPVPlayer) asyncInitialize(ctx context.Context, ipcConfig *IPCConfig) {
 	p.mu.Lock()
 	p.client = client
 	p.clientClosed = false // Reset for new connection
+	p.ipcStuck = nil
 	p.state = player.StatePlaying
 	p.mu.Unlock()
 
@@ -363,7 +363,7 @@ func (p *MPVPlayer) getProgressLocked() (*player.PlaybackProgress, error) {
 
 	// Get properties from mpv
 	// Track errors to detect IPC failures on Windows
-	if result, err := p.client.Request("get_property", "time-pos"); err == nil {
+	if result, err := p.request("get_property", "time-pos"); err == nil {
 		if val, ok := result.(float64); ok {
 			timePos = val
 		}
@@ -375,7 +375,7 @@ func (p *MPVPlayer) getProgressLocked() (*player.PlaybackProgress, error) {
 		}
 	}
 
-	if result, err := p.client.Request("get_property", "duration"); err == nil {
+	if result, err := p.request("get_property", "duration"); err == nil {
 		if val, ok := result.(float64); ok {
 			duration = val
 		}
@@ -387,7 +387,7 @@ func (p *MPVPlayer) getProgressLocked() (*player.PlaybackProgress, error) {
 		}
 	}
 
-	if result, err := p.client.Request("get_property", "pause"); err == nil {
+	if result, err := p.request("get_property", "pause"); err == nil {
 		if val, ok := result.(bool); ok {
 			paused = val
 		}
@@ -395,7 +395,7 @@ func (p *MPVPlayer) getProgressLocked() (*player.PlaybackProgress, error) {
 		propertyErrors++
 	}
 
-	if result, err := p.client.Request("get_property", "eof-reached"); err == nil {
+	if result, err := p.request("get_property", "eof-reached"); err == nil {
 		if val, ok := result.(bool); ok {
 			eof = val
 		}
@@ -403,7 +403,7 @@ func (p *MPVPlayer) getProgressLocked() (*player.PlaybackProgress, error) {
 		propertyErrors++
 	}
 
-	if result, err := p.client.Request("get_property", "volume"); err == nil {
+	if result, err := p.request("get_property", "volume"); err == nil {
 		if val, ok := result.(float64); ok {
 			volume = val
 		} else {
@@ -411,7 +411,7 @@ func (p *MPVPlayer) getProgressLocked() (*player.PlaybackProgress, error) {
 		}
 	}
 
-	if result, err := p.client.Request("get_property", "speed"); err == nil {
+	if result, err := p.request("get_property", "speed"); err == nil {
 		if val, ok := result.(float64); ok {
 			speed = val
 		} else {
@@ -454,13 +454,95 @@ func (p *MPVPlayer) Seek(ctx context.Context, position time.Duration) error {
 	}
 
 	seconds := position.Seconds()
-	if _, err := p.client.Request("set_property", "time-pos", seconds); err != nil {
+	if _, err := p.request("set_property", "time-pos", seconds); err != nil {
 		return fmt.Errorf("failed to seek: %w", err)
 	}
 
 	return nil
 }
 
+// SetPaused pauses or resumes playback
+func (p *MPVPlayer) SetPaused(ctx context.Context, paused bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client == nil {
+		return fmt.Errorf("player not initialized")
+	}
+
+	if _, err := p.request("set_property", "pause", paused); err != nil {
+		return fmt.Errorf("failed to set pause: %w", err)
+	}
+
+	if paused {
+		p.state = player.StatePaused
+	} else {
+		p.state = player.StatePlaying
+	}
+	return nil
+}
+
+// ipcTimeoutFromConfig interprets player.ipc_timeout. A bare number such as
+// "ipc_timeout: 5" is decoded as nanoseconds, so values that small are taken
+// to mean seconds.
+func ipcTimeoutFromConfig(d time.Duration) time.Duration {
+	if d > 0 && d < time.Millisecond {
+		return d * time.Second
+	}
+	return d
+}
+
+// getIPCTimeout returns player.ipc_timeout, or the default if it is unset
+func (p *MPVPlayer) getIPCTimeout() time.Duration {
+	if p.ipcTimeout > 0 {
+		return p.ipcTimeout
+	}
+	return defaultIPCTimeout
+}
+
+// request sends an IPC command to mpv. gopv waits for the reply forever, so
+// the request is abandoned after the IPC timeout to keep a hung mpv from
+// blocking the caller (must be called with lock held). gopv has no way to
+// cancel a request, and closing the client doesn't release it either, so
+// while an abandoned request is still waiting further requests fail at once
+// instead of piling up more blocked goroutines.
+func (p *MPVPlayer) request(command ...any) (any, error) {
+	type reply struct {
+		result any
+		err    error
+	}
+
+	if p.ipcStuck != nil {
+		select {
+		case <-p.ipcStuck:
+			p.ipcStuck = nil
+		default:
+			return nil, fmt.Errorf("mpv IPC %v skipped: mpv has not answered an earlier request", command[0])
+		}
+	}
+
+	client := p.client
+	done := make(chan reply, 1)
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		result, err := client.Request(command...)
+		done <- reply{result, err}
+	}()
+
+	timeout := p.getIPCTimeout()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case r := <-done:
+		return r.result, r.err
+	case <-timer.C:
+		p.ipcStuck = returned
+		return nil, fmt.Errorf("mpv IPC %v timed out after %v", command[0], timeout)
+	}
+}
+
 // OnProgressUpdate sets the progress update callback
 func (p *MPVPlayer) OnProgressUpdate(callback func(progress player.PlaybackProgress)) {
 	p.mu.Lock()
@@ -564,7 +646,14 @@ func (p *MPVPlayer) monitorProcess() {
 	_ = p.Stop(context.Background())
 }
 
-// buildMPVArgs builds the command-line arguments for mpv
+// defaultUserAgent is sent when neither the options nor the stream's
+// headers name a User-Agent, to avoid 403s
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// buildMPVArgs builds the command-line arguments for mpv. Player settings
+// come first; the stream's headers, subtitle, start position, mpv_args and
+// the URL are added by player.BuildArgs, so mpv_args can override anything
+// before them.
 func (p *MPVPlayer) buildMPVArgs(url string, opts player.PlayOptions) []string {
 	args := []string{
 		GetMPVIPCArgument(p.ipcConfig),
@@ -583,11 +672,6 @@ func (p *MPVPlayer) buildMPVArgs(url string, opts player.PlayOptions) []string {
 		args = append(args, "--msg-level=all=warn")
 	}
 
-	// Start time
-	if opts.StartTime > 0 {
-		args = append(args, fmt.Sprintf("--start=%f", opts.StartTime.Seconds()))
-	}
-
 	// Volume
 	if opts.Volume > 0 {
 		args = append(args, fmt.Sprintf("--volume=%d", opts.Volume))
@@ -603,15 +687,6 @@ func (p *MPVPlayer) buildMPVArgs(url string, opts player.PlayOptions) []string {
 		args = append(args, "--fullscreen")
 	}
 
-	// Subtitles
-	if opts.SubtitleURL != "" {
-		args = append(args, fmt.Sprintf("--sub-file=%s", opts.SubtitleURL))
-	}
-
-	if opts.SubtitleLang != "" {
-		args = append(args, fmt.Sprintf("--slang=%s", opts.SubtitleLang))
-	}
-
 	if opts.SubtitleDelay > 0 {
 		args = append(args, fmt.Sprintf("--sub-delay=%f", opts.SubtitleDelay.Seconds()))
 	}
@@ -621,53 +696,48 @@ func (p *MPVPlayer) buildMPVArgs(url string, opts player.PlayOptions) []string {
 		args = append(args, fmt.Sprintf("--aid=%d", opts.AudioTrack))
 	}
 
-	// User-Agent
-	if opts.UserAgent != "" {
-		args = append(args, fmt.Sprintf("--user-agent=%s", opts.UserAgent))
-	} else {
-		// Use a default user agent to avoid 403s
-		args = append(args, "--user-agent=Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	}
-
-	// Referer - use dedicated --referrer option (more reliable than http-header-fields)
-	if opts.Referer != "" {
-		args = append(args, fmt.Sprintf("--referrer=%s", opts.Referer))
+	// Title - use force-media-title to ensure it's displayed in mpv
+	if opts.Title != "" {
+		args = append(args, fmt.Sprintf("--force-media-title=%s", opts.Title))
 	}
 
-	// Additional HTTP headers (Origin, etc)
-	headersList := []string{}
+	// Everything about the stream itself goes through the shared builder
+	headers := make(map[string]string, len(opts.Headers)+1)
+	userAgent := opts.UserAgent
 	for key, value := range opts.Headers {
-		if key != "User-Agent" && key != "Referer" { // Already handled above
-			headersList = append(headersList, fmt.Sprintf("%s: %s", key, value))
+		if strings.EqualFold(key, "User-Agent") {
+			if userAgent == "" {
+				userAgent = value
+			}
+			continue
 		}
+		headers[key] = value
 	}
-
-	// Add other headers as comma-separated list in a single argument
-	if len(headersList) > 0 {
-		headersStr := strings.Join(headersList, ",")
-		args = append(args, fmt.Sprintf("--http-header-fields=%s", headersStr))
+	if userAgent == "" {
+		userAgent = defaultUserAgent
 	}
+	headers["User-Agent"] = userAgent
 
-	// Title - use force-media-title to ensure it's displayed in mpv
-	if opts.Title != "" {
-		args = append(args, fmt.Sprintf("--force-media-title=%s", opts.Title))
+	stream := providers.StreamURL{URL: url, Referer: opts.Referer, Headers: headers}
+	if opts.SubtitleURL != "" {
+		stream.Subtitles = []providers.Subtitle{{URL: opts.SubtitleURL, Language: opts.SubtitleLang}}
+	}
+	cfg := config.PlayerConfig{
+		AutoSubtitles: true,
+		SubtitleLang:  opts.SubtitleLang,
+		Resume:        true,
+		MPVArgs:       append(append([]string(nil), p.mpvArgs...), opts.MPVArgs...),
 	}
 
-	// Custom mpv args
-	args = append(args, opts.MPVArgs...)
-
-	// URL must be last
-	args = append(args, url)
-
-	return args
+	return append(args, player.BuildArgs(stream, cfg, opts.StartTime)...)
 }
 
 // waitForIPC waits for the IPC connection to be ready
 func (p *MPVPlayer) waitForIPC(ctx context.Context) error {
 	// Use longer timeout for named pipes and TCP (mpv.exe takes longer to start from WSL)
-	timeoutDuration := 5 * time.Second
+	timeoutDuration := p.getIPCTimeout()
 	if p.ipcConfig.Type == IPCTCP || p.ipcConfig.Type == IPCNamedPipe {
-		timeoutDuration = 10 * time.Second
+		timeoutDuration *= 2
 	}
 
 	timeout := time.After(timeoutDuration)
@@ -133,7 +133,7 @@ func TestBuildMPVArgs(t *testing.T) {
 			},
 			expected: []string{
 				"--idle=yes",
-				"--referrer=https://example.com",
+				"--http-header-fields=Referer: https://example.com",
 				"--user-agent=Mozilla/5.0",
 				"https://example.com/video.mp4",
 			},
@@ -204,7 +204,7 @@ func TestBuildMPVArgs(t *testing.T) {
 				"--slang=eng",
 				"--sub-delay=2",
 				"--aid=2",
-				"--referrer=https://example.com",
+				"--http-header-fields=Referer: https://example.com",
 				"--user-agent=Mozilla/5.0",
 				"--force-media-title=Full Test",
 				"--cache=yes",
@@ -248,6 +248,33 @@ func TestBuildMPVArgs(t *testing.T) {
 	}
 }
 
+func TestBuildMPVArgsStreamArgs(t *testing.T) {
+	p := &MPVPlayer{
+		ipcConfig: &IPCConfig{Type: IPCUnixSocket, Address: "/tmp/test.sock", IsSocket: true},
+		mpvArgs:   []string{"--hwdec=auto"},
+	}
+
+	args := p.buildMPVArgs("https://cdn.example.com/master.m3u8", player.PlayOptions{
+		Title:     "Dark",
+		StartTime: 90 * time.Second,
+		Referer:   "https://megacloud.tv/",
+		Headers:   map[string]string{"Origin": "https://megacloud.tv", "user-agent": "greg-test"},
+		MPVArgs:   []string{"--force-media-title=Override"},
+	})
+
+	// Stream arguments follow the player's own, then player.mpv_args and
+	// the per-play args, and the URL is last
+	assert.Equal(t, []string{
+		"--force-media-title=Dark",
+		"--user-agent=greg-test",
+		"--http-header-fields=Referer: https://megacloud.tv/,Origin: https://megacloud.tv",
+		"--start=90",
+		"--hwdec=auto",
+		"--force-media-title=Override",
+		"https://cdn.example.com/master.m3u8",
+	}, args[len(args)-7:])
+}
+
 func TestPlayerState(t *testing.T) {
 	p, err := NewMPVPlayer()
 	require.NoError(t, err)
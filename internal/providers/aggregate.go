@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SourceFailure is one provider's failure inside an AggregateError
+type SourceFailure struct {
+	Provider string
+	// Mirrors is how many of the provider's mirror domains were tried before
+	// it failed, or zero if the failure didn't involve its mirrors
+	Mirrors int
+	Err     error
+}
+
+func (f SourceFailure) Error() string {
+	return fmt.Sprintf("%s: %v", f.Provider, f.Err)
+}
+
+func (f SourceFailure) Unwrap() error {
+	return f.Err
+}
+
+// summary describes the failure in a few words, e.g. "sflix (3 mirrors)"
+func (f SourceFailure) summary() string {
+	var details []string
+	if f.Mirrors > 1 {
+		details = append(details, fmt.Sprintf("%d mirrors", f.Mirrors))
+	}
+	if reason := failureReason(f.Err); reason != "" {
+		details = append(details, reason)
+	}
+	if len(details) == 0 {
+		return f.Provider
+	}
+	return fmt.Sprintf("%s (%s)", f.Provider, strings.Join(details, ", "))
+}
+
+// failureReason names the common ways a provider fails, or returns an empty
+// string when the error is nothing more specific than a failure
+func failureReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, ErrThrottled):
+		return "throttled"
+	case errors.Is(err, ErrBlocked):
+		return "blocked"
+	case errors.Is(err, ErrLayoutChanged):
+		return "layout changed"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timed out"
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	}
+	return ""
+}
+
+// AggregateError is returned when every provider tried for a request failed,
+// after each one's mirrors and retries. Its message sums up what was tried,
+// e.g. "tried sflix (3 mirrors), flixhq (blocked): all sources unavailable",
+// while the individual errors stay reachable through Failures, errors.Is and
+// errors.As.
+type AggregateError struct {
+	Failures []SourceFailure
+}
+
+func (e *AggregateError) Error() string {
+	if len(e.Failures) == 0 {
+		return "all sources unavailable"
+	}
+	tried := make([]string, len(e.Failures))
+	for i, f := range e.Failures {
+		tried[i] = f.summary()
+	}
+	return fmt.Sprintf("tried %s: all sources unavailable", strings.Join(tried, ", "))
+}
+
+// Unwrap exposes each failure to errors.Is and errors.As
+func (e *AggregateError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, f := range e.Failures {
+		errs[i] = f
+	}
+	return errs
+}
+
+// Add records a failure of p. ctx is the context p was called with; if it
+// came from withMirrorTrace, the mirrors its requests reached are counted.
+func (e *AggregateError) Add(ctx context.Context, p Provider, err error) {
+	e.Failures = append(e.Failures, SourceFailure{Provider: p.Name(), Mirrors: mirrorsTried(ctx), Err: err})
+}
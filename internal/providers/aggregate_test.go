@@ -0,0 +1,60 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateError(t *testing.T) {
+	err := &AggregateError{Failures: []SourceFailure{
+		{Provider: "sflix", Mirrors: 3, Err: errors.New("connection reset")},
+		{Provider: "flixhq", Mirrors: 1, Err: fmt.Errorf("search: %w", ErrBlocked)},
+		{Provider: "hdrezka", Err: context.DeadlineExceeded},
+		{Provider: "remote", Err: errors.New("bad gateway")},
+	}}
+
+	assert.Equal(t, "tried sflix (3 mirrors), flixhq (blocked), hdrezka (timed out), remote: all sources unavailable", err.Error())
+	assert.ErrorIs(t, err, ErrBlocked)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var failure SourceFailure
+	assert.ErrorAs(t, err, &failure)
+	assert.Equal(t, "sflix", failure.Provider)
+}
+
+func TestAggregateErrorCountsMirrorsTried(t *testing.T) {
+	var urls []string
+	for range 3 {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+		urls = append(urls, srv.URL)
+	}
+	transport := NewMirrorTransport(nil, NewMirrorSet(urls[0], urls[1:]), 2)
+	transport.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	client := &http.Client{Transport: transport}
+	p := &mockProvider{name: "sflix", mediaType: MediaTypeMovieTV}
+
+	failed := &AggregateError{}
+	blocked := withMirrorTrace(context.Background())
+	req, err := http.NewRequestWithContext(blocked, http.MethodGet, urls[0]+"/search/heat", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	failed.Add(blocked, p, ErrBlocked)
+
+	// A failure that never reached the site doesn't claim any mirrors
+	failed.Add(withMirrorTrace(context.Background()), p, ErrLayoutChanged)
+
+	assert.Equal(t, "tried sflix (3 mirrors, blocked), sflix (layout changed): all sources unavailable", failed.Error())
+}
@@ -0,0 +1,15 @@
+// Package all registers every built-in provider. Import it for its side
+// effects wherever providers are loaded from config:
+//
+//	import _ "github.com/justchokingaround/greg/internal/providers/all"
+package all
+
+import (
+	_ "github.com/justchokingaround/greg/internal/providers/anime/allanime"
+	_ "github.com/justchokingaround/greg/internal/providers/anime/hdrezka"
+	_ "github.com/justchokingaround/greg/internal/providers/anime/hianime"
+	_ "github.com/justchokingaround/greg/internal/providers/manga/comix"
+	_ "github.com/justchokingaround/greg/internal/providers/movies/flixhq"
+	_ "github.com/justchokingaround/greg/internal/providers/movies/hdrezka"
+	_ "github.com/justchokingaround/greg/internal/providers/movies/sflix"
+)
@@ -14,6 +14,7 @@ import (
 	"time"
 	"unicode"
 
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
 	"github.com/justchokingaround/greg/pkg/types"
 )
@@ -24,20 +25,50 @@ type AllAnime struct {
 	Client      *http.Client
 	searchCache sync.Map
 	infoCache   sync.Map
+
+	// language is the sub or dub variant to favour
+	language providers.LanguagePreference
+
+	// CacheSwitch turns the caches off when cache_enabled is false
+	providers.CacheSwitch
+}
+
+// allanimeClockURL serves the source links the API's relative paths point to
+const allanimeClockURL = "https://allanime.day"
+
+func init() {
+	providers.RegisterFactory("allanime", "anime", func(settings config.ProviderSettings) providers.Provider { return New(settings) })
 }
 
-func New() *AllAnime {
-	return &AllAnime{
+// New creates the provider, applying the site and API URLs, request timeout
+// and headers from settings. Zero settings keep the built-in defaults.
+func New(settings config.ProviderSettings) *AllAnime {
+	a := &AllAnime{
 		BaseURL: "https://allanime.to",
 		APIURL:  "https://api.allanime.day",
-		Client:  &http.Client{},
 	}
+	if settings.BaseURL != "" {
+		a.BaseURL = strings.TrimSuffix(settings.BaseURL, "/")
+	}
+	if settings.APIURL != "" {
+		a.APIURL = strings.TrimSuffix(settings.APIURL, "/")
+	}
+	a.Client = &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: providers.NewHeaderTransport(nil, settings.Headers, a.BaseURL, a.APIURL, allanimeClockURL),
+	}
+	return a
 }
 
 func (a *AllAnime) Name() string {
 	return "allanime"
 }
 
+// SetLanguagePreference implements providers.LanguagePreferrer
+func (a *AllAnime) SetLanguagePreference(pref providers.LanguagePreference) {
+	a.language = pref
+}
+
 func (a *AllAnime) Type() providers.MediaType {
 	return providers.MediaTypeAnime
 }
@@ -90,7 +121,7 @@ type linkData struct {
 
 // Search searches for anime by query
 func (a *AllAnime) Search(ctx context.Context, query string) ([]providers.Media, error) {
-	if cached, ok := a.searchCache.Load(query); ok {
+	if cached, ok := a.searchCache.Load(query); ok && a.CacheEnabled() {
 		return cached.([]providers.Media), nil
 	}
 
@@ -129,7 +160,7 @@ func (a *AllAnime) Search(ctx context.Context, query string) ([]providers.Media,
 		url.QueryEscape(string(variablesJSON)),
 		url.QueryEscape(searchGQL))
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -166,16 +197,40 @@ func (a *AllAnime) Search(ctx context.Context, query string) ([]providers.Media,
 			Title:     strings.TrimSpace(title),
 			Type:      providers.MediaTypeAnime,
 			PosterURL: anime.Thumbnail,
+			Languages: availableLanguages(anime.AvailableEpisodes),
 		})
 	}
 
-	a.searchCache.Store(query, results)
+	if a.CacheEnabled() {
+		a.searchCache.Store(query, results)
+	}
 	return results, nil
 }
 
+// availableLanguages lists the variants with episodes out of a show's
+// availableEpisodes, e.g. {"sub": 12, "dub": 8, "raw": 0}
+func availableLanguages(available interface{}) []string {
+	counts, ok := available.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var languages []string
+	for _, lang := range []providers.LanguagePreference{providers.LanguageSub, providers.LanguageDub} {
+		if n, ok := counts[string(lang)].(float64); ok && n > 0 {
+			languages = append(languages, string(lang))
+		}
+	}
+	return languages
+}
+
 // GetInfo fetches detailed info for an anime
 func (a *AllAnime) GetInfo(id string) (interface{}, error) {
-	if cached, ok := a.infoCache.Load(id); ok {
+	return a.GetInfoContext(context.Background(), id)
+}
+
+// GetInfoContext is GetInfo with a context for its requests
+func (a *AllAnime) GetInfoContext(ctx context.Context, id string) (interface{}, error) {
+	if cached, ok := a.infoCache.Load(id); ok && a.CacheEnabled() {
 		return cached.(*types.AnimeInfo), nil
 	}
 
@@ -206,7 +261,7 @@ func (a *AllAnime) GetInfo(id string) (interface{}, error) {
 		url.QueryEscape(string(variablesJSON)),
 		url.QueryEscape(infoGQL))
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -274,12 +329,14 @@ func (a *AllAnime) GetInfo(id string) (interface{}, error) {
 		})
 	}
 
-	a.infoCache.Store(id, info)
+	if a.CacheEnabled() {
+		a.infoCache.Store(id, info)
+	}
 	return info, nil
 }
 
 func (a *AllAnime) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
-	info, err := a.GetInfo(id)
+	info, err := a.GetInfoContext(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -313,7 +370,7 @@ func (a *AllAnime) GetSeasons(ctx context.Context, mediaID string) ([]providers.
 
 func (a *AllAnime) GetEpisodes(ctx context.Context, seasonID string) ([]providers.Episode, error) {
 	mediaID := seasonID
-	info, err := a.GetInfo(mediaID)
+	info, err := a.GetInfoContext(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
@@ -337,7 +394,7 @@ func (a *AllAnime) GetEpisodes(ctx context.Context, seasonID string) ([]provider
 }
 
 func (a *AllAnime) GetStreamURL(ctx context.Context, episodeID string, quality providers.Quality) (*providers.StreamURL, error) {
-	res, err := a.GetSources(episodeID)
+	res, err := a.GetSourcesContext(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -370,7 +427,7 @@ func (a *AllAnime) GetStreamURL(ctx context.Context, episodeID string, quality p
 }
 
 func (a *AllAnime) GetAvailableQualities(ctx context.Context, episodeID string) ([]providers.Quality, error) {
-	res, err := a.GetSources(episodeID)
+	res, err := a.GetSourcesContext(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -396,8 +453,24 @@ func (a *AllAnime) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// ClearCache drops all cached search and info results
+func (a *AllAnime) ClearCache() {
+	a.searchCache.Clear()
+	a.infoCache.Clear()
+}
+
+// ClearCacheFor drops the cached info for a single media ID
+func (a *AllAnime) ClearCacheFor(mediaID string) {
+	a.infoCache.Delete(mediaID)
+}
+
 // GetServers fetches available servers for an episode
 func (a *AllAnime) GetServers(episodeID string) ([]types.EpisodeServer, error) {
+	return a.GetServersContext(context.Background(), episodeID)
+}
+
+// GetServersContext is GetServers, aborting once ctx is done
+func (a *AllAnime) GetServersContext(ctx context.Context, episodeID string) ([]types.EpisodeServer, error) {
 	// AllAnime doesn't have a traditional server selection
 	// The servers are embedded in the source URLs
 	// Return a generic server entry
@@ -486,6 +559,11 @@ func (a *AllAnime) isValidStreamURL(urlStr string) bool {
 
 // GetSources fetches video sources for an episode
 func (a *AllAnime) GetSources(episodeID string) (interface{}, error) {
+	return a.GetSourcesContext(context.Background(), episodeID)
+}
+
+// GetSourcesContext is GetSources, aborting once ctx is done
+func (a *AllAnime) GetSourcesContext(ctx context.Context, episodeID string) (interface{}, error) {
 	// Parse episodeID format: "animeID-episodeNumber"
 	parts := strings.Split(episodeID, "-")
 	if len(parts) < 2 {
@@ -495,8 +573,15 @@ func (a *AllAnime) GetSources(episodeID string) (interface{}, error) {
 	episodeNum := parts[len(parts)-1]
 	animeID := strings.Join(parts[:len(parts)-1], "-")
 
-	// Fetch source URLs from API
-	links, err := a.getEpisodeLinks(animeID, episodeNum)
+	// Fetch source URLs from API, falling back to the sub when there is no dub
+	translationType := "sub"
+	if a.language == providers.LanguageDub {
+		translationType = "dub"
+	}
+	links, err := a.getEpisodeLinks(ctx, animeID, episodeNum, translationType)
+	if err == nil && len(links) == 0 && translationType != "sub" {
+		links, err = a.getEpisodeLinks(ctx, animeID, episodeNum, "sub")
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get episode links: %w", err)
 	}
@@ -540,12 +625,12 @@ func (a *AllAnime) GetSources(episodeID string) (interface{}, error) {
 }
 
 // getEpisodeLinks fetches episode video links using GraphQL
-func (a *AllAnime) getEpisodeLinks(animeID, episodeNum string) ([]string, error) {
+func (a *AllAnime) getEpisodeLinks(ctx context.Context, animeID, episodeNum, translationType string) ([]string, error) {
 	query := `query($showId:String!,$translationType:VaildTranslationTypeEnumType!,$episodeString:String!){episode(showId:$showId,translationType:$translationType,episodeString:$episodeString){episodeString sourceUrls}}`
 
 	variables := map[string]string{
 		"showId":          animeID,
-		"translationType": "sub",
+		"translationType": translationType,
 		"episodeString":   episodeNum,
 	}
 
@@ -559,7 +644,7 @@ func (a *AllAnime) getEpisodeLinks(animeID, episodeNum string) ([]string, error)
 		url.QueryEscape(string(variablesJSON)),
 		url.QueryEscape(query))
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -593,7 +678,7 @@ func (a *AllAnime) getEpisodeLinks(animeID, episodeNum string) ([]string, error)
 			validCount++
 			go func(encoded string) {
 				decodedURL := a.decodeProviderID(encoded[2:])
-				links := a.extractLinks(decodedURL)
+				links := a.extractLinks(ctx, decodedURL)
 				resultChan <- links
 			}(sourceURL.SourceURL)
 		}
@@ -655,7 +740,7 @@ func (a *AllAnime) decodeProviderID(encoded string) string {
 }
 
 // extractLinks extracts video links from the decoded provider ID
-func (a *AllAnime) extractLinks(providerID string) []string {
+func (a *AllAnime) extractLinks(ctx context.Context, providerID string) []string {
 	// Check if it's already a full URL (external link)
 	if strings.HasPrefix(providerID, "http://") || strings.HasPrefix(providerID, "https://") {
 		// Clean up double slashes
@@ -677,9 +762,9 @@ func (a *AllAnime) extractLinks(providerID string) []string {
 	}
 
 	// It's a relative path for allanime API
-	reqURL := "https://allanime.day" + providerID
+	reqURL := allanimeClockURL + providerID
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 	if err != nil {
 		return []string{}
 	}
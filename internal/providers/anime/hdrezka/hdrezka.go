@@ -3,6 +3,7 @@ package hdrezka
 import (
 	"context"
 
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
 	"github.com/justchokingaround/greg/internal/providers/movies/hdrezka"
 	"github.com/justchokingaround/greg/pkg/types"
@@ -12,9 +13,14 @@ type HDRezka struct {
 	*hdrezka.HDRezka
 }
 
-func New() *HDRezka {
+func init() {
+	providers.RegisterFactory("hdrezka_anime", "anime", func(settings config.ProviderSettings) providers.Provider { return New(settings) })
+}
+
+// New creates the provider on top of the movies provider, which applies settings
+func New(settings config.ProviderSettings) *HDRezka {
 	return &HDRezka{
-		HDRezka: hdrezka.New(),
+		HDRezka: hdrezka.New(settings),
 	}
 }
 
@@ -63,7 +69,12 @@ func (p *HDRezka) HealthCheck(ctx context.Context) error {
 }
 
 func (p *HDRezka) GetInfo(id string) (interface{}, error) {
-	info, err := p.HDRezka.GetInfo(id)
+	return p.GetInfoContext(context.Background(), id)
+}
+
+// GetInfoContext is GetInfo with a context for its requests
+func (p *HDRezka) GetInfoContext(ctx context.Context, id string) (interface{}, error) {
+	info, err := p.HDRezka.GetInfoContext(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -13,6 +13,7 @@ import (
 	"sync"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
 	"github.com/justchokingaround/greg/pkg/extractors"
 	"github.com/justchokingaround/greg/pkg/types"
@@ -23,32 +24,59 @@ type HiAnime struct {
 	Client      *http.Client
 	searchCache sync.Map
 	infoCache   sync.Map
+
+	// language is the sub or dub variant to favour
+	language providers.LanguagePreference
+
+	// CacheSwitch turns the caches off when cache_enabled is false
+	providers.CacheSwitch
 }
 
-func New() *HiAnime {
-	return &HiAnime{
-		BaseURL: "https://hianime.to",
-		Client:  &http.Client{},
+func init() {
+	providers.RegisterFactory("hianime", "anime", func(settings config.ProviderSettings) providers.Provider { return New(settings) })
+}
+
+// New creates the provider, applying the base URL, request timeout and
+// headers from settings. Zero settings keep the built-in defaults.
+func New(settings config.ProviderSettings) *HiAnime {
+	h := &HiAnime{BaseURL: "https://hianime.to"}
+	if settings.BaseURL != "" {
+		h.BaseURL = strings.TrimSuffix(settings.BaseURL, "/")
+	}
+	h.Client = &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: providers.NewHeaderTransport(nil, settings.Headers, h.BaseURL),
 	}
+	return h
 }
 
 func (h *HiAnime) Name() string {
 	return "hianime"
 }
 
+// SetLanguagePreference implements providers.LanguagePreferrer
+func (h *HiAnime) SetLanguagePreference(pref providers.LanguagePreference) {
+	h.language = pref
+}
+
 func (h *HiAnime) Type() providers.MediaType {
 	return providers.MediaTypeAnime
 }
 
 // Search searches for anime by query
 func (h *HiAnime) Search(ctx context.Context, query string) ([]providers.Media, error) {
-	if cached, ok := h.searchCache.Load(query); ok {
+	if cached, ok := h.searchCache.Load(query); ok && h.CacheEnabled() {
 		return cached.([]providers.Media), nil
 	}
 
 	searchURL := fmt.Sprintf("%s/search?keyword=%s", h.BaseURL, url.QueryEscape(query))
 
-	resp, err := h.Client.Get(searchURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search request: %w", err)
+	}
+
+	resp, err := h.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch search results: %w", err)
 	}
@@ -66,6 +94,14 @@ func (h *HiAnime) Search(ctx context.Context, query string) ([]providers.Media,
 		href, _ := s.Find("h3.film-name a").Attr("href")
 		image, _ := s.Find("img").Attr("data-src")
 
+		var languages []string
+		if s.Find(".tick-sub").Length() > 0 {
+			languages = append(languages, string(providers.LanguageSub))
+		}
+		if s.Find(".tick-dub").Length() > 0 {
+			languages = append(languages, string(providers.LanguageDub))
+		}
+
 		if href != "" {
 			// Extract ID from href (e.g., /watch/naruto-100 -> naruto-100)
 			parts := strings.Split(href, "/")
@@ -86,23 +122,31 @@ func (h *HiAnime) Search(ctx context.Context, query string) ([]providers.Media,
 				Title:     strings.TrimSpace(title),
 				Type:      providers.MediaTypeAnime,
 				PosterURL: image,
+				Languages: languages,
 			})
 		}
 	})
 
-	h.searchCache.Store(query, results)
+	if h.CacheEnabled() {
+		h.searchCache.Store(query, results)
+	}
 	return results, nil
 }
 
 // GetInfo fetches detailed info for an anime
 func (h *HiAnime) GetInfo(id string) (interface{}, error) {
-	if cached, ok := h.infoCache.Load(id); ok {
+	return h.GetInfoContext(context.Background(), id)
+}
+
+// GetInfoContext is GetInfo with a context for its requests
+func (h *HiAnime) GetInfoContext(ctx context.Context, id string) (interface{}, error) {
+	if cached, ok := h.infoCache.Load(id); ok && h.CacheEnabled() {
 		return cached.(*types.AnimeInfo), nil
 	}
 
 	infoURL := fmt.Sprintf("%s/%s", h.BaseURL, id)
 
-	req, err := http.NewRequest("GET", infoURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", infoURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -171,22 +215,24 @@ func (h *HiAnime) GetInfo(id string) (interface{}, error) {
 
 	// Fetch episodes from AJAX endpoint
 	if dataID != "" {
-		episodes, err := h.fetchEpisodeList(dataID)
+		episodes, err := h.fetchEpisodeList(ctx, dataID)
 		if err == nil && len(episodes) > 0 {
 			info.Episodes = episodes
 			info.TotalEpisodes = len(episodes)
 		}
 	}
 
-	h.infoCache.Store(id, info)
+	if h.CacheEnabled() {
+		h.infoCache.Store(id, info)
+	}
 	return info, nil
 }
 
 // fetchEpisodeList fetches the episode list from the AJAX endpoint
-func (h *HiAnime) fetchEpisodeList(animeDataID string) ([]types.Episode, error) {
+func (h *HiAnime) fetchEpisodeList(ctx context.Context, animeDataID string) ([]types.Episode, error) {
 	episodeURL := fmt.Sprintf("%s/ajax/v2/episode/list/%s", h.BaseURL, animeDataID)
 
-	req, err := http.NewRequest("GET", episodeURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", episodeURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create episode list request: %w", err)
 	}
@@ -257,7 +303,7 @@ func (h *HiAnime) fetchEpisodeList(animeDataID string) ([]types.Episode, error)
 }
 
 func (h *HiAnime) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
-	info, err := h.GetInfo(id)
+	info, err := h.GetInfoContext(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -292,7 +338,7 @@ func (h *HiAnime) GetSeasons(ctx context.Context, mediaID string) ([]providers.S
 
 func (h *HiAnime) GetEpisodes(ctx context.Context, seasonID string) ([]providers.Episode, error) {
 	mediaID := seasonID
-	info, err := h.GetInfo(mediaID)
+	info, err := h.GetInfoContext(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
@@ -316,7 +362,7 @@ func (h *HiAnime) GetEpisodes(ctx context.Context, seasonID string) ([]providers
 }
 
 func (h *HiAnime) GetStreamURL(ctx context.Context, episodeID string, quality providers.Quality) (*providers.StreamURL, error) {
-	res, err := h.GetSources(episodeID)
+	res, err := h.GetSourcesContext(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -334,21 +380,27 @@ func (h *HiAnime) GetStreamURL(ctx context.Context, episodeID string, quality pr
 	var selectedSource types.Source
 	found := false
 
-	targetQuality := string(quality)
+	// In auto mode hand the HLS master playlist to the player so it can
+	// switch variants itself
 	if quality == providers.QualityAuto {
-		targetQuality = "auto"
-	}
-
-	for _, src := range v.Sources {
-		if strings.EqualFold(src.Quality, targetQuality) {
-			selectedSource = src
-			found = true
-			break
+		for _, src := range v.Sources {
+			if providers.IsMasterPlaylist(src.URL, src.Quality) {
+				selectedSource = src
+				selectedSource.IsM3U8 = true
+				found = true
+				break
+			}
 		}
 	}
 
+	// Otherwise walk the quality ladder, e.g. from 1080p down to 720p for an
+	// episode, falling back to the first source
 	if !found {
-		selectedSource = v.Sources[0]
+		labels := make([]string, len(v.Sources))
+		for i, src := range v.Sources {
+			labels[i] = src.Quality
+		}
+		selectedSource = v.Sources[providers.SelectSourceQuality(labels, quality, providers.MediaTypeAnime)]
 	}
 
 	streamType := providers.StreamTypeHLS
@@ -377,7 +429,7 @@ func (h *HiAnime) GetStreamURL(ctx context.Context, episodeID string, quality pr
 }
 
 func (h *HiAnime) GetAvailableQualities(ctx context.Context, episodeID string) ([]providers.Quality, error) {
-	res, err := h.GetSources(episodeID)
+	res, err := h.GetSourcesContext(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -393,8 +445,13 @@ func (h *HiAnime) GetAvailableQualities(ctx context.Context, episodeID string) (
 
 // GetSources fetches video sources for an episode
 func (h *HiAnime) GetSources(episodeID string) (interface{}, error) {
+	return h.GetSourcesContext(context.Background(), episodeID)
+}
+
+// GetSourcesContext is GetSources, aborting once ctx is done
+func (h *HiAnime) GetSourcesContext(ctx context.Context, episodeID string) (interface{}, error) {
 	// Get available servers
-	servers, err := h.GetServers(episodeID)
+	servers, err := h.GetServersContext(ctx, episodeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch servers: %w", err)
 	}
@@ -409,7 +466,7 @@ func (h *HiAnime) GetSources(episodeID string) (interface{}, error) {
 	// Try each server until we get valid sources
 	var lastErr error
 	for _, server := range servers {
-		sources, err := h.extractSourcesFromServer(server)
+		sources, err := h.extractSourcesFromServer(ctx, server)
 		if err != nil {
 			lastErr = err
 			continue
@@ -432,14 +489,14 @@ func (h *HiAnime) GetSources(episodeID string) (interface{}, error) {
 }
 
 // extractSourcesFromServer extracts video sources from a specific server
-func (h *HiAnime) extractSourcesFromServer(server types.EpisodeServer) (*types.VideoSources, error) {
+func (h *HiAnime) extractSourcesFromServer(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
 	// The server.URL contains the server ID
 	serverID := server.URL
 
 	// Get the embed URL from the sources endpoint
 	sourcesURL := fmt.Sprintf("%s/ajax/v2/episode/sources?id=%s", h.BaseURL, serverID)
 
-	req, err := http.NewRequest("GET", sourcesURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", sourcesURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sources request: %w", err)
 	}
@@ -478,8 +535,7 @@ func (h *HiAnime) extractSourcesFromServer(server types.EpisodeServer) (*types.V
 	embedURL := jsonResponse.Link
 
 	// Use the extractor to get actual video sources
-	extractor := extractors.GetExtractor(server.Name)
-	extracted, err := extractor.Extract(embedURL)
+	extracted, err := extractors.Extract(ctx, server.Name, embedURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to extract from embed URL %s: %w", embedURL, err)
 	}
@@ -489,9 +545,14 @@ func (h *HiAnime) extractSourcesFromServer(server types.EpisodeServer) (*types.V
 
 // GetServers fetches available servers for an episode
 func (h *HiAnime) GetServers(episodeID string) ([]types.EpisodeServer, error) {
+	return h.GetServersContext(context.Background(), episodeID)
+}
+
+// GetServersContext is GetServers, aborting once ctx is done
+func (h *HiAnime) GetServersContext(ctx context.Context, episodeID string) ([]types.EpisodeServer, error) {
 	serverURL := fmt.Sprintf("%s/ajax/v2/episode/servers?episodeId=%s", h.BaseURL, episodeID)
 
-	req, err := http.NewRequest("GET", serverURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", serverURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create servers request: %w", err)
 	}
@@ -532,6 +593,7 @@ func (h *HiAnime) GetServers(episodeID string) ([]types.EpisodeServer, error) {
 	}
 
 	servers := []types.EpisodeServer{}
+	var preferred, others []types.EpisodeServer
 
 	// Find all server items
 	doc.Find(".server-item").Each(func(i int, s *goquery.Selection) {
@@ -550,12 +612,21 @@ func (h *HiAnime) GetServers(episodeID string) ([]types.EpisodeServer, error) {
 			displayName = fmt.Sprintf("%s (%s)", serverName, serverType)
 		}
 
-		servers = append(servers, types.EpisodeServer{
+		server := types.EpisodeServer{
 			Name: displayName,
 			URL:  serverID, // Store server ID in URL field for later use
-		})
+		}
+		if h.language != providers.LanguageAny && serverType == string(h.language) {
+			preferred = append(preferred, server)
+		} else {
+			others = append(others, server)
+		}
 	})
 
+	// Sources are extracted from the first working server, so list the
+	// preferred variant's servers first
+	servers = append(servers, preferred...)
+	servers = append(servers, others...)
 	return servers, nil
 }
 
@@ -570,3 +641,14 @@ func (h *HiAnime) GetRecent(ctx context.Context) ([]providers.Media, error) {
 func (h *HiAnime) HealthCheck(ctx context.Context) error {
 	return nil
 }
+
+// ClearCache drops all cached search and info results
+func (h *HiAnime) ClearCache() {
+	h.searchCache.Clear()
+	h.infoCache.Clear()
+}
+
+// ClearCacheFor drops the cached info for a single media ID
+func (h *HiAnime) ClearCacheFor(mediaID string) {
+	h.infoCache.Delete(mediaID)
+}
@@ -0,0 +1,20 @@
+package providers
+
+import "sync/atomic"
+
+// CacheSwitch lets a provider's in-memory caches be turned off. Providers
+// embed it to implement CacheToggler and check CacheEnabled before reading
+// or writing a cache. The zero value is enabled.
+type CacheSwitch struct {
+	disabled atomic.Bool
+}
+
+// SetCacheEnabled turns the provider's caches on or off
+func (c *CacheSwitch) SetCacheEnabled(enabled bool) {
+	c.disabled.Store(!enabled)
+}
+
+// CacheEnabled reports whether the provider may use its caches
+func (c *CacheSwitch) CacheEnabled() bool {
+	return !c.disabled.Load()
+}
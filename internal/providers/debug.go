@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DumpFailedPage saves the raw body of a failed scrape to
+// <dir>/debug/<provider>-last-failure.html so it can be attached to bug
+// reports. Only the provider version, URL, status code and body are written;
+// request and response headers (and therefore cookies) are never included.
+func DumpFailedPage(dir, provider, version, pageURL string, statusCode int, body []byte) (string, error) {
+	debugDir := filepath.Join(dir, "debug")
+	if err := os.MkdirAll(debugDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create debug directory: %w", err)
+	}
+
+	// Drop any credentials embedded in the URL
+	if u, err := url.Parse(pageURL); err == nil {
+		u.User = nil
+		pageURL = u.String()
+	}
+
+	if version == "" {
+		version = "unknown"
+	}
+
+	header := fmt.Sprintf("<!--\n  provider: %s\n  version: %s\n  url: %s\n  status: %d\n  fetched: %s\n-->\n",
+		provider, version, pageURL, statusCode, time.Now().Format(time.RFC3339))
+
+	path := filepath.Join(debugDir, provider+"-last-failure.html")
+	if err := os.WriteFile(path, append([]byte(header), body...), 0644); err != nil {
+		return "", fmt.Errorf("failed to write debug dump: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,43 @@
+package providers
+
+import "context"
+
+// MetadataSource looks up metadata for a title from an external database
+type MetadataSource interface {
+	// LookupMetadata returns the best match for title, or nil if there is none.
+	// year narrows the match when it is not 0.
+	LookupMetadata(ctx context.Context, title string, mediaType MediaType, year int) (*MediaDetails, error)
+}
+
+// EnrichDetails fills the poster, synopsis, year, genres and IMDb ID of
+// details from source when the scrape left them empty. Scraped values are never replaced,
+// and a failed lookup leaves details unchanged.
+func EnrichDetails(ctx context.Context, source MetadataSource, details *MediaDetails) {
+	if source == nil || details == nil || details.Title == "" {
+		return
+	}
+	if details.PosterURL != "" && details.Synopsis != "" && details.Year != 0 && len(details.Genres) > 0 && details.IMDBID != "" {
+		return
+	}
+
+	match, err := source.LookupMetadata(ctx, details.Title, details.Type, details.Year)
+	if err != nil || match == nil {
+		return
+	}
+
+	if details.PosterURL == "" {
+		details.PosterURL = match.PosterURL
+	}
+	if details.Synopsis == "" {
+		details.Synopsis = match.Synopsis
+	}
+	if details.Year == 0 {
+		details.Year = match.Year
+	}
+	if len(details.Genres) == 0 {
+		details.Genres = match.Genres
+	}
+	if details.IMDBID == "" {
+		details.IMDBID = match.IMDBID
+	}
+}
@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMetadataSource struct {
+	media *MediaDetails
+	err   error
+	calls int
+}
+
+func (f *fakeMetadataSource) LookupMetadata(ctx context.Context, title string, mediaType MediaType, year int) (*MediaDetails, error) {
+	f.calls++
+	return f.media, f.err
+}
+
+func TestEnrichDetails(t *testing.T) {
+	match := &MediaDetails{
+		Media: Media{
+			PosterURL: "https://image.example/poster.jpg",
+			Synopsis:  "From TMDB",
+			Year:      2010,
+			Genres:    []string{"Sci-Fi"},
+		},
+		IMDBID: "tt1375666",
+	}
+
+	t.Run("fills only empty fields", func(t *testing.T) {
+		details := &MediaDetails{Media: Media{Title: "Inception", Synopsis: "Scraped synopsis"}}
+		EnrichDetails(context.Background(), &fakeMetadataSource{media: match}, details)
+
+		assert.Equal(t, "Scraped synopsis", details.Synopsis)
+		assert.Equal(t, match.PosterURL, details.PosterURL)
+		assert.Equal(t, 2010, details.Year)
+		assert.Equal(t, []string{"Sci-Fi"}, details.Genres)
+		assert.Equal(t, "tt1375666", details.IMDBID)
+	})
+
+	t.Run("complete details skip the lookup", func(t *testing.T) {
+		source := &fakeMetadataSource{media: match}
+		details := &MediaDetails{
+			Media:  Media{Title: "Inception", PosterURL: "p", Synopsis: "s", Year: 2010, Genres: []string{"Action"}},
+			IMDBID: "tt1375666",
+		}
+		EnrichDetails(context.Background(), source, details)
+		assert.Zero(t, source.calls)
+	})
+
+	t.Run("lookup errors leave details unchanged", func(t *testing.T) {
+		details := &MediaDetails{Media: Media{Title: "Inception"}}
+		EnrichDetails(context.Background(), &fakeMetadataSource{err: errors.New("offline")}, details)
+		assert.Equal(t, &MediaDetails{Media: Media{Title: "Inception"}}, details)
+	})
+
+	t.Run("nil source is a no-op", func(t *testing.T) {
+		details := &MediaDetails{Media: Media{Title: "Inception"}}
+		EnrichDetails(context.Background(), nil, details)
+		assert.Empty(t, details.PosterURL)
+	})
+}
@@ -1,7 +1,9 @@
 package providers
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -67,3 +69,78 @@ func ParseEpisodeRange(allEpisodes []Episode, rangeStr string) ([]Episode, error
 
 	return result, nil
 }
+
+// NextEpisode returns the episode after current, moving on to the first
+// episode of the next season when current ends its season. It returns nil
+// when current is the last episode of the series. Specials are never
+// entered from a regular season, and the last special ends the series.
+func NextEpisode(ctx context.Context, p Provider, mediaID string, current Episode) (*Episode, error) {
+	seasons, err := sortedSeasons(ctx, p, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if len(seasons) == 0 {
+		return nil, nil
+	}
+
+	// Shows listed without seasons report season 0 for every episode
+	idx := 0
+	for i, season := range seasons {
+		if season.Number == current.Season {
+			idx = i
+			break
+		}
+	}
+
+	episodes, err := p.GetEpisodes(ctx, seasons[idx].ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get episodes for %s: %w", SeasonTitle(seasons[idx].Number), err)
+	}
+	for i, ep := range episodes {
+		if ep.ID == current.ID || (current.ID == "" && ep.Number == current.Number) {
+			if i+1 < len(episodes) {
+				return &episodes[i+1], nil
+			}
+			break
+		}
+	}
+
+	if seasons[idx].Number == SeasonSpecials {
+		return nil, nil
+	}
+	for _, season := range seasons[idx+1:] {
+		episodes, err := p.GetEpisodes(ctx, season.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get episodes for %s: %w", SeasonTitle(season.Number), err)
+		}
+		if len(episodes) > 0 {
+			return &episodes[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// SeasonEpisodes returns the episodes of the season numbered number, or
+// nil if the show has no such season
+func SeasonEpisodes(ctx context.Context, p Provider, mediaID string, number int) ([]Episode, error) {
+	seasons, err := sortedSeasons(ctx, p, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	for _, season := range seasons {
+		if season.Number == number {
+			return p.GetEpisodes(ctx, season.ID)
+		}
+	}
+	return nil, nil
+}
+
+// sortedSeasons returns a show's seasons in season order, specials first
+func sortedSeasons(ctx context.Context, p Provider, mediaID string) ([]Season, error) {
+	seasons, err := p.GetSeasons(ctx, mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seasons for %s: %w", mediaID, err)
+	}
+	sort.SliceStable(seasons, func(i, j int) bool { return seasons[i].Number < seasons[j].Number })
+	return seasons, nil
+}
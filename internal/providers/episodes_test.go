@@ -0,0 +1,95 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seasonedProvider serves a fixed season and episode layout
+type seasonedProvider struct {
+	mockProvider
+	seasons  []Season
+	episodes map[string][]Episode
+}
+
+func (p *seasonedProvider) GetSeasons(ctx context.Context, mediaID string) ([]Season, error) {
+	return append([]Season(nil), p.seasons...), nil
+}
+
+func (p *seasonedProvider) GetEpisodes(ctx context.Context, seasonID string) ([]Episode, error) {
+	return p.episodes[seasonID], nil
+}
+
+func newSeasonedProvider() *seasonedProvider {
+	p := &seasonedProvider{
+		mockProvider: mockProvider{name: "seasoned", mediaType: MediaTypeTV},
+		// Listed out of order on purpose
+		seasons: []Season{
+			{ID: "s2", Number: 2},
+			{ID: "sp", Number: SeasonSpecials},
+			{ID: "s1", Number: 1},
+			{ID: "s3", Number: 3},
+		},
+		episodes: make(map[string][]Episode),
+	}
+	for _, season := range p.seasons {
+		count := 2
+		if season.ID == "s3" {
+			count = 0 // announced but empty
+		}
+		for n := 1; n <= count; n++ {
+			p.episodes[season.ID] = append(p.episodes[season.ID], Episode{
+				ID:     fmt.Sprintf("%s-e%d", season.ID, n),
+				Number: n,
+				Season: season.Number,
+			})
+		}
+	}
+	return p
+}
+
+func TestNextEpisode(t *testing.T) {
+	p := newSeasonedProvider()
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		current Episode
+		want    string // empty means end of series
+	}{
+		{"within a season", Episode{ID: "s1-e1", Number: 1, Season: 1}, "s1-e2"},
+		{"across a season boundary", Episode{ID: "s1-e2", Number: 2, Season: 1}, "s2-e1"},
+		{"skips empty seasons at the end", Episode{ID: "s2-e2", Number: 2, Season: 2}, ""},
+		{"within specials", Episode{ID: "sp-e1", Number: 1, Season: SeasonSpecials}, "sp-e2"},
+		{"last special ends the series", Episode{ID: "sp-e2", Number: 2, Season: SeasonSpecials}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := NextEpisode(ctx, p, "show", tt.current)
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Nil(t, next)
+				return
+			}
+			require.NotNil(t, next)
+			assert.Equal(t, tt.want, next.ID)
+		})
+	}
+}
+
+func TestSeasonEpisodes(t *testing.T) {
+	p := newSeasonedProvider()
+
+	episodes, err := SeasonEpisodes(context.Background(), p, "show", 2)
+	require.NoError(t, err)
+	assert.Len(t, episodes, 2)
+
+	episodes, err = SeasonEpisodes(context.Background(), p, "show", 9)
+	require.NoError(t, err)
+	assert.Nil(t, episodes)
+}
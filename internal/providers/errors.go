@@ -0,0 +1,43 @@
+package providers
+
+import (
+	"errors"
+
+	"github.com/justchokingaround/greg/pkg/extractors"
+)
+
+// ErrLayoutChanged is returned when a scraped page loaded successfully but
+// none of the expected elements were found, which usually means the site
+// changed its markup and the provider's selectors need updating
+var ErrLayoutChanged = errors.New("page layout changed: expected elements not found")
+
+// ErrBlocked is returned when a site answers with 403 or 503, meaning it is
+// rate limiting or blocking requests rather than failing outright
+var ErrBlocked = errors.New("request blocked by site")
+
+// ErrThrottled is returned without making a request while a site is cooling
+// down after rate limiting or blocking us. Retrying before then only extends
+// the block.
+var ErrThrottled = errors.New("provider is throttled")
+
+// ErrNoEpisodes is returned when a media page loaded fine but lists nothing
+// to watch, which usually means a wrong or stale ID rather than the site
+// being down
+var ErrNoEpisodes = errors.New("no episodes found")
+
+// ErrDRMProtected is returned when a server only offers a DRM-protected
+// stream. Trying a different server or provider may find a playable copy.
+var ErrDRMProtected = extractors.ErrDRMProtected
+
+// ErrExtractorBroken is returned when every server failed inside the same
+// extractor, so the extractor rather than the provider needs fixing
+var ErrExtractorBroken = extractors.ErrExtractorBroken
+
+// ErrTitleMismatch is returned when a media's info page describes a different
+// title or year than the search result that linked to it, which happens when
+// a site's card points at the wrong show
+var ErrTitleMismatch = errors.New("info page does not match search result")
+
+// ErrNoConfidentMatch is returned when no search result matches a query
+// closely enough to be picked without asking the user
+var ErrNoConfidentMatch = errors.New("no confident match")
@@ -0,0 +1,92 @@
+package providers
+
+// Extension names an optional capability beyond the Provider interface.
+// Each extension is backed by an optional interface in this package; callers
+// type-assert to that interface to use it, and ask the registry which
+// extensions a provider has before offering provider-specific actions.
+type Extension string
+
+const (
+	// ExtensionManga: MangaProvider, chapters and pages
+	ExtensionManga Extension = "manga"
+	// ExtensionFilteredSearch: FilteredSearcher, search narrowed by year and type
+	ExtensionFilteredSearch Extension = "filtered_search"
+	// ExtensionStreamSearch: StreamSearcher, results sent as they are parsed
+	ExtensionStreamSearch Extension = "stream_search"
+	// ExtensionSubtitleProbe: SubtitleProber, subtitle availability without a stream
+	ExtensionSubtitleProbe Extension = "subtitle_probe"
+	// ExtensionServerProbe: ServerProber, per-server availability and latency
+	ExtensionServerProbe Extension = "server_probe"
+	// ExtensionCollections: CollectionFetcher, the franchise a title belongs to
+	ExtensionCollections Extension = "collections"
+	// ExtensionCache: CacheClearer, dropping cached results
+	ExtensionCache Extension = "cache"
+	// ExtensionLanguages: LanguagePreferrer, favouring sub or dub variants
+	ExtensionLanguages Extension = "languages"
+)
+
+// extensionChecks maps each extension to a check for its interface, in the
+// order ExtensionsOf reports them
+var extensionChecks = []struct {
+	ext      Extension
+	supports func(Provider) bool
+}{
+	{ExtensionManga, func(p Provider) bool { _, ok := p.(MangaProvider); return ok }},
+	{ExtensionFilteredSearch, func(p Provider) bool { _, ok := p.(FilteredSearcher); return ok }},
+	{ExtensionStreamSearch, func(p Provider) bool { _, ok := p.(StreamSearcher); return ok }},
+	{ExtensionSubtitleProbe, func(p Provider) bool { _, ok := p.(SubtitleProber); return ok }},
+	{ExtensionServerProbe, func(p Provider) bool { _, ok := p.(ServerProber); return ok }},
+	{ExtensionCollections, func(p Provider) bool { _, ok := p.(CollectionFetcher); return ok }},
+	{ExtensionCache, func(p Provider) bool { _, ok := p.(CacheClearer); return ok }},
+	{ExtensionLanguages, func(p Provider) bool { _, ok := p.(LanguagePreferrer); return ok }},
+}
+
+// ExtensionsOf returns the extensions a provider implements
+func ExtensionsOf(p Provider) []Extension {
+	var exts []Extension
+	for _, check := range extensionChecks {
+		if check.supports(p) {
+			exts = append(exts, check.ext)
+		}
+	}
+	return exts
+}
+
+// HasExtension reports whether a provider implements ext
+func HasExtension(p Provider, ext Extension) bool {
+	for _, check := range extensionChecks {
+		if check.ext == ext {
+			return check.supports(p)
+		}
+	}
+	return false
+}
+
+// Extensions returns the extensions the named provider implements
+func (r *Registry) Extensions(name string) ([]Extension, error) {
+	provider, err := r.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return ExtensionsOf(provider), nil
+}
+
+// Supports reports whether the named provider implements ext. Unknown
+// providers support nothing.
+func (r *Registry) Supports(name string, ext Extension) bool {
+	provider, err := r.Get(name)
+	if err != nil {
+		return false
+	}
+	return HasExtension(provider, ext)
+}
+
+// Extensions returns the extensions a provider in the global registry implements
+func Extensions(name string) ([]Extension, error) {
+	return globalRegistry.Extensions(name)
+}
+
+// Supports reports whether a provider in the global registry implements ext
+func Supports(name string, ext Extension) bool {
+	return globalRegistry.Supports(name, ext)
+}
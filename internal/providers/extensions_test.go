@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subtitleCollectionProvider adds the collection and subtitle probe extensions
+type subtitleCollectionProvider struct {
+	mockProvider
+}
+
+func (p *subtitleCollectionProvider) GetCollection(ctx context.Context, mediaID string) (string, error) {
+	return "", nil
+}
+
+func (p *subtitleCollectionProvider) HasSubtitles(ctx context.Context, episodeID string) (bool, []string, error) {
+	return false, nil, nil
+}
+
+func TestExtensions(t *testing.T) {
+	registry := NewRegistry()
+	plain := &mockProvider{name: "plain", mediaType: MediaTypeMovie}
+	extended := &subtitleCollectionProvider{mockProvider{name: "extended", mediaType: MediaTypeMovie}}
+	require.NoError(t, registry.Register(plain))
+	require.NoError(t, registry.Register(extended))
+
+	assert.Empty(t, ExtensionsOf(plain))
+	assert.Equal(t, []Extension{ExtensionSubtitleProbe, ExtensionCollections}, ExtensionsOf(extended))
+
+	exts, err := registry.Extensions("extended")
+	require.NoError(t, err)
+	assert.Equal(t, []Extension{ExtensionSubtitleProbe, ExtensionCollections}, exts)
+
+	assert.True(t, registry.Supports("extended", ExtensionCollections))
+	assert.False(t, registry.Supports("extended", ExtensionManga))
+	assert.False(t, registry.Supports("plain", ExtensionCollections))
+	assert.False(t, registry.Supports("nonexistent", ExtensionCollections))
+	assert.False(t, HasExtension(extended, Extension("unknown")))
+
+	_, err = registry.Extensions("nonexistent")
+	assert.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/justchokingaround/greg/internal/config"
+)
+
+// ProviderFactory builds a provider from its configured settings
+type ProviderFactory struct {
+	Name string
+	// MediaType is the path segment the provider is served under by the
+	// remote API, e.g. "anime", "movies" or "manga"
+	MediaType string
+	New       func(settings config.ProviderSettings) Provider
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]ProviderFactory)
+)
+
+// RegisterFactory makes a provider available to registry.Load under name.
+// Provider packages call it from init(), so adding a provider only takes
+// importing its package. It panics if name is registered twice or factory
+// is nil, as both are programming errors.
+func RegisterFactory(name, mediaType string, factory func(config.ProviderSettings) Provider) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory == nil {
+		panic(fmt.Sprintf("providers: nil factory for %s", name))
+	}
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("providers: factory for %s registered twice", name))
+	}
+	factories[name] = ProviderFactory{Name: name, MediaType: mediaType, New: factory}
+}
+
+// Factories returns the registered provider factories, sorted by name
+func Factories() []ProviderFactory {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	result := make([]ProviderFactory, 0, len(factories))
+	for _, factory := range factories {
+		result = append(result, factory)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
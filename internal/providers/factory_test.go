@@ -0,0 +1,46 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterFactory(t *testing.T) {
+	t.Cleanup(func() {
+		factoriesMu.Lock()
+		delete(factories, "zz-test-movies")
+		delete(factories, "zz-test-anime")
+		factoriesMu.Unlock()
+	})
+
+	var got config.ProviderSettings
+	RegisterFactory("zz-test-movies", "movies", func(settings config.ProviderSettings) Provider {
+		got = settings
+		return &mockProvider{name: "zz-test-movies", mediaType: MediaTypeMovieTV}
+	})
+	RegisterFactory("zz-test-anime", "anime", func(config.ProviderSettings) Provider {
+		return &mockProvider{name: "zz-test-anime", mediaType: MediaTypeAnime}
+	})
+
+	var registered []ProviderFactory
+	for _, factory := range Factories() {
+		if factory.Name == "zz-test-movies" || factory.Name == "zz-test-anime" {
+			registered = append(registered, factory)
+		}
+	}
+	require.Len(t, registered, 2)
+	assert.Equal(t, "zz-test-anime", registered[0].Name, "factories are sorted by name")
+	assert.Equal(t, "anime", registered[0].MediaType)
+
+	p := registered[1].New(config.ProviderSettings{BaseURL: "https://mirror.example"})
+	assert.Equal(t, "zz-test-movies", p.Name())
+	assert.Equal(t, "https://mirror.example", got.BaseURL, "settings are passed to the factory")
+
+	assert.Panics(t, func() {
+		RegisterFactory("zz-test-anime", "anime", func(config.ProviderSettings) Provider { return nil })
+	})
+	assert.Panics(t, func() { RegisterFactory("zz-test-nil", "anime", nil) })
+}
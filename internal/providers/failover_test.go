@@ -0,0 +1,85 @@
+package providers_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchWithFailover_Mock(t *testing.T) {
+	registry := providers.NewRegistry()
+	blocked := newMock("sflix", providers.MediaTypeMovieTV).WithError(methodSearch, providers.ErrBlocked)
+	empty := newMock("flixhq", providers.MediaTypeMovieTV)
+	working := newMock("hdrezka", providers.MediaTypeMovieTV).WithSearch("dark", providers.Media{ID: "tv/dark", Title: "Dark"})
+	for _, p := range []providers.Provider{blocked, empty, working} {
+		require.NoError(t, registry.Register(p))
+	}
+
+	results, name, err := registry.SearchWithFailover(context.Background(), providers.MediaTypeTV, "dark", "sflix")
+	require.NoError(t, err)
+	assert.Equal(t, "hdrezka", name)
+	assert.Equal(t, []providers.Media{{ID: "tv/dark", Title: "Dark"}}, results)
+	assert.Equal(t, 1, blocked.Calls(methodSearch), "the preferred provider is tried first")
+	assert.Equal(t, 1, empty.Calls(methodSearch))
+
+	working.WithError(methodSearch, errors.New("layout changed"))
+	_, _, err = registry.SearchWithFailover(context.Background(), providers.MediaTypeTV, "dark", "sflix")
+	assert.NoError(t, err, "an empty result from one provider is not a total failure")
+
+	empty.WithError(methodSearch, errors.New("timeout"))
+	_, _, err = registry.SearchWithFailover(context.Background(), providers.MediaTypeTV, "dark", "sflix")
+	assert.ErrorIs(t, err, providers.ErrBlocked)
+}
+
+func TestSearchWithFailover_AllFailed(t *testing.T) {
+	registry := providers.NewRegistry()
+	sflix := newMock("sflix", providers.MediaTypeMovieTV).WithError(methodSearch, errors.New("connection reset"))
+	flixhq := newMock("flixhq", providers.MediaTypeMovieTV).WithError(methodSearch, fmt.Errorf("search: %w", providers.ErrBlocked))
+	require.NoError(t, registry.Register(sflix))
+	require.NoError(t, registry.Register(flixhq))
+
+	_, _, err := registry.SearchWithFailover(context.Background(), providers.MediaTypeMovie, "heat", "sflix")
+	var aggregate *providers.AggregateError
+	require.ErrorAs(t, err, &aggregate)
+	assert.EqualError(t, err, "tried sflix, flixhq (blocked): all sources unavailable")
+
+	_, err = registry.SearchAll(context.Background(), providers.MediaTypeMovie, "heat")
+	assert.EqualError(t, err, "tried flixhq (blocked), sflix: all sources unavailable")
+}
+
+func TestSearchAll_Mock(t *testing.T) {
+	registry := providers.NewRegistry()
+	fast := newMock("allanime", providers.MediaTypeAnime).WithSearch("frieren", providers.Media{ID: "a1"})
+	slow := newMock("hianime", providers.MediaTypeAnime).
+		WithSearch("frieren", providers.Media{ID: "h1"}).
+		WithDelay(methodSearch, time.Second)
+	require.NoError(t, registry.Register(fast))
+	require.NoError(t, registry.Register(slow))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	results, err := registry.SearchAll(ctx, providers.MediaTypeAnime, "frieren")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "the slow provider is cut off by the deadline")
+	assert.Equal(t, map[string][]providers.Media{"allanime": {{ID: "a1"}}}, results)
+}
+
+func TestGroupByCollection_PlainMock(t *testing.T) {
+	registry := providers.NewRegistry()
+	p := newMock("sflix", providers.MediaTypeMovieTV)
+	p.Collections = map[string]string{"movie/1": "Saga", "movie/2": "Saga"}
+	require.NoError(t, registry.Register(plain(p)))
+
+	results := []providers.Media{{ID: "movie/1"}, {ID: "movie/2"}}
+	groups, err := registry.GroupByCollection(context.Background(), "sflix", results)
+	require.NoError(t, err)
+	assert.Len(t, groups, 2, "without CollectionFetcher results stay ungrouped")
+	assert.Zero(t, p.Calls(methodCollection))
+	assert.Empty(t, providers.ExtensionsOf(plain(p)))
+}
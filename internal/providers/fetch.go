@@ -0,0 +1,104 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultUserAgent is sent by FetchDocument when the caller does not set one
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36"
+
+// StatusError is returned when a site answers with a non-2xx status. The
+// response body is kept so providers can dump the failed page.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned status code %d", e.URL, e.StatusCode)
+}
+
+// Unwrap lets errors.Is(err, ErrBlocked) match 403 and 503 responses
+func (e *StatusError) Unwrap() error {
+	if IsBlockedStatus(e.StatusCode) {
+		return ErrBlocked
+	}
+	return nil
+}
+
+// CheckStatus returns a *StatusError if resp is not a 2xx response. body is
+// the already read response body and is only used for the error.
+func CheckStatus(resp *http.Response, body []byte) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	return &StatusError{URL: resp.Request.URL.String(), StatusCode: resp.StatusCode, Body: body}
+}
+
+// FetchBody GETs pageURL and returns the response body. Non-2xx responses
+// are returned as *StatusError; network failures are wrapped with the URL
+// that failed. After a 429, or a few 403 and 503 responses in a row, the
+// host that answered is left alone for the Retry-After delay (or a minute),
+// and fetches return *ThrottledError until then.
+func FetchBody(ctx context.Context, client *http.Client, pageURL string, headers map[string]string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	host := throttleKey(pageURL)
+	if until, ok := throttles.check(host); ok {
+		return nil, &ThrottledError{Host: host, Until: until}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", pageURL, err)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", DefaultUserAgent)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", pageURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", pageURL, err)
+	}
+
+	// MirrorTransport may have sent the request to a mirror, so the cooldown
+	// goes on the host in resp.Request rather than pageURL's
+	throttles.observe(resp)
+	if err := CheckStatus(resp, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// FetchDocument GETs pageURL and parses the response as HTML. Errors are
+// the same as FetchBody's, plus wrapped parse failures.
+func FetchDocument(ctx context.Context, client *http.Client, pageURL string, headers map[string]string) (*goquery.Document, error) {
+	body, err := FetchBody(ctx, client, pageURL, headers)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML from %s: %w", pageURL, err)
+	}
+	return doc, nil
+}
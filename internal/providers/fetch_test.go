@@ -0,0 +1,239 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/clock"
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchDocument(t *testing.T) {
+	t.Run("parses the page and sends headers", func(t *testing.T) {
+		var gotUA, gotReferer string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotUA = r.Header.Get("User-Agent")
+			gotReferer = r.Header.Get("Referer")
+			_, _ = w.Write([]byte(`<html><body><h2 class="title">Inception</h2></body></html>`))
+		}))
+		defer srv.Close()
+
+		doc, err := FetchDocument(context.Background(), srv.Client(), srv.URL, map[string]string{"Referer": "https://example.com"})
+		require.NoError(t, err)
+		assert.Equal(t, "Inception", doc.Find("h2.title").Text())
+		assert.Equal(t, DefaultUserAgent, gotUA)
+		assert.Equal(t, "https://example.com", gotReferer)
+	})
+
+	t.Run("returns a status error with the body", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not here"))
+		}))
+		defer srv.Close()
+
+		_, err := FetchDocument(context.Background(), srv.Client(), srv.URL, nil)
+		var statusErr *StatusError
+		require.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+		assert.Equal(t, "not here", string(statusErr.Body))
+		assert.False(t, errors.Is(err, ErrBlocked))
+	})
+
+	t.Run("blocked statuses match ErrBlocked", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		_, err := FetchDocument(context.Background(), srv.Client(), srv.URL, nil)
+		assert.ErrorIs(t, err, ErrBlocked)
+	})
+}
+
+// baseURLProvider is a mockProvider that reports a base URL
+type baseURLProvider struct {
+	mockProvider
+	baseURL string
+}
+
+func (p *baseURLProvider) BaseURL() string { return p.baseURL }
+
+func TestFetchBodyThrottle(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(&baseURLProvider{
+		mockProvider: mockProvider{name: "sflix", mediaType: MediaTypeMovieTV},
+		baseURL:      srv.URL,
+	}))
+
+	throttled, _ := registry.IsThrottled("sflix")
+	assert.False(t, throttled)
+
+	_, err := FetchBody(context.Background(), srv.Client(), srv.URL+"/search", nil)
+	var statusErr *StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+
+	throttled, until := registry.IsThrottled("sflix")
+	assert.True(t, throttled)
+	assert.WithinDuration(t, time.Now().Add(2*time.Minute), until, 5*time.Second)
+
+	// During the cooldown the site is not contacted at all
+	_, err = FetchBody(context.Background(), srv.Client(), srv.URL+"/home", nil)
+	assert.ErrorIs(t, err, ErrThrottled)
+	var throttledErr *ThrottledError
+	require.True(t, errors.As(err, &throttledErr))
+	assert.Equal(t, until, throttledErr.Until)
+	assert.Equal(t, 1, hits)
+
+	throttled, _ = registry.IsThrottled("unknown")
+	assert.False(t, throttled)
+}
+
+func TestFetchBodyRepeatedBlocks(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	// A single block, even repeated after a success, is not enough
+	_, err := FetchBody(context.Background(), srv.Client(), srv.URL, nil)
+	assert.ErrorIs(t, err, ErrBlocked)
+	status = http.StatusOK
+	_, err = FetchBody(context.Background(), srv.Client(), srv.URL, nil)
+	require.NoError(t, err)
+	status = http.StatusForbidden
+	for i := 0; i < blockedStrikes-1; i++ {
+		_, err = FetchBody(context.Background(), srv.Client(), srv.URL, nil)
+		assert.ErrorIs(t, err, ErrBlocked)
+	}
+	throttled, _ := ThrottledUntil(srv.URL)
+	assert.False(t, throttled)
+
+	_, err = FetchBody(context.Background(), srv.Client(), srv.URL, nil)
+	assert.ErrorIs(t, err, ErrBlocked)
+	throttled, _ = ThrottledUntil(srv.URL)
+	assert.True(t, throttled)
+}
+
+func TestFetchBodyThrottlesAnsweringMirror(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer mirror.Close()
+
+	client := &http.Client{Transport: NewMirrorTransport(http.DefaultTransport, NewMirrorSet(primary.URL, []string{mirror.URL}), 1)}
+	_, err := FetchBody(context.Background(), client, primary.URL+"/search", nil)
+	var statusErr *StatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+
+	throttled, _ := ThrottledUntil(primary.URL)
+	assert.False(t, throttled)
+	throttled, _ = ThrottledUntil(mirror.URL)
+	assert.True(t, throttled)
+}
+
+func TestThrottleCooldownExpires(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	orig := throttles.clock
+	throttles.clock = fake
+	defer func() { throttles.clock = orig }()
+
+	throttles.block("cooldown.test", 30*time.Second)
+
+	throttled, until := ThrottledUntil("https://cooldown.test/search")
+	assert.True(t, throttled)
+	assert.Equal(t, fake.Now().Add(30*time.Second), until)
+
+	fake.Advance(30 * time.Second)
+	throttled, _ = ThrottledUntil("https://cooldown.test/search")
+	assert.False(t, throttled)
+}
+
+func TestNewTransport(t *testing.T) {
+	transport, err := NewTransport(config.NetworkConfig{
+		MaxIdleConns:        50,
+		MaxIdleConnsPerHost: 8,
+		IdleConnTimeout:     time.Minute,
+		VerifyTLS:           true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 50, transport.MaxIdleConns)
+	assert.Equal(t, 8, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+
+	assert.False(t, transport.DisableKeepAlives)
+	assert.False(t, transport.TLSClientConfig != nil && transport.TLSClientConfig.InsecureSkipVerify)
+
+	defaults, err := NewTransport(config.NetworkConfig{VerifyTLS: true})
+	require.NoError(t, err)
+	assert.Equal(t, http.DefaultTransport.(*http.Transport).MaxIdleConns, defaults.MaxIdleConns)
+
+	noKeepAlive, err := NewTransport(config.NetworkConfig{DisableKeepAlives: true, VerifyTLS: true})
+	require.NoError(t, err)
+	assert.True(t, noKeepAlive.DisableKeepAlives)
+
+	insecure, err := NewTransport(config.NetworkConfig{})
+	require.NoError(t, err)
+	require.NotNil(t, insecure.TLSClientConfig)
+	assert.True(t, insecure.TLSClientConfig.InsecureSkipVerify)
+
+	_, err = NewTransport(config.NetworkConfig{Proxy: "not a proxy", VerifyTLS: true})
+	assert.Error(t, err)
+}
+
+func TestNewTransportProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy sees the absolute URL of the target
+		proxied = r.URL.String()
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	transport, err := NewTransport(config.NetworkConfig{Proxy: proxy.URL, VerifyTLS: true})
+	require.NoError(t, err)
+
+	body, err := FetchBody(context.Background(), &http.Client{Transport: transport}, "http://preview.example/poster.jpg", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "via proxy", string(body))
+	assert.Equal(t, "http://preview.example/poster.jpg", proxied)
+}
+
+func TestSharedTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Transport")))
+	}))
+	defer srv.Close()
+
+	// The client is built before the transport is configured
+	client := &http.Client{Transport: SharedTransport{}}
+
+	orig := Transport()
+	defer SetTransport(orig)
+	SetTransport(&HeaderTransport{Base: srv.Client().Transport, Headers: map[string]string{"X-Transport": "configured"}})
+
+	body, err := FetchBody(context.Background(), client, srv.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "configured", string(body))
+}
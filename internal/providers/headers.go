@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// HeaderTransport sets extra headers on requests before passing them on.
+// The headers replace any value the provider set itself, so a user can fix
+// a wrong Referer or Origin as well as add a header a site started to
+// require.
+type HeaderTransport struct {
+	Base    http.RoundTripper
+	Headers map[string]string
+	// Hosts are the provider's own hosts. Only requests for one of them, or
+	// for a subdomain of one, get the headers, since the same client also
+	// fetches embed and CDN hosts that must not see them. Empty means every
+	// request gets them.
+	Hosts []string
+}
+
+// NewHeaderTransport wraps base so requests for the hosts of siteURLs carry
+// headers. Without headers base is returned as-is.
+func NewHeaderTransport(base http.RoundTripper, headers map[string]string, siteURLs ...string) http.RoundTripper {
+	if len(headers) == 0 {
+		return base
+	}
+	t := &HeaderTransport{Base: base, Headers: headers}
+	for _, raw := range siteURLs {
+		if u, err := url.Parse(strings.TrimSpace(raw)); err == nil && u.Host != "" {
+			t.Hosts = append(t.Hosts, strings.ToLower(u.Host))
+		}
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *HeaderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if !t.applies(req.URL) {
+		return base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(req.Context())
+	for key, value := range t.Headers {
+		req.Header.Set(key, value)
+	}
+	return base.RoundTrip(req)
+}
+
+// applies reports whether a request for u should get the headers
+func (t *HeaderTransport) applies(u *url.URL) bool {
+	if len(t.Hosts) == 0 {
+		return true
+	}
+	host := strings.ToLower(u.Host)
+	for _, h := range t.Hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
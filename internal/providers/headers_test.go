@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderTransport(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewHeaderTransport(http.DefaultTransport, map[string]string{
+		// Viper lowercases map keys from config files
+		"x-inertia": "true",
+		"referer":   "https://mirror.example/",
+	})}
+
+	// Configured headers reach requests made through the shared fetch helper
+	// and replace the provider's own values
+	_, err := FetchBody(context.Background(), client, srv.URL, map[string]string{
+		"Referer":          "https://site.example/",
+		"X-Requested-With": "XMLHttpRequest",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", got.Get("X-Inertia"))
+	assert.Equal(t, "https://mirror.example/", got.Get("Referer"))
+	assert.Equal(t, "XMLHttpRequest", got.Get("X-Requested-With"))
+	assert.Equal(t, DefaultUserAgent, got.Get("User-Agent"))
+
+	// The caller's request isn't modified
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Empty(t, req.Header.Get("X-Inertia"))
+	assert.Equal(t, "true", got.Get("X-Inertia"))
+}
+
+func TestNewHeaderTransportWithoutHeaders(t *testing.T) {
+	base := &PacedTransport{}
+	assert.Same(t, base, NewHeaderTransport(base, nil))
+}
+
+func TestHeaderTransportSiteHostsOnly(t *testing.T) {
+	var siteOrigin, cdnOrigin string
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		siteOrigin = r.Header.Get("Origin")
+	}))
+	defer site.Close()
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cdnOrigin = r.Header.Get("Origin")
+	}))
+	defer cdn.Close()
+
+	client := &http.Client{Transport: NewHeaderTransport(http.DefaultTransport, map[string]string{
+		"origin": "https://site.example",
+	}, site.URL+"/")}
+
+	for _, u := range []string{site.URL + "/search", cdn.URL + "/master.m3u8"} {
+		resp, err := client.Get(u)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	assert.Equal(t, "https://site.example", siteOrigin)
+	assert.Empty(t, cdnOrigin, "provider headers must not reach other hosts")
+
+	transport := &HeaderTransport{Hosts: []string{"flixhq.to"}}
+	assert.True(t, transport.applies(&url.URL{Host: "FlixHQ.to"}))
+	assert.True(t, transport.applies(&url.URL{Host: "www.flixhq.to"}))
+	assert.False(t, transport.applies(&url.URL{Host: "notflixhq.to"}))
+}
@@ -0,0 +1,82 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/imagecache"
+)
+
+const (
+	// imageTimeout bounds a single image download
+	imageTimeout = 30 * time.Second
+	// maxImageSize bounds how much of an image response is read
+	maxImageSize = 20 << 20
+)
+
+var (
+	imageCacheMu sync.RWMutex
+	imageCache   *imagecache.Cache
+)
+
+// SetImageCache sets the on-disk cache FetchImage serves images from. A nil
+// cache makes every call download the image.
+func SetImageCache(c *imagecache.Cache) {
+	imageCacheMu.Lock()
+	defer imageCacheMu.Unlock()
+	imageCache = c
+}
+
+// FetchImage returns the image at url and its content type. Requests go
+// through the shared provider transport so they use the configured network
+// settings, and are served from the image cache when one is set.
+func FetchImage(ctx context.Context, url string) ([]byte, string, error) {
+	imageCacheMu.RLock()
+	cache := imageCache
+	imageCacheMu.RUnlock()
+
+	if cache != nil {
+		path, err := cache.Fetch(ctx, url, map[string]string{"User-Agent": DefaultUserAgent})
+		if err != nil {
+			return nil, "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read cached image: %w", err)
+		}
+		return data, http.DetectContentType(data), nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create image request: %w", err)
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+
+	client := &http.Client{Transport: Transport(), Timeout: imageTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download image: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("image request returned status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return data, contentType, nil
+}
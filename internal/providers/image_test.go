@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/imagecache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader is enough of a PNG for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestFetchImage(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, DefaultUserAgent, r.Header.Get("User-Agent"))
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(pngHeader)
+	}))
+	defer srv.Close()
+	t.Cleanup(func() { SetImageCache(nil) })
+	ctx := context.Background()
+
+	t.Run("downloads without a cache", func(t *testing.T) {
+		SetImageCache(nil)
+		requests = 0
+
+		data, contentType, err := FetchImage(ctx, srv.URL+"/poster.png")
+		require.NoError(t, err)
+		assert.Equal(t, pngHeader, data)
+		assert.Equal(t, "image/png", contentType)
+
+		_, _, err = FetchImage(ctx, srv.URL+"/poster.png")
+		require.NoError(t, err)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("serves repeat fetches from the cache", func(t *testing.T) {
+		SetImageCache(imagecache.New(t.TempDir(), time.Hour))
+		requests = 0
+
+		for range 2 {
+			data, contentType, err := FetchImage(ctx, srv.URL+"/poster.png")
+			require.NoError(t, err)
+			assert.Equal(t, pngHeader, data)
+			assert.Equal(t, "image/png", contentType)
+		}
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("reports failed downloads", func(t *testing.T) {
+		SetImageCache(nil)
+		_, _, err := FetchImage(ctx, srv.URL+"/missing.png")
+		assert.ErrorContains(t, err, "404")
+	})
+}
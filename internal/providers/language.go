@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// LanguagePreference selects between the subbed and dubbed variants of a
+// title on sites that list them separately
+type LanguagePreference string
+
+const (
+	LanguageAny LanguagePreference = ""    // Keep the site's order
+	LanguageSub LanguagePreference = "sub" // Original audio with subtitles
+	LanguageDub LanguagePreference = "dub" // Dubbed audio
+)
+
+// ParseLanguagePreference parses "sub", "dub" or "" (any)
+func ParseLanguagePreference(s string) (LanguagePreference, error) {
+	switch pref := LanguagePreference(strings.ToLower(strings.TrimSpace(s))); pref {
+	case LanguageAny, LanguageSub, LanguageDub:
+		return pref, nil
+	default:
+		return LanguageAny, fmt.Errorf("invalid language preference %q: want sub, dub or empty", s)
+	}
+}
+
+// LanguagePreferrer is an interface for providers that offer sub and dub
+// variants and can favour one when picking servers or sources
+type LanguagePreferrer interface {
+	SetLanguagePreference(pref LanguagePreference)
+}
+
+// OffersLanguage reports whether the media is listed with the pref variant.
+// Any media offers LanguageAny.
+func (m Media) OffersLanguage(pref LanguagePreference) bool {
+	return pref == LanguageAny || slices.Contains(m.Languages, string(pref))
+}
+
+// SortByLanguage returns results with those offering pref ahead of the rest,
+// keeping the order within both groups. results itself isn't modified, as
+// providers may hand out their cached slice. The order is unchanged for
+// LanguageAny, or when none of the results lists its languages.
+func SortByLanguage(results []Media, pref LanguagePreference) []Media {
+	if pref == LanguageAny {
+		return results
+	}
+	sorted := slices.Clone(results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OffersLanguage(pref) && !sorted[j].OffersLanguage(pref)
+	})
+	return sorted
+}
@@ -0,0 +1,70 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLanguagePreference(t *testing.T) {
+	for input, want := range map[string]LanguagePreference{"": LanguageAny, "sub": LanguageSub, " DUB ": LanguageDub} {
+		got, err := ParseLanguagePreference(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	got, err := ParseLanguagePreference("raw")
+	assert.Error(t, err)
+	assert.Equal(t, LanguageAny, got)
+}
+
+func TestSortByLanguage(t *testing.T) {
+	results := []Media{
+		{ID: "sub-only", Languages: []string{"sub"}},
+		{ID: "unknown"},
+		{ID: "both", Languages: []string{"sub", "dub"}},
+		{ID: "dub-only", Languages: []string{"dub"}},
+	}
+	ids := func(media []Media) []string {
+		var out []string
+		for _, m := range media {
+			out = append(out, m.ID)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"both", "dub-only", "sub-only", "unknown"}, ids(SortByLanguage(results, LanguageDub)))
+	assert.Equal(t, []string{"sub-only", "both", "unknown", "dub-only"}, ids(SortByLanguage(results, LanguageSub)))
+	assert.Equal(t, []string{"sub-only", "unknown", "both", "dub-only"}, ids(SortByLanguage(results, LanguageAny)))
+	assert.Equal(t, "sub-only", results[0].ID, "the caller's slice is left alone")
+}
+
+// languageProvider answers every search with fixed results
+type languageProvider struct {
+	mockProvider
+	results []Media
+}
+
+func (p *languageProvider) Search(ctx context.Context, query string) ([]Media, error) {
+	return p.results, nil
+}
+
+func TestRegistry_LanguagePreference(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(&languageProvider{
+		mockProvider: mockProvider{name: "hianime", mediaType: MediaTypeAnime},
+		results:      []Media{{ID: "sub", Languages: []string{"sub"}}, {ID: "dub", Languages: []string{"sub", "dub"}}},
+	}))
+	registry.SetLanguagePreference(LanguageDub)
+
+	results, _, err := registry.SearchWithFailover(context.Background(), MediaTypeAnime, "frieren", "hianime")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "dub", results[0].ID)
+
+	all, err := registry.SearchAll(context.Background(), MediaTypeAnime, "frieren")
+	require.NoError(t, err)
+	assert.Equal(t, "dub", all["hianime"][0].ID)
+}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
 	"github.com/justchokingaround/greg/pkg/types"
 )
@@ -22,27 +23,41 @@ type Comix struct {
 	Client      *http.Client
 	searchCache sync.Map
 	infoCache   sync.Map
+
+	// CacheSwitch turns the caches off when cache_enabled is false
+	providers.CacheSwitch
+}
+
+func init() {
+	providers.RegisterFactory("comix", "manga", func(settings config.ProviderSettings) providers.Provider { return New(settings) })
 }
 
-func New() *Comix {
-	return &Comix{
-		BaseURL: "https://comix.to",
-		Client:  &http.Client{},
+// New creates the provider, applying the base URL, request timeout and
+// headers from settings. Zero settings keep the built-in defaults.
+func New(settings config.ProviderSettings) *Comix {
+	c := &Comix{BaseURL: "https://comix.to"}
+	if settings.BaseURL != "" {
+		c.BaseURL = strings.TrimSuffix(settings.BaseURL, "/")
+	}
+	c.Client = &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: providers.NewHeaderTransport(nil, settings.Headers, c.BaseURL),
 	}
+	return c
 }
 
 func (c *Comix) Name() string {
 	return "comix"
 }
 
-func (c *Comix) searchOld(query string) (*types.SearchResults, error) {
-	if cached, ok := c.searchCache.Load(query); ok {
+func (c *Comix) searchOld(ctx context.Context, query string) (*types.SearchResults, error) {
+	if cached, ok := c.searchCache.Load(query); ok && c.CacheEnabled() {
 		return cached.(*types.SearchResults), nil
 	}
 
 	page := 1
 	urlStr := fmt.Sprintf("%s/api/v2/manga?keyword=%s&limit=20&page=%d&order[relevance]=desc", c.BaseURL, url.QueryEscape(query), page)
-	req, err := http.NewRequest("GET", urlStr, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -90,12 +105,19 @@ func (c *Comix) searchOld(query string) (*types.SearchResults, error) {
 	}
 
 	res := &types.SearchResults{Results: results}
-	c.searchCache.Store(query, res)
+	if c.CacheEnabled() {
+		c.searchCache.Store(query, res)
+	}
 	return res, nil
 }
 
 func (c *Comix) GetInfo(id string) (interface{}, error) {
-	if cached, ok := c.infoCache.Load(id); ok {
+	return c.GetInfoContext(context.Background(), id)
+}
+
+// GetInfoContext is GetInfo with a context for its requests
+func (c *Comix) GetInfoContext(ctx context.Context, id string) (interface{}, error) {
+	if cached, ok := c.infoCache.Load(id); ok && c.CacheEnabled() {
 		return cached.(*types.MangaInfo), nil
 	}
 
@@ -108,7 +130,7 @@ func (c *Comix) GetInfo(id string) (interface{}, error) {
 
 	// Get manga details from the page
 	mangaUrl := fmt.Sprintf("%s/title/%s-%s", c.BaseURL, hashId, slug)
-	req, err := http.NewRequest("GET", mangaUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", mangaUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -201,7 +223,7 @@ func (c *Comix) GetInfo(id string) (interface{}, error) {
 	page := 1
 	for {
 		chaptersUrl := fmt.Sprintf("%s/api/v2/manga/%s/chapters?limit=%d&page=%d&order[number]=asc", c.BaseURL, hashId, chaptersCount, page)
-		req, err = http.NewRequest("GET", chaptersUrl, nil)
+		req, err = http.NewRequestWithContext(ctx, "GET", chaptersUrl, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -341,11 +363,18 @@ func (c *Comix) GetInfo(id string) (interface{}, error) {
 		processChapter(numStr)
 	}
 
-	c.infoCache.Store(id, mangaInfo)
+	if c.CacheEnabled() {
+		c.infoCache.Store(id, mangaInfo)
+	}
 	return mangaInfo, nil
 }
 
 func (c *Comix) GetSources(episodeID string) (interface{}, error) {
+	return c.GetSourcesContext(context.Background(), episodeID)
+}
+
+// GetSourcesContext is GetSources, aborting once ctx is done
+func (c *Comix) GetSourcesContext(ctx context.Context, episodeID string) (interface{}, error) {
 	parts := strings.Split(episodeID, "::")
 	if len(parts) != 4 {
 		return nil, fmt.Errorf("invalid chapterId format")
@@ -358,7 +387,7 @@ func (c *Comix) GetSources(episodeID string) (interface{}, error) {
 	targetPath := fmt.Sprintf("/title/%s-%s/%s-chapter-%s", hashId, slug, chapterApiId, chapterNumber)
 	targetUrl := fmt.Sprintf("%s%s", c.BaseURL, targetPath)
 
-	req, err := http.NewRequest("GET", targetUrl, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", targetUrl, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -436,6 +465,11 @@ func (c *Comix) GetSources(episodeID string) (interface{}, error) {
 }
 
 func (c *Comix) GetServers(episodeID string) ([]types.EpisodeServer, error) {
+	return c.GetServersContext(context.Background(), episodeID)
+}
+
+// GetServersContext is GetServers, aborting once ctx is done
+func (c *Comix) GetServersContext(ctx context.Context, episodeID string) ([]types.EpisodeServer, error) {
 	return []types.EpisodeServer{}, nil
 }
 
@@ -446,7 +480,7 @@ func (c *Comix) Type() providers.MediaType {
 
 // Search (new interface) searches for manga by query
 func (c *Comix) Search(ctx context.Context, query string) ([]providers.Media, error) {
-	oldResults, err := c.searchOld(query)
+	oldResults, err := c.searchOld(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -474,7 +508,7 @@ func (c *Comix) Search(ctx context.Context, query string) ([]providers.Media, er
 
 // GetMediaDetails fetches detailed info for a manga
 func (c *Comix) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
-	info, err := c.GetInfo(id)
+	info, err := c.GetInfoContext(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -516,7 +550,7 @@ func (c *Comix) GetSeasons(ctx context.Context, mediaID string) ([]providers.Sea
 
 // GetEpisodes returns chapters as episodes
 func (c *Comix) GetEpisodes(ctx context.Context, seasonID string) ([]providers.Episode, error) {
-	info, err := c.GetInfo(seasonID)
+	info, err := c.GetInfoContext(ctx, seasonID)
 	if err != nil {
 		return nil, err
 	}
@@ -556,7 +590,7 @@ func (c *Comix) GetAvailableQualities(ctx context.Context, episodeID string) ([]
 
 // GetMangaPages fetches manga pages for a chapter
 func (c *Comix) GetMangaPages(ctx context.Context, chapterID string) ([]string, error) {
-	res, err := c.GetSources(chapterID)
+	res, err := c.GetSourcesContext(ctx, chapterID)
 	if err != nil {
 		return nil, err
 	}
@@ -588,3 +622,14 @@ func (c *Comix) GetRecent(ctx context.Context) ([]providers.Media, error) {
 func (c *Comix) HealthCheck(ctx context.Context) error {
 	return nil
 }
+
+// ClearCache drops all cached search and info results
+func (c *Comix) ClearCache() {
+	c.searchCache.Clear()
+	c.infoCache.Clear()
+}
+
+// ClearCacheFor drops the cached info for a single media ID
+func (c *Comix) ClearCacheFor(mediaID string) {
+	c.infoCache.Delete(mediaID)
+}
@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	normalizeSeason     = regexp.MustCompile(`\s*season\s+\d+\s*`)
+	normalizePart       = regexp.MustCompile(`\s*part\s+\d+\s*`)
+	normalizeWhitespace = regexp.MustCompile(`\s+`)
+)
+
+// NormalizeTitle normalizes a title for comparison: it is lowercased, has
+// "season N" and "part N" removed and keeps only letters, digits and spaces
+func NormalizeTitle(title string) string {
+	title = strings.ToLower(title)
+	title = normalizeSeason.ReplaceAllString(title, " ")
+	title = normalizePart.ReplaceAllString(title, " ")
+
+	// Drop punctuation, keeping non-ASCII letters
+	var b strings.Builder
+	for _, r := range title {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == ' ' || r > 127 {
+			b.WriteRune(r)
+		}
+	}
+
+	return strings.TrimSpace(normalizeWhitespace.ReplaceAllString(b.String(), " "))
+}
+
+// LevenshteinDistance returns the minimum number of single-byte insertions,
+// deletions and substitutions needed to turn s1 into s2
+func LevenshteinDistance(s1, s2 string) int {
+	prev := make([]int, len(s2)+1)
+	curr := make([]int, len(s2)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s1); i++ {
+		curr[0] = i
+		for j := 1; j <= len(s2); j++ {
+			cost := 1
+			if s1[i-1] == s2[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(s2)]
+}
+
+// MatchScore rates how closely title matches query from 0 (nothing in
+// common) to 1 (the same once normalized), by edit distance between the
+// normalized titles
+func MatchScore(query, title string) float64 {
+	a, b := NormalizeTitle(query), NormalizeTitle(title)
+	if a == "" || b == "" {
+		return 0
+	}
+	return max(0, 1-float64(LevenshteinDistance(a, b))/float64(max(len(a), len(b))))
+}
@@ -0,0 +1,270 @@
+package providers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBlockedRetries = 2
+	defaultBlockedBackoff = 2 * time.Second
+	maxBlockedBackoff     = 30 * time.Second
+)
+
+// IsBlockedStatus reports whether a status code means the site is rate
+// limiting or blocking us (403 Forbidden, 503 Service Unavailable). Retrying
+// the same domain straight away rarely helps for these.
+func IsBlockedStatus(code int) bool {
+	return code == http.StatusForbidden || code == http.StatusServiceUnavailable
+}
+
+// RetryAfter returns the delay requested by a response's Retry-After header,
+// which may be given in seconds or as an HTTP date, or fallback if the header
+// is missing or invalid
+func RetryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return fallback
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+		return 0
+	}
+
+	return fallback
+}
+
+// MirrorSet tracks the primary base URL of a provider and its configured
+// mirrors, and which one is currently in use
+type MirrorSet struct {
+	mu      sync.Mutex
+	urls    []*url.URL
+	current int
+}
+
+// NewMirrorSet creates a mirror set starting at primary. Invalid and
+// duplicate mirror URLs are ignored.
+func NewMirrorSet(primary string, mirrors []string) *MirrorSet {
+	m := &MirrorSet{}
+	seen := make(map[string]bool)
+	for _, raw := range append([]string{primary}, mirrors...) {
+		u, err := url.Parse(strings.TrimRight(strings.TrimSpace(raw), "/"))
+		if err != nil || u.Scheme == "" || u.Host == "" || seen[u.Host] {
+			continue
+		}
+		seen[u.Host] = true
+		m.urls = append(m.urls, u)
+	}
+	return m
+}
+
+// Current returns the base URL currently in use
+func (m *MirrorSet) Current() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.urls) == 0 {
+		return ""
+	}
+	return m.urls[m.current].String()
+}
+
+// Len returns the number of base URLs in the set, including the primary
+func (m *MirrorSet) Len() int {
+	return len(m.urls)
+}
+
+// owns reports whether u points at any base URL in the set
+func (m *MirrorSet) owns(u *url.URL) bool {
+	for _, base := range m.urls {
+		if strings.EqualFold(base.Host, u.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// advance switches to the mirror after host. It only moves if host is still
+// current, so concurrent failures against the same mirror switch only once.
+func (m *MirrorSet) advance(host string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.urls) > 1 && strings.EqualFold(m.urls[m.current].Host, host) {
+		m.current = (m.current + 1) % len(m.urls)
+	}
+}
+
+// rewrite points a request for any base URL in the set at the current one
+func (m *MirrorSet) rewrite(req *http.Request) *http.Request {
+	current, err := url.Parse(m.Current())
+	if err != nil || current.Host == "" || !m.owns(req.URL) {
+		return req
+	}
+
+	out := req.Clone(req.Context())
+	out.URL.Scheme = current.Scheme
+	out.URL.Host = current.Host
+	out.Host = ""
+
+	if referer := out.Header.Get("Referer"); referer != "" {
+		if u, err := url.Parse(referer); err == nil && m.owns(u) {
+			u.Scheme = current.Scheme
+			u.Host = current.Host
+			out.Header.Set("Referer", u.String())
+		}
+	}
+
+	return out
+}
+
+type mirrorTraceKey struct{}
+
+// mirrorTrace collects the mirror hosts that requests made with one context
+// were sent to
+type mirrorTrace struct {
+	mu    sync.Mutex
+	hosts map[string]bool
+}
+
+// withMirrorTrace returns ctx set up to record which mirrors the requests
+// made with it reach, for mirrorsTried
+func withMirrorTrace(ctx context.Context) context.Context {
+	return context.WithValue(ctx, mirrorTraceKey{}, &mirrorTrace{hosts: make(map[string]bool)})
+}
+
+// recordMirror notes that a request made with ctx was sent to host
+func recordMirror(ctx context.Context, host string) {
+	trace, ok := ctx.Value(mirrorTraceKey{}).(*mirrorTrace)
+	if !ok {
+		return
+	}
+	trace.mu.Lock()
+	trace.hosts[strings.ToLower(host)] = true
+	trace.mu.Unlock()
+}
+
+// mirrorsTried returns how many different mirrors the requests made with a
+// ctx from withMirrorTrace were sent to
+func mirrorsTried(ctx context.Context) int {
+	trace, ok := ctx.Value(mirrorTraceKey{}).(*mirrorTrace)
+	if !ok {
+		return 0
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	return len(trace.hosts)
+}
+
+// MirrorTransport retries requests to the provider's own site that were
+// blocked with 403 or 503. If mirrors are configured it switches to the next
+// mirror straight away; otherwise it backs off for the Retry-After delay (or
+// a default) and retries the same domain. Requests to hosts the MirrorSet
+// doesn't own, such as embed and CDN hosts, are never retried.
+type MirrorTransport struct {
+	Base       http.RoundTripper
+	Mirrors    *MirrorSet
+	MaxRetries int
+
+	// sleep waits between retries; replaced in tests
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// NewMirrorTransport wraps base with blocked-request handling
+func NewMirrorTransport(base http.RoundTripper, mirrors *MirrorSet, maxRetries int) *MirrorTransport {
+	if maxRetries <= 0 {
+		maxRetries = defaultBlockedRetries
+	}
+	return &MirrorTransport{
+		Base:       base,
+		Mirrors:    mirrors,
+		MaxRetries: maxRetries,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *MirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	for attempt := 0; ; attempt++ {
+		out := req
+		if attempt > 0 {
+			// Requests with a body can only be replayed if it can be recreated
+			out = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				out.Body = body
+			}
+		}
+		if t.Mirrors != nil {
+			out = t.Mirrors.rewrite(out)
+			if t.Mirrors.owns(out.URL) {
+				recordMirror(req.Context(), out.URL.Host)
+			}
+		}
+
+		resp, err := base.RoundTrip(out)
+		if err != nil || !IsBlockedStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		if t.Mirrors == nil || !t.Mirrors.owns(req.URL) {
+			return resp, nil
+		}
+		if attempt >= t.MaxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		delay := time.Duration(0)
+		if t.Mirrors.Len() > 1 {
+			t.Mirrors.advance(out.URL.Host)
+		} else {
+			delay = RetryAfter(resp, defaultBlockedBackoff*time.Duration(attempt+1))
+			if delay > maxBlockedBackoff {
+				delay = maxBlockedBackoff
+			}
+		}
+
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		if err := t.wait(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait pauses for d or until ctx is done
+func (t *MirrorTransport) wait(ctx context.Context, d time.Duration) error {
+	if t.sleep != nil {
+		return t.sleep(ctx, d)
+	}
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
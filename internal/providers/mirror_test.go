@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorTransport(t *testing.T) {
+	t.Run("switches to the next mirror when blocked", func(t *testing.T) {
+		var blockedHits int32
+		blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&blockedHits, 1)
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer blocked.Close()
+
+		mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/search/dune", r.URL.Path)
+			_, _ = w.Write([]byte("ok"))
+		}))
+		defer mirror.Close()
+
+		mirrors := NewMirrorSet(blocked.URL, []string{mirror.URL})
+		transport := NewMirrorTransport(nil, mirrors, 2)
+		transport.sleep = func(ctx context.Context, d time.Duration) error {
+			assert.Zero(t, d, "switching mirrors should not back off")
+			return nil
+		}
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Get(blocked.URL + "/search/dune")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, mirror.URL, mirrors.Current())
+		assert.Equal(t, int32(1), atomic.LoadInt32(&blockedHits))
+
+		// Later requests built against the primary go straight to the mirror
+		resp2, err := client.Get(blocked.URL + "/search/dune")
+		require.NoError(t, err)
+		_ = resp2.Body.Close()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&blockedHits))
+	})
+
+	t.Run("backs off using Retry-After without mirrors", func(t *testing.T) {
+		var hits int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&hits, 1) == 1 {
+				w.Header().Set("Retry-After", "7")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		}))
+		defer server.Close()
+
+		var delays []time.Duration
+		transport := NewMirrorTransport(nil, NewMirrorSet(server.URL, nil), 2)
+		transport.sleep = func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		}
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, []time.Duration{7 * time.Second}, delays)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&hits))
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		var hits int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		var delays []time.Duration
+		transport := NewMirrorTransport(nil, NewMirrorSet(server.URL, nil), 2)
+		transport.sleep = func(ctx context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		}
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&hits))
+		assert.Equal(t, []time.Duration{defaultBlockedBackoff, 2 * defaultBlockedBackoff}, delays)
+	})
+
+	t.Run("returns blocked responses from other hosts at once", func(t *testing.T) {
+		var hits int32
+		cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer cdn.Close()
+
+		transport := NewMirrorTransport(nil, NewMirrorSet("https://sflix.example", nil), 2)
+		transport.sleep = func(ctx context.Context, d time.Duration) error {
+			t.Errorf("backed off %v for a host the mirror set doesn't own", d)
+			return nil
+		}
+		client := &http.Client{Transport: transport}
+
+		resp, err := client.Get(cdn.URL + "/video.m3u8")
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+	})
+}
@@ -0,0 +1,399 @@
+package providers_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/pkg/extractors"
+)
+
+// Method names accepted by mockProvider.Errors, Delays and Calls
+const (
+	methodSearch       = "Search"
+	methodTrending     = "GetTrending"
+	methodRecent       = "GetRecent"
+	methodDetails      = "GetMediaDetails"
+	methodSeasons      = "GetSeasons"
+	methodEpisodes     = "GetEpisodes"
+	methodStreamURL    = "GetStreamURL"
+	methodQualities    = "GetAvailableQualities"
+	methodHealthCheck  = "HealthCheck"
+	methodMangaPages   = "GetMangaPages"
+	methodSubtitles    = "HasSubtitles"
+	methodProbeServers = "ProbeServers"
+	methodCollection   = "GetCollection"
+	methodMovieEpisode = "GetMovieEpisodeID"
+)
+
+// mockProvider is a scripted in-memory provider for tests. It answers
+// without touching the network, and can inject errors and latency per method
+// to exercise failover and aggregation. Lookups answer from the maps keyed
+// by query or ID; a missing key yields empty results, or a not found error
+// for single items. The fields may be set directly before the provider is
+// used.
+//
+// mockProvider implements every optional interface in the providers package.
+// Wrap it with plain to test callers against a provider without them.
+type mockProvider struct {
+	ProviderName string
+	MediaType    providers.MediaType
+
+	SearchResults map[string][]providers.Media // By query
+	Trending      []providers.Media
+	Recent        []providers.Media
+	Details       map[string]*providers.MediaDetails // By media ID
+	Seasons       map[string][]providers.Season      // By media ID
+	Episodes      map[string][]providers.Episode     // By season ID
+	Streams       map[string]*providers.StreamURL    // By episode ID
+	Qualities     []providers.Quality
+	MangaPages    map[string][]string                 // By chapter ID
+	Subtitles     map[string][]string                 // Languages by episode ID
+	ServerProbes  map[string][]extractors.ServerProbe // By episode ID
+	Collections   map[string]string                   // By media ID
+	MovieEpisodes map[string]string                   // Episode ID by media ID
+	Version       string
+	URL           string
+
+	// Err is returned by every method that can fail, unless Errors has an
+	// entry for the method
+	Err    error
+	Errors map[string]error
+	// Delay is waited before every method answers, unless Delays has an
+	// entry for the method. A cancelled context cuts the wait short.
+	Delay  time.Duration
+	Delays map[string]time.Duration
+
+	mu           sync.Mutex
+	calls        map[string]int
+	cacheCleared []string
+	cacheEnabled bool
+	configured   bool
+	language     providers.LanguagePreference
+}
+
+var (
+	_ providers.MangaProvider        = (*mockProvider)(nil)
+	_ providers.FilteredSearcher     = (*mockProvider)(nil)
+	_ providers.StreamSearcher       = (*mockProvider)(nil)
+	_ providers.SubtitleProber       = (*mockProvider)(nil)
+	_ providers.ServerProber         = (*mockProvider)(nil)
+	_ providers.CollectionFetcher    = (*mockProvider)(nil)
+	_ providers.CacheClearer         = (*mockProvider)(nil)
+	_ providers.CacheToggler         = (*mockProvider)(nil)
+	_ providers.Configurable         = (*mockProvider)(nil)
+	_ providers.Versioned            = (*mockProvider)(nil)
+	_ providers.BaseURLReporter      = (*mockProvider)(nil)
+	_ providers.MovieEpisodeResolver = (*mockProvider)(nil)
+	_ providers.LanguagePreferrer    = (*mockProvider)(nil)
+)
+
+// newMock creates an empty provider serving mediaType
+func newMock(name string, mediaType providers.MediaType) *mockProvider {
+	return &mockProvider{ProviderName: name, MediaType: mediaType, cacheEnabled: true}
+}
+
+// WithSearch scripts the results for query and returns p for chaining
+func (p *mockProvider) WithSearch(query string, results ...providers.Media) *mockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.SearchResults == nil {
+		p.SearchResults = make(map[string][]providers.Media)
+	}
+	p.SearchResults[query] = results
+	return p
+}
+
+// WithError makes method fail with err and returns p for chaining
+func (p *mockProvider) WithError(method string, err error) *mockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Errors == nil {
+		p.Errors = make(map[string]error)
+	}
+	p.Errors[method] = err
+	return p
+}
+
+// WithDelay makes method wait d before answering and returns p for chaining
+func (p *mockProvider) WithDelay(method string, d time.Duration) *mockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Delays == nil {
+		p.Delays = make(map[string]time.Duration)
+	}
+	p.Delays[method] = d
+	return p
+}
+
+// Calls returns how many times method has been called
+func (p *mockProvider) Calls(method string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls[method]
+}
+
+// call records a call to method, waits its delay and returns its error
+func (p *mockProvider) call(ctx context.Context, method string) error {
+	p.mu.Lock()
+	if p.calls == nil {
+		p.calls = make(map[string]int)
+	}
+	p.calls[method]++
+	delay, ok := p.Delays[method]
+	if !ok {
+		delay = p.Delay
+	}
+	err, ok := p.Errors[method]
+	if !ok {
+		err = p.Err
+	}
+	p.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	return err
+}
+
+func (p *mockProvider) Name() string              { return p.ProviderName }
+func (p *mockProvider) Type() providers.MediaType { return p.MediaType }
+func (p *mockProvider) ProviderVersion() string   { return p.Version }
+func (p *mockProvider) BaseURL() string           { return p.URL }
+func (p *mockProvider) HealthCheck(ctx context.Context) error {
+	return p.call(ctx, methodHealthCheck)
+}
+
+func (p *mockProvider) Search(ctx context.Context, query string) ([]providers.Media, error) {
+	if err := p.call(ctx, methodSearch); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.SearchResults[query], nil
+}
+
+func (p *mockProvider) GetTrending(ctx context.Context) ([]providers.Media, error) {
+	if err := p.call(ctx, methodTrending); err != nil {
+		return nil, err
+	}
+	return p.Trending, nil
+}
+
+func (p *mockProvider) GetRecent(ctx context.Context) ([]providers.Media, error) {
+	if err := p.call(ctx, methodRecent); err != nil {
+		return nil, err
+	}
+	return p.Recent, nil
+}
+
+func (p *mockProvider) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
+	if err := p.call(ctx, methodDetails); err != nil {
+		return nil, err
+	}
+	details, ok := p.Details[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: media %s not found", p.ProviderName, id)
+	}
+	return details, nil
+}
+
+func (p *mockProvider) GetSeasons(ctx context.Context, mediaID string) ([]providers.Season, error) {
+	if err := p.call(ctx, methodSeasons); err != nil {
+		return nil, err
+	}
+	return p.Seasons[mediaID], nil
+}
+
+func (p *mockProvider) GetEpisodes(ctx context.Context, seasonID string) ([]providers.Episode, error) {
+	if err := p.call(ctx, methodEpisodes); err != nil {
+		return nil, err
+	}
+	return p.Episodes[seasonID], nil
+}
+
+func (p *mockProvider) GetStreamURL(ctx context.Context, episodeID string, quality providers.Quality) (*providers.StreamURL, error) {
+	if err := p.call(ctx, methodStreamURL); err != nil {
+		return nil, err
+	}
+	stream, ok := p.Streams[episodeID]
+	if !ok {
+		return nil, fmt.Errorf("%s: no stream for episode %s", p.ProviderName, episodeID)
+	}
+	return stream, nil
+}
+
+func (p *mockProvider) GetAvailableQualities(ctx context.Context, episodeID string) ([]providers.Quality, error) {
+	if err := p.call(ctx, methodQualities); err != nil {
+		return nil, err
+	}
+	return p.Qualities, nil
+}
+
+// GetMangaPages implements providers.MangaProvider
+func (p *mockProvider) GetMangaPages(ctx context.Context, chapterID string) ([]string, error) {
+	if err := p.call(ctx, methodMangaPages); err != nil {
+		return nil, err
+	}
+	return p.MangaPages[chapterID], nil
+}
+
+// SearchFiltered implements providers.FilteredSearcher
+func (p *mockProvider) SearchFiltered(ctx context.Context, query string, opts providers.SearchOptions) ([]providers.Media, error) {
+	results, err := p.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return providers.FilterMedia(results, opts), nil
+}
+
+// SearchStream implements providers.StreamSearcher, sending the scripted
+// results one at a time
+func (p *mockProvider) SearchStream(ctx context.Context, query string) (<-chan providers.Media, <-chan error) {
+	results := make(chan providers.Media)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(results)
+		defer close(errc)
+
+		media, err := p.Search(ctx, query)
+		if err != nil {
+			errc <- err
+			return
+		}
+		for _, m := range media {
+			select {
+			case results <- m:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return results, errc
+}
+
+// HasSubtitles implements providers.SubtitleProber
+func (p *mockProvider) HasSubtitles(ctx context.Context, episodeID string) (bool, []string, error) {
+	if err := p.call(ctx, methodSubtitles); err != nil {
+		return false, nil, err
+	}
+	languages := p.Subtitles[episodeID]
+	return len(languages) > 0, languages, nil
+}
+
+// ProbeServers implements providers.ServerProber
+func (p *mockProvider) ProbeServers(ctx context.Context, episodeID string) ([]extractors.ServerProbe, error) {
+	if err := p.call(ctx, methodProbeServers); err != nil {
+		return nil, err
+	}
+	return p.ServerProbes[episodeID], nil
+}
+
+// GetCollection implements providers.CollectionFetcher
+func (p *mockProvider) GetCollection(ctx context.Context, mediaID string) (string, error) {
+	if err := p.call(ctx, methodCollection); err != nil {
+		return "", err
+	}
+	return p.Collections[mediaID], nil
+}
+
+// GetMovieEpisodeID implements providers.MovieEpisodeResolver
+func (p *mockProvider) GetMovieEpisodeID(ctx context.Context, mediaID string) (string, error) {
+	if err := p.call(ctx, methodMovieEpisode); err != nil {
+		return "", err
+	}
+	id, ok := p.MovieEpisodes[mediaID]
+	if !ok {
+		return "", fmt.Errorf("%s: movie %s: %w", p.ProviderName, mediaID, providers.ErrNoEpisodes)
+	}
+	return id, nil
+}
+
+// ClearCache implements providers.CacheClearer, recording the call
+func (p *mockProvider) ClearCache() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cacheCleared = append(p.cacheCleared, "")
+}
+
+// ClearCacheFor implements providers.CacheClearer, recording the media ID
+func (p *mockProvider) ClearCacheFor(mediaID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cacheCleared = append(p.cacheCleared, mediaID)
+}
+
+// CacheCleared returns the media IDs passed to ClearCacheFor, with "" for
+// each ClearCache, in call order
+func (p *mockProvider) CacheCleared() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.cacheCleared...)
+}
+
+// SetCacheEnabled implements providers.CacheToggler
+func (p *mockProvider) SetCacheEnabled(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cacheEnabled = enabled
+}
+
+// CacheEnabled reports the last value passed to SetCacheEnabled
+func (p *mockProvider) CacheEnabled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cacheEnabled
+}
+
+// SetConfig implements providers.Configurable, recording that it was called
+func (p *mockProvider) SetConfig(cfg *config.Config, logger *slog.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configured = true
+}
+
+// Configured reports whether SetConfig has been called
+func (p *mockProvider) Configured() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.configured
+}
+
+// SetLanguagePreference implements providers.LanguagePreferrer
+func (p *mockProvider) SetLanguagePreference(pref providers.LanguagePreference) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.language = pref
+}
+
+// Language returns the last value passed to SetLanguagePreference
+func (p *mockProvider) Language() providers.LanguagePreference {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.language
+}
+
+// plainProvider hides every method outside providers.Provider
+type plainProvider struct {
+	providers.Provider
+}
+
+// plain wraps p so it implements only providers.Provider, for testing the
+// paths taken when a provider lacks an optional interface
+func plain(p providers.Provider) providers.Provider {
+	return plainProvider{p}
+}
@@ -3,72 +3,209 @@ package flixhq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/providers/utils"
+	"github.com/justchokingaround/greg/internal/tmdb"
 	"github.com/justchokingaround/greg/pkg/extractors"
 	"github.com/justchokingaround/greg/pkg/types"
+	"golang.org/x/sync/singleflight"
 )
 
 type FlixHQ struct {
-	BaseURL     string
+	baseURL     string
 	Client      *http.Client
 	searchCache sync.Map
 	infoCache   sync.Map
+
+	// CacheSwitch turns the caches off when cache_enabled is false
+	providers.CacheSwitch
+
+	// infoFlight shares a GetInfo fetch between concurrent callers
+	infoFlight singleflight.Group
+
+	// sourcesCache holds the last extracted sources per episode ID
+	sourcesCache sync.Map
+
+	// movieEpisodes records the episode IDs GetMovieEpisodeID handed out, so
+	// GetStreamURL can tell movies from shows
+	movieEpisodes sync.Map
+
+	// Settings applied via SetConfig
+	debug          bool
+	cacheDir       string
+	logger         *slog.Logger
+	sourcesTimeout time.Duration
+	infoTimeout    time.Duration // Bounds shared GetInfo fetches; defaultInfoTimeout when zero
+	numbering      providers.EpisodeNumbering
+	mirrors        *providers.MirrorSet
+	metadata       providers.MetadataSource // nil unless a TMDB API key is configured
+	posterWidth    int                      // Poster size requested for previews, zero for the site's default
+	posterHeight   int
 }
 
-func New() *FlixHQ {
-	return &FlixHQ{
-		BaseURL: "https://flixhq.to",
-		Client:  &http.Client{},
+// providerVersion identifies the flixhq site layout this scraper was
+// written against. Bump it whenever the scraping logic is updated for a
+// layout change.
+const providerVersion = "v1"
+
+// defaultSourcesTimeout bounds how long source extraction may take across all servers
+const defaultSourcesTimeout = 60 * time.Second
+
+// defaultInfoTimeout bounds a shared GetInfo fetch. It runs detached from
+// the callers' contexts, so without a limit a hung site would keep every
+// later caller for the same ID waiting on it.
+const defaultInfoTimeout = 60 * time.Second
+
+// maxConcurrentServers bounds how many servers are extracted from at once
+const maxConcurrentServers = 3
+
+// serverProbeTimeout bounds how long ProbeServers waits on a single server
+const serverProbeTimeout = 15 * time.Second
+
+func init() {
+	providers.RegisterFactory("flixhq", "movies", func(settings config.ProviderSettings) providers.Provider { return New(settings) })
+}
+
+// New creates the provider, applying the base URL, sources timeout and
+// headers from settings. Zero settings keep the built-in defaults.
+func New(settings config.ProviderSettings) *FlixHQ {
+	f := &FlixHQ{
+		baseURL:        "https://flixhq.to",
+		sourcesTimeout: settings.Timeout,
+	}
+	if settings.BaseURL != "" {
+		f.baseURL = strings.TrimSuffix(settings.BaseURL, "/")
 	}
+	f.Client = &http.Client{Transport: providers.NewHeaderTransport(nil, settings.Headers, f.baseURL)}
+	return f
 }
 
 func (f *FlixHQ) Name() string {
 	return "flixhq"
 }
 
-// searchOld searches for movies/shows by query (legacy internal method)
-func (f *FlixHQ) searchOld(query string) (*types.SearchResults, error) {
-	if cached, ok := f.searchCache.Load(query); ok {
-		return cached.(*types.SearchResults), nil
+// BaseURL returns the domain the provider is scraping
+func (f *FlixHQ) BaseURL() string {
+	if f.mirrors != nil {
+		if current := f.mirrors.Current(); current != "" {
+			return current
+		}
 	}
+	return f.baseURL
+}
 
-	// Replace non-word characters with hyphens
-	re := regexp.MustCompile(`[\W_]+`)
-	cleanQuery := re.ReplaceAllString(query, "-")
-	searchURL := fmt.Sprintf("%s/search/%s", f.BaseURL, cleanQuery)
+// ProviderVersion returns the site layout revision the scraper targets
+func (f *FlixHQ) ProviderVersion() string {
+	return providerVersion
+}
+
+// SetConfig applies runtime configuration to the provider
+func (f *FlixHQ) SetConfig(cfg *config.Config, logger *slog.Logger) {
+	f.debug = cfg.Advanced.Debug
+	f.cacheDir = cfg.Cache.Path
+	f.logger = logger
+	f.sourcesTimeout = cfg.Providers.FlixHQ.Timeout
+	f.numbering = providers.ParseEpisodeNumbering(cfg.Providers.EpisodeNumbering)
+	f.posterWidth, f.posterHeight = providers.PreviewPosterSize(cfg.UI.PreviewSize)
+
+	f.metadata = nil
+	if cfg.Providers.TMDBAPIKey != "" {
+		f.metadata = tmdb.New(cfg.Providers.TMDBAPIKey)
+	}
+
+	// Switch mirrors or back off when the site blocks us
+	settings := cfg.Providers.FlixHQ
+	f.mirrors = providers.NewMirrorSet(f.baseURL, settings.Mirrors)
+	paced := providers.NewPacedTransport(providers.Transport(), settings.RequestDelay)
+	mirrored := providers.NewMirrorTransport(paced, f.mirrors, settings.MaxRetries)
+	f.Client.Transport = providers.NewHeaderTransport(mirrored, settings.Headers, append([]string{f.baseURL}, settings.Mirrors...)...)
+}
+
+// posterURL requests a poster at the size configured for previews
+func (f *FlixHQ) posterURL(raw string) string {
+	return providers.PosterURLAt(raw, f.posterWidth, f.posterHeight)
+}
 
-	req, err := http.NewRequest("GET", searchURL, nil)
+// dumpFailure saves the page of a failed fetch when debug mode is enabled
+func (f *FlixHQ) dumpFailure(pageURL string, statusCode int, body []byte) {
+	if !f.debug || f.cacheDir == "" {
+		return
+	}
+
+	path, err := providers.DumpFailedPage(f.cacheDir, f.Name(), providerVersion, pageURL, statusCode, body)
+	if f.logger == nil {
+		return
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		f.logger.Warn("failed to dump page", "provider", f.Name(), "error", err)
+		return
 	}
+	f.logger.Debug("dumped failed page", "provider", f.Name(), "version", providerVersion, "url", pageURL, "path", path)
+}
 
-	// Add headers to mimic browser
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	req.Header.Set("Referer", f.BaseURL)
+// fetchHeaders are the headers FlixHQ requests are sent with. ajax
+// endpoints expect the X-Requested-With header.
+func (f *FlixHQ) fetchHeaders(ajax bool) map[string]string {
+	headers := map[string]string{
+		"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"Accept":     "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8",
+		"Referer":    f.baseURL,
+	}
+	if ajax {
+		headers["X-Requested-With"] = "XMLHttpRequest"
+	}
+	return headers
+}
 
-	resp, err := f.Client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch search results: %w", err)
+// fetchDocument loads a FlixHQ page, dumping the body of non-2xx responses
+func (f *FlixHQ) fetchDocument(ctx context.Context, pageURL string, ajax bool) (*goquery.Document, error) {
+	doc, err := providers.FetchDocument(ctx, f.Client, pageURL, f.fetchHeaders(ajax))
+	f.dumpStatusError(pageURL, err)
+	return doc, err
+}
+
+// fetchBody loads a FlixHQ endpoint that doesn't answer with a full HTML
+// page, dumping the body of non-2xx responses
+func (f *FlixHQ) fetchBody(ctx context.Context, pageURL string, ajax bool) ([]byte, error) {
+	body, err := providers.FetchBody(ctx, f.Client, pageURL, f.fetchHeaders(ajax))
+	f.dumpStatusError(pageURL, err)
+	return body, err
+}
+
+// dumpStatusError dumps the page behind err if it is a *StatusError
+func (f *FlixHQ) dumpStatusError(pageURL string, err error) {
+	var statusErr *providers.StatusError
+	if errors.As(err, &statusErr) {
+		f.dumpFailure(pageURL, statusErr.StatusCode, statusErr.Body)
 	}
-	defer func() { _ = resp.Body.Close() }()
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("search returned status code %d", resp.StatusCode)
+// searchOld searches for movies/shows by query (legacy internal method)
+func (f *FlixHQ) searchOld(ctx context.Context, query string) (*types.SearchResults, error) {
+	if cached, ok := f.searchCache.Load(query); ok && f.CacheEnabled() {
+		return cached.(*types.SearchResults), nil
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	// Replace non-word characters with hyphens
+	re := regexp.MustCompile(`[\W_]+`)
+	cleanQuery := re.ReplaceAllString(query, "-")
+	searchURL := fmt.Sprintf("%s/search/%s", f.baseURL, cleanQuery)
+
+	doc, err := f.fetchDocument(ctx, searchURL, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, fmt.Errorf("flixhq search: %w", err)
 	}
 
 	results := &types.SearchResults{
@@ -95,6 +232,9 @@ func (f *FlixHQ) searchOld(query string) (*types.SearchResults, error) {
 		// Extract image
 		image, _ := s.Find(".film-poster img").Attr("data-src")
 
+		// Release quality badge (HD, CAM, TS), absent on some items
+		quality := strings.TrimSpace(s.Find(".film-poster .pick").First().Text())
+
 		// Extract release date from info
 		releaseDate := ""
 		typeStr := ""
@@ -118,50 +258,89 @@ func (f *FlixHQ) searchOld(query string) (*types.SearchResults, error) {
 		results.Results = append(results.Results, types.SearchResult{
 			ID:          id,
 			Title:       title,
-			Image:       image,
-			URL:         f.BaseURL + href,
+			Image:       providers.AbsoluteURL(f.baseURL, image),
+			URL:         f.baseURL + href,
 			ReleaseDate: releaseDate,
 			Type:        typeStr,
+			Quality:     quality,
 		})
 	})
 
-	f.searchCache.Store(query, results)
+	if f.CacheEnabled() {
+		f.searchCache.Store(query, results)
+	}
 	return results, nil
 }
 
 // GetInfo fetches detailed info for a movie/show
 func (f *FlixHQ) GetInfo(id string) (interface{}, error) {
-	if cached, ok := f.infoCache.Load(id); ok {
-		return cached.(*types.MovieInfo), nil
-	}
+	return f.GetInfoContext(context.Background(), id)
+}
 
-	// Construct info URL
-	infoURL := f.BaseURL + "/" + id
-	if strings.HasPrefix(id, "/") {
-		infoURL = f.BaseURL + id
+// GetInfoContext is GetInfo with a context for its requests
+func (f *FlixHQ) GetInfoContext(ctx context.Context, id string) (interface{}, error) {
+	info, err := f.getInfo(ctx, id)
+	if err != nil {
+		return nil, err
 	}
+	return info, nil
+}
 
-	req, err := http.NewRequest("GET", infoURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// getInfo is GetInfoContext returning the concrete type. The fetch may be
+// shared with other callers, so it keeps running when ctx ends and only this
+// caller stops waiting for it. infoTimeout still bounds it.
+func (f *FlixHQ) getInfo(ctx context.Context, id string) (*types.MovieInfo, error) {
+	if cached, ok := f.infoCache.Load(id); ok && f.CacheEnabled() {
+		return cached.(*types.MovieInfo), nil
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Referer", f.BaseURL)
+	// Seasons, episodes and details are often requested together for the same
+	// show; share one fetch instead of scraping the page for each. "/tv/x" and
+	// "tv/x" name the same page.
+	ch := f.infoFlight.DoChan(strings.TrimPrefix(id, "/"), func() (interface{}, error) {
+		if cached, ok := f.infoCache.Load(id); ok && f.CacheEnabled() {
+			return cached, nil
+		}
+		timeout := f.infoTimeout
+		if timeout <= 0 {
+			timeout = defaultInfoTimeout
+		}
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		return f.fetchInfo(fetchCtx, id)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*types.MovieInfo), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
 
-	resp, err := f.Client.Do(req)
+// GetInfoRefresh fetches media info without reading the info cache.
+// The fresh result is still written back to the cache.
+func (f *FlixHQ) GetInfoRefresh(ctx context.Context, id string) (interface{}, error) {
+	info, err := f.fetchInfo(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch movie info: %w", err)
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
+	return info, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("info request returned status code %d", resp.StatusCode)
+// fetchInfo scrapes media info and stores it in the info cache
+func (f *FlixHQ) fetchInfo(ctx context.Context, id string) (*types.MovieInfo, error) {
+	// Construct info URL
+	infoURL := f.baseURL + "/" + id
+	if strings.HasPrefix(id, "/") {
+		infoURL = f.baseURL + id
 	}
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	doc, err := f.fetchDocument(ctx, infoURL, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, fmt.Errorf("flixhq info for %s: %w", id, err)
 	}
 
 	info := &types.MovieInfo{
@@ -171,17 +350,23 @@ func (f *FlixHQ) GetInfo(id string) (interface{}, error) {
 		Genres:   []string{},
 	}
 
-	// Extract title
-	info.Title = strings.TrimSpace(doc.Find(".heading-name a").First().Text())
+	// Extract title, falling back to the page metadata when the heading is empty
+	info.Title = providers.PageTitle(doc, doc.Find(".heading-name a").First().Text(), id, "FlixHQ")
 
 	// Extract image
 	if img, exists := doc.Find(".m_i-d-poster img").Attr("src"); exists {
-		info.Image = img
+		info.Image = providers.AbsoluteURL(f.baseURL, img)
 	}
 
 	// Extract description
 	info.Description = strings.TrimSpace(doc.Find(".description").Text())
 
+	// Extract the IMDB rating (format: "IMDB: 7.5")
+	if ratingText := strings.TrimSpace(doc.Find(".btn-imdb, span.imdb").First().Text()); ratingText != "" {
+		info.Rating = strings.TrimSpace(strings.TrimPrefix(ratingText, "IMDB:"))
+		info.RatingFloat, _ = providers.ParseRating(info.Rating)
+	}
+
 	// Extract type and release date from row-lines
 	doc.Find(".row-line").Each(func(i int, s *goquery.Selection) {
 		label := strings.TrimSpace(s.Find("strong").Text())
@@ -205,7 +390,7 @@ func (f *FlixHQ) GetInfo(id string) (interface{}, error) {
 		info.Type = "TV Series"
 
 		// For TV series, extract episodes
-		episodes := f.extractEpisodes(doc, id)
+		episodes := f.extractEpisodes(doc, id, info.Title)
 		info.Episodes = episodes
 	} else {
 		info.Type = "Movie"
@@ -222,18 +407,21 @@ func (f *FlixHQ) GetInfo(id string) (interface{}, error) {
 		}
 	}
 
-	f.infoCache.Store(id, info)
+	if f.CacheEnabled() {
+		f.infoCache.Store(id, info)
+	}
 	return info, nil
 }
 
-// extractEpisodes extracts episode information from the page
-func (f *FlixHQ) extractEpisodes(doc *goquery.Document, movieID string) []types.Episode {
+// extractEpisodes extracts episode information from the page. Titles are
+// cleaned of the show's name and "Episode N" labels.
+func (f *FlixHQ) extractEpisodes(doc *goquery.Document, movieID, show string) []types.Episode {
 	episodes := []types.Episode{}
 
 	// Find all episode items
 	doc.Find(".ss-list a.ssl-item.ep-item").Each(func(i int, s *goquery.Selection) {
 		epNum := strings.TrimSpace(s.Find(".ssli-order").Text())
-		epTitle := strings.TrimSpace(s.Find(".ssli-detail .ep-name").Text())
+		epTitle := providers.EpisodeTitle(s.Find(".ssli-detail .ep-name").Text(), show)
 		epID, _ := s.Attr("data-id")
 
 		// Parse episode number
@@ -254,61 +442,29 @@ func (f *FlixHQ) extractEpisodes(doc *goquery.Document, movieID string) []types.
 
 // GetServers fetches available servers for an episode
 func (f *FlixHQ) GetServers(episodeID string) ([]types.EpisodeServer, error) {
+	return f.GetServersContext(context.Background(), episodeID)
+}
+
+// GetServersContext is GetServers, aborting once ctx is done
+func (f *FlixHQ) GetServersContext(ctx context.Context, episodeID string) ([]types.EpisodeServer, error) {
 	// For movies, episodeID is actually the movie data-id
 	// Try movie endpoint first: /ajax/movie/episodes/{id}
-	movieServerURL := fmt.Sprintf("%s/ajax/movie/episodes/%s", f.BaseURL, episodeID)
-
-	req, err := http.NewRequest("GET", movieServerURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Referer", f.BaseURL)
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
-
-	resp, err := f.Client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch servers: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
+	movieServerURL := fmt.Sprintf("%s/ajax/movie/episodes/%s", f.baseURL, episodeID)
 
 	// If movie endpoint works, use it
-	if resp.StatusCode == http.StatusOK {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response: %w", err)
-		}
-
-		servers, err := f.parseServersFromMovieHTML(string(body))
-		if err == nil && len(servers) > 0 {
+	if doc, err := f.fetchDocument(ctx, movieServerURL, true); err == nil {
+		if servers := f.parseServersFromMovieHTML(doc); len(servers) > 0 {
 			return servers, nil
 		}
 	}
 
 	// Fall back to TV series endpoint: /ajax/v2/episode/servers/{id}
-	tvServerURL := fmt.Sprintf("%s/ajax/v2/episode/servers/%s", f.BaseURL, episodeID)
-
-	req2, err := http.NewRequest("GET", tvServerURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req2.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req2.Header.Set("Referer", f.BaseURL)
-	req2.Header.Set("X-Requested-With", "XMLHttpRequest")
+	tvServerURL := fmt.Sprintf("%s/ajax/v2/episode/servers/%s", f.baseURL, episodeID)
 
-	resp2, err := f.Client.Do(req2)
+	body, err := f.fetchBody(ctx, tvServerURL, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch servers: %w", err)
 	}
-	defer func() { _ = resp2.Body.Close() }()
-
-	// Read response body
-	body, err := io.ReadAll(resp2.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
 
 	// Try to parse as JSON first (new API format)
 	var jsonResponse map[string]interface{}
@@ -324,12 +480,7 @@ func (f *FlixHQ) GetServers(episodeID string) ([]types.EpisodeServer, error) {
 }
 
 // parseServersFromMovieHTML parses server list from movie episodes HTML
-func (f *FlixHQ) parseServersFromMovieHTML(htmlContent string) ([]types.EpisodeServer, error) {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlContent))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
-	}
-
+func (f *FlixHQ) parseServersFromMovieHTML(doc *goquery.Document) []types.EpisodeServer {
 	servers := []types.EpisodeServer{}
 
 	// Parse movie server links (format: <a href="/watch-movie/..." title="Vidcloud">)
@@ -345,13 +496,13 @@ func (f *FlixHQ) parseServersFromMovieHTML(htmlContent string) ([]types.EpisodeS
 				serverID := matches[1]
 				servers = append(servers, types.EpisodeServer{
 					Name: serverName,
-					URL:  fmt.Sprintf("%s/ajax/episode/sources/%s", f.BaseURL, serverID),
+					URL:  fmt.Sprintf("%s/ajax/episode/sources/%s", f.baseURL, serverID),
 				})
 			}
 		}
 	})
 
-	return servers, nil
+	return servers
 }
 
 // parseServersFromHTML parses server list from HTML content (for TV series)
@@ -371,7 +522,7 @@ func (f *FlixHQ) parseServersFromHTML(htmlContent string) ([]types.EpisodeServer
 		if exists && serverName != "" {
 			servers = append(servers, types.EpisodeServer{
 				Name: serverName,
-				URL:  fmt.Sprintf("%s/ajax/episode/sources/%s", f.BaseURL, serverID),
+				URL:  fmt.Sprintf("%s/ajax/episode/sources/%s", f.baseURL, serverID),
 			})
 		}
 	})
@@ -381,8 +532,29 @@ func (f *FlixHQ) parseServersFromHTML(htmlContent string) ([]types.EpisodeServer
 
 // GetSources fetches video sources for an episode
 func (f *FlixHQ) GetSources(episodeID string) (interface{}, error) {
+	return f.GetSourcesContext(context.Background(), episodeID)
+}
+
+// GetSourcesContext is GetSources, aborting once ctx is done
+func (f *FlixHQ) GetSourcesContext(ctx context.Context, episodeID string) (interface{}, error) {
+	sources, err := f.getSources(ctx, episodeID)
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// getSources is GetSourcesContext returning the concrete type
+func (f *FlixHQ) getSources(ctx context.Context, episodeID string) (*types.VideoSources, error) {
+	timeout := f.sourcesTimeout
+	if timeout <= 0 {
+		timeout = defaultSourcesTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// Get servers first
-	servers, err := f.GetServers(episodeID)
+	servers, err := f.GetServersContext(ctx, episodeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch servers: %w", err)
 	}
@@ -394,58 +566,38 @@ func (f *FlixHQ) GetSources(episodeID string) (interface{}, error) {
 		}, nil
 	}
 
-	// Try each server until we get valid sources
-	var lastErr error
-	for _, server := range servers {
-		sources, err := f.extractSourcesFromServer(server)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-
-		if len(sources.Sources) > 0 {
-			return sources, nil
-		}
+	// Extract from the top servers concurrently, preferring earlier servers
+	sources, err := extractors.ExtractFirst(ctx, servers, maxConcurrentServers, f.extractSourcesFromServer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract sources from all servers: %w", err)
 	}
 
-	// If all servers failed, return the last error
-	if lastErr != nil {
-		return nil, fmt.Errorf("failed to extract sources from all servers: %w", lastErr)
+	if len(sources.Sources) > 0 {
+		if f.CacheEnabled() {
+			f.sourcesCache.Store(episodeID, sources)
+		}
 	}
-
-	return &types.VideoSources{
-		Sources:   []types.Source{},
-		Subtitles: []types.Subtitle{},
-	}, nil
+	return sources, nil
 }
 
-// extractSourcesFromServer extracts video sources from a specific server
-func (f *FlixHQ) extractSourcesFromServer(server types.EpisodeServer) (*types.VideoSources, error) {
-	// Make request to get the embed URL
-	req, err := http.NewRequest("GET", server.URL, nil)
+// ProbeServers tries every server of an episode and reports which ones
+// yield sources. Servers that work are cached by the extractor, so picking
+// one afterwards doesn't extract again.
+func (f *FlixHQ) ProbeServers(ctx context.Context, episodeID string) ([]extractors.ServerProbe, error) {
+	servers, err := f.GetServersContext(ctx, episodeID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to fetch servers: %w", err)
 	}
+	return extractors.ProbeServers(ctx, servers, maxConcurrentServers, serverProbeTimeout, f.extractSourcesFromServer), nil
+}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Referer", f.BaseURL)
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
-
-	resp, err := f.Client.Do(req)
+// extractSourcesFromServer extracts video sources from a specific server
+func (f *FlixHQ) extractSourcesFromServer(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
+	// Ask the server for its embed URL
+	body, err := f.fetchBody(ctx, server.URL, true)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch sources: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned status %d for URL: %s", resp.StatusCode, server.URL)
-	}
-
-	// Read response body
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
 
 	// Try to parse as JSON to get the embed URL
 	var jsonResponse map[string]interface{}
@@ -476,10 +628,21 @@ func (f *FlixHQ) extractSourcesFromServer(server types.EpisodeServer) (*types.Vi
 
 	// If we have an embed URL, extract sources from it
 	if embedURL != "" {
-		extractor := extractors.GetExtractor(server.Name)
-		extracted, err := extractor.Extract(embedURL)
+		extracted, err := extractors.Extract(ctx, server.Name, embedURL)
 		if err != nil {
-			return nil, fmt.Errorf("failed to extract from embed URL %s: %w", embedURL, err)
+			return nil, extractors.NewExtractorError(server.Name, fmt.Errorf("failed to extract from embed URL %s: %w", embedURL, err))
+		}
+
+		// Widevine-protected servers can't be played; report them distinctly so
+		// the next server is tried and the UI can explain why
+		if err := extractors.CheckDRM(ctx, f.Client, extracted); err != nil {
+			return nil, fmt.Errorf("server %s: %w", server.Name, err)
+		}
+
+		// A source that 404s would stop the server loop but never play, so
+		// treat it as a failed server and move on to the next one
+		if err := extractors.CheckAlive(ctx, f.Client, extracted); err != nil {
+			return nil, fmt.Errorf("server %s: %w", server.Name, err)
 		}
 
 		return extracted, nil
@@ -496,7 +659,7 @@ func (f *FlixHQ) Type() providers.MediaType {
 
 // Search (new interface) searches for movies/shows by query
 func (f *FlixHQ) Search(ctx context.Context, query string) ([]providers.Media, error) {
-	oldResults, err := f.searchOld(query)
+	oldResults, err := f.searchOld(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -510,23 +673,34 @@ func (f *FlixHQ) Search(ctx context.Context, query string) ([]providers.Media, e
 			}
 		}
 
-		mediaType := providers.MediaTypeMovie
-		if strings.Contains(strings.ToLower(item.Type), "tv") || strings.Contains(strings.ToLower(item.Type), "series") {
-			mediaType = providers.MediaTypeTV
+		mediaType, err := providers.ParseMediaType(item.Type)
+		if err != nil {
+			mediaType = providers.MediaTypeMovie
 		}
 
 		mediaList = append(mediaList, providers.Media{
-			ID:        item.ID,
-			Title:     item.Title,
-			Type:      mediaType,
-			PosterURL: item.Image,
-			Year:      year,
-			Status:    item.ReleaseDate,
+			ID:            item.ID,
+			Title:         item.Title,
+			Type:          mediaType,
+			PosterURL:     f.posterURL(item.Image),
+			Year:          year,
+			Status:        item.ReleaseDate,
+			SourceQuality: item.Quality,
 		})
 	}
 	return mediaList, nil
 }
 
+// SearchFiltered searches and keeps only results matching the year and type in opts.
+// Search remains the unfiltered entry point.
+func (f *FlixHQ) SearchFiltered(ctx context.Context, query string, opts providers.SearchOptions) ([]providers.Media, error) {
+	results, err := f.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return providers.FilterMedia(results, opts), nil
+}
+
 // GetTrending returns trending media
 func (f *FlixHQ) GetTrending(ctx context.Context) ([]providers.Media, error) {
 	return nil, fmt.Errorf("not implemented")
@@ -539,19 +713,14 @@ func (f *FlixHQ) GetRecent(ctx context.Context) ([]providers.Media, error) {
 
 // GetMediaDetails fetches detailed info for a movie/show
 func (f *FlixHQ) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
-	info, err := f.GetInfo(id)
+	movieInfo, err := f.getInfo(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	movieInfo, ok := info.(*types.MovieInfo)
-	if !ok {
-		return nil, fmt.Errorf("unexpected info type")
-	}
-
-	mediaType := providers.MediaTypeMovie
-	if movieInfo.Type == "TV Series" {
-		mediaType = providers.MediaTypeTV
+	mediaType, err := providers.ParseMediaType(movieInfo.Type)
+	if err != nil {
+		mediaType = providers.MediaTypeMovie
 	}
 
 	details := &providers.MediaDetails{
@@ -559,10 +728,12 @@ func (f *FlixHQ) GetMediaDetails(ctx context.Context, id string) (*providers.Med
 			ID:        movieInfo.ID,
 			Title:     movieInfo.Title,
 			Type:      mediaType,
-			PosterURL: movieInfo.Image,
+			PosterURL: f.posterURL(movieInfo.Image),
 			Synopsis:  movieInfo.Description,
+			Year:      utils.ExtractYear(movieInfo.ReleaseDate),
 			Genres:    movieInfo.Genres,
 			Status:    movieInfo.ReleaseDate,
+			Rating:    movieInfo.RatingFloat,
 		},
 	}
 
@@ -581,7 +752,7 @@ func (f *FlixHQ) GetMediaDetails(ctx context.Context, id string) (*providers.Med
 			details.Seasons = append(details.Seasons, providers.Season{
 				ID:     fmt.Sprintf("%s|%d", id, sNum),
 				Number: sNum,
-				Title:  fmt.Sprintf("Season %d", sNum),
+				Title:  providers.SeasonTitle(sNum),
 			})
 		}
 	} else {
@@ -592,30 +763,32 @@ func (f *FlixHQ) GetMediaDetails(ctx context.Context, id string) (*providers.Med
 			Title:  "Movie",
 		}}
 	}
+	providers.EnrichDetails(ctx, f.metadata, details)
 
 	return details, nil
 }
 
 // GetSeasons returns seasons for a media
 func (f *FlixHQ) GetSeasons(ctx context.Context, mediaID string) ([]providers.Season, error) {
-	info, err := f.GetInfo(mediaID)
+	movieInfo, err := f.getInfo(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
 
-	movieInfo, ok := info.(*types.MovieInfo)
-	if !ok {
-		return nil, fmt.Errorf("unexpected info type")
-	}
+	// FlixHQ lists no per-season artwork, so every season shows the poster
+	poster := f.posterURL(movieInfo.Image)
 
 	if len(movieInfo.Episodes) == 0 {
 		return []providers.Season{{
-			ID:     mediaID,
-			Number: 1,
-			Title:  "Season 1",
+			ID:        mediaID,
+			Number:    1,
+			Title:     "Season 1",
+			PosterURL: poster,
 		}}, nil
 	}
 
+	// FlixHQ episode lists carry no season numbers, so 0 means the season is
+	// unknown rather than specials
 	seasonsMap := make(map[int]bool)
 	for _, ep := range movieInfo.Episodes {
 		sNum := ep.Season
@@ -628,9 +801,10 @@ func (f *FlixHQ) GetSeasons(ctx context.Context, mediaID string) ([]providers.Se
 	var seasons []providers.Season
 	for sNum := range seasonsMap {
 		seasons = append(seasons, providers.Season{
-			ID:     fmt.Sprintf("%s|%d", mediaID, sNum),
-			Number: sNum,
-			Title:  fmt.Sprintf("Season %d", sNum),
+			ID:        fmt.Sprintf("%s|%d", mediaID, sNum),
+			Number:    sNum,
+			Title:     providers.SeasonTitle(sNum),
+			PosterURL: poster,
 		})
 	}
 
@@ -654,18 +828,14 @@ func (f *FlixHQ) GetEpisodes(ctx context.Context, seasonID string) ([]providers.
 		mediaID = seasonID
 	}
 
-	info, err := f.GetInfo(mediaID)
+	movieInfo, err := f.getInfo(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
 
-	movieInfo, ok := info.(*types.MovieInfo)
-	if !ok {
-		return nil, fmt.Errorf("unexpected info type")
-	}
-
 	var episodes []providers.Episode
-	if len(movieInfo.Episodes) == 0 && movieInfo.Type == "Movie" {
+	offset := 0
+	if mediaType, _ := providers.ParseMediaType(movieInfo.Type); len(movieInfo.Episodes) == 0 && mediaType == providers.MediaTypeMovie {
 		// Single movie episode
 		episodes = append(episodes, providers.Episode{
 			ID:     movieInfo.ID,
@@ -680,6 +850,9 @@ func (f *FlixHQ) GetEpisodes(ctx context.Context, seasonID string) ([]providers.
 				epSeason = 1
 			}
 
+			if epSeason < seasonNum {
+				offset++
+			}
 			if epSeason == seasonNum {
 				episodes = append(episodes, providers.Episode{
 					ID:     ep.ID,
@@ -691,21 +864,18 @@ func (f *FlixHQ) GetEpisodes(ctx context.Context, seasonID string) ([]providers.
 		}
 	}
 
+	providers.ApplyEpisodeNumbering(episodes, offset, f.numbering)
+
 	return episodes, nil
 }
 
 // GetStreamURL fetches video stream URL for an episode
 func (f *FlixHQ) GetStreamURL(ctx context.Context, episodeID string, quality providers.Quality) (*providers.StreamURL, error) {
-	res, err := f.GetSources(episodeID)
+	videoSources, err := f.getSources(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
 
-	videoSources, ok := res.(*types.VideoSources)
-	if !ok {
-		return nil, fmt.Errorf("unexpected source type")
-	}
-
 	if len(videoSources.Sources) == 0 {
 		return nil, fmt.Errorf("no sources found")
 	}
@@ -714,21 +884,27 @@ func (f *FlixHQ) GetStreamURL(ctx context.Context, episodeID string, quality pro
 	var selectedSource types.Source
 	found := false
 
-	targetQuality := string(quality)
+	// In auto mode hand the HLS master playlist to the player so it can
+	// switch variants itself
 	if quality == providers.QualityAuto {
-		targetQuality = "auto"
-	}
-
-	for _, src := range videoSources.Sources {
-		if strings.EqualFold(src.Quality, targetQuality) {
-			selectedSource = src
-			found = true
-			break
+		for _, src := range videoSources.Sources {
+			if providers.IsMasterPlaylist(src.URL, src.Quality) {
+				selectedSource = src
+				selectedSource.IsM3U8 = true
+				found = true
+				break
+			}
 		}
 	}
 
+	// Otherwise walk the quality ladder, e.g. from 1080p down to 720p for an
+	// episode, falling back to the first source
 	if !found {
-		selectedSource = videoSources.Sources[0]
+		labels := make([]string, len(videoSources.Sources))
+		for i, src := range videoSources.Sources {
+			labels[i] = src.Quality
+		}
+		selectedSource = videoSources.Sources[providers.SelectSourceQuality(labels, quality, f.episodeMediaType(episodeID))]
 	}
 
 	streamType := providers.StreamTypeHLS
@@ -749,7 +925,7 @@ func (f *FlixHQ) GetStreamURL(ctx context.Context, episodeID string, quality pro
 	for _, sub := range videoSources.Subtitles {
 		streamURL.Subtitles = append(streamURL.Subtitles, providers.Subtitle{
 			Language: sub.Lang,
-			URL:      sub.URL,
+			URL:      providers.AbsoluteURL(f.baseURL, sub.URL),
 		})
 	}
 
@@ -758,16 +934,11 @@ func (f *FlixHQ) GetStreamURL(ctx context.Context, episodeID string, quality pro
 
 // GetAvailableQualities returns available video qualities
 func (f *FlixHQ) GetAvailableQualities(ctx context.Context, episodeID string) ([]providers.Quality, error) {
-	res, err := f.GetSources(episodeID)
+	videoSources, err := f.getSources(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
 
-	videoSources, ok := res.(*types.VideoSources)
-	if !ok {
-		return nil, fmt.Errorf("unexpected source type")
-	}
-
 	var qualities []providers.Quality
 	for _, src := range videoSources.Sources {
 		qualities = append(qualities, providers.Quality(src.Quality))
@@ -776,23 +947,61 @@ func (f *FlixHQ) GetAvailableQualities(ctx context.Context, episodeID string) ([
 	return qualities, nil
 }
 
+// HasSubtitles reports whether an episode has subtitles and lists their
+// languages. Previously extracted sources are reused when available.
+func (f *FlixHQ) HasSubtitles(ctx context.Context, episodeID string) (bool, []string, error) {
+	var v *types.VideoSources
+	if cached, ok := f.sourcesCache.Load(episodeID); ok && f.CacheEnabled() {
+		v = cached.(*types.VideoSources)
+	} else {
+		sources, err := f.getSources(ctx, episodeID)
+		if err != nil {
+			return false, nil, err
+		}
+		v = sources
+	}
+
+	languages := make([]string, 0, len(v.Subtitles))
+	for _, sub := range v.Subtitles {
+		languages = append(languages, sub.Lang)
+	}
+	return len(languages) > 0, languages, nil
+}
+
 // HealthCheck checks if the provider is accessible
 func (f *FlixHQ) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// ClearCache drops all cached search and info results
+func (f *FlixHQ) ClearCache() {
+	f.searchCache.Clear()
+	f.infoCache.Clear()
+	f.sourcesCache.Clear()
+}
+
+// ClearCacheFor drops the cached info for a single media ID
+func (f *FlixHQ) ClearCacheFor(mediaID string) {
+	f.infoCache.Delete(mediaID)
+}
+
 // GetMovieEpisodeID retrieves the episode ID for a movie
 func (f *FlixHQ) GetMovieEpisodeID(ctx context.Context, mediaID string) (string, error) {
-	info, err := f.GetInfo(mediaID)
+	movieInfo, err := f.getInfo(ctx, mediaID)
 	if err != nil {
 		return "", err
 	}
-	movieInfo, ok := info.(*types.MovieInfo)
-	if !ok {
-		return "", fmt.Errorf("invalid info type")
-	}
 	if len(movieInfo.Episodes) > 0 {
+		f.movieEpisodes.Store(movieInfo.Episodes[0].ID, struct{}{})
 		return movieInfo.Episodes[0].ID, nil
 	}
-	return "", fmt.Errorf("no episodes found for movie %s", mediaID)
+	return "", fmt.Errorf("movie %s: %w", mediaID, providers.ErrNoEpisodes)
+}
+
+// episodeMediaType reports whether episodeID belongs to a movie or a show
+func (f *FlixHQ) episodeMediaType(episodeID string) providers.MediaType {
+	if _, ok := f.movieEpisodes.Load(episodeID); ok {
+		return providers.MediaTypeMovie
+	}
+	return providers.MediaTypeTV
 }
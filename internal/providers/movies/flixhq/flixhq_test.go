@@ -0,0 +1,377 @@
+package flixhq
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/providers/utils"
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+// serveFixture answers with the contents of a testdata file
+func serveFixture(t *testing.T, name string) http.HandlerFunc {
+	t.Helper()
+	body, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}
+}
+
+// newTestProvider points a FlixHQ at a server answering with the given fixtures
+func newTestProvider(t *testing.T, routes map[string]string) (*FlixHQ, *httptest.Server) {
+	t.Helper()
+	mux := http.NewServeMux()
+	for path, fixture := range routes {
+		mux.HandleFunc(path, serveFixture(t, fixture))
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	f := New(config.ProviderSettings{})
+	f.baseURL = srv.URL
+	f.Client = srv.Client()
+	return f, srv
+}
+
+func TestSearch(t *testing.T) {
+	f, srv := newTestProvider(t, map[string]string{"/search/inception-dark": "search.html"})
+
+	results, err := f.Search(context.Background(), "inception dark")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+
+	want := []providers.Media{
+		{
+			ID:            "movie/watch-inception-19764",
+			Title:         "Inception",
+			Type:          providers.MediaTypeMovie,
+			PosterURL:     srv.URL + "/poster/inception.jpg",
+			Year:          2010,
+			Status:        "2010",
+			SourceQuality: "HD",
+		},
+		{
+			ID:        "tv/watch-dark-39490",
+			Title:     "Dark",
+			Type:      providers.MediaTypeTV,
+			PosterURL: "https://img.flixhq.to/dark.jpg",
+		},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("Search() = %+v, want %+v", results, want)
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	f, srv := newTestProvider(t, map[string]string{
+		"/movie/watch-inception-19764": "info_movie.html",
+		"/tv/watch-dark-39490":         "info_tv.html",
+	})
+
+	tests := []struct {
+		id   string
+		want *types.MovieInfo
+	}{
+		{
+			id: "movie/watch-inception-19764",
+			want: &types.MovieInfo{
+				ID:          "movie/watch-inception-19764",
+				Title:       "Inception",
+				URL:         srv.URL + "/movie/watch-inception-19764",
+				Image:       srv.URL + "/poster/inception.jpg",
+				Description: "A thief who steals corporate secrets through dream-sharing technology.",
+				Type:        "Movie",
+				ReleaseDate: "2010-07-16",
+				Rating:      "8.8",
+				RatingFloat: 8.8,
+				Genres:      []string{"Action", "Science Fiction"},
+				Episodes:    []types.Episode{{ID: "19764", Number: 1, Title: "Inception"}},
+			},
+		},
+		{
+			id: "tv/watch-dark-39490",
+			want: &types.MovieInfo{
+				ID:          "tv/watch-dark-39490",
+				Title:       "Dark",
+				URL:         srv.URL + "/tv/watch-dark-39490",
+				Image:       "https://img.flixhq.to/dark.jpg",
+				Description: "A family saga with a supernatural twist.",
+				Type:        "TV Series",
+				ReleaseDate: "2017-12-01",
+				Rating:      "N/A",
+				Genres:      []string{"Drama"},
+				Episodes: []types.Episode{
+					{ID: "1001", Number: 1, Title: "Secrets"},
+					{ID: "1002", Number: 2, Title: "Lies"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			info, err := f.GetInfo(tt.id)
+			if err != nil {
+				t.Fatalf("GetInfo() error = %v", err)
+			}
+			if !reflect.DeepEqual(info, tt.want) {
+				t.Errorf("GetInfo() = %+v, want %+v", info, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitleMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     string
+		mismatch bool
+	}{
+		{"info page matches the card", "info_movie.html", false},
+		{"card links to a different title", "info_mismatch.html", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, _ := newTestProvider(t, map[string]string{
+				"/search/inception":            "search.html",
+				"/movie/watch-inception-19764": tt.info,
+			})
+			ctx := context.Background()
+
+			results, err := f.Search(ctx, "inception")
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			details, err := f.GetMediaDetails(ctx, results[0].ID)
+			if err != nil {
+				t.Fatalf("GetMediaDetails() error = %v", err)
+			}
+
+			err = utils.CheckTitleMatch(results[0], details)
+			if got := errors.Is(err, providers.ErrTitleMismatch); got != tt.mismatch {
+				t.Errorf("CheckTitleMatch() = %v, want mismatch %v", err, tt.mismatch)
+			}
+		})
+	}
+}
+
+func TestSeasonsAndEpisodes(t *testing.T) {
+	f, _ := newTestProvider(t, map[string]string{
+		"/movie/watch-inception-19764": "info_movie.html",
+		"/tv/watch-dark-39490":         "info_tv.html",
+	})
+	ctx := context.Background()
+
+	seasons, err := f.GetSeasons(ctx, "tv/watch-dark-39490")
+	if err != nil {
+		t.Fatalf("GetSeasons() error = %v", err)
+	}
+	wantSeasons := []providers.Season{{ID: "tv/watch-dark-39490|1", Number: 1, Title: providers.SeasonTitle(1), PosterURL: "https://img.flixhq.to/dark.jpg"}}
+	if !reflect.DeepEqual(seasons, wantSeasons) {
+		t.Fatalf("GetSeasons() = %+v, want %+v", seasons, wantSeasons)
+	}
+
+	episodes, err := f.GetEpisodes(ctx, seasons[0].ID)
+	if err != nil {
+		t.Fatalf("GetEpisodes() error = %v", err)
+	}
+	wantEpisodes := []providers.Episode{
+		{ID: "1001", Number: 1, Title: "Secrets", Season: 1},
+		{ID: "1002", Number: 2, Title: "Lies", Season: 1},
+	}
+	providers.ApplyEpisodeNumbering(wantEpisodes, 0, f.numbering)
+	if !reflect.DeepEqual(episodes, wantEpisodes) {
+		t.Errorf("GetEpisodes() = %+v, want %+v", episodes, wantEpisodes)
+	}
+
+	// A movie is a single season holding its watch ID
+	movieEpisodes, err := f.GetEpisodes(ctx, "movie/watch-inception-19764")
+	if err != nil {
+		t.Fatalf("GetEpisodes(movie) error = %v", err)
+	}
+	if len(movieEpisodes) != 1 || movieEpisodes[0].ID != "19764" {
+		t.Errorf("GetEpisodes(movie) = %+v, want the watch ID 19764", movieEpisodes)
+	}
+}
+
+func TestGetServers(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes map[string]string
+		id     string
+		want   []types.EpisodeServer
+	}{
+		{
+			name:   "movie endpoint",
+			routes: map[string]string{"/ajax/movie/episodes/19764": "servers_movie.html"},
+			id:     "19764",
+			want: []types.EpisodeServer{
+				{Name: "Vidcloud", URL: "/ajax/episode/sources/1613445"},
+				{Name: "UpCloud", URL: "/ajax/episode/sources/1613446"},
+			},
+		},
+		{
+			name:   "tv endpoint when the movie endpoint is missing",
+			routes: map[string]string{"/ajax/v2/episode/servers/1001": "servers_tv.json"},
+			id:     "1001",
+			want: []types.EpisodeServer{
+				{Name: "UpCloud", URL: "/ajax/episode/sources/5001"},
+				{Name: "MegaCloud", URL: "/ajax/episode/sources/5002"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, srv := newTestProvider(t, tt.routes)
+			for i := range tt.want {
+				tt.want[i].URL = srv.URL + tt.want[i].URL
+			}
+
+			servers, err := f.GetServers(tt.id)
+			if err != nil {
+				t.Fatalf("GetServers() error = %v", err)
+			}
+			if !reflect.DeepEqual(servers, tt.want) {
+				t.Errorf("GetServers() = %+v, want %+v", servers, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetInfoConcurrentCallersShareFetch(t *testing.T) {
+	body, err := os.ReadFile("testdata/info_tv.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tv/watch-dark-39490", func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		// Keep the fetch open long enough for every caller to join it
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write(body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f := New(config.ProviderSettings{})
+	f.baseURL = srv.URL
+	f.Client = srv.Client()
+
+	// GetSeasons, GetEpisodes and GetMediaDetails racing for the same show
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			if _, err := f.GetInfo(id); err != nil {
+				t.Errorf("GetInfo(%q) error = %v", id, err)
+			}
+		}([]string{"tv/watch-dark-39490", "/tv/watch-dark-39490"}[i%2])
+	}
+	wg.Wait()
+
+	if got := hits.Load(); got != 1 {
+		t.Errorf("upstream info requests = %d, want 1", got)
+	}
+}
+
+func TestGetInfoContextCancelled(t *testing.T) {
+	body, err := os.ReadFile("testdata/info_movie.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	f := New(config.ProviderSettings{})
+	f.baseURL = srv.URL
+	f.Client = srv.Client()
+
+	// A second caller joins the same fetch and still gets its result after
+	// the first one gives up
+	shared := make(chan error, 1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, err := f.GetInfo("movie/watch-inception-19764")
+		shared <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = f.GetInfoContext(ctx, "movie/watch-inception-19764")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetInfoContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetInfoContext() returned after %v, want it aborted with ctx", elapsed)
+	}
+
+	close(release)
+	if err := <-shared; err != nil {
+		t.Errorf("shared GetInfo() error = %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("upstream info requests = %d, want 1", got)
+	}
+}
+
+func TestGetInfoHungSite(t *testing.T) {
+	body, err := os.ReadFile("testdata/info_movie.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hung atomic.Bool
+	hung.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hung.Load() {
+			// Never answer; the request only ends when the client gives up
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	f := New(config.ProviderSettings{})
+	f.baseURL = srv.URL
+	f.Client = srv.Client()
+	f.infoTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	if _, err := f.GetInfo("movie/watch-inception-19764"); err == nil {
+		t.Fatal("GetInfo() succeeded against a site that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetInfo() returned after %v, want it bounded by infoTimeout", elapsed)
+	}
+
+	// The timed out fetch doesn't keep later callers waiting on it
+	hung.Store(false)
+	if _, err := f.GetInfo("movie/watch-inception-19764"); err != nil {
+		t.Errorf("GetInfo() after the site recovered error = %v", err)
+	}
+}
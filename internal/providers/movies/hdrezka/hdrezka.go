@@ -14,40 +14,60 @@ import (
 	"sync"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
 	"github.com/justchokingaround/greg/pkg/types"
 )
 
 type HDRezka struct {
 	Client      *http.Client
-	BaseURL     string
+	baseURL     string
 	searchCache sync.Map
 	infoCache   sync.Map
+
+	// CacheSwitch turns the caches off when cache_enabled is false
+	providers.CacheSwitch
+}
+
+func init() {
+	providers.RegisterFactory("hdrezka", "movies", func(settings config.ProviderSettings) providers.Provider { return New(settings) })
 }
 
-func New() *HDRezka {
+// New creates the provider, applying the base URL, request timeout and
+// headers from settings. Zero settings keep the built-in defaults.
+func New(settings config.ProviderSettings) *HDRezka {
 	// Create client with transport that handles compression
 	transport := &http.Transport{
 		DisableCompression: false,
 	}
-	return &HDRezka{
-		Client:  &http.Client{Transport: transport},
-		BaseURL: "https://hdrezka.website",
+	p := &HDRezka{baseURL: "https://hdrezka.website"}
+	if settings.BaseURL != "" {
+		p.baseURL = strings.TrimSuffix(settings.BaseURL, "/")
 	}
+	p.Client = &http.Client{
+		Timeout:   settings.Timeout,
+		Transport: providers.NewHeaderTransport(transport, settings.Headers, p.baseURL),
+	}
+	return p
 }
 
 func (p *HDRezka) Name() string {
 	return "hdrezka"
 }
 
-func (p *HDRezka) searchOld(query string) (*types.SearchResults, error) {
-	if cached, ok := p.searchCache.Load(query); ok {
+// BaseURL returns the domain the provider is scraping
+func (p *HDRezka) BaseURL() string {
+	return p.baseURL
+}
+
+func (p *HDRezka) searchOld(ctx context.Context, query string) (*types.SearchResults, error) {
+	if cached, ok := p.searchCache.Load(query); ok && p.CacheEnabled() {
 		return cached.(*types.SearchResults), nil
 	}
 
 	encodedQuery := url.QueryEscape(query)
-	url := fmt.Sprintf("%s/search/?do=search&subaction=search&q=%s", p.BaseURL, encodedQuery)
-	req, err := http.NewRequest("GET", url, nil)
+	url := fmt.Sprintf("%s/search/?do=search&subaction=search&q=%s", p.baseURL, encodedQuery)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -81,7 +101,7 @@ func (p *HDRezka) searchOld(query string) (*types.SearchResults, error) {
 		// or https://hdrezka.website/series/12345-series.html
 
 		// Remove BaseURL
-		path := strings.TrimPrefix(href, p.BaseURL+"/")
+		path := strings.TrimPrefix(href, p.baseURL+"/")
 		path = strings.TrimSuffix(path, ".html")
 
 		parts := strings.Split(path, "/")
@@ -109,12 +129,19 @@ func (p *HDRezka) searchOld(query string) (*types.SearchResults, error) {
 	})
 
 	res := &types.SearchResults{Results: results}
-	p.searchCache.Store(query, res)
+	if p.CacheEnabled() {
+		p.searchCache.Store(query, res)
+	}
 	return res, nil
 }
 
 func (p *HDRezka) GetInfo(id string) (interface{}, error) {
-	if cached, ok := p.infoCache.Load(id); ok {
+	return p.GetInfoContext(context.Background(), id)
+}
+
+// GetInfoContext is GetInfo with a context for its requests
+func (p *HDRezka) GetInfoContext(ctx context.Context, id string) (interface{}, error) {
+	if cached, ok := p.infoCache.Load(id); ok && p.CacheEnabled() {
 		return cached.(*types.MovieInfo), nil
 	}
 
@@ -122,18 +149,18 @@ func (p *HDRezka) GetInfo(id string) (interface{}, error) {
 	// We need to handle if it doesn't have the prefix if passed from somewhere else,
 	// but Search returns it with prefix (e.g. "films/watch/..." without baseurl? No, Search returns "films/watch/12345-movie")
 	// Wait, Search logic:
-	// path := strings.TrimPrefix(href, p.BaseURL+"/") -> "films/watch/12345-movie.html"
+	// path := strings.TrimPrefix(href, p.baseURL+"/") -> "films/watch/12345-movie.html"
 	// parts := strings.Split(path, "/") -> ["films", "watch", "12345-movie.html"] (Wait, suffix removed)
 	// id := strings.Join(parts[1:], "/") -> "watch/12345-movie"
 	// So ID is "watch/12345-movie" or similar.
 
-	// The original code used: urlStr := fmt.Sprintf("%s/%s.html", p.BaseURL, movieID)
+	// The original code used: urlStr := fmt.Sprintf("%s/%s.html", p.baseURL, movieID)
 	// So if ID is "watch/12345-movie", URL is "https://hdrezka.website/watch/12345-movie.html"
 	// This seems correct if the ID logic matches.
 
-	urlStr := fmt.Sprintf("%s/%s.html", p.BaseURL, id)
+	urlStr := fmt.Sprintf("%s/%s.html", p.baseURL, id)
 
-	req, err := http.NewRequest("GET", urlStr, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", urlStr, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -197,7 +224,7 @@ func (p *HDRezka) GetInfo(id string) (interface{}, error) {
 			}
 			seasonNum := i + 1
 
-			eps, err := p.fetchEpisodes(dataID, translatorID, seasonID)
+			eps, err := p.fetchEpisodes(ctx, dataID, translatorID, seasonID)
 			if err == nil {
 				for _, ep := range eps {
 					ep.Season = seasonNum
@@ -209,7 +236,7 @@ func (p *HDRezka) GetInfo(id string) (interface{}, error) {
 		// It's a series but no season tabs found, assume Season 1
 		seasonNum := 1
 		seasonID := "1"
-		eps, err := p.fetchEpisodes(dataID, translatorID, seasonID)
+		eps, err := p.fetchEpisodes(ctx, dataID, translatorID, seasonID)
 		if err == nil {
 			for _, ep := range eps {
 				ep.Season = seasonNum
@@ -233,18 +260,20 @@ func (p *HDRezka) GetInfo(id string) (interface{}, error) {
 		Episodes:    episodes,
 	}
 
-	p.infoCache.Store(id, info)
+	if p.CacheEnabled() {
+		p.infoCache.Store(id, info)
+	}
 	return info, nil
 }
 
-func (p *HDRezka) fetchEpisodes(dataID, translatorID, seasonID string) ([]types.Episode, error) {
+func (p *HDRezka) fetchEpisodes(ctx context.Context, dataID, translatorID, seasonID string) ([]types.Episode, error) {
 	data := url.Values{}
 	data.Set("id", dataID)
 	data.Set("translator_id", translatorID)
 	data.Set("season", seasonID)
 	data.Set("action", "get_episodes")
 
-	req, err := http.NewRequest("POST", p.BaseURL+"/ajax/get_cdn_series/", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/ajax/get_cdn_series/", strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -294,6 +323,11 @@ func (p *HDRezka) fetchEpisodes(dataID, translatorID, seasonID string) ([]types.
 }
 
 func (p *HDRezka) GetSources(episodeID string) (interface{}, error) {
+	return p.GetSourcesContext(context.Background(), episodeID)
+}
+
+// GetSourcesContext is GetSources, aborting once ctx is done
+func (p *HDRezka) GetSourcesContext(ctx context.Context, episodeID string) (interface{}, error) {
 	parts := strings.Split(episodeID, ":")
 	if len(parts) < 3 {
 		return nil, fmt.Errorf("invalid episode ID: %s", episodeID)
@@ -320,7 +354,7 @@ func (p *HDRezka) GetSources(episodeID string) (interface{}, error) {
 		data.Set("action", "get_movie")
 	}
 
-	req, err := http.NewRequest("POST", p.BaseURL+"/ajax/get_cdn_series/", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/ajax/get_cdn_series/", strings.NewReader(data.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -373,6 +407,11 @@ func (p *HDRezka) GetSources(episodeID string) (interface{}, error) {
 }
 
 func (p *HDRezka) GetServers(episodeID string) ([]types.EpisodeServer, error) {
+	return p.GetServersContext(context.Background(), episodeID)
+}
+
+// GetServersContext is GetServers, aborting once ctx is done
+func (p *HDRezka) GetServersContext(ctx context.Context, episodeID string) ([]types.EpisodeServer, error) {
 	return []types.EpisodeServer{
 		{Name: "HDRezka", URL: ""},
 	}, nil
@@ -412,7 +451,7 @@ func (p *HDRezka) Type() providers.MediaType {
 
 // Search (new interface) searches for movies/shows by query
 func (p *HDRezka) Search(ctx context.Context, query string) ([]providers.Media, error) {
-	oldResults, err := p.searchOld(query)
+	oldResults, err := p.searchOld(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -445,7 +484,7 @@ func (p *HDRezka) Search(ctx context.Context, query string) ([]providers.Media,
 
 // GetMediaDetails fetches detailed info for a movie/show
 func (p *HDRezka) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
-	info, err := p.GetInfo(id)
+	info, err := p.GetInfoContext(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -501,7 +540,7 @@ func (p *HDRezka) GetMediaDetails(ctx context.Context, id string) (*providers.Me
 
 // GetSeasons returns seasons for a media
 func (p *HDRezka) GetSeasons(ctx context.Context, mediaID string) ([]providers.Season, error) {
-	info, err := p.GetInfo(mediaID)
+	info, err := p.GetInfoContext(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
@@ -557,7 +596,7 @@ func (p *HDRezka) GetEpisodes(ctx context.Context, seasonID string) ([]providers
 		mediaID = seasonID
 	}
 
-	info, err := p.GetInfo(mediaID)
+	info, err := p.GetInfoContext(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
@@ -599,7 +638,7 @@ func (p *HDRezka) GetEpisodes(ctx context.Context, seasonID string) ([]providers
 
 // GetStreamURL fetches video stream URL for an episode
 func (p *HDRezka) GetStreamURL(ctx context.Context, episodeID string, quality providers.Quality) (*providers.StreamURL, error) {
-	res, err := p.GetSources(episodeID)
+	res, err := p.GetSourcesContext(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -617,21 +656,27 @@ func (p *HDRezka) GetStreamURL(ctx context.Context, episodeID string, quality pr
 	var selectedSource types.Source
 	found := false
 
-	targetQuality := string(quality)
+	// In auto mode hand the HLS master playlist to the player so it can
+	// switch variants itself
 	if quality == providers.QualityAuto {
-		targetQuality = "auto"
-	}
-
-	for _, src := range videoSources.Sources {
-		if strings.EqualFold(src.Quality, targetQuality) {
-			selectedSource = src
-			found = true
-			break
+		for _, src := range videoSources.Sources {
+			if providers.IsMasterPlaylist(src.URL, src.Quality) {
+				selectedSource = src
+				selectedSource.IsM3U8 = true
+				found = true
+				break
+			}
 		}
 	}
 
+	// Otherwise walk the quality ladder, e.g. from 1080p down to 720p for an
+	// episode, falling back to the first source
 	if !found {
-		selectedSource = videoSources.Sources[0]
+		labels := make([]string, len(videoSources.Sources))
+		for i, src := range videoSources.Sources {
+			labels[i] = src.Quality
+		}
+		selectedSource = videoSources.Sources[providers.SelectSourceQuality(labels, quality, p.Type())]
 	}
 
 	streamType := providers.StreamTypeHLS
@@ -652,7 +697,7 @@ func (p *HDRezka) GetStreamURL(ctx context.Context, episodeID string, quality pr
 
 // GetAvailableQualities returns available video qualities
 func (p *HDRezka) GetAvailableQualities(ctx context.Context, episodeID string) ([]providers.Quality, error) {
-	res, err := p.GetSources(episodeID)
+	res, err := p.GetSourcesContext(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -684,3 +729,14 @@ func (p *HDRezka) GetRecent(ctx context.Context) ([]providers.Media, error) {
 func (p *HDRezka) HealthCheck(ctx context.Context) error {
 	return nil
 }
+
+// ClearCache drops all cached search and info results
+func (p *HDRezka) ClearCache() {
+	p.searchCache.Clear()
+	p.infoCache.Clear()
+}
+
+// ClearCacheFor drops the cached info for a single media ID
+func (p *HDRezka) ClearCacheFor(mediaID string) {
+	p.infoCache.Delete(mediaID)
+}
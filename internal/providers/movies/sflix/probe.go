@@ -0,0 +1,99 @@
+package sflix
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+const (
+	// probeTimeout bounds a single mirror probe
+	probeTimeout = 5 * time.Second
+	// probeConcurrency bounds how many mirrors are probed at once
+	probeConcurrency = 4
+)
+
+// MirrorStatus is the result of probing one candidate mirror
+type MirrorStatus struct {
+	URL        string
+	StatusCode int           // Zero if the request failed
+	Latency    time.Duration // Time until the response headers arrived
+	Err        error
+}
+
+// Reachable reports whether the mirror answered with a usable page. Mirrors
+// that answer 403 or 503 are up but blocking us, so they don't count.
+func (m MirrorStatus) Reachable() bool {
+	return m.Err == nil && m.StatusCode >= 200 && m.StatusCode < 400
+}
+
+// ProbeMirrors checks each candidate domain's home page and reports its
+// status and latency, in the order given. Candidates without a scheme are
+// probed over https.
+func ProbeMirrors(ctx context.Context, candidates []string) []MirrorStatus {
+	return probeMirrors(ctx, &http.Client{Transport: providers.Transport()}, candidates)
+}
+
+func probeMirrors(ctx context.Context, client *http.Client, candidates []string) []MirrorStatus {
+	results := make([]MirrorStatus, len(candidates))
+	sem := make(chan struct{}, probeConcurrency)
+	var wg sync.WaitGroup
+
+	for i, candidate := range candidates {
+		wg.Add(1)
+		go func(i int, candidate string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = probeMirror(ctx, client, candidate)
+		}(i, candidate)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// probeMirror GETs a single mirror's home page
+func probeMirror(ctx context.Context, client *http.Client, candidate string) MirrorStatus {
+	raw := strings.TrimRight(strings.TrimSpace(candidate), "/")
+	if raw != "" && !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	status := MirrorStatus{URL: raw}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		status.Err = fmt.Errorf("invalid mirror URL %q", candidate)
+		return status
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw+"/home", nil)
+	if err != nil {
+		status.Err = fmt.Errorf("failed to create probe request: %w", err)
+		return status
+	}
+	req.Header.Set("User-Agent", providers.DefaultUserAgent)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		status.Err = fmt.Errorf("failed to reach %s: %w", raw, err)
+		return status
+	}
+	status.Latency = time.Since(start)
+	status.StatusCode = resp.StatusCode
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+
+	return status
+}
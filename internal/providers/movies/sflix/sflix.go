@@ -1,78 +1,281 @@
 package sflix
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/providers/utils"
+	"github.com/justchokingaround/greg/internal/tmdb"
 	"github.com/justchokingaround/greg/pkg/extractors"
 	"github.com/justchokingaround/greg/pkg/types"
+	"golang.org/x/sync/singleflight"
 )
 
 type SFlix struct {
-	BaseURL     string
+	baseURL     string
 	Client      *http.Client
 	searchCache sync.Map
 	infoCache   sync.Map
+
+	// CacheSwitch turns the caches off when cache_enabled is false
+	providers.CacheSwitch
+
+	// infoFlight shares a GetInfo fetch between concurrent callers
+	infoFlight singleflight.Group
+
+	// sourcesCache holds the last extracted sources per episode ID
+	sourcesCache sync.Map
+
+	// episodesCache holds each TV season's episodes by season ID. GetInfo
+	// only fetches the season list; episodes are loaded per season on demand.
+	episodesCache sync.Map
+
+	// Settings applied via SetConfig
+	debug          bool
+	cacheDir       string
+	logger         *slog.Logger
+	sourcesTimeout time.Duration
+	infoTimeout    time.Duration // Bounds shared GetInfo fetches; defaultInfoTimeout when zero
+	numbering      providers.EpisodeNumbering
+	mirrors        *providers.MirrorSet
+	metadata       providers.MetadataSource // nil unless a TMDB API key is configured
+	posterWidth    int                      // Poster size requested for previews, zero for the site's default
+	posterHeight   int
+}
+
+// providerVersion identifies the sflix site layout this scraper was
+// written against. Bump it whenever the scraping logic is updated for a
+// layout change.
+const providerVersion = "v1"
+
+// defaultSourcesTimeout bounds how long source extraction may take across all servers
+const defaultSourcesTimeout = 60 * time.Second
+
+// defaultInfoTimeout bounds a shared GetInfo fetch. It runs detached from
+// the callers' contexts, so without a limit a hung site would keep every
+// later caller for the same ID waiting on it.
+const defaultInfoTimeout = 60 * time.Second
+
+// maxConcurrentServers bounds how many servers are extracted from at once
+const maxConcurrentServers = 3
+
+// serverProbeTimeout bounds how long ProbeServers waits on a single server
+const serverProbeTimeout = 15 * time.Second
+
+// maxBodyExcerpt bounds how much of an unparseable response goes into errors
+const maxBodyExcerpt = 200
+
+// sourcesRetryDelay is the pause before re-asking the sources endpoint after
+// it answered with something that isn't JSON. It is a variable to allow
+// shortening in tests.
+var sourcesRetryDelay = 500 * time.Millisecond
+
+func init() {
+	providers.RegisterFactory("sflix", "movies", func(settings config.ProviderSettings) providers.Provider { return New(settings) })
 }
 
-func New() *SFlix {
-	return &SFlix{
-		BaseURL: "https://sflix.ps",
-		Client:  &http.Client{},
+// New creates the provider, applying the base URL, sources timeout and
+// headers from settings. Zero settings keep the built-in defaults.
+func New(settings config.ProviderSettings) *SFlix {
+	s := &SFlix{
+		baseURL:        "https://sflix.ps",
+		sourcesTimeout: settings.Timeout,
+	}
+	if settings.BaseURL != "" {
+		s.baseURL = strings.TrimSuffix(settings.BaseURL, "/")
 	}
+	s.Client = &http.Client{Transport: providers.NewHeaderTransport(nil, settings.Headers, s.baseURL)}
+	return s
 }
 
 func (s *SFlix) Name() string {
 	return "sflix"
 }
 
+// BaseURL returns the domain the provider is scraping
+func (s *SFlix) BaseURL() string {
+	if s.mirrors != nil {
+		if current := s.mirrors.Current(); current != "" {
+			return current
+		}
+	}
+	return s.baseURL
+}
+
+// ProviderVersion returns the site layout revision the scraper targets
+func (s *SFlix) ProviderVersion() string {
+	return providerVersion
+}
+
 func (s *SFlix) Type() providers.MediaType {
 	return providers.MediaTypeMovieTV
 }
 
+// SetConfig applies runtime configuration to the provider
+func (s *SFlix) SetConfig(cfg *config.Config, logger *slog.Logger) {
+	s.debug = cfg.Advanced.Debug
+	s.cacheDir = cfg.Cache.Path
+	s.logger = logger
+	s.sourcesTimeout = cfg.Providers.SFlix.Timeout
+	s.numbering = providers.ParseEpisodeNumbering(cfg.Providers.EpisodeNumbering)
+	s.posterWidth, s.posterHeight = providers.PreviewPosterSize(cfg.UI.PreviewSize)
+
+	s.metadata = nil
+	if cfg.Providers.TMDBAPIKey != "" {
+		s.metadata = tmdb.New(cfg.Providers.TMDBAPIKey)
+	}
+
+	// Switch mirrors or back off when the site blocks us
+	settings := cfg.Providers.SFlix
+	s.mirrors = providers.NewMirrorSet(s.baseURL, settings.Mirrors)
+	paced := providers.NewPacedTransport(providers.Transport(), settings.RequestDelay)
+	mirrored := providers.NewMirrorTransport(paced, s.mirrors, settings.MaxRetries)
+	s.Client.Transport = providers.NewHeaderTransport(mirrored, settings.Headers, append([]string{s.baseURL}, settings.Mirrors...)...)
+}
+
+// posterURL requests a poster at the size configured for previews
+func (s *SFlix) posterURL(raw string) string {
+	return providers.PosterURLAt(raw, s.posterWidth, s.posterHeight)
+}
+
+// dumpFailure saves the page of a failed fetch when debug mode is enabled
+func (s *SFlix) dumpFailure(pageURL string, statusCode int, body []byte) {
+	if !s.debug || s.cacheDir == "" {
+		return
+	}
+
+	path, err := providers.DumpFailedPage(s.cacheDir, s.Name(), providerVersion, pageURL, statusCode, body)
+	if s.logger == nil {
+		return
+	}
+	if err != nil {
+		s.logger.Warn("failed to dump page", "provider", s.Name(), "error", err)
+		return
+	}
+	s.logger.Debug("dumped failed page", "provider", s.Name(), "version", providerVersion, "url", pageURL, "path", path)
+}
+
+// requestHeaders returns the headers sent with every request. ajax
+// endpoints expect the X-Requested-With header.
+func (s *SFlix) requestHeaders(ajax bool) map[string]string {
+	headers := map[string]string{"Referer": s.baseURL}
+	if ajax {
+		headers["X-Requested-With"] = "XMLHttpRequest"
+	}
+	return headers
+}
+
+// dumpStatusError saves the body of a non-2xx response
+func (s *SFlix) dumpStatusError(pageURL string, err error) {
+	var statusErr *providers.StatusError
+	if errors.As(err, &statusErr) {
+		s.dumpFailure(pageURL, statusErr.StatusCode, statusErr.Body)
+	}
+}
+
+// fetchDocument loads an sflix page, dumping the body of non-2xx responses
+func (s *SFlix) fetchDocument(ctx context.Context, pageURL string, ajax bool) (*goquery.Document, error) {
+	doc, err := providers.FetchDocument(ctx, s.Client, pageURL, s.requestHeaders(ajax))
+	s.dumpStatusError(pageURL, err)
+	return doc, err
+}
+
+// fetchBody loads an sflix ajax endpoint, dumping the body of non-2xx
+// responses
+func (s *SFlix) fetchBody(ctx context.Context, pageURL string, ajax bool) ([]byte, error) {
+	body, err := providers.FetchBody(ctx, s.Client, pageURL, s.requestHeaders(ajax))
+	s.dumpStatusError(pageURL, err)
+	return body, err
+}
+
+// dumpDocument saves a page that loaded but did not have the expected layout
+func (s *SFlix) dumpDocument(pageURL string, doc *goquery.Document) {
+	html, _ := doc.Html()
+	s.dumpFailure(pageURL, http.StatusOK, []byte(html))
+}
+
 // Search searches for movies/shows by query
 func (s *SFlix) Search(ctx context.Context, query string) ([]providers.Media, error) {
-	if cached, ok := s.searchCache.Load(query); ok {
+	if cached, ok := s.searchCache.Load(query); ok && s.CacheEnabled() {
 		return cached.([]providers.Media), nil
 	}
+	return s.search(ctx, query, func(providers.Media) bool { return true })
+}
 
-	// Sflix uses dashes instead of spaces in search URLs
-	searchQuery := strings.ReplaceAll(query, " ", "-")
-	searchURL := fmt.Sprintf("%s/search/%s", s.BaseURL, searchQuery)
+// SearchStream sends search results as each one is parsed
+func (s *SFlix) SearchStream(ctx context.Context, query string) (<-chan providers.Media, <-chan error) {
+	results := make(chan providers.Media)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(results)
+
+		send := func(media providers.Media) bool {
+			select {
+			case results <- media:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
 
-	req, err := http.NewRequest("GET", searchURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+		if cached, ok := s.searchCache.Load(query); ok && s.CacheEnabled() {
+			for _, media := range cached.([]providers.Media) {
+				if !send(media) {
+					errc <- ctx.Err()
+					return
+				}
+			}
+			return
+		}
+
+		if _, err := s.search(ctx, query, send); err != nil {
+			errc <- err
+		}
+	}()
+	return results, errc
+}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Referer", s.BaseURL)
+// search fetches and parses the search page, passing each result to emit as
+// soon as it is parsed. The results are only cached if emit accepted them all.
+func (s *SFlix) search(ctx context.Context, query string, emit func(providers.Media) bool) ([]providers.Media, error) {
+	// Sflix uses dashes instead of spaces in search URLs
+	searchQuery := strings.ReplaceAll(query, " ", "-")
+	searchURL := fmt.Sprintf("%s/search/%s", s.baseURL, searchQuery)
 
-	resp, err := s.Client.Do(req)
+	doc, err := s.fetchDocument(ctx, searchURL, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch search results: %w", err)
+		return nil, fmt.Errorf("sflix search: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	// An empty result list is only legitimate if the results container itself
+	// is present; otherwise the page structure is not what we expect
+	if doc.Find("div.flw-item").Length() == 0 && doc.Find(".film_list-wrap").Length() == 0 {
+		s.dumpDocument(searchURL, doc)
+		return nil, fmt.Errorf("sflix search (provider %s): %w", providerVersion, providers.ErrLayoutChanged)
 	}
 
 	var results []providers.Media
 
-	doc.Find("div.flw-item").Each(func(i int, sel *goquery.Selection) {
+	doc.Find("div.flw-item").EachWithBreak(func(i int, sel *goquery.Selection) bool {
 		title := sel.Find("h2.film-name a").Text()
 		href, _ := sel.Find("h2.film-name a").Attr("href")
 		image, _ := sel.Find("img").Attr("data-src")
+		quality := strings.TrimSpace(sel.Find(".film-poster .pick").First().Text())
 
 		// Extract year
 		var year int
@@ -98,29 +301,45 @@ func (s *SFlix) Search(ctx context.Context, query string) ([]providers.Media, er
 				id = parts[0]
 			}
 
-			results = append(results, providers.Media{
-				ID:        id,
-				Title:     strings.TrimSpace(title),
-				Type:      mediaType,
-				PosterURL: image,
-				Year:      year,
-			})
+			media := providers.Media{
+				ID:            id,
+				Title:         strings.TrimSpace(title),
+				Type:          mediaType,
+				PosterURL:     s.posterURL(providers.AbsoluteURL(s.baseURL, image)),
+				Year:          year,
+				SourceQuality: quality,
+			}
+			results = append(results, media)
+			if !emit(media) {
+				return false
+			}
 		}
+		return true
 	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
-	s.searchCache.Store(query, results)
+	if s.CacheEnabled() {
+		s.searchCache.Store(query, results)
+	}
 	return results, nil
 }
 
-func (s *SFlix) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
-	info, err := s.GetInfo(id)
+// SearchFiltered searches and keeps only results matching the year and type in opts.
+// Search remains the unfiltered entry point.
+func (s *SFlix) SearchFiltered(ctx context.Context, query string, opts providers.SearchOptions) ([]providers.Media, error) {
+	results, err := s.Search(ctx, query)
 	if err != nil {
 		return nil, err
 	}
+	return providers.FilterMedia(results, opts), nil
+}
 
-	movieInfo, ok := info.(*types.MovieInfo)
-	if !ok {
-		return nil, fmt.Errorf("invalid info type")
+func (s *SFlix) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
+	movieInfo, err := s.getInfo(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
 	mediaType := providers.MediaTypeMovie
@@ -128,53 +347,88 @@ func (s *SFlix) GetMediaDetails(ctx context.Context, id string) (*providers.Medi
 		mediaType = providers.MediaTypeTV
 	}
 
-	return &providers.MediaDetails{
+	details := &providers.MediaDetails{
 		Media: providers.Media{
 			ID:        id,
 			Title:     movieInfo.Title,
 			Type:      mediaType,
-			PosterURL: movieInfo.Image,
+			PosterURL: s.posterURL(movieInfo.Image),
 			Synopsis:  movieInfo.Description,
+			Year:      utils.ExtractYear(movieInfo.ReleaseDate),
 			Genres:    movieInfo.Genres,
+			Rating:    movieInfo.RatingFloat,
 		},
-	}, nil
+		Collection: movieInfo.Collection,
+	}
+	providers.EnrichDetails(ctx, s.metadata, details)
+
+	return details, nil
 }
 
-func (s *SFlix) GetSeasons(ctx context.Context, mediaID string) ([]providers.Season, error) {
-	info, err := s.GetInfo(mediaID)
+// GetCollection returns the franchise or collection the title belongs to,
+// or an empty string when the detail page doesn't show one
+func (s *SFlix) GetCollection(ctx context.Context, mediaID string) (string, error) {
+	movieInfo, err := s.getInfo(ctx, mediaID)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
+	return movieInfo.Collection, nil
+}
 
-	movieInfo, ok := info.(*types.MovieInfo)
-	if !ok {
-		return nil, fmt.Errorf("invalid info type")
+// seasonOf returns the season an episode is listed under. Season numbers
+// come from parseSeasons, where 0 is the specials season; movies have no
+// seasons and are listed under season 1.
+func seasonOf(info *types.MovieInfo, ep types.Episode) int {
+	if len(info.Seasons) == 0 && ep.Season == 0 {
+		return 1
+	}
+	return ep.Season
+}
+
+func (s *SFlix) GetSeasons(ctx context.Context, mediaID string) ([]providers.Season, error) {
+	movieInfo, err := s.getInfo(ctx, mediaID)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(movieInfo.Episodes) == 0 {
+	if len(movieInfo.Seasons) == 0 && len(movieInfo.Episodes) == 0 {
 		return []providers.Season{{
-			ID:     mediaID,
-			Number: 1,
-			Title:  "Season 1",
+			ID:        mediaID,
+			Number:    1,
+			Title:     "Season 1",
+			PosterURL: s.posterURL(movieInfo.Image),
 		}}, nil
 	}
 
-	seasonsMap := make(map[int]bool)
 	var seasons []providers.Season
-
-	for _, ep := range movieInfo.Episodes {
-		sNum := ep.Season
-		if sNum == 0 {
-			sNum = 1
-		}
-		if !seasonsMap[sNum] {
-			seasonsMap[sNum] = true
+	if len(movieInfo.Seasons) > 0 {
+		for _, season := range movieInfo.Seasons {
+			poster := movieInfo.Image
+			if season.PosterURL != "" {
+				poster = providers.AbsoluteURL(s.baseURL, season.PosterURL)
+			}
 			seasons = append(seasons, providers.Season{
-				ID:     fmt.Sprintf("%s|%d", mediaID, sNum),
-				Number: sNum,
-				Title:  fmt.Sprintf("Season %d", sNum),
+				ID:        fmt.Sprintf("%s|%d", mediaID, season.Number),
+				Number:    season.Number,
+				Title:     providers.SeasonTitle(season.Number),
+				PosterURL: s.posterURL(poster),
 			})
 		}
+	} else {
+		// Movies list their single episode without a season
+		seasonsMap := make(map[int]bool)
+		for _, ep := range movieInfo.Episodes {
+			sNum := seasonOf(movieInfo, ep)
+			if !seasonsMap[sNum] {
+				seasonsMap[sNum] = true
+				seasons = append(seasons, providers.Season{
+					ID:        fmt.Sprintf("%s|%d", mediaID, sNum),
+					Number:    sNum,
+					Title:     fmt.Sprintf("Season %d", sNum),
+					PosterURL: s.posterURL(movieInfo.Image),
+				})
+			}
+		}
 	}
 
 	// Sort seasons
@@ -205,24 +459,25 @@ func (s *SFlix) GetEpisodes(ctx context.Context, seasonID string) ([]providers.E
 		mediaID = seasonID
 	}
 
-	info, err := s.GetInfo(mediaID)
+	movieInfo, err := s.getInfo(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
 
-	movieInfo, ok := info.(*types.MovieInfo)
-	if !ok {
-		return nil, fmt.Errorf("invalid info type")
+	if len(movieInfo.Seasons) > 0 {
+		return s.getSeasonEpisodes(ctx, movieInfo, seasonNum)
 	}
 
 	var episodes []providers.Episode
+	offset := 0
 
 	for _, ep := range movieInfo.Episodes {
-		epSeason := ep.Season
-		if epSeason == 0 {
-			epSeason = 1
-		}
+		epSeason := seasonOf(movieInfo, ep)
 
+		// Specials don't shift the absolute numbering of regular seasons
+		if epSeason != providers.SeasonSpecials && epSeason < seasonNum {
+			offset++
+		}
 		if epSeason == seasonNum {
 			id := ep.ID
 			if ep.URL != "" && ep.URL != ep.ID {
@@ -232,7 +487,7 @@ func (s *SFlix) GetEpisodes(ctx context.Context, seasonID string) ([]providers.E
 			episodes = append(episodes, providers.Episode{
 				ID:     id,
 				Number: ep.Number,
-				Title:  ep.Title,
+				Title:  providers.EpisodeTitle(ep.Title, movieInfo.Title),
 				Season: epSeason,
 			})
 		}
@@ -247,20 +502,53 @@ func (s *SFlix) GetEpisodes(ctx context.Context, seasonID string) ([]providers.E
 		})
 	}
 
+	providers.ApplyEpisodeNumbering(episodes, offset, s.numbering)
+
+	return episodes, nil
+}
+
+// getSeasonEpisodes loads one season of a TV show. Absolute numbering needs
+// the episode count of every earlier season, so only then are those fetched too.
+func (s *SFlix) getSeasonEpisodes(ctx context.Context, info *types.MovieInfo, seasonNum int) ([]providers.Episode, error) {
+	var episodes []providers.Episode
+	offset := 0
+
+	for _, season := range info.Seasons {
+		earlier := season.Number != providers.SeasonSpecials && season.Number < seasonNum
+		if season.Number != seasonNum && !(earlier && s.numbering == providers.EpisodeNumberingAbsolute) {
+			continue
+		}
+
+		seasonEps, err := s.seasonEpisodes(ctx, season)
+		if err != nil {
+			return nil, err
+		}
+		if earlier {
+			offset += len(seasonEps)
+			continue
+		}
+
+		for _, ep := range seasonEps {
+			episodes = append(episodes, providers.Episode{
+				ID:     fmt.Sprintf("%s|%s", ep.ID, info.ID),
+				Number: ep.Number,
+				Title:  providers.EpisodeTitle(ep.Title, info.Title),
+				Season: season.Number,
+			})
+		}
+	}
+
+	providers.ApplyEpisodeNumbering(episodes, offset, s.numbering)
+
 	return episodes, nil
 }
 
 func (s *SFlix) GetStreamURL(ctx context.Context, episodeID string, quality providers.Quality) (*providers.StreamURL, error) {
-	res, err := s.GetSources(episodeID)
+	v, err := s.getSources(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
 
-	v, ok := res.(*types.VideoSources)
-	if !ok {
-		return nil, fmt.Errorf("invalid source type")
-	}
-
 	if len(v.Sources) == 0 {
 		return nil, fmt.Errorf("no sources found")
 	}
@@ -268,21 +556,27 @@ func (s *SFlix) GetStreamURL(ctx context.Context, episodeID string, quality prov
 	var selectedSource types.Source
 	found := false
 
-	targetQuality := string(quality)
+	// In auto mode hand the HLS master playlist to the player so it can
+	// switch variants itself
 	if quality == providers.QualityAuto {
-		targetQuality = "auto"
-	}
-
-	for _, src := range v.Sources {
-		if strings.EqualFold(src.Quality, targetQuality) {
-			selectedSource = src
-			found = true
-			break
+		for _, src := range v.Sources {
+			if providers.IsMasterPlaylist(src.URL, src.Quality) {
+				selectedSource = src
+				selectedSource.IsM3U8 = true
+				found = true
+				break
+			}
 		}
 	}
 
+	// Otherwise walk the quality ladder, e.g. from 1080p down to 720p for an
+	// episode, falling back to the first source
 	if !found {
-		selectedSource = v.Sources[0]
+		labels := make([]string, len(v.Sources))
+		for i, src := range v.Sources {
+			labels[i] = src.Quality
+		}
+		selectedSource = v.Sources[providers.SelectSourceQuality(labels, quality, episodeMediaType(episodeID))]
 	}
 
 	streamType := providers.StreamTypeHLS
@@ -303,7 +597,7 @@ func (s *SFlix) GetStreamURL(ctx context.Context, episodeID string, quality prov
 	for _, sub := range v.Subtitles {
 		streamURL.Subtitles = append(streamURL.Subtitles, providers.Subtitle{
 			Language: sub.Lang,
-			URL:      sub.URL,
+			URL:      providers.AbsoluteURL(s.baseURL, sub.URL),
 		})
 	}
 
@@ -311,20 +605,39 @@ func (s *SFlix) GetStreamURL(ctx context.Context, episodeID string, quality prov
 }
 
 func (s *SFlix) GetAvailableQualities(ctx context.Context, episodeID string) ([]providers.Quality, error) {
-	res, err := s.GetSources(episodeID)
+	v, err := s.getSources(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
 
 	var qualities []providers.Quality
-	if v, ok := res.(*types.VideoSources); ok {
-		for _, src := range v.Sources {
-			qualities = append(qualities, providers.Quality(src.Quality))
-		}
+	for _, src := range v.Sources {
+		qualities = append(qualities, providers.Quality(src.Quality))
 	}
 	return qualities, nil
 }
 
+// HasSubtitles reports whether an episode has subtitles and lists their
+// languages. Previously extracted sources are reused when available.
+func (s *SFlix) HasSubtitles(ctx context.Context, episodeID string) (bool, []string, error) {
+	var v *types.VideoSources
+	if cached, ok := s.sourcesCache.Load(episodeID); ok && s.CacheEnabled() {
+		v = cached.(*types.VideoSources)
+	} else {
+		sources, err := s.getSources(ctx, episodeID)
+		if err != nil {
+			return false, nil, err
+		}
+		v = sources
+	}
+
+	languages := make([]string, 0, len(v.Subtitles))
+	for _, sub := range v.Subtitles {
+		languages = append(languages, sub.Lang)
+	}
+	return len(languages) > 0, languages, nil
+}
+
 func (s *SFlix) GetTrending(ctx context.Context) ([]providers.Media, error) {
 	return nil, fmt.Errorf("not implemented")
 }
@@ -337,63 +650,148 @@ func (s *SFlix) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
-// GetInfo fetches detailed info for a movie/show with episodes
+// ClearCache drops all cached search and info results
+func (s *SFlix) ClearCache() {
+	s.searchCache.Clear()
+	s.infoCache.Clear()
+	s.sourcesCache.Clear()
+	s.episodesCache.Clear()
+}
+
+// ClearCacheFor drops the cached info and season episodes for a single media ID
+func (s *SFlix) ClearCacheFor(mediaID string) {
+	if cached, ok := s.infoCache.LoadAndDelete(mediaID); ok {
+		for _, season := range cached.(*types.MovieInfo).Seasons {
+			s.episodesCache.Delete(season.ID)
+		}
+	}
+}
+
+// watchIDSuffix matches the ".{episodeID}" sflix appends to watch URLs
+var watchIDSuffix = regexp.MustCompile(`\.\d+$`)
+
+// normalizeInfoID turns a pasted sflix URL or canonical watch path such as
+// "https://sflix.to/watch-movie/free-inception-hd-19764.5432876" into the
+// info-page form "movie/free-inception-hd-19764". Other IDs are returned
+// unchanged.
+func normalizeInfoID(id string) string {
+	id = strings.TrimSpace(id)
+	if strings.Contains(id, "://") {
+		if u, err := url.Parse(id); err == nil {
+			id = u.Path
+		}
+	}
+	id = strings.Trim(id, "/")
+
+	for watchPrefix, infoPrefix := range map[string]string{
+		"watch-movie/": "movie/",
+		"watch-tv/":    "tv/",
+	} {
+		if slug, ok := strings.CutPrefix(id, watchPrefix); ok {
+			return infoPrefix + watchIDSuffix.ReplaceAllString(slug, "")
+		}
+	}
+	return id
+}
+
+// GetInfo fetches detailed info for a movie/show with episodes.
+// Pasted watch URLs (/watch-movie/..., /watch-tv/...) are accepted too.
 func (s *SFlix) GetInfo(id string) (interface{}, error) {
-	if cached, ok := s.infoCache.Load(id); ok {
+	return s.GetInfoContext(context.Background(), id)
+}
+
+// GetInfoContext is GetInfo with a context for its requests
+func (s *SFlix) GetInfoContext(ctx context.Context, id string) (interface{}, error) {
+	info, err := s.getInfo(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// getInfo is GetInfoContext returning the concrete type. The fetch may be
+// shared with other callers, so it keeps running when ctx ends and only this
+// caller stops waiting for it. infoTimeout still bounds it.
+func (s *SFlix) getInfo(ctx context.Context, id string) (*types.MovieInfo, error) {
+	id = normalizeInfoID(id)
+	if cached, ok := s.infoCache.Load(id); ok && s.CacheEnabled() {
 		return cached.(*types.MovieInfo), nil
 	}
 
+	// Seasons, episodes and details are often requested together for the same
+	// show; share one fetch instead of scraping the page for each
+	ch := s.infoFlight.DoChan(id, func() (interface{}, error) {
+		if cached, ok := s.infoCache.Load(id); ok && s.CacheEnabled() {
+			return cached, nil
+		}
+		timeout := s.infoTimeout
+		if timeout <= 0 {
+			timeout = defaultInfoTimeout
+		}
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		return s.fetchInfo(fetchCtx, id)
+	})
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.(*types.MovieInfo), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// GetInfoRefresh fetches media info without reading the info cache.
+// The fresh result is still written back to the cache, and the show's
+// season episodes are dropped so they are refetched too.
+func (s *SFlix) GetInfoRefresh(ctx context.Context, id string) (interface{}, error) {
+	info, err := s.fetchInfo(ctx, normalizeInfoID(id))
+	if err != nil {
+		return nil, err
+	}
+	for _, season := range info.Seasons {
+		s.episodesCache.Delete(season.ID)
+	}
+	return info, nil
+}
+
+// fetchInfo scrapes media info and stores it in the info cache
+func (s *SFlix) fetchInfo(ctx context.Context, id string) (*types.MovieInfo, error) {
 	// Determine media type from id
 	var mediaType string
 	var infoURL string
 
 	if strings.HasPrefix(id, "movie/") {
 		mediaType = "movie"
-		infoURL = fmt.Sprintf("%s/%s", s.BaseURL, id)
+		infoURL = fmt.Sprintf("%s/%s", s.baseURL, id)
 	} else if strings.HasPrefix(id, "tv/") {
 		mediaType = "tv"
-		infoURL = fmt.Sprintf("%s/%s", s.BaseURL, id)
+		infoURL = fmt.Sprintf("%s/%s", s.baseURL, id)
 	} else {
 		// Try movie URL first
-		infoURL = fmt.Sprintf("%s/movie/%s", s.BaseURL, id)
+		infoURL = fmt.Sprintf("%s/movie/%s", s.baseURL, id)
 		mediaType = "movie"
 	}
 
-	req, err := http.NewRequest("GET", infoURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Referer", s.BaseURL)
-
-	resp, err := s.Client.Do(req)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		// Try TV show URL if movie fails
-		if resp != nil {
-			_ = resp.Body.Close()
-		}
-		infoURL = fmt.Sprintf("%s/tv/%s", s.BaseURL, id)
+	doc, err := s.fetchDocument(ctx, infoURL, false)
+	if err != nil && !strings.Contains(id, "/") && ctx.Err() == nil {
+		// Bare IDs don't say what they are, so try the TV page if the movie one fails
+		infoURL = fmt.Sprintf("%s/tv/%s", s.baseURL, id)
 		mediaType = "tv"
-
-		req, err = http.NewRequest("GET", infoURL, nil)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create request: %w", err)
-		}
-
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-		req.Header.Set("Referer", s.BaseURL)
-
-		resp, err = s.Client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to fetch info: %w", err)
-		}
+		doc, err = s.fetchDocument(ctx, infoURL, false)
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+		return nil, fmt.Errorf("sflix info for %s: %w", id, err)
+	}
+
+	// Every detail page has a heading and a watch button; if neither is present
+	// the selectors below would silently produce an empty result
+	if doc.Find("h2.heading-name").Length() == 0 &&
+		doc.Find(".detail_page-watch, #watch").Length() == 0 {
+		s.dumpDocument(infoURL, doc)
+		return nil, fmt.Errorf("sflix info for %s (provider %s): %w", id, providerVersion, providers.ErrLayoutChanged)
 	}
 
 	// Extract clean media ID (e.g., "movie/watch-inception-19764")
@@ -410,12 +808,12 @@ func (s *SFlix) GetInfo(id string) (interface{}, error) {
 		Type:     mediaType,
 	}
 
-	// Extract title
-	info.Title = strings.TrimSpace(doc.Find("h2.heading-name").Text())
+	// Extract title, falling back to the page metadata when the heading is empty
+	info.Title = providers.PageTitle(doc, doc.Find("h2.heading-name").Text(), cleanMediaID, "SFlix")
 
 	// Extract image
 	if img, exists := doc.Find("img.film-poster-img").Attr("src"); exists {
-		info.Image = img
+		info.Image = providers.AbsoluteURL(s.baseURL, img)
 	}
 
 	// Extract description
@@ -427,6 +825,7 @@ func (s *SFlix) GetInfo(id string) (interface{}, error) {
 		// Extract just the number from "IMDB: 7.5"
 		ratingText = strings.TrimPrefix(ratingText, "IMDB:")
 		info.Rating = strings.TrimSpace(ratingText)
+		info.RatingFloat, _ = providers.ParseRating(info.Rating)
 	}
 
 	// Extract release date from elements section (format: "Released: YYYY-MM-DD")
@@ -441,6 +840,24 @@ func (s *SFlix) GetInfo(id string) (interface{}, error) {
 		}
 	})
 
+	// Extract the franchise, shown on some pages as "Collection:" or "Franchise:"
+	doc.Find("div.elements .row-line").Each(func(i int, sel *goquery.Selection) {
+		if info.Collection != "" {
+			return
+		}
+		text := sel.Text()
+		for _, label := range []string{"Collection:", "Franchise:"} {
+			if _, after, found := strings.Cut(text, label); found {
+				if link := strings.TrimSpace(sel.Find("a").First().Text()); link != "" {
+					info.Collection = link
+				} else {
+					info.Collection = strings.TrimSpace(after)
+				}
+				return
+			}
+		}
+	})
+
 	// Extract genres - only from the row-line that contains "Genre:"
 	doc.Find("div.elements .row-line").Each(func(i int, sel *goquery.Selection) {
 		text := sel.Text()
@@ -461,163 +878,218 @@ func (s *SFlix) GetInfo(id string) (interface{}, error) {
 		dataID, _ = doc.Find("#watch").Attr("data-id")
 	}
 
-	// For movies, create single episode with mediaID stored in URL field
-	if mediaType == "movie" && dataID != "" {
-		info.Episodes = []types.Episode{
-			{
-				ID:     dataID,
-				Number: 1,
-				Title:  info.Title,
-				URL:    cleanMediaID, // Store mediaID in URL field for later use
-			},
+	// For movies, create single episode with mediaID stored in URL field.
+	// Some movies list extras such as trailers next to the feature, in the
+	// same markup as a season's episodes; keep them all so GetMovieEpisodeID
+	// can pick the feature.
+	if mediaType == "movie" {
+		doc.Find(".eps-item").Each(func(i int, sel *goquery.Selection) {
+			if epID, ok := sel.Attr("data-id"); ok && epID != "" {
+				info.Episodes = append(info.Episodes, types.Episode{
+					ID:     epID,
+					Number: len(info.Episodes) + 1,
+					Title:  strings.TrimSpace(sel.Find(".film-name").Text()),
+					URL:    cleanMediaID,
+				})
+			}
+		})
+		if len(info.Episodes) == 0 && dataID != "" {
+			info.Episodes = []types.Episode{
+				{
+					ID:     dataID,
+					Number: 1,
+					Title:  info.Title,
+					URL:    cleanMediaID, // Store mediaID in URL field for later use
+				},
+			}
 		}
 	} else if mediaType == "tv" && dataID != "" {
-		// For TV shows, fetch episode list
-		episodes, err := s.fetchEpisodeList(dataID)
-		if err == nil && len(episodes) > 0 {
-			// Add mediaID to each episode
-			for i := range episodes {
-				episodes[i].URL = cleanMediaID
-			}
-			info.Episodes = episodes
-
-			// Calculate last season and episode count for that season
-			lastSeason := 0
-			episodeCountInLastSeason := 0
-			for _, ep := range episodes {
-				if ep.Season > lastSeason {
-					lastSeason = ep.Season
-					episodeCountInLastSeason = 1
-				} else if ep.Season == lastSeason {
-					episodeCountInLastSeason++
-				}
+		// For TV shows only the season list is fetched here; long-running shows
+		// have hundreds of episodes, so GetEpisodes loads them per season
+		seasons, err := s.fetchSeasonList(ctx, dataID)
+		if err != nil {
+			// Don't cache a show without seasons just because the list failed
+			return nil, fmt.Errorf("failed to fetch season list for %s: %w", id, err)
+		}
+		info.Seasons = seasons
+		for _, season := range seasons {
+			if season.Number > info.LastSeason {
+				info.LastSeason = season.Number
 			}
-			info.LastSeason = lastSeason
-			info.TotalEpisodesLastSeason = episodeCountInLastSeason
 		}
 	}
 
-	s.infoCache.Store(id, info)
+	if s.CacheEnabled() {
+		s.infoCache.Store(id, info)
+	}
 	return info, nil
 }
 
-// fetchEpisodeList fetches episodes for TV shows using the new two-step Sflix API
-func (s *SFlix) fetchEpisodeList(showID string) ([]types.Episode, error) {
-	// Step 1: Get all seasons
-	seasonURL := fmt.Sprintf("%s/ajax/season/list/%s", s.BaseURL, showID)
-
-	req, err := http.NewRequest("GET", seasonURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create season list request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Referer", s.BaseURL)
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
-
-	resp, err := s.Client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch season list: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
+// seasonLabel matches the number in labels like "Season 2"
+var seasonLabel = regexp.MustCompile(`(?i)season\s*(\d+)`)
 
-	seasonBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read season list response: %w", err)
-	}
+// specialsLabel matches the specials season, which some shows list as "Specials"
+var specialsLabel = regexp.MustCompile(`(?i)\bspecials?\b`)
 
-	// Parse seasons HTML
-	seasonDoc, err := goquery.NewDocumentFromReader(strings.NewReader(string(seasonBody)))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse season HTML: %w", err)
-	}
+// parseSeasons reads the season list and assigns each season its number.
+// This is the only place season numbers are decided; episodes, GetSeasons
+// and GetEpisodes all use them. Specials get season 0. Labels without a
+// number, or repeating one, get the next number not used by any labelled
+// season. Seasons with their own artwork keep its URL as listed.
+func parseSeasons(doc *goquery.Document) []types.Season {
+	const unnumbered = -1
 
-	episodes := []types.Episode{}
+	var seasons []types.Season
+	used := make(map[int]bool)
 
-	// Step 2: For each season, fetch its episodes
-	seasonDoc.Find(".ss-item").Each(func(seasonIdx int, seasonSel *goquery.Selection) {
-		seasonID, exists := seasonSel.Attr("data-id")
+	doc.Find(".ss-item").Each(func(i int, sel *goquery.Selection) {
+		seasonID, exists := sel.Attr("data-id")
 		if !exists {
 			return
 		}
 
-		seasonNumber := seasonIdx + 1
-		seasonText := strings.TrimSpace(seasonSel.Text())
-		// Try to extract season number from text like "Season 1"
-		if strings.Contains(seasonText, "Season ") {
-			parts := strings.Split(seasonText, "Season ")
-			if len(parts) > 1 {
-				if num, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
-					seasonNumber = num
-				}
+		title := strings.TrimSpace(sel.Text())
+		number := unnumbered
+		if m := seasonLabel.FindStringSubmatch(title); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				number = n
 			}
+		} else if specialsLabel.MatchString(title) {
+			number = providers.SeasonSpecials
 		}
+		if number != unnumbered && used[number] {
+			number = unnumbered
+		}
+		if number != unnumbered {
+			used[number] = true
+		}
+		seasons = append(seasons, types.Season{ID: seasonID, Number: number, Title: title, PosterURL: seasonPoster(sel)})
+	})
 
-		// Fetch episodes for this season
-		episodeURL := fmt.Sprintf("%s/ajax/season/episodes/%s", s.BaseURL, seasonID)
-
-		epReq, err := http.NewRequest("GET", episodeURL, nil)
-		if err != nil {
-			return
+	next := 1
+	for i := range seasons {
+		if seasons[i].Number != unnumbered {
+			continue
+		}
+		for used[next] {
+			next++
 		}
+		seasons[i].Number = next
+		used[next] = true
+	}
 
-		epReq.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-		epReq.Header.Set("Referer", s.BaseURL)
-		epReq.Header.Set("X-Requested-With", "XMLHttpRequest")
+	return seasons
+}
 
-		epResp, err := s.Client.Do(epReq)
-		if err != nil {
-			return
+// seasonPoster returns the artwork of a season list item, given either as a
+// data-poster attribute or as a (lazy-loaded) image inside the item
+func seasonPoster(sel *goquery.Selection) string {
+	if poster := strings.TrimSpace(sel.AttrOr("data-poster", "")); poster != "" {
+		return poster
+	}
+	img := sel.Find("img").First()
+	for _, attr := range []string{"data-src", "src"} {
+		if src := strings.TrimSpace(img.AttrOr(attr, "")); src != "" && !strings.HasPrefix(src, "data:") {
+			return src
 		}
-		defer func() { _ = epResp.Body.Close() }()
+	}
+	return ""
+}
 
-		epBody, err := io.ReadAll(epResp.Body)
-		if err != nil {
-			return
-		}
+// fetchSeasonList fetches and numbers the seasons of a TV show
+func (s *SFlix) fetchSeasonList(ctx context.Context, showID string) ([]types.Season, error) {
+	seasonURL := fmt.Sprintf("%s/ajax/season/list/%s", s.baseURL, showID)
 
-		// Parse episode HTML
-		epDoc, err := goquery.NewDocumentFromReader(strings.NewReader(string(epBody)))
-		if err != nil {
-			return
-		}
+	seasonDoc, err := s.fetchDocument(ctx, seasonURL, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseSeasons(seasonDoc), nil
+}
 
-		// Find all episodes in this season
-		epDoc.Find(".eps-item").Each(func(epIdx int, epSel *goquery.Selection) {
-			epID, exists := epSel.Attr("data-id")
-			if !exists {
-				return
-			}
+// fetchSeasonEpisodes fetches the episodes of a single season
+func (s *SFlix) fetchSeasonEpisodes(ctx context.Context, season types.Season) ([]types.Episode, error) {
+	episodeURL := fmt.Sprintf("%s/ajax/season/episodes/%s", s.baseURL, season.ID)
 
-			// Extract episode number and title from the HTML
-			epNumber := epIdx + 1
-			epTitle := ""
+	epDoc, err := s.fetchDocument(ctx, episodeURL, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch episodes for season %d: %w", season.Number, err)
+	}
 
-			// Try to get episode number from the episode-number div
-			epNumberText := epSel.Find(".episode-number").Text()
-			epNumberText = strings.TrimSpace(strings.TrimPrefix(epNumberText, "Episode "))
-			epNumberText = strings.TrimSuffix(epNumberText, ":")
-			if parsedNum, err := strconv.Atoi(epNumberText); err == nil {
-				epNumber = parsedNum
-			}
+	episodes := []types.Episode{}
+	epDoc.Find(".eps-item").Each(func(epIdx int, epSel *goquery.Selection) {
+		epID, exists := epSel.Attr("data-id")
+		if !exists {
+			return
+		}
 
-			// Get episode title
-			epTitle = strings.TrimSpace(epSel.Find(".film-name a").Text())
+		// Extract episode number and title from the HTML
+		epNumber := epIdx + 1
 
-			episodes = append(episodes, types.Episode{
-				ID:     epID,
-				Number: epNumber,
-				Season: seasonNumber,
-				Title:  epTitle,
-			})
+		// Try to get episode number from the episode-number div
+		epNumberText := epSel.Find(".episode-number").Text()
+		epNumberText = strings.TrimSpace(strings.TrimPrefix(epNumberText, "Episode "))
+		epNumberText = strings.TrimSuffix(epNumberText, ":")
+		if parsedNum, err := strconv.Atoi(epNumberText); err == nil {
+			epNumber = parsedNum
+		}
+
+		episodes = append(episodes, types.Episode{
+			ID:     epID,
+			Number: epNumber,
+			Season: season.Number,
+			Title:  strings.TrimSpace(epSel.Find(".film-name a").Text()),
 		})
 	})
 
 	return episodes, nil
 }
 
+// seasonEpisodes returns a season's episodes, fetching them on first use.
+// Failed fetches are not cached.
+func (s *SFlix) seasonEpisodes(ctx context.Context, season types.Season) ([]types.Episode, error) {
+	if cached, ok := s.episodesCache.Load(season.ID); ok && s.CacheEnabled() {
+		return cached.([]types.Episode), nil
+	}
+
+	episodes, err := s.fetchSeasonEpisodes(ctx, season)
+	if err != nil {
+		return nil, err
+	}
+	if s.CacheEnabled() {
+		s.episodesCache.Store(season.ID, episodes)
+	}
+	return episodes, nil
+}
+
+// ProbeServers tries every server of an episode and reports which ones
+// yield sources. Servers that work are cached by the extractor, so picking
+// one afterwards doesn't extract again.
+func (s *SFlix) ProbeServers(ctx context.Context, episodeID string) ([]extractors.ServerProbe, error) {
+	actualEpisodeID, mediaID, _ := strings.Cut(episodeID, "|")
+	servers, err := s.FetchEpisodeServersWithMediaID(ctx, actualEpisodeID, mediaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch servers: %w", err)
+	}
+	return extractors.ProbeServers(ctx, servers, maxConcurrentServers, serverProbeTimeout, s.extractSourcesFromServer), nil
+}
+
+// episodeMediaType reports whether an "id|mediaID" episode ID belongs to a
+// movie or a show
+func episodeMediaType(episodeID string) providers.MediaType {
+	if _, mediaID, ok := strings.Cut(episodeID, "|"); ok && strings.Contains(mediaID, "movie") {
+		return providers.MediaTypeMovie
+	}
+	return providers.MediaTypeTV
+}
+
 // GetServers fetches available servers for an episode
 func (s *SFlix) GetServers(episodeID string) ([]types.EpisodeServer, error) {
+	return s.GetServersContext(context.Background(), episodeID)
+}
+
+// GetServersContext is GetServers, aborting once ctx is done
+func (s *SFlix) GetServersContext(ctx context.Context, episodeID string) ([]types.EpisodeServer, error) {
 	// Check if episodeID contains mediaID (format: "id|mediaID")
 	var actualEpisodeID, mediaID string
 	parts := strings.Split(episodeID, "|")
@@ -628,60 +1100,84 @@ func (s *SFlix) GetServers(episodeID string) ([]types.EpisodeServer, error) {
 		actualEpisodeID = episodeID
 	}
 
-	return s.FetchEpisodeServersWithMediaID(actualEpisodeID, mediaID)
+	return s.FetchEpisodeServersWithMediaID(ctx, actualEpisodeID, mediaID)
 }
 
 // FetchEpisodeServersWithMediaID fetches available servers with mediaID context
-func (s *SFlix) FetchEpisodeServersWithMediaID(episodeID string, mediaID string) ([]types.EpisodeServer, error) {
+func (s *SFlix) FetchEpisodeServersWithMediaID(ctx context.Context, episodeID string, mediaID string) ([]types.EpisodeServer, error) {
 	// Determine endpoint based on whether it's a movie or TV show
 	var endpoint string
 	isMovie := strings.Contains(mediaID, "movie")
 
 	if isMovie {
 		// For movies, use /ajax/episode/list/{episodeId}
-		endpoint = fmt.Sprintf("%s/ajax/episode/list/%s", s.BaseURL, episodeID)
+		endpoint = fmt.Sprintf("%s/ajax/episode/list/%s", s.baseURL, episodeID)
 	} else {
 		// For TV shows, use /ajax/episode/servers/{episodeId}
-		endpoint = fmt.Sprintf("%s/ajax/episode/servers/%s", s.BaseURL, episodeID)
+		endpoint = fmt.Sprintf("%s/ajax/episode/servers/%s", s.baseURL, episodeID)
 	}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create servers request: %w", err)
+	servers, err := s.fetchServerList(ctx, endpoint, mediaID, isMovie)
+	if !isMovie && (isNotFound(err) || (err == nil && len(servers) == 0)) {
+		// sflix is moving TV server lists to the v2 endpoint flixhq uses
+		endpoint = fmt.Sprintf("%s/ajax/v2/episode/servers/%s", s.baseURL, episodeID)
+		servers, err = s.fetchServerList(ctx, endpoint, mediaID, isMovie)
 	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Referer", s.BaseURL)
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
-
-	resp, err := s.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch servers: %w", err)
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	body, err := io.ReadAll(resp.Body)
+	return servers, nil
+}
+
+// isNotFound reports whether err is a 404 response
+func isNotFound(err error) bool {
+	var statusErr *providers.StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// fetchServerList fetches and parses a server list endpoint
+func (s *SFlix) fetchServerList(ctx context.Context, endpoint, mediaID string, isMovie bool) ([]types.EpisodeServer, error) {
+	body, err := s.fetchBody(ctx, endpoint, true)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read servers response: %w", err)
+		return nil, err
 	}
 
-	// Parse the HTML content
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	doc, err := serverListDocument(body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse servers HTML: %w", err)
 	}
+	return s.parseServers(doc, mediaID, isMovie), nil
+}
+
+// serverListDocument parses a server list response. The v2 endpoint wraps
+// the HTML in JSON ({"html": "..."}), the v1 endpoints return it directly.
+func serverListDocument(body []byte) (*goquery.Document, error) {
+	var wrapped struct {
+		HTML string `json:"html"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err == nil && wrapped.HTML != "" {
+		body = []byte(wrapped.HTML)
+	}
+	return goquery.NewDocumentFromReader(bytes.NewReader(body))
+}
 
+// parseServers reads the servers from a v1 (.ulclear) or v2 (.nav-item) server list
+func (s *SFlix) parseServers(doc *goquery.Document, mediaID string, isMovie bool) []types.EpisodeServer {
 	servers := []types.EpisodeServer{}
 
 	// Find all server items
-	doc.Find(".ulclear > li").Each(func(i int, sel *goquery.Selection) {
+	doc.Find(".ulclear > li, .nav-item").Each(func(i int, sel *goquery.Selection) {
 		dataID, exists := sel.Find("a").Attr("data-id")
 		if !exists {
 			return
 		}
 
-		// Server name is in <span> tag
+		// Server name is in a <span> tag, or the link text on v2 lists
 		serverName := strings.TrimSpace(sel.Find("a span").Text())
+		if serverName == "" {
+			serverName = strings.TrimSpace(sel.Find("a").Text())
+		}
 		if serverName == "" {
 			return
 		}
@@ -697,7 +1193,7 @@ func (s *SFlix) FetchEpisodeServersWithMediaID(episodeID string, mediaID string)
 			replacement = "/watch-tv/"
 		}
 
-		serverURL := fmt.Sprintf("%s/%s.%s", s.BaseURL, mediaID, dataID)
+		serverURL := fmt.Sprintf("%s/%s.%s", s.baseURL, mediaID, dataID)
 		serverURL = strings.Replace(serverURL, urlPattern, replacement, 1)
 
 		servers = append(servers, types.EpisodeServer{
@@ -709,11 +1205,25 @@ func (s *SFlix) FetchEpisodeServersWithMediaID(episodeID string, mediaID string)
 		servers[len(servers)-1].URL = dataID
 	})
 
-	return servers, nil
+	return servers
 }
 
 // GetSources fetches video sources for an episode
 func (s *SFlix) GetSources(episodeID string) (interface{}, error) {
+	return s.GetSourcesContext(context.Background(), episodeID)
+}
+
+// GetSourcesContext is GetSources, aborting once ctx is done
+func (s *SFlix) GetSourcesContext(ctx context.Context, episodeID string) (interface{}, error) {
+	sources, err := s.getSources(ctx, episodeID)
+	if err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// getSources is GetSourcesContext returning the concrete type
+func (s *SFlix) getSources(ctx context.Context, episodeID string) (*types.VideoSources, error) {
 	// Check if episodeID contains mediaID (format: "id|mediaID")
 	var actualEpisodeID, mediaID string
 	parts := strings.Split(episodeID, "|")
@@ -724,13 +1234,30 @@ func (s *SFlix) GetSources(episodeID string) (interface{}, error) {
 		actualEpisodeID = episodeID
 	}
 
-	return s.FetchEpisodeSourcesWithMediaID(actualEpisodeID, mediaID)
+	sources, err := s.FetchEpisodeSourcesWithMediaID(ctx, actualEpisodeID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sources.Sources) > 0 {
+		if s.CacheEnabled() {
+			s.sourcesCache.Store(episodeID, sources)
+		}
+	}
+	return sources, nil
 }
 
 // FetchEpisodeSourcesWithMediaID fetches video sources with mediaID context
-func (s *SFlix) FetchEpisodeSourcesWithMediaID(episodeID string, mediaID string) (*types.VideoSources, error) {
+func (s *SFlix) FetchEpisodeSourcesWithMediaID(ctx context.Context, episodeID string, mediaID string) (*types.VideoSources, error) {
+	timeout := s.sourcesTimeout
+	if timeout <= 0 {
+		timeout = defaultSourcesTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// Get available servers with mediaID
-	servers, err := s.FetchEpisodeServersWithMediaID(episodeID, mediaID)
+	servers, err := s.FetchEpisodeServersWithMediaID(ctx, episodeID, mediaID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch servers: %w", err)
 	}
@@ -742,80 +1269,167 @@ func (s *SFlix) FetchEpisodeSourcesWithMediaID(episodeID string, mediaID string)
 		}, nil
 	}
 
-	// Try each server until we get valid sources
-	var lastErr error
-	for _, server := range servers {
-		sources, err := s.extractSourcesFromServer(server)
+	// Extract from the top servers concurrently, preferring earlier servers
+	sources, err := extractors.ExtractFirst(ctx, servers, maxConcurrentServers, s.extractSourcesFromServer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract sources from all servers: %w", err)
+	}
+
+	return sources, nil
+}
+
+// fetchEmbedLinks asks /ajax/episode/sources/{serverID} for the server's
+// embed URLs. Most responses carry a single "link", but some mirrors return a
+// "sources" array with several embeds instead; both shapes are accepted. The
+// endpoint sometimes answers with an empty body or an HTML error page, so a
+// response that isn't JSON is retried once before giving up.
+func (s *SFlix) fetchEmbedLinks(ctx context.Context, serverID string) ([]string, error) {
+	sourcesURL := fmt.Sprintf("%s/ajax/episode/sources/%s", s.baseURL, serverID)
+
+	var parseErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(sourcesRetryDelay):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		body, err := s.fetchBody(ctx, sourcesURL, true)
 		if err != nil {
-			lastErr = err
-			continue
+			return nil, fmt.Errorf("failed to fetch embed URL: %w", err)
 		}
 
-		if len(sources.Sources) > 0 {
-			return sources, nil
+		links, err := parseEmbedLinks(body)
+		if err != nil {
+			parseErr = fmt.Errorf("failed to parse sources JSON: %w (body: %q)", err, bodyExcerpt(body))
+			continue
 		}
+		if len(links) == 0 {
+			return nil, fmt.Errorf("no embed link found in response")
+		}
+		return links, nil
+	}
+
+	return nil, parseErr
+}
+
+// parseEmbedLinks reads the embed URLs from a sources response, either
+// {"link":"..."} or {"sources":[{"link":"..."}, "...", ...]}
+func parseEmbedLinks(body []byte) ([]string, error) {
+	var response struct {
+		Link    string            `json:"link"`
+		Sources []json.RawMessage `json:"sources"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
 	}
 
-	// If all servers failed, return the last error
-	if lastErr != nil {
-		return nil, fmt.Errorf("failed to extract sources from all servers: %w", lastErr)
+	if response.Link != "" {
+		return []string{response.Link}, nil
 	}
 
-	return &types.VideoSources{
-		Sources:   []types.Source{},
-		Subtitles: []types.Subtitle{},
-	}, nil
+	var links []string
+	for _, raw := range response.Sources {
+		var link string
+		if err := json.Unmarshal(raw, &link); err != nil {
+			var entry struct {
+				Link string `json:"link"`
+				URL  string `json:"url"`
+			}
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				continue
+			}
+			link = entry.Link
+			if link == "" {
+				link = entry.URL
+			}
+		}
+		if link != "" {
+			links = append(links, link)
+		}
+	}
+	return links, nil
 }
 
-// extractSourcesFromServer extracts video sources from a specific server
-func (s *SFlix) extractSourcesFromServer(server types.EpisodeServer) (*types.VideoSources, error) {
-	// The server.URL now contains just the dataID (server ID)
-	serverID := server.URL
+// mergeSources appends src's sources and subtitles to dst, skipping URLs
+// dst already has
+func mergeSources(dst, src *types.VideoSources) {
+	seen := make(map[string]bool, len(dst.Sources)+len(dst.Subtitles))
+	for _, source := range dst.Sources {
+		seen[source.URL] = true
+	}
+	for _, sub := range dst.Subtitles {
+		seen[sub.URL] = true
+	}
 
-	// Use /ajax/episode/sources/{serverID} endpoint
-	sourcesURL := fmt.Sprintf("%s/ajax/episode/sources/%s", s.BaseURL, serverID)
+	for _, source := range src.Sources {
+		if !seen[source.URL] {
+			seen[source.URL] = true
+			dst.Sources = append(dst.Sources, source)
+		}
+	}
+	for _, sub := range src.Subtitles {
+		if !seen[sub.URL] {
+			seen[sub.URL] = true
+			dst.Subtitles = append(dst.Subtitles, sub)
+		}
+	}
+}
 
-	req, err := http.NewRequest("GET", sourcesURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create sources request: %w", err)
+// bodyExcerpt returns the start of body for error messages
+func bodyExcerpt(body []byte) string {
+	excerpt := strings.TrimSpace(string(body))
+	if len(excerpt) > maxBodyExcerpt {
+		excerpt = excerpt[:maxBodyExcerpt] + "..."
 	}
+	return excerpt
+}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
-	req.Header.Set("Referer", s.BaseURL)
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+// extractSourcesFromServer extracts video sources from a specific server
+func (s *SFlix) extractSourcesFromServer(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
+	// The server.URL now contains just the dataID (server ID)
+	serverID := server.URL
 
-	resp, err := s.Client.Do(req)
+	embedURLs, err := s.fetchEmbedLinks(ctx, serverID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch embed URL: %w", err)
+		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read sources response: %w", err)
-	}
+	// Use the extractor to get actual video sources from every embed
+	extracted := &types.VideoSources{}
+	var errs []error
+	for _, embedURL := range embedURLs {
+		sources, err := extractors.Extract(ctx, server.Name, embedURL)
+		if err != nil {
+			errs = append(errs, extractors.NewExtractorError(server.Name, fmt.Errorf("failed to extract from embed URL %s: %w", embedURL, err)))
+			continue
+		}
 
-	// Parse JSON response to get embed URL
-	// Response format: {"link":"https://megacloud.tv/..."}
-	var jsonResponse struct {
-		Link string `json:"link"`
-	}
+		// Widevine-protected embeds can't be played; report them distinctly so
+		// the next server is tried and the UI can explain why
+		if err := extractors.CheckDRM(ctx, s.Client, sources); err != nil {
+			errs = append(errs, fmt.Errorf("server %s: %w", server.Name, err))
+			continue
+		}
 
-	if err := json.Unmarshal(body, &jsonResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse sources JSON: %w", err)
+		mergeSources(extracted, sources)
 	}
-
-	if jsonResponse.Link == "" {
-		return nil, fmt.Errorf("no embed link found in response")
+	if len(extracted.Sources) == 0 {
+		if len(errs) == 1 {
+			return nil, errs[0]
+		}
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+		return nil, fmt.Errorf("server %s: no sources extracted", server.Name)
 	}
 
-	embedURL := jsonResponse.Link
-
-	// Use the extractor to get actual video sources
-	extractor := extractors.GetExtractor(server.Name)
-	extracted, err := extractor.Extract(embedURL)
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract from embed URL %s: %w", embedURL, err)
+	// A source that 404s would stop the server loop but never play, so
+	// treat it as a failed server and move on to the next one
+	if err := extractors.CheckAlive(ctx, s.Client, extracted); err != nil {
+		return nil, fmt.Errorf("server %s: %w", server.Name, err)
 	}
 
 	return extracted, nil
@@ -823,21 +1437,40 @@ func (s *SFlix) extractSourcesFromServer(server types.EpisodeServer) (*types.Vid
 
 // GetMovieEpisodeID retrieves the episode ID for a movie
 func (s *SFlix) GetMovieEpisodeID(ctx context.Context, mediaID string) (string, error) {
-	info, err := s.GetInfo(mediaID)
+	movieInfo, err := s.getInfo(ctx, mediaID)
 	if err != nil {
 		return "", err
 	}
-	movieInfo, ok := info.(*types.MovieInfo)
-	if !ok {
-		return "", fmt.Errorf("invalid info type")
-	}
 	if len(movieInfo.Episodes) > 0 {
-		ep := movieInfo.Episodes[0]
+		ep := mainFeature(movieInfo.Episodes, movieInfo.Title)
 		// If URL is available (it stores mediaID), append it to the ID separated by |
 		if ep.URL != "" {
 			return fmt.Sprintf("%s|%s", ep.ID, ep.URL), nil
 		}
 		return ep.ID, nil
 	}
-	return "", fmt.Errorf("no episodes found for movie %s", mediaID)
+	return "", fmt.Errorf("movie %s: %w", mediaID, providers.ErrNoEpisodes)
+}
+
+// extraTitle matches the labels of trailers and other extras listed next to
+// a movie
+var extraTitle = regexp.MustCompile(`(?i)\b(trailers?|teasers?|featurettes?|behind the scenes|making of|bonus|extras?|clips?|interviews?|deleted scenes?)\b`)
+
+// mainFeature picks the movie itself out of the entries listed on its page:
+// the entry named after the movie, else the first one that isn't labelled
+// as an extra, else the first entry
+func mainFeature(episodes []types.Episode, title string) types.Episode {
+	if want := utils.NormalizeTitle(title); want != "" {
+		for _, ep := range episodes {
+			if utils.NormalizeTitle(ep.Title) == want {
+				return ep
+			}
+		}
+	}
+	for _, ep := range episodes {
+		if !extraTitle.MatchString(ep.Title) {
+			return ep
+		}
+	}
+	return episodes[0]
 }
@@ -0,0 +1,812 @@
+package sflix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+func TestNormalizeInfoID(t *testing.T) {
+	tests := map[string]string{
+		"movie/free-inception-hd-19764":                                "movie/free-inception-hd-19764",
+		"tv/free-dark-hd-39490":                                        "tv/free-dark-hd-39490",
+		"watch-movie/free-inception-hd-19764.5432876":                  "movie/free-inception-hd-19764",
+		"/watch-tv/free-dark-hd-39490.4859302":                         "tv/free-dark-hd-39490",
+		"watch-tv/free-dark-hd-39490":                                  "tv/free-dark-hd-39490",
+		"https://sflix.to/watch-movie/free-inception-hd-19764.5432876": "movie/free-inception-hd-19764",
+		"https://sflix.to/tv/free-dark-hd-39490/":                      "tv/free-dark-hd-39490",
+		"19764": "19764",
+	}
+
+	for id, want := range tests {
+		if got := normalizeInfoID(id); got != want {
+			t.Errorf("normalizeInfoID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+// serveFixture answers with the contents of a testdata file
+func serveFixture(t *testing.T, name string) http.HandlerFunc {
+	t.Helper()
+	body, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(body)
+	}
+}
+
+func TestNewAppliesSettings(t *testing.T) {
+	var gotHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader = r.Header.Get("X-Test")
+	}))
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{
+		BaseURL: srv.URL + "/",
+		Timeout: 5 * time.Second,
+		Headers: map[string]string{"X-Test": "yes"},
+	})
+	if s.baseURL != srv.URL {
+		t.Errorf("baseURL = %q, want %q", s.baseURL, srv.URL)
+	}
+	if s.sourcesTimeout != 5*time.Second {
+		t.Errorf("sourcesTimeout = %v, want 5s", s.sourcesTimeout)
+	}
+
+	resp, err := s.Client.Get(s.baseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if gotHeader != "yes" {
+		t.Errorf("X-Test header = %q, want %q", gotHeader, "yes")
+	}
+
+	if got := New(config.ProviderSettings{}).baseURL; got != "https://sflix.ps" {
+		t.Errorf("default baseURL = %q, want https://sflix.ps", got)
+	}
+}
+
+func TestFetchEpisodeServers(t *testing.T) {
+	want := []types.EpisodeServer{
+		{Name: "upcloud", URL: "10344001"},
+		{Name: "vidcloud", URL: "10344002"},
+	}
+
+	tests := []struct {
+		name   string
+		routes map[string]string
+	}{
+		{
+			name:   "v1 endpoint",
+			routes: map[string]string{"/ajax/episode/servers/123": "servers_v1.html"},
+		},
+		{
+			name:   "v2 fallback when v1 is missing",
+			routes: map[string]string{"/ajax/v2/episode/servers/123": "servers_v2.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			for path, fixture := range tt.routes {
+				mux.HandleFunc(path, serveFixture(t, fixture))
+			}
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			s := New(config.ProviderSettings{})
+			s.baseURL = srv.URL
+			s.Client = srv.Client()
+
+			servers, err := s.FetchEpisodeServersWithMediaID(context.Background(), "123", "tv/free-dark-hd-39490")
+			if err != nil {
+				t.Fatalf("FetchEpisodeServersWithMediaID() error = %v", err)
+			}
+			if !reflect.DeepEqual(servers, want) {
+				t.Errorf("FetchEpisodeServersWithMediaID() = %+v, want %+v", servers, want)
+			}
+		})
+	}
+}
+
+func TestGetServersContextCancelled(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := s.GetServersContext(ctx, "123|tv/free-dark-hd-39490")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetServersContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetServersContext() returned after %v, want it aborted with ctx", elapsed)
+	}
+}
+
+func TestSeasonsAndEpisodesAgree(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tv/free-dark-hd-39490", serveFixture(t, "info_tv.html"))
+	mux.HandleFunc("/ajax/season/list/39490", serveFixture(t, "seasons.html"))
+	mux.HandleFunc("/ajax/season/episodes/s1", serveFixture(t, "episodes_s1.html"))
+	mux.HandleFunc("/ajax/season/episodes/s2", serveFixture(t, "episodes_s2.html"))
+	mux.HandleFunc("/ajax/season/episodes/s3", serveFixture(t, "episodes_s3.html"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	ctx := context.Background()
+	seasons, err := s.GetSeasons(ctx, "tv/free-dark-hd-39490")
+	if err != nil {
+		t.Fatalf("GetSeasons() error = %v", err)
+	}
+
+	// The site lists seasons newest first and labels one without a number
+	var numbers []int
+	for _, season := range seasons {
+		numbers = append(numbers, season.Number)
+	}
+	if !reflect.DeepEqual(numbers, []int{1, 2, 3}) {
+		t.Fatalf("GetSeasons() numbers = %v, want [1 2 3]", numbers)
+	}
+
+	for _, season := range seasons {
+		episodes, err := s.GetEpisodes(ctx, season.ID)
+		if err != nil {
+			t.Fatalf("GetEpisodes(%s) error = %v", season.ID, err)
+		}
+		if len(episodes) != 2 {
+			t.Fatalf("GetEpisodes(%s) returned %d episodes, want 2", season.ID, len(episodes))
+		}
+		prefix := fmt.Sprintf("S%d ", season.Number)
+		for _, ep := range episodes {
+			if ep.Season != season.Number || !strings.HasPrefix(ep.Title, prefix) {
+				t.Errorf("season %d has episode %+v", season.Number, ep)
+			}
+		}
+	}
+}
+
+func TestSeasonPosters(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tv/free-dark-hd-39490", serveFixture(t, "info_tv.html"))
+	mux.HandleFunc("/ajax/season/list/39490", serveFixture(t, "seasons_posters.html"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	seasons, err := s.GetSeasons(context.Background(), "tv/free-dark-hd-39490")
+	if err != nil {
+		t.Fatalf("GetSeasons() error = %v", err)
+	}
+
+	// Lazy-loaded and relative artwork is resolved; seasons without any
+	// use the show poster
+	want := map[int]string{
+		1: "https://img.example/dark-s1.jpg",
+		2: srv.URL + "/poster/dark.jpg",
+		3: srv.URL + "/season/dark-s3.jpg",
+	}
+	if len(seasons) != len(want) {
+		t.Fatalf("GetSeasons() returned %d seasons, want %d", len(seasons), len(want))
+	}
+	for _, season := range seasons {
+		if season.PosterURL != want[season.Number] {
+			t.Errorf("season %d poster = %q, want %q", season.Number, season.PosterURL, want[season.Number])
+		}
+	}
+}
+
+func TestSpecialsSeason(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tv/free-dark-hd-39490", serveFixture(t, "info_tv.html"))
+	mux.HandleFunc("/ajax/season/list/39490", serveFixture(t, "seasons_specials.html"))
+	mux.HandleFunc("/ajax/season/episodes/s1", serveFixture(t, "episodes_s1.html"))
+	mux.HandleFunc("/ajax/season/episodes/s2", serveFixture(t, "episodes_s2.html"))
+	mux.HandleFunc("/ajax/season/episodes/sp", serveFixture(t, "episodes_s3.html"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+	s.numbering = providers.EpisodeNumberingAbsolute
+
+	ctx := context.Background()
+	seasons, err := s.GetSeasons(ctx, "tv/free-dark-hd-39490")
+	if err != nil {
+		t.Fatalf("GetSeasons() error = %v", err)
+	}
+	if len(seasons) != 3 || seasons[0].Number != 0 || seasons[0].Title != "Specials" {
+		t.Fatalf("GetSeasons() = %+v, want specials as season 0", seasons)
+	}
+
+	specials, err := s.GetEpisodes(ctx, seasons[0].ID)
+	if err != nil {
+		t.Fatalf("GetEpisodes(specials) error = %v", err)
+	}
+	if len(specials) != 2 || specials[0].Season != 0 || specials[0].Number != 1 {
+		t.Errorf("GetEpisodes(specials) = %+v", specials)
+	}
+
+	// Specials must not be folded into season 1 or shift its numbering
+	first, err := s.GetEpisodes(ctx, seasons[1].ID)
+	if err != nil {
+		t.Fatalf("GetEpisodes(season 1) error = %v", err)
+	}
+	if len(first) != 2 || first[0].Title != "S1 Opening" || first[0].Number != 1 {
+		t.Errorf("GetEpisodes(season 1) = %+v", first)
+	}
+}
+
+func TestEpisodesLoadPerSeason(t *testing.T) {
+	var mu sync.Mutex
+	hits := make(map[string]int)
+	count := func(name string, next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[name]++
+			mu.Unlock()
+			next(w, r)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tv/free-dark-hd-39490", serveFixture(t, "info_tv.html"))
+	mux.HandleFunc("/ajax/season/list/39490", serveFixture(t, "seasons.html"))
+	mux.HandleFunc("/ajax/season/episodes/s1", count("s1", serveFixture(t, "episodes_s1.html")))
+	mux.HandleFunc("/ajax/season/episodes/s2", count("s2", serveFixture(t, "episodes_s2.html")))
+	mux.HandleFunc("/ajax/season/episodes/s3", count("s3", serveFixture(t, "episodes_s3.html")))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	ctx := context.Background()
+	seasons, err := s.GetSeasons(ctx, "tv/free-dark-hd-39490")
+	if err != nil {
+		t.Fatalf("GetSeasons() error = %v", err)
+	}
+	if len(seasons) != 3 || len(hits) != 0 {
+		t.Fatalf("GetSeasons() = %+v, fetched episodes %v; want 3 seasons and no episode fetches", seasons, hits)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.GetEpisodes(ctx, seasons[1].ID); err != nil {
+			t.Fatalf("GetEpisodes(season 2) error = %v", err)
+		}
+	}
+	if !reflect.DeepEqual(hits, map[string]int{"s2": 1}) {
+		t.Errorf("episode fetches = %v, want season 2 once", hits)
+	}
+
+	// Absolute numbering needs the earlier seasons' episode counts
+	s.numbering = providers.EpisodeNumberingAbsolute
+	third, err := s.GetEpisodes(ctx, seasons[2].ID)
+	if err != nil {
+		t.Fatalf("GetEpisodes(season 3) error = %v", err)
+	}
+	if len(third) != 2 || third[0].Number != 5 || third[0].SeasonNumber != 1 {
+		t.Errorf("GetEpisodes(season 3) = %+v, want absolute numbers from 5", third)
+	}
+	if !reflect.DeepEqual(hits, map[string]int{"s1": 1, "s2": 1, "s3": 1}) {
+		t.Errorf("episode fetches = %v, want each season once", hits)
+	}
+}
+
+func TestSearchQualityBadge(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/release", serveFixture(t, "search.html"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	results, err := s.Search(context.Background(), "release")
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Search() returned %d results, want 2", len(results))
+	}
+	if results[0].SourceQuality != "CAM" {
+		t.Errorf("results[0].SourceQuality = %q, want CAM", results[0].SourceQuality)
+	}
+	if results[1].SourceQuality != "" {
+		t.Errorf("results[1].SourceQuality = %q, want empty", results[1].SourceQuality)
+	}
+}
+
+func TestFetchEmbedLinksRetriesParseFailure(t *testing.T) {
+	orig := sourcesRetryDelay
+	sourcesRetryDelay = 0
+	defer func() { sourcesRetryDelay = orig }()
+
+	tests := []struct {
+		name      string
+		responses []string
+		wantLink  string
+		wantErr   string
+		wantHits  int
+	}{
+		{
+			name:      "empty body then JSON",
+			responses: []string{"", `{"link":"https://megacloud.tv/embed/abc"}`},
+			wantLink:  "https://megacloud.tv/embed/abc",
+			wantHits:  2,
+		},
+		{
+			name:      "first response parses",
+			responses: []string{`{"link":"https://megacloud.tv/embed/abc"}`},
+			wantLink:  "https://megacloud.tv/embed/abc",
+			wantHits:  1,
+		},
+		{
+			name:      "HTML twice",
+			responses: []string{"<html>oops</html>", "<html>" + strings.Repeat("x", 300) + "</html>"},
+			wantErr:   "<html>" + strings.Repeat("x", 194) + "...",
+			wantHits:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/ajax/episode/sources/42" {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = fmt.Fprint(w, tt.responses[min(hits, len(tt.responses)-1)])
+				hits++
+			}))
+			defer srv.Close()
+
+			s := New(config.ProviderSettings{})
+			s.baseURL = srv.URL
+			s.Client = srv.Client()
+
+			links, err := s.fetchEmbedLinks(context.Background(), "42")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("fetchEmbedLinks() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("fetchEmbedLinks() error = %v", err)
+			}
+			var link string
+			if len(links) > 0 {
+				link = links[0]
+			}
+			if link != tt.wantLink {
+				t.Errorf("fetchEmbedLinks() = %q, want %q", links, tt.wantLink)
+			}
+			if hits != tt.wantHits {
+				t.Errorf("endpoint hit %d times, want %d", hits, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestParseEmbedLinks(t *testing.T) {
+	tests := map[string][]string{
+		`{"link":"https://a.example/e/1"}`:                                                   {"https://a.example/e/1"},
+		`{"sources":[{"link":"https://a.example/e/1"},{"link":"https://b.example/e/2"}]}`:    {"https://a.example/e/1", "https://b.example/e/2"},
+		`{"sources":["https://a.example/e/1",{"url":"https://b.example/e/2"},{"type":"x"}]}`: {"https://a.example/e/1", "https://b.example/e/2"},
+		`{"link":"","sources":[]}`:                                                           nil,
+	}
+
+	for body, want := range tests {
+		got, err := parseEmbedLinks([]byte(body))
+		if err != nil {
+			t.Errorf("parseEmbedLinks(%s) error = %v", body, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("parseEmbedLinks(%s) = %v, want %v", body, got, want)
+		}
+	}
+}
+
+func TestMergeSources(t *testing.T) {
+	dst := &types.VideoSources{
+		Sources:   []types.Source{{URL: "https://cdn/a.m3u8"}},
+		Subtitles: []types.Subtitle{{URL: "https://cdn/en.vtt", Lang: "English"}},
+	}
+	mergeSources(dst, &types.VideoSources{
+		Sources:   []types.Source{{URL: "https://cdn/a.m3u8"}, {URL: "https://cdn/b.m3u8"}},
+		Subtitles: []types.Subtitle{{URL: "https://cdn/en.vtt", Lang: "English"}, {URL: "https://cdn/fr.vtt", Lang: "French"}},
+	})
+
+	if len(dst.Sources) != 2 || dst.Sources[1].URL != "https://cdn/b.m3u8" {
+		t.Errorf("merged sources = %+v", dst.Sources)
+	}
+	if len(dst.Subtitles) != 2 || dst.Subtitles[1].Lang != "French" {
+		t.Errorf("merged subtitles = %+v", dst.Subtitles)
+	}
+}
+
+func TestCollectionLabel(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/movie/free-spider-man-hd-11223", serveFixture(t, "info_movie_collection.html"))
+	mux.HandleFunc("/tv/free-dark-hd-39490", serveFixture(t, "info_tv.html"))
+	mux.HandleFunc("/ajax/season/list/39490", serveFixture(t, "seasons.html"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	ctx := context.Background()
+	details, err := s.GetMediaDetails(ctx, "movie/free-spider-man-hd-11223")
+	if err != nil {
+		t.Fatalf("GetMediaDetails() error = %v", err)
+	}
+	if details.Collection != "Spider-Man Collection" {
+		t.Errorf("Collection = %q, want %q", details.Collection, "Spider-Man Collection")
+	}
+	if details.Rating != 7.4 {
+		t.Errorf("Rating = %v, want 7.4", details.Rating)
+	}
+	if details.Year != 2002 {
+		t.Errorf("Year = %d, want 2002 from the release date", details.Year)
+	}
+
+	collection, err := s.GetCollection(ctx, "tv/free-dark-hd-39490")
+	if err != nil {
+		t.Fatalf("GetCollection() error = %v", err)
+	}
+	if collection != "" {
+		t.Errorf("GetCollection() = %q, want empty for a page without a collection", collection)
+	}
+}
+
+func TestGetInfo(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/movie/free-spider-man-hd-11223", serveFixture(t, "info_movie_collection.html"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	info, err := s.GetInfo("movie/free-spider-man-hd-11223")
+	if err != nil {
+		t.Fatalf("GetInfo() error = %v", err)
+	}
+
+	want := &types.MovieInfo{
+		ID:          "movie/free-spider-man-hd-11223",
+		Title:       "Spider-Man",
+		URL:         srv.URL + "/movie/free-spider-man-hd-11223",
+		Image:       srv.URL + "/poster/spider-man.jpg",
+		Description: "Bitten by a genetically altered spider, a teenager gains spider-like powers.",
+		Type:        "movie",
+		ReleaseDate: "2002-05-01",
+		Rating:      "7.4",
+		RatingFloat: 7.4,
+		Genres:      []string{"Action"},
+		Collection:  "Spider-Man Collection",
+		Episodes: []types.Episode{
+			{ID: "11223", Number: 1, Title: "Spider-Man", URL: "movie/free-spider-man-hd-11223"},
+		},
+	}
+	if !reflect.DeepEqual(info, want) {
+		t.Errorf("GetInfo() = %+v, want %+v", info, want)
+	}
+}
+
+func TestGetInfoTitleFallback(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/movie/free-spider-man-hd-11223", serveFixture(t, "info_movie_og_title.html"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	info, err := s.GetInfo("movie/free-spider-man-hd-11223")
+	if err != nil {
+		t.Fatalf("GetInfo() error = %v", err)
+	}
+	if got := info.(*types.MovieInfo).Title; got != "Spider-Man" {
+		t.Errorf("Title = %q, want %q from og:title", got, "Spider-Man")
+	}
+}
+
+func TestGetMovieEpisodeIDSkipsExtras(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/movie/free-spider-man-hd-11223", serveFixture(t, "info_movie_extras.html"))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	// The trailer is listed first, the feature second
+	got, err := s.GetMovieEpisodeID(context.Background(), "movie/free-spider-man-hd-11223")
+	if err != nil {
+		t.Fatalf("GetMovieEpisodeID() error = %v", err)
+	}
+	if want := "11223|movie/free-spider-man-hd-11223"; got != want {
+		t.Errorf("GetMovieEpisodeID() = %q, want %q", got, want)
+	}
+}
+
+func TestMainFeature(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		episodes []types.Episode
+		want     string
+	}{
+		{"single entry", "Dune", []types.Episode{{ID: "1", Title: "Dune"}}, "1"},
+		{"entry named after the movie", "Dune", []types.Episode{{ID: "1", Title: "Dune - Clip"}, {ID: "2", Title: "Featurette"}, {ID: "3", Title: "Dune"}}, "3"},
+		{"first entry that isn't an extra", "Dune", []types.Episode{{ID: "1", Title: "Teaser Trailer"}, {ID: "2", Title: "Full Movie"}}, "2"},
+		{"only extras", "Dune", []types.Episode{{ID: "1", Title: "Trailer"}, {ID: "2", Title: "Trailer 2"}}, "1"},
+		{"movie named like an extra", "The Interview", []types.Episode{{ID: "1", Title: "Trailer"}, {ID: "2", Title: "The Interview"}}, "2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mainFeature(tt.episodes, tt.title); got.ID != tt.want {
+				t.Errorf("mainFeature() = %q, want %q", got.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeMirrors(t *testing.T) {
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/home" {
+			t.Errorf("probed %s, want /home", r.URL.Path)
+		}
+		_, _ = w.Write([]byte("<html></html>"))
+	}))
+	defer up.Close()
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer blocked.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	results := probeMirrors(context.Background(), http.DefaultClient, []string{up.URL + "/", blocked.URL, down.URL, "://bad"})
+	if len(results) != 4 {
+		t.Fatalf("ProbeMirrors() returned %d results, want 4", len(results))
+	}
+
+	if !results[0].Reachable() || results[0].URL != up.URL || results[0].Latency <= 0 {
+		t.Errorf("results[0] = %+v, want reachable %s with latency", results[0], up.URL)
+	}
+	if results[1].Reachable() || results[1].StatusCode != http.StatusForbidden {
+		t.Errorf("results[1] = %+v, want unreachable with 403", results[1])
+	}
+	if results[2].Reachable() || results[2].Err == nil {
+		t.Errorf("results[2] = %+v, want a connection error", results[2])
+	}
+	if results[3].Err == nil {
+		t.Errorf("results[3] = %+v, want an invalid URL error", results[3])
+	}
+}
+
+func TestGetInfoConcurrentCallersShareFetch(t *testing.T) {
+	body, err := os.ReadFile("testdata/info_movie_collection.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	hits := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/movie/free-spider-man-hd-11223", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		// Keep the fetch open long enough for every caller to join it
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write(body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	// Pasted URLs and info paths share the same canonical ID
+	ids := []string{
+		"movie/free-spider-man-hd-11223",
+		srv.URL + "/movie/free-spider-man-hd-11223",
+		"/watch-movie/free-spider-man-hd-11223.5432876",
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 9; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			info, err := s.GetInfo(id)
+			if err != nil {
+				t.Errorf("GetInfo(%q) error = %v", id, err)
+				return
+			}
+			if got := info.(*types.MovieInfo).Title; got != "Spider-Man" {
+				t.Errorf("GetInfo(%q) title = %q", id, got)
+			}
+		}(ids[i%len(ids)])
+	}
+	wg.Wait()
+
+	if hits != 1 {
+		t.Errorf("upstream info requests = %d, want 1", hits)
+	}
+}
+
+func TestGetInfoContextCancelled(t *testing.T) {
+	body, err := os.ReadFile("testdata/info_movie_collection.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	// A second caller joins the same fetch and still gets its result after
+	// the first one gives up
+	shared := make(chan error, 1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_, err := s.GetInfo("movie/free-spider-man-hd-11223")
+		shared <- err
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = s.GetInfoContext(ctx, "movie/free-spider-man-hd-11223")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GetInfoContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetInfoContext() returned after %v, want it aborted with ctx", elapsed)
+	}
+
+	close(release)
+	if err := <-shared; err != nil {
+		t.Errorf("shared GetInfo() error = %v", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("upstream info requests = %d, want 1", got)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	var mu sync.Mutex
+	hits := 0
+	body, err := os.ReadFile("testdata/info_movie_collection.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/movie/free-spider-man-hd-11223", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		_, _ = w.Write(body)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.GetInfo("movie/free-spider-man-hd-11223"); err != nil {
+			t.Fatalf("GetInfo() error = %v", err)
+		}
+	}
+	if hits != 1 {
+		t.Fatalf("upstream requests with caching = %d, want 1", hits)
+	}
+
+	// A cached entry from before the switch is ignored too
+	s.SetCacheEnabled(false)
+	for i := 0; i < 2; i++ {
+		if _, err := s.GetInfo("movie/free-spider-man-hd-11223"); err != nil {
+			t.Fatalf("GetInfo() error = %v", err)
+		}
+	}
+	if hits != 3 {
+		t.Errorf("upstream requests without caching = %d, want 3", hits)
+	}
+}
+
+func TestGetInfoHungSite(t *testing.T) {
+	body, err := os.ReadFile("testdata/info_movie_collection.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hung atomic.Bool
+	hung.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hung.Load() {
+			// Never answer; the request only ends when the client gives up
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	s := New(config.ProviderSettings{})
+	s.baseURL = srv.URL
+	s.Client = srv.Client()
+	s.infoTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	if _, err := s.GetInfo("movie/free-spider-man-hd-11223"); err == nil {
+		t.Fatal("GetInfo() succeeded against a site that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("GetInfo() returned after %v, want it bounded by infoTimeout", elapsed)
+	}
+
+	// The timed out fetch doesn't keep later callers waiting on it
+	hung.Store(false)
+	if _, err := s.GetInfo("movie/free-spider-man-hd-11223"); err != nil {
+		t.Errorf("GetInfo() after the site recovered error = %v", err)
+	}
+}
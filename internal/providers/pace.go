@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// PacedTransport spaces out requests to a provider. Each request starts at
+// least Delay after the previous one, plus up to half of Delay of random
+// jitter, so concurrent fetches don't hit the site in bursts.
+type PacedTransport struct {
+	Base  http.RoundTripper
+	Delay time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewPacedTransport wraps base with request pacing. A zero delay disables pacing.
+func NewPacedTransport(base http.RoundTripper, delay time.Duration) *PacedTransport {
+	return &PacedTransport{Base: base, Delay: delay}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *PacedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if err := t.wait(req.Context()); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	return base.RoundTrip(req)
+}
+
+// wait reserves the next request slot and sleeps until it starts
+func (t *PacedTransport) wait(ctx context.Context) error {
+	if t.Delay <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	start := t.next
+	if start.Before(now) {
+		start = now
+	}
+	t.next = start.Add(t.Delay + jitter(t.Delay/2))
+	t.mu.Unlock()
+
+	d := time.Until(start)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// jitter returns a random duration in [0, max)
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	return rand.N(max)
+}
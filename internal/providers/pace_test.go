@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPacedTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	get := func(client *http.Client, ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	t.Run("spaces out concurrent requests", func(t *testing.T) {
+		delay := 40 * time.Millisecond
+		client := &http.Client{Transport: NewPacedTransport(nil, delay)}
+
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, get(client, context.Background()))
+			}()
+		}
+		wg.Wait()
+
+		// The first request goes out straight away, the other two wait at least one delay each
+		assert.GreaterOrEqual(t, time.Since(start), 2*delay)
+	})
+
+	t.Run("zero delay does not wait", func(t *testing.T) {
+		client := &http.Client{Transport: NewPacedTransport(nil, 0)}
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			require.NoError(t, get(client, context.Background()))
+		}
+		assert.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("waiting stops when the context is cancelled", func(t *testing.T) {
+		client := &http.Client{Transport: NewPacedTransport(nil, time.Hour)}
+		require.NoError(t, get(client, context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, get(client, ctx), context.DeadlineExceeded)
+	})
+}
@@ -2,6 +2,10 @@ package providers
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -42,6 +46,51 @@ const (
 	MediaTypeAll          MediaType = "all"            // Supports all types
 )
 
+// mediaTypeAliases maps the labels sites and configs use to canonical types
+var mediaTypeAliases = map[string]MediaType{
+	"anime":          MediaTypeAnime,
+	"movie":          MediaTypeMovie,
+	"movies":         MediaTypeMovie,
+	"film":           MediaTypeMovie,
+	"tv":             MediaTypeTV,
+	"tv series":      MediaTypeTV,
+	"tv show":        MediaTypeTV,
+	"series":         MediaTypeTV,
+	"show":           MediaTypeTV,
+	"movie_tv":       MediaTypeMovieTV,
+	"anime_movie_tv": MediaTypeAnimeMovieTV,
+	"manga":          MediaTypeManga,
+	"all":            MediaTypeAll,
+}
+
+// ParseMediaType normalizes a media type label such as "TV Series", "tv" or
+// "Film" to its canonical MediaType
+func ParseMediaType(s string) (MediaType, error) {
+	key := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if mediaType, ok := mediaTypeAliases[key]; ok {
+		return mediaType, nil
+	}
+	return "", fmt.Errorf("unknown media type %q", s)
+}
+
+// ParseRating parses a 0-10 rating such as "7.5" or "IMDB: 7.5". Empty and
+// "N/A" ratings, and values outside the range, are errors.
+func ParseRating(s string) (float64, error) {
+	raw := strings.TrimSpace(s)
+	if prefix, rest, found := strings.Cut(raw, ":"); found && strings.EqualFold(strings.TrimSpace(prefix), "imdb") {
+		raw = strings.TrimSpace(rest)
+	}
+	if raw == "" || strings.EqualFold(raw, "n/a") {
+		return 0, fmt.Errorf("no rating in %q", s)
+	}
+
+	rating, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(rating) || rating < 0 || rating > 10 {
+		return 0, fmt.Errorf("invalid rating %q", s)
+	}
+	return rating, nil
+}
+
 // MangaProvider defines the interface for manga providers
 type MangaProvider interface {
 	Provider
@@ -59,25 +108,41 @@ type Media struct {
 	Rating        float64   `json:"rating"`
 	Genres        []string  `json:"genres"`
 	TotalEpisodes int       `json:"total_episodes"`
-	Status        string    `json:"status"` // "Ongoing", "Completed", etc.
+	Status        string    `json:"status"`                   // "Ongoing", "Completed", etc.
+	SourceQuality string    `json:"source_quality,omitempty"` // Release badge such as "HD" or "CAM", if the site shows one
+	Languages     []string  `json:"languages,omitempty"`      // Variants on offer, "sub" and/or "dub"; empty when the site doesn't say
 }
 
 // MediaDetails provides extended information about a media item
 type MediaDetails struct {
 	Media
-	Seasons   []Season `json:"seasons"`
-	Cast      []string `json:"cast"`
-	Studio    string   `json:"studio"`   // For anime
-	Director  string   `json:"director"` // For movies
-	AniListID int      `json:"anilist_id,omitempty"`
-	IMDBID    string   `json:"imdb_id,omitempty"`
+	Seasons    []Season `json:"seasons"`
+	Cast       []string `json:"cast"`
+	Studio     string   `json:"studio"`   // For anime
+	Director   string   `json:"director"` // For movies
+	AniListID  int      `json:"anilist_id,omitempty"`
+	IMDBID     string   `json:"imdb_id,omitempty"`
+	Collection string   `json:"collection,omitempty"` // Franchise or collection, e.g. "Spider-Man Collection"; empty when not applicable
 }
 
 // Season represents a season of a TV show
 type Season struct {
-	ID     string `json:"id"`
-	Number int    `json:"number"`
-	Title  string `json:"title"`
+	ID        string `json:"id"`
+	Number    int    `json:"number"`
+	Title     string `json:"title"`
+	PosterURL string `json:"poster_url,omitempty"` // Season artwork, or the show's poster when there is none
+}
+
+// SeasonSpecials is the season number of a show's specials, kept apart from
+// the regular seasons
+const SeasonSpecials = 0
+
+// SeasonTitle returns the display title for a season number
+func SeasonTitle(number int) string {
+	if number == SeasonSpecials {
+		return "Specials"
+	}
+	return fmt.Sprintf("Season %d", number)
 }
 
 // Episode represents a single episode or movie
@@ -85,6 +150,7 @@ type Episode struct {
 	ID           string        `json:"id"`
 	Number       int           `json:"number"`
 	Season       int           `json:"season"`
+	SeasonNumber int           `json:"season_number,omitempty"` // Original per-season number when Number is absolute
 	Title        string        `json:"title"`
 	Synopsis     string        `json:"synopsis"`
 	ThumbnailURL string        `json:"thumbnail_url"`
@@ -92,6 +158,36 @@ type Episode struct {
 	ReleaseDate  time.Time     `json:"release_date"`
 }
 
+// EpisodeNumbering controls how episodes of multi-season shows are numbered
+type EpisodeNumbering string
+
+const (
+	EpisodeNumberingSeason   EpisodeNumbering = "season"   // Numbering restarts every season
+	EpisodeNumberingAbsolute EpisodeNumbering = "absolute" // Numbering continues across seasons
+)
+
+// ParseEpisodeNumbering parses an episode numbering mode, defaulting to per-season numbering
+func ParseEpisodeNumbering(s string) EpisodeNumbering {
+	if strings.EqualFold(s, string(EpisodeNumberingAbsolute)) {
+		return EpisodeNumberingAbsolute
+	}
+	return EpisodeNumberingSeason
+}
+
+// ApplyEpisodeNumbering renumbers episodes according to mode.
+// offset is the number of episodes in all earlier seasons. The original
+// per-season number is always kept in SeasonNumber.
+func ApplyEpisodeNumbering(episodes []Episode, offset int, mode EpisodeNumbering) {
+	for i := range episodes {
+		if episodes[i].SeasonNumber == 0 {
+			episodes[i].SeasonNumber = episodes[i].Number
+		}
+		if mode == EpisodeNumberingAbsolute {
+			episodes[i].Number = offset + i + 1
+		}
+	}
+}
+
 // StreamURL contains streaming information
 type StreamURL struct {
 	URL         string            `json:"url"`
@@ -158,6 +254,7 @@ type ProviderStatus struct {
 	Status       string // e.g., "Online", "Offline", "Error: ...", "Checking..."
 	LastCheck    time.Time
 	LastResult   *HealthCheckResult
+	Disabled     bool // Switched off for this session with Registry.Disable
 }
 
 // ParseQuality parses a quality string into a Quality type
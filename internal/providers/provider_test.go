@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMediaType(t *testing.T) {
+	tests := []struct {
+		input string
+		want  MediaType
+	}{
+		{"TV Series", MediaTypeTV},
+		{"tv", MediaTypeTV},
+		{" Series ", MediaTypeTV},
+		{"tv  show", MediaTypeTV},
+		{"Movie", MediaTypeMovie},
+		{"film", MediaTypeMovie},
+		{"anime", MediaTypeAnime},
+		{"movie_tv", MediaTypeMovieTV},
+		{"Manga", MediaTypeManga},
+	}
+	for _, tt := range tests {
+		got, err := ParseMediaType(tt.input)
+		require.NoError(t, err, tt.input)
+		assert.Equal(t, tt.want, got, tt.input)
+	}
+
+	_, err := ParseMediaType("podcast")
+	assert.Error(t, err)
+	_, err = ParseMediaType("")
+	assert.Error(t, err)
+}
+
+func TestParseRating(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"7.5", 7.5, false},
+		{"IMDB: 8.8", 8.8, false},
+		{" 10 ", 10, false},
+		{"0", 0, false},
+		{"", 0, true},
+		{"N/A", 0, true},
+		{"imdb: n/a", 0, true},
+		{"11.2", 0, true},
+		{"-1", 0, true},
+		{"NaN", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseRating(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package providers
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/justchokingaround/greg/internal/config"
+)
+
+var (
+	// resolutionPattern matches labels like "1080p", "1080p60" or "720p HDR"
+	resolutionPattern = regexp.MustCompile(`(\d{3,4})[pi]`)
+	// dimensionsPattern matches labels like "1920x1080"
+	dimensionsPattern = regexp.MustCompile(`\d{3,4}x(\d{3,4})`)
+)
+
+// NormalizeQuality maps a provider quality label to a Quality for matching.
+// Frame rate and HDR annotations are stripped ("1080p60" and "1080p HDR"
+// become 1080p) and common aliases such as "4K" and "HD" are resolved.
+// Labels that cannot be interpreted are returned trimmed and lowercased.
+// The original label should still be used for display.
+func NormalizeQuality(label string) Quality {
+	l := strings.ToLower(strings.TrimSpace(label))
+
+	switch l {
+	case "auto", "default", "adaptive":
+		return QualityAuto
+	case "4k", "uhd", "4k uhd":
+		return Quality4K
+	case "2k", "qhd":
+		return Quality1440p
+	case "fhd", "full hd", "fullhd":
+		return Quality1080p
+	case "hd":
+		return Quality720p
+	case "sd":
+		return Quality480p
+	}
+
+	if m := dimensionsPattern.FindStringSubmatch(l); m != nil {
+		return Quality(m[1] + "p")
+	}
+	if m := resolutionPattern.FindStringSubmatch(l); m != nil {
+		return Quality(m[1] + "p")
+	}
+	if strings.HasPrefix(l, "4k") {
+		return Quality4K
+	}
+
+	return Quality(l)
+}
+
+// MatchesQuality reports whether a provider quality label refers to target
+func MatchesQuality(label string, target Quality) bool {
+	return NormalizeQuality(label) == NormalizeQuality(string(target))
+}
+
+// SelectQuality picks the quality to request from providerName. An explicit
+// request wins, then the provider's default_quality, then player.quality.
+// Unparseable settings are skipped and 1080p is used as a last resort.
+func SelectQuality(requested Quality, providerName string, cfg *config.Config) Quality {
+	if requested != "" {
+		return requested
+	}
+	if cfg == nil {
+		return Quality1080p
+	}
+
+	if settings, ok := cfg.Providers.Settings(providerName); ok && settings.DefaultQuality != "" {
+		if q, err := ParseQuality(settings.DefaultQuality); err == nil {
+			return q
+		}
+	}
+	if q, err := ParseQuality(cfg.Player.Quality); err == nil {
+		return q
+	}
+	return Quality1080p
+}
+
+// standardQualities are the fixed qualities from lowest to highest
+var standardQualities = []Quality{Quality360p, Quality480p, Quality720p, Quality1080p, Quality1440p, Quality4K}
+
+// QualityLadder is the order in which qualities are tried when picking a
+// source. The requested quality always comes first.
+type QualityLadder []Quality
+
+// DefaultQualityLadder returns the fallback order for requested. Movies
+// prefer going up in quality before going down; TV and anime prefer the
+// requested quality or one step down, then higher, then lower. In auto mode
+// the highest quality wins.
+func DefaultQualityLadder(requested Quality, mediaType MediaType) QualityLadder {
+	requested = NormalizeQuality(string(requested))
+	pos := -1
+	for i, q := range standardQualities {
+		if q == requested {
+			pos = i
+		}
+	}
+
+	var ladder QualityLadder
+	if pos == -1 {
+		// auto or an unknown label: best first
+		if requested != QualityAuto && requested != "" {
+			ladder = append(ladder, requested)
+		}
+		for i := len(standardQualities) - 1; i >= 0; i-- {
+			ladder = append(ladder, standardQualities[i])
+		}
+		return ladder
+	}
+
+	ladder = append(ladder, requested)
+	lower := pos - 1
+	if mediaType != MediaTypeMovie && lower >= 0 {
+		ladder = append(ladder, standardQualities[lower])
+		lower--
+	}
+	ladder = append(ladder, standardQualities[pos+1:]...)
+	for ; lower >= 0; lower-- {
+		ladder = append(ladder, standardQualities[lower])
+	}
+	return ladder
+}
+
+// configuredLadders are the fallback orders set from player.quality_ladder,
+// by media type
+var (
+	laddersMu         sync.RWMutex
+	configuredLadders map[MediaType]QualityLadder
+)
+
+// SetQualityLadders replaces the configured fallback orders. Each ladder
+// lists the qualities tried, in order, after the requested one.
+func SetQualityLadders(ladders map[MediaType]QualityLadder) {
+	laddersMu.Lock()
+	defer laddersMu.Unlock()
+	configuredLadders = ladders
+}
+
+// ParseQualityLadders reads player.quality_ladder. Keys are media types
+// ("movie", "tv", "anime"); unknown qualities are skipped and returned as
+// an error alongside the usable ladders.
+func ParseQualityLadders(cfg map[string][]string) (map[MediaType]QualityLadder, error) {
+	ladders := make(map[MediaType]QualityLadder, len(cfg))
+	var invalid []string
+	for mediaType, qualities := range cfg {
+		var ladder QualityLadder
+		for _, label := range qualities {
+			q, err := ParseQuality(strings.ToLower(strings.TrimSpace(label)))
+			if err != nil {
+				invalid = append(invalid, mediaType+": "+label)
+				continue
+			}
+			ladder = append(ladder, q)
+		}
+		if len(ladder) > 0 {
+			ladders[MediaType(strings.ToLower(mediaType))] = ladder
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return ladders, &ErrInvalidQuality{Quality: strings.Join(invalid, ", ")}
+	}
+	return ladders, nil
+}
+
+// QualityLadderFor returns the fallback order for requested: the configured
+// ladder for mediaType if there is one, otherwise DefaultQualityLadder
+func QualityLadderFor(requested Quality, mediaType MediaType) QualityLadder {
+	laddersMu.RLock()
+	configured, ok := configuredLadders[mediaType]
+	laddersMu.RUnlock()
+	if !ok {
+		return DefaultQualityLadder(requested, mediaType)
+	}
+
+	ladder := QualityLadder{}
+	if requested != "" && requested != QualityAuto {
+		ladder = append(ladder, NormalizeQuality(string(requested)))
+	}
+	return append(ladder, configured...)
+}
+
+// SelectSourceQuality returns the index of the source label to play for
+// requested, walking the quality ladder for mediaType. Sources whose labels
+// don't match any rung are only used when nothing else does, in which case
+// the first source is returned.
+func SelectSourceQuality(labels []string, requested Quality, mediaType MediaType) int {
+	for _, q := range QualityLadderFor(requested, mediaType) {
+		for i, label := range labels {
+			if MatchesQuality(label, q) {
+				return i
+			}
+		}
+	}
+	return 0
+}
+
+// IsMasterPlaylist reports whether a source looks like an HLS master playlist,
+// i.e. an m3u8 that lists several variants for the player to choose from.
+// Sources labelled "auto" and URLs named master.m3u8 or playlist.m3u8 are
+// treated as master playlists.
+func IsMasterPlaylist(sourceURL, label string) bool {
+	u, err := url.Parse(sourceURL)
+	if err != nil {
+		return false
+	}
+
+	name := strings.ToLower(path.Base(u.Path))
+	if !strings.HasSuffix(name, ".m3u8") {
+		return false
+	}
+
+	if NormalizeQuality(label) == QualityAuto {
+		return true
+	}
+	return strings.Contains(name, "master") || name == "playlist.m3u8"
+}
@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeQuality(t *testing.T) {
+	tests := []struct {
+		label string
+		want  Quality
+	}{
+		{"1080p", Quality1080p},
+		{"1080p60", Quality1080p},
+		{"1080p HDR", Quality1080p},
+		{"720p 60fps", Quality720p},
+		{"2160p", Quality4K},
+		{"4K", Quality4K},
+		{"4K HDR", Quality4K},
+		{"HD", Quality720p},
+		{"1920x1080", Quality1080p},
+		{"auto", QualityAuto},
+		{" Auto ", QualityAuto},
+		{"unknown", Quality("unknown")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeQuality(tt.label))
+		})
+	}
+}
+
+func TestMatchesQuality(t *testing.T) {
+	assert.True(t, MatchesQuality("1080p60", Quality1080p))
+	assert.True(t, MatchesQuality("2160p", Quality4K))
+	assert.True(t, MatchesQuality("4K", Quality4K))
+	assert.True(t, MatchesQuality("HD", Quality720p))
+	assert.True(t, MatchesQuality("AUTO", QualityAuto))
+	assert.False(t, MatchesQuality("720p", Quality1080p))
+	assert.False(t, MatchesQuality("1080p", QualityAuto))
+}
+
+func TestIsMasterPlaylist(t *testing.T) {
+	assert.True(t, IsMasterPlaylist("https://cdn.example.com/hls/master.m3u8", "1080p"))
+	assert.True(t, IsMasterPlaylist("https://cdn.example.com/hls/playlist.m3u8?token=abc", ""))
+	assert.True(t, IsMasterPlaylist("https://cdn.example.com/hls/index.m3u8", "auto"))
+	assert.False(t, IsMasterPlaylist("https://cdn.example.com/hls/index-v1.m3u8", "1080p"))
+	assert.False(t, IsMasterPlaylist("https://cdn.example.com/video.mp4", "auto"))
+}
+
+func TestSelectQuality(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Player.Quality = "1080p"
+	cfg.Providers.HiAnime.DefaultQuality = "720p"
+	cfg.Providers.FlixHQ.DefaultQuality = "bogus"
+
+	t.Run("explicit request wins", func(t *testing.T) {
+		assert.Equal(t, Quality480p, SelectQuality(Quality480p, "hianime", cfg))
+	})
+
+	t.Run("provider default overrides global", func(t *testing.T) {
+		assert.Equal(t, Quality720p, SelectQuality("", "hianime", cfg))
+	})
+
+	t.Run("global used without provider default", func(t *testing.T) {
+		assert.Equal(t, Quality1080p, SelectQuality("", "sflix", cfg))
+		assert.Equal(t, Quality1080p, SelectQuality("", "unknown", cfg))
+	})
+
+	t.Run("invalid provider default falls back to global", func(t *testing.T) {
+		assert.Equal(t, Quality1080p, SelectQuality("", "flixhq", cfg))
+	})
+
+	t.Run("nil config", func(t *testing.T) {
+		assert.Equal(t, Quality1080p, SelectQuality("", "sflix", nil))
+	})
+}
+
+func TestDefaultQualityLadder(t *testing.T) {
+	// Movies try higher qualities before settling for less
+	assert.Equal(t, QualityLadder{Quality720p, Quality1080p, Quality1440p, Quality4K, Quality480p, Quality360p},
+		DefaultQualityLadder(Quality720p, MediaTypeMovie))
+
+	// Shows try one step down before going up
+	assert.Equal(t, QualityLadder{Quality720p, Quality480p, Quality1080p, Quality1440p, Quality4K, Quality360p},
+		DefaultQualityLadder(Quality720p, MediaTypeTV))
+	assert.Equal(t, QualityLadder{Quality360p, Quality480p, Quality720p, Quality1080p, Quality1440p, Quality4K},
+		DefaultQualityLadder(Quality360p, MediaTypeAnime))
+
+	// Auto picks the best available
+	assert.Equal(t, QualityLadder{Quality4K, Quality1440p, Quality1080p, Quality720p, Quality480p, Quality360p},
+		DefaultQualityLadder(QualityAuto, MediaTypeTV))
+}
+
+func TestParseQualityLadders(t *testing.T) {
+	ladders, err := ParseQualityLadders(map[string][]string{
+		"movie": {"4K", "1080p"},
+		"TV":    {"720p", "bogus", "480p"},
+		"anime": {"nope"},
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "TV: bogus")
+	assert.Contains(t, err.Error(), "anime: nope")
+	assert.Equal(t, map[MediaType]QualityLadder{
+		MediaTypeMovie: {Quality4K, Quality1080p},
+		MediaTypeTV:    {Quality720p, Quality480p},
+	}, ladders)
+
+	ladders, err = ParseQualityLadders(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, ladders)
+}
+
+func TestSelectSourceQuality(t *testing.T) {
+	tests := []struct {
+		name      string
+		labels    []string
+		requested Quality
+		mediaType MediaType
+		want      int
+	}{
+		{"exact match", []string{"480p", "1080p", "720p"}, Quality720p, MediaTypeTV, 2},
+		{"annotated label", []string{"480p", "1080p60"}, Quality1080p, MediaTypeMovie, 1},
+		{"movie steps up", []string{"480p", "1080p"}, Quality720p, MediaTypeMovie, 1},
+		{"tv steps down", []string{"480p", "1080p"}, Quality720p, MediaTypeTV, 0},
+		{"tv steps up when nothing is one step down", []string{"360p", "1080p"}, Quality720p, MediaTypeTV, 1},
+		{"movie steps down when nothing is higher", []string{"360p", "480p"}, Quality720p, MediaTypeMovie, 1},
+		{"auto takes the best", []string{"480p", "4K", "1080p"}, QualityAuto, MediaTypeAnime, 1},
+		{"unknown labels fall back to the first source", []string{"server a", "server b"}, Quality1080p, MediaTypeMovie, 0},
+		{"known labels beat unknown ones", []string{"server a", "360p"}, Quality1080p, MediaTypeMovie, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SelectSourceQuality(tt.labels, tt.requested, tt.mediaType))
+		})
+	}
+}
+
+func TestSelectSourceQualityConfiguredLadder(t *testing.T) {
+	SetQualityLadders(map[MediaType]QualityLadder{MediaTypeTV: {Quality480p, Quality360p}})
+	defer SetQualityLadders(nil)
+
+	labels := []string{"1080p", "360p", "480p"}
+
+	// The requested quality still wins when available
+	assert.Equal(t, 0, SelectSourceQuality(labels, Quality1080p, MediaTypeTV))
+	// Otherwise the configured order is followed, e.g. to save bandwidth
+	assert.Equal(t, 2, SelectSourceQuality(labels, Quality720p, MediaTypeTV))
+	assert.Equal(t, QualityLadder{Quality720p, Quality480p, Quality360p}, QualityLadderFor(Quality720p, MediaTypeTV))
+
+	// Media types without a configured ladder use the default
+	assert.Equal(t, 0, SelectSourceQuality(labels, Quality720p, MediaTypeMovie))
+}
@@ -0,0 +1,175 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// QuickPlayMinScore is the match score a search result needs for QuickPlay
+// to pick it. Lower scores usually mean a different title that shares some
+// words with the query.
+const QuickPlayMinScore = 0.8
+
+// MovieEpisodeResolver is an interface for providers whose movies are played
+// through an episode ID that has to be looked up, rather than through their
+// single season
+type MovieEpisodeResolver interface {
+	GetMovieEpisodeID(ctx context.Context, mediaID string) (string, error)
+}
+
+// QuickPlayResult is what QuickPlay picked and the stream to play
+type QuickPlayResult struct {
+	Provider string
+	Media    Media
+	Score    float64
+	Episode  Episode
+	Stream   *StreamURL
+}
+
+// quickPlayMatch is a search result scored against the query
+type quickPlayMatch struct {
+	provider string
+	media    Media
+	score    float64
+	exact    bool
+}
+
+// QuickPlay searches every provider serving mediaType for query, picks the
+// result whose title matches it best and resolves a stream for it: the
+// movie itself, or the latest episode of a show. If that fails, the best
+// confident match from each other provider is tried in turn, and an
+// *AggregateError is returned when none of them plays. It returns an error
+// wrapping ErrNoConfidentMatch rather than guessing when no result scores at
+// least QuickPlayMinScore.
+func (r *Registry) QuickPlay(ctx context.Context, query string, mediaType MediaType) (*QuickPlayResult, error) {
+	results, searchErr := r.SearchAll(ctx, mediaType, query)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	matches := r.rankMatches(query, mediaType, results)
+	if len(matches) == 0 {
+		if searchErr != nil {
+			return nil, searchErr
+		}
+		return nil, fmt.Errorf("%w for %q: no results", ErrNoConfidentMatch, query)
+	}
+	if best := matches[0]; best.score < QuickPlayMinScore {
+		return nil, fmt.Errorf("%w for %q: closest was %q on %s (score %.2f)",
+			ErrNoConfidentMatch, query, best.media.Title, best.provider, best.score)
+	}
+
+	failed := &AggregateError{}
+	for _, match := range matches {
+		if match.score < QuickPlayMinScore {
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		provider, err := r.Get(match.provider)
+		if err != nil {
+			return nil, err
+		}
+		traced := withMirrorTrace(ctx)
+		result, err := playMatch(traced, provider, match)
+		if err != nil {
+			failed.Add(traced, provider, err)
+			continue
+		}
+		return result, nil
+	}
+	return nil, failed
+}
+
+// playMatch resolves the episode QuickPlay plays for match and its stream
+func playMatch(ctx context.Context, provider Provider, match quickPlayMatch) (*QuickPlayResult, error) {
+	episode, err := defaultEpisode(ctx, provider, match.media)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := provider.GetStreamURL(ctx, episode.ID, QualityAuto)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream for %s: %w", match.media.Title, err)
+	}
+
+	return &QuickPlayResult{
+		Provider: match.provider,
+		Media:    match.media,
+		Score:    match.score,
+		Episode:  episode,
+		Stream:   stream,
+	}, nil
+}
+
+// rankMatches returns each provider's result matching query best, best
+// first. Titles spelled exactly like the query win ties, then providers in
+// name order and each provider's own ranking.
+func (r *Registry) rankMatches(query string, mediaType MediaType, results map[string][]Media) []quickPlayMatch {
+	var opts SearchOptions
+	if mediaType == MediaTypeMovie || mediaType == MediaTypeTV {
+		opts.Type = mediaType
+	}
+
+	var matches []quickPlayMatch
+	for _, p := range r.searchCandidates(mediaType, "") {
+		var (
+			best  quickPlayMatch
+			found bool
+		)
+		for _, media := range FilterMedia(results[p.Name()], opts) {
+			match := quickPlayMatch{
+				provider: p.Name(),
+				media:    media,
+				score:    MatchScore(query, media.Title),
+				exact:    strings.EqualFold(strings.TrimSpace(query), strings.TrimSpace(media.Title)),
+			}
+			if !found || match.better(best) {
+				best, found = match, true
+			}
+		}
+		if found {
+			matches = append(matches, best)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].better(matches[j])
+	})
+	return matches
+}
+
+// better reports whether m should be played rather than other
+func (m quickPlayMatch) better(other quickPlayMatch) bool {
+	return m.score > other.score || (m.score == other.score && m.exact && !other.exact)
+}
+
+// defaultEpisode returns the episode QuickPlay plays for media: the movie
+// itself, or the last episode of a show's latest season
+func defaultEpisode(ctx context.Context, p Provider, media Media) (Episode, error) {
+	if resolver, ok := p.(MovieEpisodeResolver); ok && media.Type == MediaTypeMovie {
+		id, err := resolver.GetMovieEpisodeID(ctx, media.ID)
+		if err != nil {
+			return Episode{}, fmt.Errorf("failed to get movie episode for %s: %w", media.Title, err)
+		}
+		return Episode{ID: id, Number: 1, Title: media.Title}, nil
+	}
+
+	seasons, err := sortedSeasons(ctx, p, media.ID)
+	if err != nil {
+		return Episode{}, err
+	}
+	// Specials sort first, so the last season is the latest regular one
+	for i := len(seasons) - 1; i >= 0; i-- {
+		episodes, err := p.GetEpisodes(ctx, seasons[i].ID)
+		if err != nil {
+			return Episode{}, fmt.Errorf("failed to get episodes for %s: %w", SeasonTitle(seasons[i].Number), err)
+		}
+		if len(episodes) > 0 {
+			return episodes[len(episodes)-1], nil
+		}
+	}
+	return Episode{}, fmt.Errorf("%s: %w", media.Title, ErrNoEpisodes)
+}
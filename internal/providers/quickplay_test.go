@@ -0,0 +1,92 @@
+package providers_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuickPlay(t *testing.T) {
+	t.Run("plays the latest episode of the best match", func(t *testing.T) {
+		registry := providers.NewRegistry()
+		allanime := newMock("allanime", providers.MediaTypeAnime).
+			WithSearch("frieren", providers.Media{ID: "a1", Title: "Frieren: Beyond Journey's End"})
+		hianime := newMock("hianime", providers.MediaTypeAnime).
+			WithSearch("frieren", providers.Media{ID: "h2", Title: "Frieren Specials"}, providers.Media{ID: "h1", Title: "Frieren"})
+		hianime.Seasons = map[string][]providers.Season{"h1": {{ID: "s2", Number: 2}, {ID: "s0", Number: providers.SeasonSpecials}, {ID: "s1", Number: 1}}}
+		hianime.Episodes = map[string][]providers.Episode{
+			"s1": {{ID: "e1", Number: 1}, {ID: "e28", Number: 28}},
+			"s2": {{ID: "e29", Number: 29}, {ID: "e30", Number: 30}},
+		}
+		hianime.Streams = map[string]*providers.StreamURL{"e30": {URL: "https://cdn.example/e30.m3u8"}}
+		require.NoError(t, registry.Register(allanime))
+		require.NoError(t, registry.Register(hianime))
+
+		result, err := registry.QuickPlay(context.Background(), "frieren", providers.MediaTypeAnime)
+		require.NoError(t, err)
+		assert.Equal(t, "hianime", result.Provider)
+		assert.Equal(t, "h1", result.Media.ID)
+		assert.Equal(t, 1.0, result.Score)
+		assert.Equal(t, "e30", result.Episode.ID)
+		assert.Equal(t, "https://cdn.example/e30.m3u8", result.Stream.URL)
+	})
+
+	t.Run("plays movies through their episode ID", func(t *testing.T) {
+		registry := providers.NewRegistry()
+		sflix := newMock("sflix", providers.MediaTypeMovieTV).
+			WithSearch("spider-man", providers.Media{ID: "movie/spider-man-11223", Title: "Spider-Man", Type: providers.MediaTypeMovie})
+		sflix.MovieEpisodes = map[string]string{"movie/spider-man-11223": "11223"}
+		sflix.Streams = map[string]*providers.StreamURL{"11223": {URL: "https://cdn.example/11223.m3u8"}}
+		require.NoError(t, registry.Register(sflix))
+
+		result, err := registry.QuickPlay(context.Background(), "spider-man", providers.MediaTypeMovie)
+		require.NoError(t, err)
+		assert.Equal(t, "11223", result.Episode.ID)
+		assert.Equal(t, "https://cdn.example/11223.m3u8", result.Stream.URL)
+		assert.Zero(t, sflix.Calls(methodSeasons))
+	})
+
+	t.Run("refuses to guess without a confident match", func(t *testing.T) {
+		registry := providers.NewRegistry()
+		hianime := newMock("hianime", providers.MediaTypeAnime).
+			WithSearch("frieren", providers.Media{ID: "h1", Title: "Fire Force"})
+		require.NoError(t, registry.Register(hianime))
+
+		_, err := registry.QuickPlay(context.Background(), "frieren", providers.MediaTypeAnime)
+		assert.ErrorIs(t, err, providers.ErrNoConfidentMatch)
+		assert.ErrorContains(t, err, `closest was "Fire Force"`)
+		assert.Zero(t, hianime.Calls(methodStreamURL))
+
+		_, err = registry.QuickPlay(context.Background(), "dandadan", providers.MediaTypeAnime)
+		assert.ErrorIs(t, err, providers.ErrNoConfidentMatch)
+	})
+	t.Run("falls back to the next provider when the stream fails", func(t *testing.T) {
+		registry := providers.NewRegistry()
+		flixhq := newMock("flixhq", providers.MediaTypeMovieTV).
+			WithSearch("heat", providers.Media{ID: "movie/heat-1", Title: "Heat", Type: providers.MediaTypeMovie}).
+			WithError(methodStreamURL, providers.ErrBlocked)
+		flixhq.MovieEpisodes = map[string]string{"movie/heat-1": "1"}
+		sflix := newMock("sflix", providers.MediaTypeMovieTV).
+			WithSearch("heat", providers.Media{ID: "movie/heat-2", Title: "Heat", Type: providers.MediaTypeMovie})
+		sflix.MovieEpisodes = map[string]string{"movie/heat-2": "2"}
+		sflix.Streams = map[string]*providers.StreamURL{"2": {URL: "https://cdn.example/2.m3u8"}}
+		require.NoError(t, registry.Register(flixhq))
+		require.NoError(t, registry.Register(sflix))
+
+		result, err := registry.QuickPlay(context.Background(), "heat", providers.MediaTypeMovie)
+		require.NoError(t, err)
+		assert.Equal(t, "sflix", result.Provider)
+		assert.Equal(t, "https://cdn.example/2.m3u8", result.Stream.URL)
+
+		sflix.WithError(methodStreamURL, errors.New("no servers"))
+		_, err = registry.QuickPlay(context.Background(), "heat", providers.MediaTypeMovie)
+		var aggregate *providers.AggregateError
+		require.ErrorAs(t, err, &aggregate)
+		assert.ErrorIs(t, err, providers.ErrBlocked)
+		assert.EqualError(t, err, "tried flixhq (blocked), sflix: all sources unavailable")
+	})
+}
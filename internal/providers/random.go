@@ -0,0 +1,117 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+)
+
+// randomSearchWords are common title words searched when a provider has no
+// trending or recent listing to pick from
+var randomSearchWords = []string{
+	"love", "night", "dark", "world", "man", "girl", "war", "day", "life",
+	"last", "blood", "king", "house", "star", "dream", "city", "time", "moon",
+}
+
+// randomSearchAttempts bounds how many words are tried per provider
+const randomSearchAttempts = 3
+
+// SetRand replaces the random source Random picks from, so tests can make
+// the choice deterministic
+func (r *Registry) SetRand(rng *rand.Rand) {
+	r.randMu.Lock()
+	defer r.randMu.Unlock()
+	r.rng = rng
+}
+
+// intN returns a random index below n
+func (r *Registry) intN(n int) int {
+	r.randMu.Lock()
+	defer r.randMu.Unlock()
+	return r.rng.IntN(n)
+}
+
+// Random picks a random title of mediaType for a "surprise me" pick. It
+// tries the providers serving mediaType in random order and picks from each
+// one's trending list, then its recent list, then the results of a search
+// for a random common word. Adult titles are skipped unless safe search is
+// off. It returns the title and the name of the provider it came from.
+func (r *Registry) Random(ctx context.Context, mediaType MediaType) (Media, string, error) {
+	candidates := r.searchCandidates(mediaType, "")
+	if len(candidates) == 0 {
+		return Media{}, "", fmt.Errorf("no %s providers available", mediaType)
+	}
+
+	r.randMu.Lock()
+	r.rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	r.randMu.Unlock()
+
+	var errs []error
+	for _, p := range candidates {
+		if ctx.Err() != nil {
+			return Media{}, "", ctx.Err()
+		}
+		pool, err := r.randomPool(ctx, p, mediaType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+		}
+		if len(pool) > 0 {
+			return pool[r.intN(len(pool))], p.Name(), nil
+		}
+	}
+
+	if len(errs) > 0 {
+		return Media{}, "", fmt.Errorf("no random %s title found: %w", mediaType, errors.Join(errs...))
+	}
+	return Media{}, "", fmt.Errorf("no random %s title found", mediaType)
+}
+
+// randomPool returns the titles of mediaType a provider offers to pick from
+func (r *Registry) randomPool(ctx context.Context, p Provider, mediaType MediaType) ([]Media, error) {
+	// Providers serving combined types list both movies and shows, so only
+	// narrow down when a single one was asked for
+	var opts SearchOptions
+	if mediaType == MediaTypeMovie || mediaType == MediaTypeTV {
+		opts.Type = mediaType
+	}
+
+	var lastErr error
+	for _, list := range []func(context.Context) ([]Media, error){p.GetTrending, p.GetRecent} {
+		media, err := list(ctx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if pool := r.randomCandidates(media, opts); len(pool) > 0 {
+			return pool, nil
+		}
+	}
+
+	for range randomSearchAttempts {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		word := randomSearchWords[r.intN(len(randomSearchWords))]
+		media, err := p.Search(ctx, word)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if pool := r.randomCandidates(media, opts); len(pool) > 0 {
+			return pool, nil
+		}
+	}
+	return nil, lastErr
+}
+
+// randomCandidates narrows media to the titles Random may pick
+func (r *Registry) randomCandidates(media []Media, opts SearchOptions) []Media {
+	pool := FilterMedia(media, opts)
+	if r.safeSearchEnabled() {
+		pool = FilterAdult(pool)
+	}
+	return pool
+}
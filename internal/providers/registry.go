@@ -2,14 +2,20 @@ package providers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/imagecache"
+	"github.com/justchokingaround/greg/pkg/extractors"
 )
 
 // Registry manages registered providers and their health statuses
@@ -18,8 +24,28 @@ type Registry struct {
 	providers map[string]Provider
 	byType    map[MediaType][]Provider
 	statuses  map[string]*ProviderStatus
+	cacheDir  string
+
+	// disabled holds the providers switched off for this session
+	disabled map[string]bool
+
+	// maxChecks bounds how many health checks HealthCheckAll runs at once
+	maxChecks int
+
+	// language orders search results offering the preferred variant first
+	language LanguagePreference
+
+	// safeSearch keeps adult titles out of Random's picks
+	safeSearch bool
+
+	// rng picks Random's titles; seeded from the time unless set by SetRand
+	randMu sync.Mutex
+	rng    *rand.Rand
 }
 
+// healthCheckTimeout bounds a single provider's health check
+const healthCheckTimeout = 10 * time.Second
+
 var (
 	// globalRegistry is the default provider registry
 	globalRegistry = NewRegistry()
@@ -28,9 +54,12 @@ var (
 // NewRegistry creates a new provider registry
 func NewRegistry() *Registry {
 	return &Registry{
-		providers: make(map[string]Provider),
-		byType:    make(map[MediaType][]Provider),
-		statuses:  make(map[string]*ProviderStatus),
+		providers:  make(map[string]Provider),
+		byType:     make(map[MediaType][]Provider),
+		statuses:   make(map[string]*ProviderStatus),
+		disabled:   make(map[string]bool),
+		safeSearch: true,
+		rng:        rand.New(rand.NewPCG(uint64(time.Now().UnixNano()), 0)),
 	}
 }
 
@@ -99,6 +128,7 @@ func (r *Registry) Unregister(name string) error {
 	// Remove from name map, statuses, and type map
 	delete(r.providers, name)
 	delete(r.statuses, name)
+	delete(r.disabled, name)
 	providerType := provider.Type()
 	r.byType[providerType] = removeProvider(r.byType[providerType], provider)
 
@@ -122,6 +152,46 @@ func (r *Registry) Unregister(name string) error {
 	return nil
 }
 
+// Disable takes a registered provider out of the active set for the rest
+// of the session, e.g. when it keeps failing. It is left out of GetAll,
+// GetByType, List, searches and health checks until Enable is called. Get
+// still returns it, so playback that is already under way can finish. The
+// config is not changed; use config.ProvidersConfig.SetEnabled and Save to
+// make it stick.
+func (r *Registry) Disable(name string) error {
+	return r.setDisabled(name, true)
+}
+
+// Enable puts a provider switched off with Disable back into the active set
+func (r *Registry) Enable(name string) error {
+	return r.setDisabled(name, false)
+}
+
+func (r *Registry) setDisabled(name string, disabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.providers[name]; !exists {
+		return fmt.Errorf("provider %s is not registered", name)
+	}
+	if disabled {
+		r.disabled[name] = true
+	} else {
+		delete(r.disabled, name)
+	}
+	if status, ok := r.statuses[name]; ok {
+		status.Disabled = disabled
+	}
+	return nil
+}
+
+// IsDisabled reports whether a provider was switched off with Disable
+func (r *Registry) IsDisabled(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.disabled[name]
+}
+
 // Get returns a provider by name
 func (r *Registry) Get(name string) (Provider, error) {
 	r.mu.RLock()
@@ -135,39 +205,52 @@ func (r *Registry) Get(name string) (Provider, error) {
 	return provider, nil
 }
 
-// GetByType returns all providers that support the given media type
+// GetByType returns all enabled providers that support the given media type
 func (r *Registry) GetByType(mediaType MediaType) []Provider {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	providers := r.byType[mediaType]
 	// Return a copy to prevent external modification
-	result := make([]Provider, len(providers))
-	copy(result, providers)
+	providers := r.byType[mediaType]
+	result := make([]Provider, 0, len(providers))
+	for _, provider := range providers {
+		if !r.disabled[provider.Name()] {
+			result = append(result, provider)
+		}
+	}
 	return result
 }
 
-// GetAll returns all registered providers
+// GetAll returns all enabled providers, sorted by name
 func (r *Registry) GetAll() []Provider {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	result := make([]Provider, 0, len(r.providers))
-	for _, provider := range r.providers {
-		result = append(result, provider)
+	for name, provider := range r.providers {
+		if !r.disabled[name] {
+			result = append(result, provider)
+		}
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
 	return result
 }
 
-// List returns the names of all registered providers
+// List returns the names of all enabled providers in alphabetical order
 func (r *Registry) List() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	names := make([]string, 0, len(r.providers))
 	for name := range r.providers {
-		names = append(names, name)
+		if !r.disabled[name] {
+			names = append(names, name)
+		}
 	}
+	// Map order is random; sort so pickers don't reorder between runs
+	sort.Strings(names)
 	return names
 }
 
@@ -187,6 +270,111 @@ func (r *Registry) Clear() {
 	r.providers = make(map[string]Provider)
 	r.byType = make(map[MediaType][]Provider)
 	r.statuses = make(map[string]*ProviderStatus)
+	r.disabled = make(map[string]bool)
+}
+
+// SetCacheDir sets the on-disk cache directory wiped by ClearCache
+func (r *Registry) SetCacheDir(dir string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cacheDir = dir
+}
+
+// SetMaxConcurrentChecks bounds how many health checks HealthCheckAll runs
+// at once. Zero or less means no limit.
+func (r *Registry) SetMaxConcurrentChecks(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.maxChecks = n
+}
+
+// SetLanguagePreference makes searches list the results offering pref first
+func (r *Registry) SetLanguagePreference(pref LanguagePreference) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.language = pref
+}
+
+// SetSafeSearch sets whether Random skips titles marked as adult content
+func (r *Registry) SetSafeSearch(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.safeSearch = enabled
+}
+
+// safeSearchEnabled returns the setting made by SetSafeSearch
+func (r *Registry) safeSearchEnabled() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.safeSearch
+}
+
+// languagePreference returns the preference set by SetLanguagePreference
+func (r *Registry) languagePreference() LanguagePreference {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.language
+}
+
+// cacheEntries are the entries greg creates under the cache directory that
+// ClearCache removes. The directory is user-configurable, so anything else in
+// it, including subtitles and debug dumps, is left alone.
+var cacheEntries = []string{imagecache.DirName}
+
+// ClearCache wipes the in-memory caches of all registered providers and
+// the cached files in the on-disk cache directory, if one is set
+func (r *Registry) ClearCache() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, provider := range r.providers {
+		if clearer, ok := provider.(CacheClearer); ok {
+			clearer.ClearCache()
+		}
+	}
+
+	if r.cacheDir == "" {
+		return nil
+	}
+
+	for _, name := range cacheEntries {
+		if err := os.RemoveAll(filepath.Join(r.cacheDir, name)); err != nil {
+			return fmt.Errorf("failed to remove cache entry %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// ClearProviderCache wipes the in-memory cache of a single provider
+func (r *Registry) ClearProviderCache(name string) error {
+	provider, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+
+	if clearer, ok := provider.(CacheClearer); ok {
+		clearer.ClearCache()
+	}
+	return nil
+}
+
+// ClearCacheFor drops cached info for a single title from every provider
+func (r *Registry) ClearCacheFor(mediaID string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, provider := range r.providers {
+		if clearer, ok := provider.(CacheClearer); ok {
+			clearer.ClearCacheFor(mediaID)
+		}
+	}
 }
 
 // formatCurlCommand generates a curl command for debugging
@@ -232,7 +420,7 @@ func (r *Registry) CheckAllProviders(ctx context.Context) {
 			}
 
 			// Run health check with a timeout
-			checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
 			defer cancel()
 
 			startTime := time.Now()
@@ -273,6 +461,271 @@ func (r *Registry) CheckAllProviders(ctx context.Context) {
 	wg.Wait()
 }
 
+// HealthCheckAll runs every provider's HealthCheck concurrently, each with
+// its own timeout, and returns the result by provider name. A nil error
+// means the provider is healthy. Unlike CheckAllProviders it does not touch
+// the stored statuses.
+func (r *Registry) HealthCheckAll(ctx context.Context) map[string]error {
+	providers := r.GetAll()
+
+	r.mu.RLock()
+	limit := r.maxChecks
+	r.mu.RUnlock()
+	if limit <= 0 || limit > len(providers) {
+		limit = len(providers)
+	}
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string]error, len(providers))
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, max(limit, 1))
+	)
+	for _, p := range providers {
+		wg.Add(1)
+		go func(provider Provider) {
+			defer wg.Done()
+
+			var err error
+			select {
+			case sem <- struct{}{}:
+				checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+				err = provider.HealthCheck(checkCtx)
+				cancel()
+				<-sem
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			mu.Lock()
+			results[provider.Name()] = err
+			mu.Unlock()
+		}(p)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// servesType reports whether a provider of providerType may be queried for
+// a mediaType search. MovieTV providers serve movies and TV only, never anime.
+func servesType(providerType, mediaType MediaType) bool {
+	if providerType == MediaTypeAll || providerType == mediaType {
+		return true
+	}
+	switch mediaType {
+	case MediaTypeAnime:
+		return providerType == MediaTypeAnimeMovieTV
+	case MediaTypeMovie, MediaTypeTV:
+		return providerType == MediaTypeMovieTV || providerType == MediaTypeAnimeMovieTV
+	case MediaTypeMovieTV:
+		return providerType == MediaTypeMovie || providerType == MediaTypeTV || providerType == MediaTypeAnimeMovieTV
+	case MediaTypeAnimeMovieTV:
+		return providerType != MediaTypeManga
+	case MediaTypeAll:
+		return true
+	}
+	return false
+}
+
+// searchCandidates returns the providers whose Type() strictly serves
+// mediaType, with preferred (if eligible) first and the rest by name.
+func (r *Registry) searchCandidates(mediaType MediaType, preferred string) []Provider {
+	var candidates []Provider
+	for _, p := range r.GetAll() {
+		if !servesType(p.Type(), mediaType) {
+			continue
+		}
+		if p.Name() == preferred {
+			candidates = append([]Provider{p}, candidates...)
+			continue
+		}
+		candidates = append(candidates, p)
+	}
+	return candidates
+}
+
+// SearchWithFailover searches the preferred provider first and falls back to
+// the other providers serving mediaType until one returns results. It returns
+// the results and the name of the provider that produced them, with those
+// offering the preferred language variant first. If every provider fails the
+// error is an *AggregateError.
+func (r *Registry) SearchWithFailover(ctx context.Context, mediaType MediaType, query, preferred string) ([]Media, string, error) {
+	candidates := r.searchCandidates(mediaType, preferred)
+	if len(candidates) == 0 {
+		return nil, "", fmt.Errorf("no %s providers available", mediaType)
+	}
+
+	failed := &AggregateError{}
+	for _, p := range candidates {
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+		traced := withMirrorTrace(ctx)
+		results, err := p.Search(traced, query)
+		if err != nil {
+			failed.Add(traced, p, err)
+			continue
+		}
+		if len(results) > 0 {
+			return SortByLanguage(results, r.languagePreference()), p.Name(), nil
+		}
+	}
+
+	if len(failed.Failures) == len(candidates) {
+		return nil, "", failed
+	}
+	return nil, "", nil
+}
+
+// SearchAll queries every provider serving mediaType concurrently and returns
+// the results by provider name, each ordered by the language preference.
+// Failed providers are reported in the joined error while successful results
+// are still returned; if every provider fails the error is an *AggregateError.
+func (r *Registry) SearchAll(ctx context.Context, mediaType MediaType, query string) (map[string][]Media, error) {
+	candidates := r.searchCandidates(mediaType, "")
+	language := r.languagePreference()
+
+	var (
+		mu      sync.Mutex
+		results = make(map[string][]Media, len(candidates))
+		failed  = &AggregateError{}
+		wg      sync.WaitGroup
+	)
+	for _, p := range candidates {
+		wg.Add(1)
+		go func(provider Provider) {
+			defer wg.Done()
+
+			traced := withMirrorTrace(ctx)
+			media, err := provider.Search(traced, query)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failed.Add(traced, provider, err)
+				return
+			}
+			results[provider.Name()] = SortByLanguage(media, language)
+		}(p)
+	}
+	wg.Wait()
+
+	// Report failures in a stable order rather than the order they finished
+	sort.Slice(failed.Failures, func(i, j int) bool {
+		return failed.Failures[i].Provider < failed.Failures[j].Provider
+	})
+	switch {
+	case len(failed.Failures) == 0:
+		return results, nil
+	case len(failed.Failures) == len(candidates):
+		return results, failed
+	}
+	return results, errors.Join(failed.Unwrap()...)
+}
+
+// collectionLookupConcurrency bounds how many collection lookups run at once
+const collectionLookupConcurrency = 4
+
+// CollectionGroup is a set of search results from the same franchise
+type CollectionGroup struct {
+	Collection string // Empty for a result that belongs to no collection
+	Media      []Media
+}
+
+// GroupByCollection clusters search results from the named provider by
+// franchise, keeping the order in which each group first appears. Results
+// without a collection stay in groups of their own. Providers that don't
+// implement CollectionFetcher, and failed lookups, leave results ungrouped.
+func (r *Registry) GroupByCollection(ctx context.Context, providerName string, results []Media) ([]CollectionGroup, error) {
+	provider, err := r.Get(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	collections := make([]string, len(results))
+	if fetcher, ok := provider.(CollectionFetcher); ok {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, collectionLookupConcurrency)
+		for i, media := range results {
+			wg.Add(1)
+			go func(i int, mediaID string) {
+				defer wg.Done()
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+				defer func() { <-sem }()
+
+				if collection, err := fetcher.GetCollection(ctx, mediaID); err == nil {
+					collections[i] = strings.TrimSpace(collection)
+				}
+			}(i, media.ID)
+		}
+		wg.Wait()
+	}
+
+	var groups []CollectionGroup
+	index := make(map[string]int)
+	for i, media := range results {
+		key := strings.ToLower(collections[i])
+		if key == "" {
+			groups = append(groups, CollectionGroup{Media: []Media{media}})
+			continue
+		}
+		if g, ok := index[key]; ok {
+			groups[g].Media = append(groups[g].Media, media)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, CollectionGroup{Collection: collections[i], Media: []Media{media}})
+	}
+	return groups, ctx.Err()
+}
+
+// prefetchConcurrency bounds how many detail lookups PrefetchDetails runs at once
+const prefetchConcurrency = 4
+
+// PrefetchDetails loads the details of each result from the named provider in
+// the background so its info cache is warm when one of them is opened.
+// Duplicate IDs are fetched once and lookup failures are ignored; it returns
+// early with ctx's error when ctx is cancelled.
+func (r *Registry) PrefetchDetails(ctx context.Context, providerName string, media []Media) error {
+	provider, err := r.Get(providerName)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(media))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, prefetchConcurrency)
+	for _, m := range media {
+		if m.ID == "" || seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+
+		wg.Add(1)
+		go func(mediaID string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			if ctx.Err() == nil {
+				_, _ = provider.GetMediaDetails(ctx, mediaID)
+			}
+		}(m.ID)
+	}
+	wg.Wait()
+
+	return ctx.Err()
+}
+
 // GetProviderStatuses returns the health status of all registered providers.
 func (r *Registry) GetProviderStatuses() []*ProviderStatus {
 	r.mu.RLock()
@@ -392,18 +845,149 @@ func Get(name string) (Provider, error) {
 	return globalRegistry.Get(name)
 }
 
-// GetByType returns all providers that support the given media type from the global registry
+// GetByType returns all enabled providers that support the given media type from the global registry
 func GetByType(mediaType MediaType) []Provider {
 	return globalRegistry.GetByType(mediaType)
 }
 
+// Disable switches a provider in the global registry off for the session
+func Disable(name string) error {
+	return globalRegistry.Disable(name)
+}
+
+// Enable switches a provider in the global registry back on
+func Enable(name string) error {
+	return globalRegistry.Enable(name)
+}
+
+// IsDisabled reports whether a provider in the global registry is switched off
+func IsDisabled(name string) bool {
+	return globalRegistry.IsDisabled(name)
+}
+
+// IsThrottled reports whether a provider in the global registry is cooling down after being blocked
+func IsThrottled(name string) (bool, time.Time) {
+	return globalRegistry.IsThrottled(name)
+}
+
 // Configurable is an interface for providers that can be configured at runtime
 type Configurable interface {
 	SetConfig(cfg *config.Config, logger *slog.Logger)
 }
 
+// CacheClearer is an interface for providers that cache search and info results
+type CacheClearer interface {
+	// ClearCache drops all cached search and info results
+	ClearCache()
+	// ClearCacheFor drops the cached info for a single media ID
+	ClearCacheFor(mediaID string)
+}
+
+// CacheToggler is an interface for providers whose caches can be turned
+// off, so a misbehaving provider always fetches fresh data
+type CacheToggler interface {
+	SetCacheEnabled(enabled bool)
+}
+
+// SubtitleProber is an interface for providers that can report subtitle
+// availability for an episode without resolving a stream
+type SubtitleProber interface {
+	HasSubtitles(ctx context.Context, episodeID string) (bool, []string, error)
+}
+
+// ServerProber is an interface for providers that can check which of an
+// episode's servers currently yield sources, so a server can be picked
+// knowing it works
+type ServerProber interface {
+	// ProbeServers reports the availability and latency of every server,
+	// in the order the provider lists them
+	ProbeServers(ctx context.Context, episodeID string) ([]extractors.ServerProbe, error)
+}
+
+// CollectionFetcher is an interface for providers that can report the
+// franchise or collection a title belongs to
+type CollectionFetcher interface {
+	// GetCollection returns the collection label, or "" when there is none
+	GetCollection(ctx context.Context, mediaID string) (string, error)
+}
+
+// Versioned is an interface for providers that report which revision of the
+// site layout they were written against
+type Versioned interface {
+	ProviderVersion() string
+}
+
+// VersionOf returns the provider version, or an empty string if the provider
+// does not report one
+func VersionOf(p Provider) string {
+	if v, ok := p.(Versioned); ok {
+		return v.ProviderVersion()
+	}
+	return ""
+}
+
+// BaseURLReporter is an interface for providers that can report the base URL
+// they are currently using
+type BaseURLReporter interface {
+	BaseURL() string
+}
+
+// BaseURLOf returns the base URL a provider is using, or an empty string if
+// the provider does not report one
+func BaseURLOf(p Provider) string {
+	if reporter, ok := p.(BaseURLReporter); ok {
+		return reporter.BaseURL()
+	}
+	return ""
+}
+
+// IsThrottled reports whether the named provider's site is cooling down after
+// rate limiting or blocking us, and when requests will be attempted again
+func (r *Registry) IsThrottled(name string) (bool, time.Time) {
+	provider, err := r.Get(name)
+	if err != nil {
+		return false, time.Time{}
+	}
+	baseURL := BaseURLOf(provider)
+	if baseURL == "" {
+		return false, time.Time{}
+	}
+	return ThrottledUntil(baseURL)
+}
+
 // ConfigureAll configures all registered providers that implement the Configurable interface
 func ConfigureAll(cfg *config.Config, logger *slog.Logger) {
+	extractors.SetKeySource(cfg.Providers.ExtractorKeySource)
+	if err := extractors.SetAliases(cfg.Providers.ExtractorAliases); err != nil && logger != nil {
+		logger.Warn("ignoring invalid extractor aliases", "error", err)
+	}
+	globalRegistry.SetMaxConcurrentChecks(cfg.Advanced.MaxGoroutines)
+
+	ladders, err := ParseQualityLadders(cfg.Player.QualityLadder)
+	if err != nil && logger != nil {
+		logger.Warn("ignoring invalid qualities in player.quality_ladder", "error", err)
+	}
+	SetQualityLadders(ladders)
+
+	language, err := ParseLanguagePreference(cfg.Providers.LanguagePreference)
+	if err != nil && logger != nil {
+		logger.Warn("ignoring providers.language_preference", "error", err)
+	}
+	globalRegistry.SetLanguagePreference(language)
+	globalRegistry.SetSafeSearch(cfg.UI.SafeSearch)
+	transport, err := NewTransport(cfg.Network)
+	if err != nil && logger != nil {
+		logger.Warn("ignoring network.proxy", "error", err)
+	}
+	SetTransport(NewRequestLogTransport(transport, logger))
+
+	var images *imagecache.Cache
+	if cfg.Cache.Enabled && cfg.Cache.Path != "" {
+		images = imagecache.New(cfg.Cache.Path, cfg.Cache.TTL.Images)
+		images.SetTransport(Transport())
+	}
+	SetImageCache(images)
+
 	globalRegistry.mu.RLock()
 	defer globalRegistry.mu.RUnlock()
 
@@ -411,6 +995,17 @@ func ConfigureAll(cfg *config.Config, logger *slog.Logger) {
 		if configurable, ok := provider.(Configurable); ok {
 			configurable.SetConfig(cfg, logger)
 		}
+		if preferrer, ok := provider.(LanguagePreferrer); ok {
+			preferrer.SetLanguagePreference(language)
+		}
+		if toggler, ok := provider.(CacheToggler); ok {
+			settings, ok := cfg.Providers.Settings(provider.Name())
+			enabled := !ok || settings.CacheEnabled
+			toggler.SetCacheEnabled(enabled)
+			if clearer, ok := provider.(CacheClearer); ok && !enabled {
+				clearer.ClearCache()
+			}
+		}
 	}
 }
 
@@ -439,11 +1034,61 @@ func GetRegistry() *Registry {
 	return globalRegistry
 }
 
+// ClearCache wipes provider and on-disk caches in the global registry
+func ClearCache() error {
+	return globalRegistry.ClearCache()
+}
+
+// ClearCacheFor drops cached info for a single title from the global registry
+func ClearCacheFor(mediaID string) {
+	globalRegistry.ClearCacheFor(mediaID)
+}
+
 // CheckAllProviders runs health checks on all providers in the global registry.
 func CheckAllProviders(ctx context.Context) {
 	globalRegistry.CheckAllProviders(ctx)
 }
 
+// HealthCheckAll checks every provider in the global registry and returns
+// the results by provider name.
+func HealthCheckAll(ctx context.Context) map[string]error {
+	return globalRegistry.HealthCheckAll(ctx)
+}
+
+// SearchWithFailover searches the global registry's providers for mediaType,
+// preferring the named provider.
+func SearchWithFailover(ctx context.Context, mediaType MediaType, query, preferred string) ([]Media, string, error) {
+	return globalRegistry.SearchWithFailover(ctx, mediaType, query, preferred)
+}
+
+// SearchAll searches every provider in the global registry serving mediaType.
+func SearchAll(ctx context.Context, mediaType MediaType, query string) (map[string][]Media, error) {
+	return globalRegistry.SearchAll(ctx, mediaType, query)
+}
+
+// GroupByCollection clusters search results from the named provider in the
+// global registry by franchise.
+func GroupByCollection(ctx context.Context, providerName string, results []Media) ([]CollectionGroup, error) {
+	return globalRegistry.GroupByCollection(ctx, providerName, results)
+}
+
+// PrefetchDetails warms the named provider's info cache for results using
+// the global registry
+func PrefetchDetails(ctx context.Context, providerName string, media []Media) error {
+	return globalRegistry.PrefetchDetails(ctx, providerName, media)
+}
+
+// Random picks a random title of mediaType using the global registry
+func Random(ctx context.Context, mediaType MediaType) (Media, string, error) {
+	return globalRegistry.Random(ctx, mediaType)
+}
+
+// QuickPlay picks the best match for query among the global registry's
+// providers serving mediaType and resolves a stream for it.
+func QuickPlay(ctx context.Context, query string, mediaType MediaType) (*QuickPlayResult, error) {
+	return globalRegistry.QuickPlay(ctx, query, mediaType)
+}
+
 // GetProviderStatuses returns the health statuses from the global registry.
 func GetProviderStatuses() []*ProviderStatus {
 	return globalRegistry.GetProviderStatuses()
@@ -2,6 +2,9 @@ package providers
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -260,6 +263,61 @@ func TestRegistry_List(t *testing.T) {
 		names := registry.List()
 		assert.Empty(t, names)
 	})
+
+	t.Run("sorts names alphabetically", func(t *testing.T) {
+		registry := NewRegistry()
+		for _, name := range []string{"sflix", "allanime", "hdrezka", "flixhq", "comix"} {
+			_ = registry.Register(&mockProvider{name: name, mediaType: MediaTypeMovie})
+		}
+
+		assert.Equal(t, []string{"allanime", "comix", "flixhq", "hdrezka", "sflix"}, registry.List())
+	})
+}
+
+func TestRegistry_DisableEnable(t *testing.T) {
+	registry := NewRegistry()
+	flaky := &searchProvider{mockProvider: mockProvider{name: "hianime", mediaType: MediaTypeAnime}, err: errors.New("spamming errors")}
+	steady := &searchProvider{mockProvider: mockProvider{name: "allanime", mediaType: MediaTypeAnime}, results: []Media{{ID: "anime"}}}
+	require.NoError(t, registry.Register(flaky))
+	require.NoError(t, registry.Register(steady))
+
+	require.NoError(t, registry.Disable("hianime"))
+	assert.True(t, registry.IsDisabled("hianime"))
+	assert.Equal(t, []string{"allanime"}, registry.List())
+	assert.Len(t, registry.GetAll(), 1)
+	assert.Len(t, registry.GetByType(MediaTypeAnime), 1)
+	assert.Equal(t, 2, registry.Count(), "disabled providers stay registered")
+
+	// Searches skip it entirely
+	results, err := registry.SearchAll(context.Background(), MediaTypeAnime, "one piece")
+	require.NoError(t, err)
+	assert.Contains(t, results, "allanime")
+	assert.NotContains(t, results, "hianime")
+	assert.Zero(t, flaky.searched)
+
+	// It can still be looked up by name, e.g. by playback already under way
+	p, err := registry.Get("hianime")
+	require.NoError(t, err)
+	assert.Equal(t, "hianime", p.Name())
+
+	statuses := registry.GetProviderStatuses()
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[1].Disabled)
+
+	require.NoError(t, registry.Enable("hianime"))
+	assert.False(t, registry.IsDisabled("hianime"))
+	assert.Equal(t, []string{"allanime", "hianime"}, registry.List())
+	assert.Len(t, registry.GetByType(MediaTypeAnime), 2)
+	assert.False(t, registry.GetProviderStatuses()[1].Disabled)
+
+	assert.Error(t, registry.Disable("nonexistent"))
+	assert.Error(t, registry.Enable("nonexistent"))
+
+	// Unregistering forgets the switch
+	require.NoError(t, registry.Disable("hianime"))
+	require.NoError(t, registry.Unregister("hianime"))
+	require.NoError(t, registry.Register(flaky))
+	assert.False(t, registry.IsDisabled("hianime"))
 }
 
 func TestRegistry_Count(t *testing.T) {
@@ -331,3 +389,69 @@ func TestGlobalRegistry(t *testing.T) {
 		assert.Len(t, providers, 1)
 	})
 }
+
+func TestRegistry_SelfTest(t *testing.T) {
+	t.Run("reports the failing step", func(t *testing.T) {
+		registry := NewRegistry()
+		require.NoError(t, registry.Register(&mockProvider{name: "test", mediaType: MediaTypeMovieTV}))
+
+		result, err := registry.SelfTest(context.Background(), "test")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "step 1 (search)")
+		assert.False(t, result.Passed())
+		require.NotNil(t, result.FailedStep())
+		assert.Equal(t, SelfTestStepSearch, result.FailedStep().Name)
+		assert.Equal(t, defaultSelfTestQuery, result.Query)
+	})
+
+	t.Run("returns error for unknown provider", func(t *testing.T) {
+		registry := NewRegistry()
+		_, err := registry.SelfTest(context.Background(), "missing")
+		assert.Error(t, err)
+	})
+}
+
+// healthCheckProvider is a mockProvider whose HealthCheck returns err
+type healthCheckProvider struct {
+	mockProvider
+	err error
+}
+
+func (p *healthCheckProvider) HealthCheck(ctx context.Context) error { return p.err }
+
+func TestRegistry_HealthCheckAll(t *testing.T) {
+	t.Run("reports each provider's result", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.SetMaxConcurrentChecks(1)
+		down := errors.New("site down")
+		require.NoError(t, registry.Register(&healthCheckProvider{mockProvider: mockProvider{name: "up", mediaType: MediaTypeMovie}}))
+		require.NoError(t, registry.Register(&healthCheckProvider{mockProvider: mockProvider{name: "down", mediaType: MediaTypeMovie}, err: down}))
+
+		results := registry.HealthCheckAll(context.Background())
+		require.Len(t, results, 2)
+		assert.NoError(t, results["up"])
+		assert.ErrorIs(t, results["down"], down)
+	})
+
+	t.Run("empty registry", func(t *testing.T) {
+		assert.Empty(t, NewRegistry().HealthCheckAll(context.Background()))
+	})
+}
+
+func TestRegistry_ClearCacheKeepsUnknownFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"images/abc.jpg", "subtitles/test-1-en.srt", "debug/sflix-last-failure.html", "notes.txt"} {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("x"), 0644))
+	}
+
+	registry := NewRegistry()
+	registry.SetCacheDir(dir)
+	require.NoError(t, registry.ClearCache())
+
+	assert.NoDirExists(t, filepath.Join(dir, "images"))
+	assert.FileExists(t, filepath.Join(dir, "subtitles/test-1-en.srt"))
+	assert.FileExists(t, filepath.Join(dir, "debug/sflix-last-failure.html"))
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"))
+}
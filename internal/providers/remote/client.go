@@ -15,14 +15,14 @@ import (
 
 type Client struct {
 	NameStr string
-	BaseURL string
+	baseURL string
 	Client  *http.Client
 }
 
 func New(name, baseURL string) *Client {
 	return &Client{
 		NameStr: name,
-		BaseURL: strings.TrimRight(baseURL, "/"),
+		baseURL: strings.TrimRight(baseURL, "/"),
 		Client:  &http.Client{},
 	}
 }
@@ -31,14 +31,28 @@ func (c *Client) Name() string {
 	return c.NameStr
 }
 
-func (c *Client) searchOld(query string) (*types.SearchResults, error) {
+// BaseURL returns the remote API endpoint the client talks to
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// get sends a GET request that is aborted once ctx is done
+func (c *Client) get(ctx context.Context, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) searchOld(ctx context.Context, query string) (*types.SearchResults, error) {
 	// Assuming the remote API follows /{query} pattern for search
 	// But standard consumet is /{provider}/{query}
 	// The BaseURL should include the provider path, e.g. http://host/anime/gogoanime
 
-	searchURL := fmt.Sprintf("%s/%s", c.BaseURL, url.PathEscape(query))
+	searchURL := fmt.Sprintf("%s/%s", c.baseURL, url.PathEscape(query))
 
-	resp, err := c.Client.Get(searchURL)
+	resp, err := c.get(ctx, searchURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch search results: %w", err)
 	}
@@ -57,10 +71,15 @@ func (c *Client) searchOld(query string) (*types.SearchResults, error) {
 }
 
 func (c *Client) GetInfo(id string) (interface{}, error) {
+	return c.GetInfoContext(context.Background(), id)
+}
+
+// GetInfoContext is GetInfo with a context for its requests
+func (c *Client) GetInfoContext(ctx context.Context, id string) (interface{}, error) {
 	// Consumet API: /{provider}/info/{id}
-	infoURL := fmt.Sprintf("%s/info/%s", c.BaseURL, id)
+	infoURL := fmt.Sprintf("%s/info/%s", c.baseURL, id)
 
-	resp, err := c.Client.Get(infoURL)
+	resp, err := c.get(ctx, infoURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch info: %w", err)
 	}
@@ -112,6 +131,11 @@ func (c *Client) GetInfo(id string) (interface{}, error) {
 }
 
 func (c *Client) GetSources(episodeID string) (interface{}, error) {
+	return c.GetSourcesContext(context.Background(), episodeID)
+}
+
+// GetSourcesContext is GetSources, aborting once ctx is done
+func (c *Client) GetSourcesContext(ctx context.Context, episodeID string) (interface{}, error) {
 	// Consumet API: /{provider}/watch/{episodeId}
 	// Note: Some providers use /read for manga?
 	// Let's assume /watch for now, or check if it's manga.
@@ -124,9 +148,9 @@ func (c *Client) GetSources(episodeID string) (interface{}, error) {
 	// Manga: /manga/{provider}/read/{chapterId}
 
 	// We can try /watch first.
-	watchURL := fmt.Sprintf("%s/watch/%s", c.BaseURL, episodeID)
+	watchURL := fmt.Sprintf("%s/watch/%s", c.baseURL, episodeID)
 
-	resp, err := c.Client.Get(watchURL)
+	resp, err := c.get(ctx, watchURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch sources: %w", err)
 	}
@@ -134,8 +158,8 @@ func (c *Client) GetSources(episodeID string) (interface{}, error) {
 
 	if resp.StatusCode == http.StatusNotFound {
 		// Try /read for manga
-		readURL := fmt.Sprintf("%s/read/%s", c.BaseURL, episodeID)
-		resp, err = c.Client.Get(readURL)
+		readURL := fmt.Sprintf("%s/read/%s", c.baseURL, episodeID)
+		resp, err = c.get(ctx, readURL)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch manga pages: %w", err)
 		}
@@ -167,15 +191,20 @@ func (c *Client) GetSources(episodeID string) (interface{}, error) {
 }
 
 func (c *Client) GetServers(episodeID string) ([]types.EpisodeServer, error) {
+	return c.GetServersContext(context.Background(), episodeID)
+}
+
+// GetServersContext is GetServers, aborting once ctx is done
+func (c *Client) GetServersContext(ctx context.Context, episodeID string) ([]types.EpisodeServer, error) {
 	// Consumet API usually doesn't have a separate servers endpoint exposed in the same way for all providers,
 	// or it's included in the source response?
 	// Actually, consumet-api often has /servers/{episodeId}?
 	// Let's assume /{provider}/servers/{episodeId} exists if needed.
 	// If not, we return empty.
 
-	serverURL := fmt.Sprintf("%s/servers/%s", c.BaseURL, episodeID)
+	serverURL := fmt.Sprintf("%s/servers/%s", c.baseURL, episodeID)
 
-	resp, err := c.Client.Get(serverURL)
+	resp, err := c.get(ctx, serverURL)
 	if err != nil {
 		// If failed, just return empty list, don't error out completely as it might not be supported
 		return []types.EpisodeServer{}, nil
@@ -202,7 +231,7 @@ func (c *Client) Type() providers.MediaType {
 
 // Search (new interface) searches by query
 func (c *Client) Search(ctx context.Context, query string) ([]providers.Media, error) {
-	oldResults, err := c.searchOld(query)
+	oldResults, err := c.searchOld(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -241,7 +270,7 @@ func (c *Client) Search(ctx context.Context, query string) ([]providers.Media, e
 
 // GetMediaDetails fetches detailed info
 func (c *Client) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
-	info, err := c.GetInfo(id)
+	info, err := c.GetInfoContext(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -343,7 +372,7 @@ func (c *Client) GetMediaDetails(ctx context.Context, id string) (*providers.Med
 
 // GetSeasons returns seasons
 func (c *Client) GetSeasons(ctx context.Context, mediaID string) ([]providers.Season, error) {
-	info, err := c.GetInfo(mediaID)
+	info, err := c.GetInfoContext(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
@@ -430,7 +459,7 @@ func (c *Client) GetEpisodes(ctx context.Context, seasonID string) ([]providers.
 		mediaID = seasonID
 	}
 
-	info, err := c.GetInfo(mediaID)
+	info, err := c.GetInfoContext(ctx, mediaID)
 	if err != nil {
 		return nil, err
 	}
@@ -496,7 +525,7 @@ func (c *Client) GetEpisodes(ctx context.Context, seasonID string) ([]providers.
 
 // GetStreamURL fetches video stream URL
 func (c *Client) GetStreamURL(ctx context.Context, episodeID string, quality providers.Quality) (*providers.StreamURL, error) {
-	res, err := c.GetSources(episodeID)
+	res, err := c.GetSourcesContext(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -514,16 +543,26 @@ func (c *Client) GetStreamURL(ctx context.Context, episodeID string, quality pro
 	var selectedSource types.Source
 	found := false
 
-	targetQuality := string(quality)
+	// In auto mode hand the HLS master playlist to the player so it can
+	// switch variants itself
 	if quality == providers.QualityAuto {
-		targetQuality = "auto"
+		for _, src := range videoSources.Sources {
+			if providers.IsMasterPlaylist(src.URL, src.Quality) {
+				selectedSource = src
+				selectedSource.IsM3U8 = true
+				found = true
+				break
+			}
+		}
 	}
 
-	for _, src := range videoSources.Sources {
-		if strings.EqualFold(src.Quality, targetQuality) {
-			selectedSource = src
-			found = true
-			break
+	if !found {
+		for _, src := range videoSources.Sources {
+			if providers.MatchesQuality(src.Quality, quality) {
+				selectedSource = src
+				found = true
+				break
+			}
 		}
 	}
 
@@ -558,7 +597,7 @@ func (c *Client) GetStreamURL(ctx context.Context, episodeID string, quality pro
 
 // GetAvailableQualities returns available video qualities
 func (c *Client) GetAvailableQualities(ctx context.Context, episodeID string) ([]providers.Quality, error) {
-	res, err := c.GetSources(episodeID)
+	res, err := c.GetSourcesContext(ctx, episodeID)
 	if err != nil {
 		return nil, err
 	}
@@ -578,7 +617,7 @@ func (c *Client) GetAvailableQualities(ctx context.Context, episodeID string) ([
 
 // GetMangaPages fetches manga pages
 func (c *Client) GetMangaPages(ctx context.Context, chapterID string) ([]string, error) {
-	res, err := c.GetSources(chapterID)
+	res, err := c.GetSourcesContext(ctx, chapterID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+type requestIDKey struct{}
+
+// WithRequestID tags ctx with id, so every provider request made with it is
+// logged under the same ID. Tagging the search and the info, episode and
+// source lookups that follow with one ID lets the whole flow be read back
+// from the debug log.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the ID ctx was tagged with, or "" if it has none
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// NewRequestID returns a short random ID for WithRequestID
+func NewRequestID() string {
+	return fmt.Sprintf("%08x", rand.Uint32())
+}
+
+// RequestLogTransport logs every request sent through it at debug level,
+// with the request ID from its context, the status and how long it took
+type RequestLogTransport struct {
+	Base   http.RoundTripper
+	Logger *slog.Logger
+}
+
+// NewRequestLogTransport wraps base so its requests are logged to logger.
+// base is returned unchanged when logger is nil.
+func NewRequestLogTransport(base http.RoundTripper, logger *slog.Logger) http.RoundTripper {
+	if logger == nil {
+		return base
+	}
+	return &RequestLogTransport{Base: base, Logger: logger}
+}
+
+func (t *RequestLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+
+	attrs := []any{"method", req.Method, "url", req.URL.Redacted(), "duration", time.Since(start)}
+	if id := RequestID(req.Context()); id != "" {
+		attrs = append([]any{"request_id", id}, attrs...)
+	}
+	if err != nil {
+		t.Logger.Debug("provider request failed", append(attrs, "error", err)...)
+		return nil, err
+	}
+	t.Logger.Debug("provider request", append(attrs, "status", resp.StatusCode)...)
+	return resp, nil
+}
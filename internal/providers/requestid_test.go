@@ -0,0 +1,59 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	assert.Empty(t, RequestID(context.Background()))
+
+	ctx := WithRequestID(context.Background(), "abc123")
+	assert.Equal(t, "abc123", RequestID(ctx))
+
+	id := NewRequestID()
+	assert.Len(t, id, 8)
+	assert.NotEqual(t, id, NewRequestID())
+}
+
+func TestRequestLogTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := &http.Client{Transport: NewRequestLogTransport(http.DefaultTransport, logger)}
+
+	// Every request of a flow is logged under its ID
+	ctx := WithRequestID(context.Background(), "flow42")
+	_, err := FetchBody(ctx, client, srv.URL+"/search?keyword=dark", nil)
+	require.NoError(t, err)
+	_, err = FetchBody(ctx, client, srv.URL+"/missing", nil)
+	require.Error(t, err)
+	_, err = FetchBody(context.Background(), client, srv.URL+"/untagged", nil)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "request_id=flow42")
+	assert.Contains(t, lines[0], "/search?keyword=dark")
+	assert.Contains(t, lines[0], "status=200")
+	assert.Contains(t, lines[1], "request_id=flow42")
+	assert.Contains(t, lines[1], "status=404")
+	assert.NotContains(t, lines[2], "request_id")
+
+	// Without a logger the transport is left alone
+	assert.Equal(t, http.DefaultTransport, NewRequestLogTransport(http.DefaultTransport, nil))
+}
@@ -0,0 +1,121 @@
+package providers
+
+import (
+	"context"
+	"strings"
+)
+
+// SearchOptions narrows search results after they have been fetched
+type SearchOptions struct {
+	Year int       // Exact release year, 0 matches any year
+	Type MediaType // MediaTypeMovie or MediaTypeTV, empty matches any type
+}
+
+// FilteredSearcher is an interface for providers that support filtered search
+type FilteredSearcher interface {
+	SearchFiltered(ctx context.Context, query string, opts SearchOptions) ([]Media, error)
+}
+
+// FilterMedia returns the results that match opts
+func FilterMedia(results []Media, opts SearchOptions) []Media {
+	filtered := make([]Media, 0, len(results))
+	for _, media := range results {
+		if opts.Year != 0 && media.Year != opts.Year {
+			continue
+		}
+		if opts.Type != "" && media.Type != opts.Type {
+			continue
+		}
+		filtered = append(filtered, media)
+	}
+	return filtered
+}
+
+// SearchFiltered searches p and filters the results with opts, using the
+// provider's own SearchFiltered when it has one
+func SearchFiltered(ctx context.Context, p Provider, query string, opts SearchOptions) ([]Media, error) {
+	if searcher, ok := p.(FilteredSearcher); ok {
+		return searcher.SearchFiltered(ctx, query, opts)
+	}
+
+	results, err := p.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return FilterMedia(results, opts), nil
+}
+
+// StreamSearcher is an interface for providers that can emit search results
+// as they are parsed instead of after the whole search has finished
+type StreamSearcher interface {
+	SearchStream(ctx context.Context, query string) (<-chan Media, <-chan error)
+}
+
+// SearchStream searches p and sends results on the first channel as they
+// arrive. Providers without their own SearchStream fall back to a batch
+// Search. The error channel receives at most one error and both channels are
+// closed once the search ends.
+func SearchStream(ctx context.Context, p Provider, query string) (<-chan Media, <-chan error) {
+	if searcher, ok := p.(StreamSearcher); ok {
+		return searcher.SearchStream(ctx, query)
+	}
+
+	results := make(chan Media)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(results)
+
+		media, err := p.Search(ctx, query)
+		if err != nil {
+			errc <- err
+			return
+		}
+		for _, m := range media {
+			select {
+			case results <- m:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return results, errc
+}
+
+// adultMarkers are genre or label values that mark a title as adult content
+var adultMarkers = map[string]bool{
+	"adult":   true,
+	"erotic":  true,
+	"erotica": true,
+	"hentai":  true,
+	"porn":    true,
+	"xxx":     true,
+	"18+":     true,
+	"r18":     true,
+	"r-18":    true,
+}
+
+// IsAdult reports whether any of the genres or labels is an adult marker
+func IsAdult(labels ...string) bool {
+	for _, label := range labels {
+		if adultMarkers[strings.ToLower(strings.TrimSpace(label))] {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterAdult drops results whose genres or labels mark them as adult
+// content. Only what the search page listed is checked: most sites only show
+// genres on the detail page, and fetching it for every result would cost a
+// request each. Details loaded later are checked with IsAdult.
+func FilterAdult(results []Media) []Media {
+	filtered := make([]Media, 0, len(results))
+	for _, media := range results {
+		if !IsAdult(media.Genres...) && !IsAdult(media.SourceQuality) {
+			filtered = append(filtered, media)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,403 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterMedia(t *testing.T) {
+	results := []Media{
+		{ID: "movie/dune-2021", Title: "Dune", Type: MediaTypeMovie, Year: 2021},
+		{ID: "movie/dune-1984", Title: "Dune", Type: MediaTypeMovie, Year: 1984},
+		{ID: "tv/dune-prophecy", Title: "Dune: Prophecy", Type: MediaTypeTV, Year: 2024},
+	}
+
+	t.Run("no options keeps everything", func(t *testing.T) {
+		assert.Len(t, FilterMedia(results, SearchOptions{}), 3)
+	})
+
+	t.Run("year matches exactly", func(t *testing.T) {
+		filtered := FilterMedia(results, SearchOptions{Year: 2021})
+		assert.Len(t, filtered, 1)
+		assert.Equal(t, "movie/dune-2021", filtered[0].ID)
+	})
+
+	t.Run("type filter", func(t *testing.T) {
+		filtered := FilterMedia(results, SearchOptions{Type: MediaTypeTV})
+		assert.Len(t, filtered, 1)
+		assert.Equal(t, "tv/dune-prophecy", filtered[0].ID)
+	})
+
+	t.Run("year and type combined", func(t *testing.T) {
+		assert.Empty(t, FilterMedia(results, SearchOptions{Year: 2021, Type: MediaTypeTV}))
+	})
+}
+
+// searchProvider returns fixed batch results from Search
+type searchProvider struct {
+	mockProvider
+	results  []Media
+	err      error
+	mu       sync.Mutex
+	searched int
+}
+
+func (p *searchProvider) Search(ctx context.Context, query string) ([]Media, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.searched++
+	return p.results, p.err
+}
+
+// streamingProvider implements StreamSearcher
+type streamingProvider struct {
+	mockProvider
+	streamed bool
+}
+
+func (p *streamingProvider) SearchStream(ctx context.Context, query string) (<-chan Media, <-chan error) {
+	p.streamed = true
+	results := make(chan Media, 1)
+	errc := make(chan error)
+	results <- Media{ID: "streamed"}
+	close(results)
+	close(errc)
+	return results, errc
+}
+
+func collectStream(results <-chan Media, errc <-chan error) ([]Media, error) {
+	var media []Media
+	for m := range results {
+		media = append(media, m)
+	}
+	return media, <-errc
+}
+
+func TestSearchStream(t *testing.T) {
+	t.Run("falls back to batch search", func(t *testing.T) {
+		p := &searchProvider{results: []Media{{ID: "a"}, {ID: "b"}}}
+		media, err := collectStream(SearchStream(context.Background(), p, "dune"))
+		assert.NoError(t, err)
+		assert.Equal(t, []Media{{ID: "a"}, {ID: "b"}}, media)
+	})
+
+	t.Run("batch search error is reported", func(t *testing.T) {
+		searchErr := errors.New("boom")
+		p := &searchProvider{err: searchErr}
+		media, err := collectStream(SearchStream(context.Background(), p, "dune"))
+		assert.Empty(t, media)
+		assert.ErrorIs(t, err, searchErr)
+	})
+
+	t.Run("uses the provider stream when available", func(t *testing.T) {
+		p := &streamingProvider{}
+		media, err := collectStream(SearchStream(context.Background(), p, "dune"))
+		assert.NoError(t, err)
+		assert.True(t, p.streamed)
+		assert.Equal(t, []Media{{ID: "streamed"}}, media)
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		p := &searchProvider{results: []Media{{ID: "a"}, {ID: "b"}}}
+		results, errc := SearchStream(ctx, p, "dune")
+		<-results
+		cancel()
+		assert.ErrorIs(t, <-errc, context.Canceled)
+	})
+}
+
+func TestRegistry_SearchTypeGuard(t *testing.T) {
+	newSearchRegistry := func() (*Registry, *searchProvider, *searchProvider, *searchProvider) {
+		registry := NewRegistry()
+		sflix := &searchProvider{mockProvider: mockProvider{name: "sflix", mediaType: MediaTypeMovieTV}, results: []Media{{ID: "live-action"}}}
+		allanime := &searchProvider{mockProvider: mockProvider{name: "allanime", mediaType: MediaTypeAnime}, results: []Media{{ID: "anime"}}}
+		hianime := &searchProvider{mockProvider: mockProvider{name: "hianime", mediaType: MediaTypeAnime}, err: errors.New("blocked")}
+		require.NoError(t, registry.Register(sflix))
+		require.NoError(t, registry.Register(allanime))
+		require.NoError(t, registry.Register(hianime))
+		return registry, sflix, allanime, hianime
+	}
+
+	t.Run("anime failover never touches sflix", func(t *testing.T) {
+		registry, sflix, allanime, hianime := newSearchRegistry()
+
+		results, name, err := registry.SearchWithFailover(context.Background(), MediaTypeAnime, "one piece", "hianime")
+		require.NoError(t, err)
+		assert.Equal(t, "allanime", name)
+		assert.Equal(t, "anime", results[0].ID)
+		assert.Equal(t, 1, hianime.searched)
+		assert.Equal(t, 1, allanime.searched)
+		assert.Zero(t, sflix.searched)
+	})
+
+	t.Run("anime search all never touches sflix", func(t *testing.T) {
+		registry, sflix, _, _ := newSearchRegistry()
+
+		results, err := registry.SearchAll(context.Background(), MediaTypeAnime, "one piece")
+		assert.Error(t, err)
+		assert.Contains(t, results, "allanime")
+		assert.NotContains(t, results, "sflix")
+		assert.Zero(t, sflix.searched)
+	})
+
+	t.Run("movie search skips anime providers", func(t *testing.T) {
+		registry, sflix, allanime, _ := newSearchRegistry()
+
+		_, name, err := registry.SearchWithFailover(context.Background(), MediaTypeMovie, "heat", "")
+		require.NoError(t, err)
+		assert.Equal(t, "sflix", name)
+		assert.Equal(t, 1, sflix.searched)
+		assert.Zero(t, allanime.searched)
+	})
+
+	t.Run("no eligible providers", func(t *testing.T) {
+		registry, _, _, _ := newSearchRegistry()
+
+		_, _, err := registry.SearchWithFailover(context.Background(), MediaTypeManga, "berserk", "")
+		assert.Error(t, err)
+	})
+}
+
+// collectionProvider is a mockProvider that reports collections by media ID
+type collectionProvider struct {
+	mockProvider
+	collections map[string]string
+}
+
+func (p *collectionProvider) GetCollection(ctx context.Context, mediaID string) (string, error) {
+	if mediaID == "broken" {
+		return "", errors.New("lookup failed")
+	}
+	return p.collections[mediaID], nil
+}
+
+func TestRegistry_GroupByCollection(t *testing.T) {
+	results := []Media{
+		{ID: "spider-man-2002"}, {ID: "into-the-spider-verse"}, {ID: "spider-man-2004"},
+		{ID: "broken"}, {ID: "into-the-spider-verse-2"},
+	}
+
+	t.Run("clusters by collection in first-seen order", func(t *testing.T) {
+		registry := NewRegistry()
+		require.NoError(t, registry.Register(&collectionProvider{
+			mockProvider: mockProvider{name: "sflix", mediaType: MediaTypeMovieTV},
+			collections: map[string]string{
+				"spider-man-2002":         "Spider-Man Collection",
+				"spider-man-2004":         "spider-man collection",
+				"into-the-spider-verse":   "Spider-Verse Collection",
+				"into-the-spider-verse-2": "Spider-Verse Collection",
+			},
+		}))
+
+		groups, err := registry.GroupByCollection(context.Background(), "sflix", results)
+		require.NoError(t, err)
+		require.Len(t, groups, 3)
+		assert.Equal(t, "Spider-Man Collection", groups[0].Collection)
+		assert.Equal(t, []Media{{ID: "spider-man-2002"}, {ID: "spider-man-2004"}}, groups[0].Media)
+		assert.Equal(t, "Spider-Verse Collection", groups[1].Collection)
+		assert.Len(t, groups[1].Media, 2)
+		assert.Empty(t, groups[2].Collection)
+		assert.Equal(t, []Media{{ID: "broken"}}, groups[2].Media)
+	})
+
+	t.Run("providers without collections leave results ungrouped", func(t *testing.T) {
+		registry := NewRegistry()
+		require.NoError(t, registry.Register(&mockProvider{name: "flixhq", mediaType: MediaTypeMovieTV}))
+
+		groups, err := registry.GroupByCollection(context.Background(), "flixhq", results)
+		require.NoError(t, err)
+		require.Len(t, groups, len(results))
+		for _, g := range groups {
+			assert.Empty(t, g.Collection)
+			assert.Len(t, g.Media, 1)
+		}
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := NewRegistry().GroupByCollection(context.Background(), "nope", results)
+		assert.Error(t, err)
+	})
+}
+
+// detailsProvider is a mockProvider that records which media IDs it was
+// asked for details of
+type detailsProvider struct {
+	mockProvider
+	mu     sync.Mutex
+	looked []string
+}
+
+func (p *detailsProvider) GetMediaDetails(ctx context.Context, id string) (*MediaDetails, error) {
+	p.mu.Lock()
+	p.looked = append(p.looked, id)
+	p.mu.Unlock()
+	if id == "broken" {
+		return nil, errors.New("lookup failed")
+	}
+	return &MediaDetails{Media: Media{ID: id}}, nil
+}
+
+func TestFilterAdult(t *testing.T) {
+	results := []Media{
+		{ID: "tagged", Genres: []string{"Comedy", "Hentai"}},
+		{ID: "listed", Genres: []string{"Action"}},
+		{ID: "labelled", SourceQuality: "18+"},
+		{ID: "spaced", Genres: []string{"Romance", " Erotic "}},
+		{ID: "unknown"},
+	}
+
+	var ids []string
+	for _, m := range FilterAdult(results) {
+		ids = append(ids, m.ID)
+	}
+	assert.Equal(t, []string{"listed", "unknown"}, ids)
+}
+
+func TestRegistry_PrefetchDetails(t *testing.T) {
+	t.Run("looks up each result once", func(t *testing.T) {
+		p := &detailsProvider{mockProvider: mockProvider{name: "sflix", mediaType: MediaTypeMovieTV}}
+		registry := NewRegistry()
+		require.NoError(t, registry.Register(p))
+
+		results := []Media{{ID: "dark"}, {ID: "broken"}, {ID: "dark"}, {ID: ""}, {ID: "dune"}}
+		require.NoError(t, registry.PrefetchDetails(context.Background(), "sflix", results))
+		assert.ElementsMatch(t, []string{"dark", "broken", "dune"}, p.looked)
+	})
+
+	t.Run("stops when cancelled", func(t *testing.T) {
+		p := &detailsProvider{mockProvider: mockProvider{name: "sflix", mediaType: MediaTypeMovieTV}}
+		registry := NewRegistry()
+		require.NoError(t, registry.Register(p))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := registry.PrefetchDetails(ctx, "sflix", []Media{{ID: "dark"}, {ID: "dune"}})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, p.looked)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		assert.Error(t, NewRegistry().PrefetchDetails(context.Background(), "nope", nil))
+	})
+}
+
+// listingProvider serves fixed trending and recent lists
+type listingProvider struct {
+	searchProvider
+	trending []Media
+	recent   []Media
+	listErr  error
+}
+
+func (p *listingProvider) GetTrending(ctx context.Context) ([]Media, error) {
+	return p.trending, p.listErr
+}
+
+func (p *listingProvider) GetRecent(ctx context.Context) ([]Media, error) {
+	return p.recent, p.listErr
+}
+
+func TestRegistry_Random(t *testing.T) {
+	newRegistry := func(t *testing.T, providers ...Provider) *Registry {
+		registry := NewRegistry()
+		for _, p := range providers {
+			require.NoError(t, registry.Register(p))
+		}
+		registry.SetRand(rand.New(rand.NewPCG(1, 2)))
+		return registry
+	}
+
+	t.Run("picks from trending first", func(t *testing.T) {
+		p := &listingProvider{
+			searchProvider: searchProvider{mockProvider: mockProvider{name: "hianime", mediaType: MediaTypeAnime}},
+			trending:       []Media{{ID: "frieren"}, {ID: "dandadan"}},
+			recent:         []Media{{ID: "recent"}},
+		}
+		media, name, err := newRegistry(t, p).Random(context.Background(), MediaTypeAnime)
+		require.NoError(t, err)
+		assert.Equal(t, "hianime", name)
+		assert.Contains(t, []string{"frieren", "dandadan"}, media.ID)
+		assert.Zero(t, p.searched)
+	})
+
+	t.Run("narrows combined listings to the requested type", func(t *testing.T) {
+		p := &listingProvider{
+			searchProvider: searchProvider{mockProvider: mockProvider{name: "sflix", mediaType: MediaTypeMovieTV}},
+			recent:         []Media{{ID: "dark", Type: MediaTypeTV}, {ID: "dune", Type: MediaTypeMovie}},
+		}
+		media, _, err := newRegistry(t, p).Random(context.Background(), MediaTypeMovie)
+		require.NoError(t, err)
+		assert.Equal(t, "dune", media.ID)
+	})
+
+	t.Run("falls back to a common-word search", func(t *testing.T) {
+		p := &listingProvider{
+			searchProvider: searchProvider{
+				mockProvider: mockProvider{name: "flixhq", mediaType: MediaTypeMovieTV},
+				results:      []Media{{ID: "found"}},
+			},
+			listErr: errors.New("not implemented"),
+		}
+		media, name, err := newRegistry(t, p).Random(context.Background(), MediaTypeMovieTV)
+		require.NoError(t, err)
+		assert.Equal(t, "flixhq", name)
+		assert.Equal(t, "found", media.ID)
+		assert.Equal(t, 1, p.searched)
+	})
+
+	t.Run("skips adult titles with safe search", func(t *testing.T) {
+		p := &listingProvider{
+			searchProvider: searchProvider{mockProvider: mockProvider{name: "hianime", mediaType: MediaTypeAnime}},
+			trending:       []Media{{ID: "adult", Genres: []string{"Hentai"}}, {ID: "frieren"}},
+		}
+		for range 10 {
+			media, _, err := newRegistry(t, p).Random(context.Background(), MediaTypeAnime)
+			require.NoError(t, err)
+			assert.Equal(t, "frieren", media.ID)
+		}
+
+		// A list of only adult titles falls through to the next source
+		p.trending = []Media{{ID: "adult", Genres: []string{"Hentai"}}}
+		p.recent = []Media{{ID: "recent"}}
+		media, _, err := newRegistry(t, p).Random(context.Background(), MediaTypeAnime)
+		require.NoError(t, err)
+		assert.Equal(t, "recent", media.ID)
+
+		registry := newRegistry(t, p)
+		registry.SetSafeSearch(false)
+		media, _, err = registry.Random(context.Background(), MediaTypeAnime)
+		require.NoError(t, err)
+		assert.Equal(t, "adult", media.ID)
+	})
+
+	t.Run("same seed, same pick", func(t *testing.T) {
+		p := &listingProvider{
+			searchProvider: searchProvider{mockProvider: mockProvider{name: "hianime", mediaType: MediaTypeAnime}},
+			trending:       []Media{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}},
+		}
+		first, _, err := newRegistry(t, p).Random(context.Background(), MediaTypeAnime)
+		require.NoError(t, err)
+		second, _, err := newRegistry(t, p).Random(context.Background(), MediaTypeAnime)
+		require.NoError(t, err)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("nothing found", func(t *testing.T) {
+		p := &listingProvider{
+			searchProvider: searchProvider{mockProvider: mockProvider{name: "comix", mediaType: MediaTypeManga}, err: errors.New("down")},
+			listErr:        errors.New("not implemented"),
+		}
+		_, _, err := newRegistry(t, p).Random(context.Background(), MediaTypeManga)
+		assert.ErrorContains(t, err, "down")
+
+		_, _, err = NewRegistry().Random(context.Background(), MediaTypeManga)
+		assert.Error(t, err)
+	})
+}
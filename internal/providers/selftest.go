@@ -0,0 +1,170 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Self-test step names, in the order they run
+const (
+	SelfTestStepSearch   = "search"
+	SelfTestStepInfo     = "info"
+	SelfTestStepEpisodes = "episodes"
+	SelfTestStepStream   = "stream"
+)
+
+// selfTestQueries are well-known titles every provider of a type should find
+var selfTestQueries = map[MediaType]string{
+	MediaTypeAnime: "One Piece",
+	MediaTypeManga: "One Piece",
+}
+
+// defaultSelfTestQuery is used for movie, TV and multi-type providers
+const defaultSelfTestQuery = "Inception"
+
+// SelfTestStep records the outcome of a single self-test step
+type SelfTestStep struct {
+	Name     string
+	Duration time.Duration
+	Detail   string // e.g. the media or episode that was used
+	Err      error
+}
+
+// SelfTestResult holds the outcome of an end-to-end provider self-test
+type SelfTestResult struct {
+	Provider string
+	Query    string
+	Steps    []SelfTestStep
+	Duration time.Duration
+}
+
+// Passed reports whether every step succeeded
+func (r SelfTestResult) Passed() bool {
+	return r.FailedStep() == nil
+}
+
+// FailedStep returns the step that failed, or nil if all steps passed
+func (r SelfTestResult) FailedStep() *SelfTestStep {
+	for i := range r.Steps {
+		if r.Steps[i].Err != nil {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// SelfTest runs a canned search against a provider, fetches info for the first
+// result, lists its episodes and resolves a stream URL (or manga pages),
+// timing each step. It stops at the first failing step and returns an error
+// naming it; the result is populated either way.
+func (r *Registry) SelfTest(ctx context.Context, name string) (result SelfTestResult, err error) {
+	provider, err := r.Get(name)
+	if err != nil {
+		return SelfTestResult{Provider: name}, err
+	}
+
+	query, ok := selfTestQueries[provider.Type()]
+	if !ok {
+		query = defaultSelfTestQuery
+	}
+
+	result = SelfTestResult{Provider: name, Query: query}
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	run := func(step string, fn func() (string, error)) error {
+		stepStart := time.Now()
+		detail, err := fn()
+		result.Steps = append(result.Steps, SelfTestStep{
+			Name:     step,
+			Duration: time.Since(stepStart),
+			Detail:   detail,
+			Err:      err,
+		})
+		if err != nil {
+			return fmt.Errorf("step %d (%s) failed: %w", len(result.Steps), step, err)
+		}
+		return nil
+	}
+
+	var media Media
+	if err := run(SelfTestStepSearch, func() (string, error) {
+		results, err := provider.Search(ctx, query)
+		if err != nil {
+			return "", err
+		}
+		if len(results) == 0 {
+			return "", fmt.Errorf("no results for %q", query)
+		}
+		media = results[0]
+		return fmt.Sprintf("%d results, using %q", len(results), media.Title), nil
+	}); err != nil {
+		return result, err
+	}
+
+	if err := run(SelfTestStepInfo, func() (string, error) {
+		details, err := provider.GetMediaDetails(ctx, media.ID)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%q (%d seasons)", details.Title, len(details.Seasons)), nil
+	}); err != nil {
+		return result, err
+	}
+
+	var episode Episode
+	if err := run(SelfTestStepEpisodes, func() (string, error) {
+		seasonID := media.ID
+		seasons, err := provider.GetSeasons(ctx, media.ID)
+		if err != nil {
+			return "", err
+		}
+		if len(seasons) > 0 {
+			seasonID = seasons[0].ID
+		}
+
+		episodes, err := provider.GetEpisodes(ctx, seasonID)
+		if err != nil {
+			return "", err
+		}
+		if len(episodes) == 0 {
+			return "", fmt.Errorf("no episodes for %q", media.Title)
+		}
+		episode = episodes[0]
+		return fmt.Sprintf("%d episodes, using episode %d", len(episodes), episode.Number), nil
+	}); err != nil {
+		return result, err
+	}
+
+	if err := run(SelfTestStepStream, func() (string, error) {
+		if manga, ok := provider.(MangaProvider); ok {
+			pages, err := manga.GetMangaPages(ctx, episode.ID)
+			if err != nil {
+				return "", err
+			}
+			if len(pages) == 0 {
+				return "", fmt.Errorf("no pages for chapter %d", episode.Number)
+			}
+			return fmt.Sprintf("%d pages", len(pages)), nil
+		}
+
+		stream, err := provider.GetStreamURL(ctx, episode.ID, QualityAuto)
+		if err != nil {
+			return "", err
+		}
+		if stream == nil || stream.URL == "" {
+			return "", fmt.Errorf("empty stream URL")
+		}
+		return fmt.Sprintf("%s stream (%s)", stream.Type, stream.Quality), nil
+	}); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// SelfTest runs an end-to-end self-test of a provider in the global registry
+func SelfTest(ctx context.Context, name string) (SelfTestResult, error) {
+	return globalRegistry.SelfTest(ctx, name)
+}
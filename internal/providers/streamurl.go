@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"net/url"
+	"strings"
+)
+
+// maxRedirectDepth bounds how many nested redirect wrappers are unwrapped
+const maxRedirectDepth = 5
+
+// redirectPaths are the paths of known redirector endpoints, which carry the
+// real target in a query parameter
+var redirectPaths = map[string]bool{
+	"/redirect":     true,
+	"/redirect.php": true,
+	"/out":          true,
+	"/out.php":      true,
+	"/go":           true,
+	"/go.php":       true,
+	"/away":         true,
+	"/away.php":     true,
+	"/url":          true,
+	"/link":         true,
+	"/l.php":        true,
+	"/r":            true,
+}
+
+// redirectParams are the query parameters redirectors put the target in,
+// in the order they are checked
+var redirectParams = []string{"url", "u", "q", "link", "target", "to", "dest", "destination"}
+
+// trackingParams are query parameters that only identify where a link was
+// shared from; utm_* parameters are dropped as well
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_eid":  true,
+	"igshid":  true,
+}
+
+// CleanStreamURL unwraps known redirect wrappers around an extracted stream
+// URL, such as "https://example.com/redirect?url=https%3A%2F%2Fcdn...", and
+// drops tracking parameters. Other query parameters, including expiring
+// tokens the CDN needs, are kept. URLs that can't be parsed are returned
+// trimmed but otherwise unchanged.
+func CleanStreamURL(rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+
+	for range maxRedirectDepth {
+		target, ok := redirectTarget(u)
+		if !ok {
+			break
+		}
+		u = target
+	}
+
+	u.RawQuery = stripTrackingParams(u.RawQuery)
+	return u.String()
+}
+
+// stripTrackingParams removes tracking parameters from a raw query string.
+// The remaining parameters keep their order and encoding, as signed CDN URLs
+// may cover the query exactly as it was issued.
+func stripTrackingParams(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+	params := strings.Split(rawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		key = strings.ToLower(key)
+		if trackingParams[key] || strings.HasPrefix(key, "utm_") {
+			continue
+		}
+		kept = append(kept, param)
+	}
+	return strings.Join(kept, "&")
+}
+
+// redirectTarget returns the URL a redirector points to, if u is one
+func redirectTarget(u *url.URL) (*url.URL, bool) {
+	if !redirectPaths[strings.ToLower(strings.TrimSuffix(u.Path, "/"))] {
+		return nil, false
+	}
+	query := u.Query()
+	for _, param := range redirectParams {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		target, err := url.Parse(value)
+		if err != nil || target.Host == "" || (target.Scheme != "http" && target.Scheme != "https") {
+			continue
+		}
+		return target, true
+	}
+	return nil, false
+}
+
+// RedactedStreamURL returns rawURL with its query values, credentials and
+// fragment masked so it can be logged or pasted into a bug report without
+// leaking tokens. The host, path and parameter names are kept for
+// debugging. URLs that can't be parsed are replaced entirely.
+func RedactedStreamURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "[unparseable URL]"
+	}
+
+	if u.User != nil {
+		u.User = url.User("REDACTED")
+	}
+	if u.Fragment != "" {
+		u.Fragment = "REDACTED"
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key, values := range query {
+			for i := range values {
+				values[i] = "REDACTED"
+			}
+			query[key] = values
+		}
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/clock"
+)
+
+const (
+	// defaultThrottleCooldown is how long a host is left alone after blocking
+	// us when the response carries no Retry-After
+	defaultThrottleCooldown = time.Minute
+	// blockedStrikes is how many 403 or 503 responses in a row from a host
+	// start a cooldown. MirrorTransport already retries these, so one left
+	// over is not enough to give up on the whole site.
+	blockedStrikes = 3
+)
+
+// ThrottledError is returned by FetchBody while a host is cooling down after
+// a 429 response or repeated 403 and 503 responses
+type ThrottledError struct {
+	Host  string
+	Until time.Time
+}
+
+func (e *ThrottledError) Error() string {
+	wait := time.Until(e.Until).Round(time.Second)
+	return fmt.Sprintf("%s is rate limiting requests, try again in %s", e.Host, max(wait, time.Second))
+}
+
+// Unwrap lets errors.Is(err, ErrThrottled) match throttled fetches
+func (e *ThrottledError) Unwrap() error {
+	return ErrThrottled
+}
+
+// throttleTable records per-host "blocked until" times, and how many blocked
+// responses each host has sent in a row
+type throttleTable struct {
+	mu      sync.Mutex
+	until   map[string]time.Time
+	strikes map[string]int
+	clock   clock.Clock
+}
+
+var throttles = newThrottleTable(clock.Real{})
+
+func newThrottleTable(c clock.Clock) *throttleTable {
+	return &throttleTable{
+		until:   make(map[string]time.Time),
+		strikes: make(map[string]int),
+		clock:   c,
+	}
+}
+
+// block marks host as throttled for d
+func (t *throttleTable) block(host string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.blockLocked(host, d)
+}
+
+func (t *throttleTable) blockLocked(host string, d time.Duration) {
+	if host == "" || d <= 0 {
+		return
+	}
+	until := t.clock.Now().Add(d)
+	if until.After(t.until[host]) {
+		t.until[host] = until
+	}
+}
+
+// observe updates the cooldown of the host that answered resp. A 429 starts
+// one straight away; 403 and 503 only after blockedStrikes in a row. Any
+// other status clears the host's strikes.
+func (t *throttleTable) observe(resp *http.Response) {
+	if resp.Request == nil || resp.Request.URL == nil {
+		return
+	}
+	host := strings.ToLower(resp.Request.URL.Host)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		delete(t.strikes, host)
+		t.blockLocked(host, RetryAfter(resp, defaultThrottleCooldown))
+	case IsBlockedStatus(resp.StatusCode):
+		t.strikes[host]++
+		if t.strikes[host] >= blockedStrikes {
+			delete(t.strikes, host)
+			t.blockLocked(host, RetryAfter(resp, defaultThrottleCooldown))
+		}
+	default:
+		delete(t.strikes, host)
+	}
+}
+
+// check returns when host's cooldown ends, if it is still cooling down
+func (t *throttleTable) check(host string) (time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	until, ok := t.until[host]
+	if !ok {
+		return time.Time{}, false
+	}
+	if !t.clock.Now().Before(until) {
+		delete(t.until, host)
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// throttleKey returns the host a URL's cooldown is tracked under
+func throttleKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Host)
+}
+
+// ThrottledUntil reports whether requests to rawURL's host are cooling down
+// after being blocked, and until when
+func ThrottledUntil(rawURL string) (bool, time.Time) {
+	until, ok := throttles.check(throttleKey(rawURL))
+	return ok, until
+}
@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	titleWatchPrefix  = regexp.MustCompile(`(?i)^watch\s+`)
+	titleOnlineSuffix = regexp.MustCompile(`(?i)\s+(?:full\s+(?:movie|tv\s+series|tv\s+show)\s+)?online\b.*$`)
+	titleYearSuffix   = regexp.MustCompile(`\s*\(\d{4}\)$`)
+	slugPrefix        = regexp.MustCompile(`^(?:watch|free)-`)
+	slugSuffix        = regexp.MustCompile(`(?:-hd)?-\d+$`)
+
+	// episodeLabelPrefix matches "Episode 3:", "Ep. 3 -", "EP03" or "Eps 3"
+	// at the start of an episode title
+	episodeLabelPrefix = regexp.MustCompile(`(?i)^(?:episode|eps?)\.?\s*\d+(?:\.\d+)?(?:\s*[:.|\-–—]\s*|\s+|$)`)
+	// episodeCodePrefix matches "E03 -" or "S01E03:"; unlike the spelled out
+	// label it needs punctuation after it, so titles like "E3 Expo" survive
+	episodeCodePrefix = regexp.MustCompile(`(?i)^(?:s\d+\s*)?e\d+(?:\s*[:.|\-–—]\s*|$)`)
+	// titleSeparators are the characters left dangling around a stripped prefix
+	titleSeparators = " :|-–—"
+)
+
+// PageTitle returns heading if it isn't empty, and otherwise falls back to
+// the page's og:title meta tag, then its <title> element and finally the
+// title spelled out by the slug in id (e.g. "movie/watch-inception-19764").
+// Page titles have site boilerplate such as "Watch ... Online Free on
+// site" or "... | site" stripped, with site matched case-insensitively.
+func PageTitle(doc *goquery.Document, heading, id, site string) string {
+	if title := strings.TrimSpace(heading); title != "" {
+		return title
+	}
+
+	if doc != nil {
+		if og, ok := doc.Find(`meta[property="og:title"]`).Attr("content"); ok {
+			if title := stripSiteTitle(og, site); title != "" {
+				return title
+			}
+		}
+		if title := stripSiteTitle(doc.Find("title").First().Text(), site); title != "" {
+			return title
+		}
+	}
+
+	return slugTitle(id)
+}
+
+// stripSiteTitle removes the site name and streaming boilerplate from a page
+// title
+func stripSiteTitle(title, site string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	if site != "" {
+		quoted := regexp.QuoteMeta(site)
+		siteSuffix := regexp.MustCompile(`(?i)\s*(?:[|\-–—]\s*` + quoted + `|\bon\s+` + quoted + `)\s*$`)
+		title = siteSuffix.ReplaceAllString(title, "")
+		if strings.EqualFold(title, site) {
+			return ""
+		}
+	}
+	title = titleWatchPrefix.ReplaceAllString(title, "")
+	title = titleOnlineSuffix.ReplaceAllString(title, "")
+	title = titleYearSuffix.ReplaceAllString(title, "")
+	return strings.TrimSpace(title)
+}
+
+// slugTitle turns the last path segment of an ID such as
+// "movie/free-spider-man-hd-11223" into "Spider Man"
+func slugTitle(id string) string {
+	slug := path.Base(strings.Trim(id, "/"))
+	if slug == "." || slug == "/" {
+		return ""
+	}
+	slug = slugSuffix.ReplaceAllString(slug, "")
+	slug = slugPrefix.ReplaceAllString(slug, "")
+
+	words := strings.FieldsFunc(slug, func(r rune) bool { return r == '-' || r == '_' })
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// EpisodeTitle cleans up a scraped episode title for display. Whitespace is
+// collapsed, and a leading show name or "Episode N" label is stripped, since
+// the number is shown separately. A title that is nothing but the label
+// becomes empty; one that is just the show name is kept, as pilots are often
+// named after the show.
+func EpisodeTitle(title, show string) string {
+	title = strings.Join(strings.Fields(title), " ")
+	show = strings.Join(strings.Fields(show), " ")
+
+	if show != "" && len(title) > len(show) && strings.EqualFold(title[:len(show)], show) {
+		if rest := title[len(show):]; strings.IndexAny(rest, titleSeparators) == 0 {
+			title = strings.TrimLeft(rest, titleSeparators)
+		}
+	}
+
+	title = episodeLabelPrefix.ReplaceAllString(title, "")
+	title = episodeCodePrefix.ReplaceAllString(title, "")
+	return strings.Trim(title, titleSeparators)
+}
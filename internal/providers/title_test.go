@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageTitle(t *testing.T) {
+	tests := []struct {
+		name    string
+		html    string
+		heading string
+		id      string
+		want    string
+	}{
+		{"heading wins", `<meta property="og:title" content="Other">`, " Inception ", "movie/watch-inception-19764", "Inception"},
+		{"og:title", `<meta property="og:title" content="Watch Inception Full Movie Online Free on FlixHQ">`, "", "movie/watch-inception-19764", "Inception"},
+		{"title element with site suffix", `<title>Dark (2017) | FlixHQ</title>`, "", "tv/watch-dark-39490", "Dark"},
+		{"keeps titles that mention free", `<title>Born Free - FlixHQ</title>`, "", "movie/watch-born-free-1", "Born Free"},
+		{"slug when the page has no title", `<title>FlixHQ</title>`, "", "movie/free-spider-man-hd-11223", "Spider Man"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><head>" + tt.html + "</head><body></body></html>"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, PageTitle(doc, tt.heading, tt.id, "FlixHQ"))
+		})
+	}
+}
+
+func TestEpisodeTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		show  string
+		want  string
+	}{
+		{"Episode 3: The Bridge", "Dark", "The Bridge"},
+		{"  Eps 12:   Secrets\n ", "Dark", "Secrets"},
+		{"Ep. 4 - Double Lives", "Dark", "Double Lives"},
+		{"EP03 The Hunt", "", "The Hunt"},
+		{"Episode 10.5: Recap", "", "Recap"},
+		{"S01E02 - Lies", "", "Lies"},
+		{"E05: Truths", "", "Truths"},
+		{"Dark - Episode 1: Secrets", "Dark", "Secrets"},
+		{"DARK: Beginnings and Endings", "Dark", "Beginnings and Endings"},
+		{"Episode 7:", "", ""},
+		{"Episode 7", "", ""},
+		{"Sic Mundus Creatus Est:", "", "Sic Mundus Creatus Est"},
+		// Genuine titles are left alone
+		{"Dark", "Dark", "Dark"},
+		{"Darkness Falls", "Dark", "Darkness Falls"},
+		{"E3 Expo", "", "E3 Expo"},
+		{"Episode of the Year", "", "Episode of the Year"},
+		{"The Episode 5 Problem", "", "The Episode 5 Problem"},
+		{"", "Dark", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			assert.Equal(t, tt.want, EpisodeTitle(tt.title, tt.show))
+		})
+	}
+}
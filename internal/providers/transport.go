@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/justchokingaround/greg/internal/config"
+)
+
+var (
+	transportMu     sync.RWMutex
+	sharedTransport http.RoundTripper = http.DefaultTransport
+)
+
+// NewTransport builds an HTTP transport with the connection pool, proxy and
+// TLS settings from cfg. Zero values keep the standard library defaults. With
+// DisableKeepAlives every request opens a fresh connection, trading speed
+// for resilience on networks that silently drop idle ones. An invalid proxy
+// URL is reported as an error; the returned transport then uses the proxy
+// from the environment, as it does when none is set.
+func NewTransport(cfg config.NetworkConfig) (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.MaxIdleConns > 0 {
+		t.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		t.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	t.DisableKeepAlives = cfg.DisableKeepAlives
+
+	if !cfg.VerifyTLS {
+		if t.TLSClientConfig == nil {
+			t.TLSClientConfig = &tls.Config{}
+		}
+		t.TLSClientConfig.InsecureSkipVerify = true
+	}
+
+	if proxy := strings.TrimSpace(cfg.Proxy); proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return t, fmt.Errorf("invalid network.proxy %q", proxy)
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	return t, nil
+}
+
+// SetTransport replaces the transport scraping providers send requests
+// through. It is shared so concurrent requests to the same site reuse
+// pooled connections.
+func SetTransport(t http.RoundTripper) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	sharedTransport = t
+}
+
+// Transport returns the shared provider transport
+func Transport() http.RoundTripper {
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return sharedTransport
+}
+
+// SharedTransport sends each request through whatever Transport returns at
+// the time, so clients built before SetTransport still use the configured one
+type SharedTransport struct{}
+
+// RoundTrip implements http.RoundTripper
+func (SharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return Transport().RoundTrip(req)
+}
@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/justchokingaround/greg/internal/config"
+)
+
+// AbsoluteURL resolves a scraped URL against the provider base URL.
+// Protocol-relative ("//img.example.com/a.jpg") and root-relative
+// ("/subs/en.vtt") references become absolute; absolute URLs and empty
+// strings are returned unchanged.
+func AbsoluteURL(base, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ""
+	}
+
+	refURL, err := url.Parse(ref)
+	if err != nil || refURL.IsAbs() {
+		return ref
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil || !baseURL.IsAbs() {
+		return ref
+	}
+
+	return baseURL.ResolveReference(refURL).String()
+}
+
+// posterSizeToken matches the WIDTHxHEIGHT path segment image CDNs use to
+// pick a resized poster, e.g. ".../resize/300x450/ab/cd/poster.jpg"
+var posterSizeToken = regexp.MustCompile(`/\d{2,4}x\d{2,4}/`)
+
+// Pixels per terminal cell used to turn ui.preview_size into an image size.
+// Cells are roughly twice as tall as wide; erring large keeps previews sharp
+// on HiDPI terminals.
+const (
+	previewCellWidth  = 10
+	previewCellHeight = 20
+)
+
+// PosterURLAt rewrites the size token in a poster URL to request the image
+// at width x height. URLs without a recognized token, and non-positive
+// sizes, are returned unchanged.
+func PosterURLAt(posterURL string, width, height int) string {
+	if width <= 0 || height <= 0 {
+		return posterURL
+	}
+	loc := posterSizeToken.FindAllStringIndex(posterURL, -1)
+	if len(loc) == 0 {
+		return posterURL
+	}
+	last := loc[len(loc)-1]
+	return posterURL[:last[0]] + fmt.Sprintf("/%dx%d/", width, height) + posterURL[last[1]:]
+}
+
+// PreviewPosterSize returns the poster size in pixels to request for a
+// preview of the given size in terminal cells, keeping the 2:3 aspect ratio
+// posters are published in. It returns zeros when no size is configured.
+func PreviewPosterSize(size config.PreviewSize) (width, height int) {
+	if size.Width <= 0 && size.Height <= 0 {
+		return 0, 0
+	}
+	width = max(size.Width*previewCellWidth, size.Height*previewCellHeight*2/3)
+	return width, width * 3 / 2
+}
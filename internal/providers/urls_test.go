@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAbsoluteURL(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+		ref  string
+		want string
+	}{
+		{"protocol-relative", "https://sflix.ps", "//img.sflix.ps/poster.jpg", "https://img.sflix.ps/poster.jpg"},
+		{"root-relative", "https://sflix.ps", "/subs/en.vtt", "https://sflix.ps/subs/en.vtt"},
+		{"root-relative with base path", "https://example.com/api/movies", "/subs/en.vtt", "https://example.com/subs/en.vtt"},
+		{"already absolute", "https://sflix.ps", "https://cdn.example.com/a.jpg", "https://cdn.example.com/a.jpg"},
+		{"empty", "https://sflix.ps", "", ""},
+		{"invalid base", "", "/subs/en.vtt", "/subs/en.vtt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AbsoluteURL(tt.base, tt.ref))
+		})
+	}
+}
+
+func TestPosterURLAt(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"rewrites the size token", "https://img.flixhq.to/xxrz/250x400/379/ab/cd/poster.jpg", "https://img.flixhq.to/xxrz/400x600/379/ab/cd/poster.jpg"},
+		{"rewrites only the last token", "https://cdn.example.com/100x100/resize/300x450/poster.jpg", "https://cdn.example.com/100x100/resize/400x600/poster.jpg"},
+		{"unrecognized pattern", "https://img.flixhq.to/poster.jpg", "https://img.flixhq.to/poster.jpg"},
+		{"size in the file name", "https://img.example.com/poster-300x450.jpg", "https://img.example.com/poster-300x450.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PosterURLAt(tt.url, 400, 600))
+		})
+	}
+
+	t.Run("no size keeps the URL", func(t *testing.T) {
+		url := "https://img.flixhq.to/xxrz/250x400/poster.jpg"
+		assert.Equal(t, url, PosterURLAt(url, 0, 0))
+	})
+}
+
+func TestPreviewPosterSize(t *testing.T) {
+	w, h := PreviewPosterSize(config.PreviewSize{Width: 40, Height: 20})
+	assert.Equal(t, 400, w)
+	assert.Equal(t, 600, h)
+
+	// A tall preview is limited by its height
+	w, h = PreviewPosterSize(config.PreviewSize{Width: 20, Height: 40})
+	assert.Equal(t, 533, w)
+	assert.Equal(t, 799, h)
+
+	w, h = PreviewPosterSize(config.PreviewSize{})
+	assert.Zero(t, w)
+	assert.Zero(t, h)
+}
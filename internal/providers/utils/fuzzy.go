@@ -10,85 +10,13 @@ import (
 // It returns the minimum number of single-character edits (insertions, deletions, or substitutions)
 // required to change one string into the other
 func LevenshteinDistance(s1, s2 string) int {
-	len1 := len(s1)
-	len2 := len(s2)
-
-	// Create a 2D matrix to store distances
-	matrix := make([][]int, len1+1)
-	for i := range matrix {
-		matrix[i] = make([]int, len2+1)
-	}
-
-	// Initialize first row and column
-	for i := 0; i <= len1; i++ {
-		matrix[i][0] = i
-	}
-	for j := 0; j <= len2; j++ {
-		matrix[0][j] = j
-	}
-
-	// Fill in the rest of the matrix
-	for i := 1; i <= len1; i++ {
-		for j := 1; j <= len2; j++ {
-			cost := 0
-			if s1[i-1] != s2[j-1] {
-				cost = 1
-			}
-
-			matrix[i][j] = min(
-				matrix[i-1][j]+1,      // deletion
-				matrix[i][j-1]+1,      // insertion
-				matrix[i-1][j-1]+cost, // substitution
-			)
-		}
-	}
-
-	return matrix[len1][len2]
-}
-
-// min returns the minimum of three integers
-func min(a, b, c int) int {
-	if a < b {
-		if a < c {
-			return a
-		}
-		return c
-	}
-	if b < c {
-		return b
-	}
-	return c
+	return providers.LevenshteinDistance(s1, s2)
 }
 
 // SimilarityScore calculates a similarity score between two titles (0.0 to 1.0)
 // 1.0 means perfect match, 0.0 means completely different
 func SimilarityScore(title1, title2 string) float64 {
-	// Normalize both titles
-	norm1 := NormalizeTitle(title1)
-	norm2 := NormalizeTitle(title2)
-
-	// Handle empty strings
-	if norm1 == "" || norm2 == "" {
-		return 0.0
-	}
-
-	// Calculate Levenshtein distance
-	distance := LevenshteinDistance(norm1, norm2)
-
-	// Calculate similarity as 1 - (distance / max_length)
-	maxLen := len(norm1)
-	if len(norm2) > maxLen {
-		maxLen = len(norm2)
-	}
-
-	similarity := 1.0 - float64(distance)/float64(maxLen)
-
-	// Ensure similarity is between 0 and 1
-	if similarity < 0 {
-		similarity = 0
-	}
-
-	return similarity
+	return providers.MatchScore(title1, title2)
 }
 
 // MatchResult represents a search result with its similarity score
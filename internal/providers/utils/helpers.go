@@ -4,6 +4,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/justchokingaround/greg/internal/providers"
 )
 
 var (
@@ -130,24 +132,5 @@ func DefaultInt(values ...int) int {
 // NormalizeTitle normalizes a title for comparison
 // Removes special characters, converts to lowercase, removes season/part patterns
 func NormalizeTitle(title string) string {
-	// Convert to lowercase
-	title = strings.ToLower(title)
-
-	// Remove "season N" patterns
-	title = regexp.MustCompile(`\s*season\s+\d+\s*`).ReplaceAllString(title, " ")
-
-	// Remove "part N" patterns
-	title = regexp.MustCompile(`\s*part\s+\d+\s*`).ReplaceAllString(title, " ")
-
-	// Remove special characters except spaces and alphanumeric (including unicode)
-	result := strings.Builder{}
-	for _, r := range title {
-		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == ' ' || r > 127 {
-			result.WriteRune(r)
-		}
-	}
-
-	// Clean whitespace
-	title = CleanText(result.String())
-	return title
+	return providers.NormalizeTitle(title)
 }
@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+const (
+	// titleMismatchScore is the similarity below which a search result's
+	// title and its info page's title are considered different shows
+	titleMismatchScore = 0.6
+	// yearMismatchTolerance allows for a show listed by its first air date on
+	// one page and its premiere season's year on another
+	yearMismatchTolerance = 1
+)
+
+// CheckTitleMatch compares a search result with the details fetched for it
+// and returns an error wrapping providers.ErrTitleMismatch if the info page
+// names a different title or a year further off than a year. Titles where one
+// contains the other ("Dark" and "Dark (2017)") still match, and a missing
+// year on either side is not a mismatch.
+func CheckTitleMatch(result providers.Media, details *providers.MediaDetails) error {
+	if details == nil {
+		return nil
+	}
+
+	if result.Title != "" && details.Title != "" && !titlesMatch(result.Title, details.Title) {
+		return fmt.Errorf("%w: picked %q but the info page is for %q", providers.ErrTitleMismatch, result.Title, details.Title)
+	}
+
+	resultYear, detailsYear := MediaYear(result), MediaYear(details.Media)
+	if resultYear != 0 && detailsYear != 0 {
+		diff := resultYear - detailsYear
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > yearMismatchTolerance {
+			return fmt.Errorf("%w: picked %q (%d) but the info page is from %d", providers.ErrTitleMismatch, result.Title, resultYear, detailsYear)
+		}
+	}
+
+	return nil
+}
+
+// titlesMatch reports whether two titles plausibly name the same show
+func titlesMatch(a, b string) bool {
+	normA, normB := NormalizeTitle(a), NormalizeTitle(b)
+	if normA == "" || normB == "" {
+		return true
+	}
+	if strings.Contains(normA, normB) || strings.Contains(normB, normA) {
+		return true
+	}
+	return SimilarityScore(a, b) >= titleMismatchScore
+}
+
+// MediaYear returns the media's year, falling back to the leading year of its
+// status, which movie providers fill with the release date
+func MediaYear(m providers.Media) int {
+	if m.Year != 0 {
+		return m.Year
+	}
+	status := strings.TrimSpace(m.Status)
+	if len(status) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(status[:4])
+	if err != nil || year < 1870 {
+		return 0
+	}
+	return year
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+func TestCheckTitleMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   providers.Media
+		details  providers.Media
+		mismatch bool
+	}{
+		{"same title and year", providers.Media{Title: "Inception", Year: 2010}, providers.Media{Title: "Inception", Status: "2010-07-16"}, false},
+		{"title with year suffix", providers.Media{Title: "Dark"}, providers.Media{Title: "Dark (2017)"}, false},
+		{"small spelling difference", providers.Media{Title: "Spider-Man: Homecoming"}, providers.Media{Title: "Spiderman Homecoming"}, false},
+		{"year off by one", providers.Media{Title: "Dark", Year: 2017}, providers.Media{Title: "Dark", Year: 2018}, false},
+		{"missing year", providers.Media{Title: "Dune", Year: 2021}, providers.Media{Title: "Dune"}, false},
+		{"different title", providers.Media{Title: "Inception", Year: 2010}, providers.Media{Title: "Interstellar", Year: 2010}, true},
+		{"same title different year", providers.Media{Title: "Dune", Year: 2021}, providers.Media{Title: "Dune", Status: "1984-12-14"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckTitleMatch(tt.result, &providers.MediaDetails{Media: tt.details})
+			if got := errors.Is(err, providers.ErrTitleMismatch); got != tt.mismatch {
+				t.Errorf("CheckTitleMatch() = %v, want mismatch %v", err, tt.mismatch)
+			}
+		})
+	}
+}
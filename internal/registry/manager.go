@@ -2,17 +2,11 @@ package registry
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
-	"github.com/justchokingaround/greg/internal/providers/anime/allanime"
-	"github.com/justchokingaround/greg/internal/providers/anime/hdrezka"
-	"github.com/justchokingaround/greg/internal/providers/anime/hianime"
-	"github.com/justchokingaround/greg/internal/providers/manga/comix"
-	"github.com/justchokingaround/greg/internal/providers/movies/flixhq"
-	hdrezkamovie "github.com/justchokingaround/greg/internal/providers/movies/hdrezka"
-	"github.com/justchokingaround/greg/internal/providers/movies/sflix"
 	"github.com/justchokingaround/greg/internal/providers/remote"
 )
 
@@ -26,11 +20,18 @@ func New() *Registry {
 	}
 }
 
+// Load instantiates every provider registered with providers.RegisterFactory
+// that is enabled in cfg. Provider packages register themselves when
+// imported, usually through internal/providers/all. Providers without a
+// config section are enabled with default settings.
 func (r *Registry) Load(cfg *config.Config) {
-	// Helper to register a provider
-	register := func(name string, settings config.ProviderSettings, localFactory func() providers.Provider, mediaType string) {
+	for _, factory := range providers.Factories() {
+		settings, ok := cfg.Providers.Settings(factory.Name)
+		if !ok {
+			settings = config.ProviderSettings{Enabled: true}
+		}
 		if !settings.Enabled {
-			return
+			continue
 		}
 
 		if settings.Mode == "remote" {
@@ -40,27 +41,16 @@ func (r *Registry) Load(cfg *config.Config) {
 				url := settings.RemoteURL
 				// If URL is generic (no type/name path), append them
 				// This assumes greg-api structure: /type/name
-				if !strings.Contains(url, "/"+name) {
-					url = fmt.Sprintf("%s/%s/%s", strings.TrimRight(url, "/"), mediaType, name)
+				if !strings.Contains(url, "/"+factory.Name) {
+					url = fmt.Sprintf("%s/%s/%s", strings.TrimRight(url, "/"), factory.MediaType, factory.Name)
 				}
-				r.providers[name] = remote.New(name, url)
-			}
-		} else {
-			// Use local factory
-			if localFactory != nil {
-				r.providers[name] = localFactory()
+				r.providers[factory.Name] = remote.New(factory.Name, url)
 			}
+			continue
 		}
-	}
 
-	// Register known providers
-	register("hianime", cfg.Providers.HiAnime, func() providers.Provider { return hianime.New() }, "anime")
-	register("allanime", cfg.Providers.AllAnime, func() providers.Provider { return allanime.New() }, "anime")
-	register("sflix", cfg.Providers.SFlix, func() providers.Provider { return sflix.New() }, "movies")
-	register("flixhq", cfg.Providers.FlixHQ, func() providers.Provider { return flixhq.New() }, "movies")
-	register("hdrezka", cfg.Providers.HDRezka, func() providers.Provider { return hdrezkamovie.New() }, "movies")
-	register("hdrezka_anime", cfg.Providers.HDRezka, func() providers.Provider { return hdrezka.New() }, "anime") // Special case for anime wrapper
-	register("comix", cfg.Providers.Comix, func() providers.Provider { return comix.New() }, "manga")
+		r.providers[factory.Name] = factory.New(settings)
+	}
 }
 
 func (r *Registry) Get(name string) (providers.Provider, error) {
@@ -75,5 +65,6 @@ func (r *Registry) List() []string {
 	for k := range r.providers {
 		keys = append(keys, k)
 	}
+	sort.Strings(keys)
 	return keys
 }
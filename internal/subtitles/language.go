@@ -0,0 +1,87 @@
+package subtitles
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Language holds the codes a subtitle language may be labelled with
+type Language struct {
+	ISO1 string // ISO 639-1, as used in player.subtitle_language and downloads.subtitle_languages
+	ISO2 string // ISO 639-2, as written into container metadata
+	Name string // English name, as used in provider labels
+}
+
+var languages = []Language{
+	{"ar", "ara", "arabic"},
+	{"bg", "bul", "bulgarian"},
+	{"cs", "ces", "czech"},
+	{"da", "dan", "danish"},
+	{"de", "deu", "german"},
+	{"el", "ell", "greek"},
+	{"en", "eng", "english"},
+	{"es", "spa", "spanish"},
+	{"fi", "fin", "finnish"},
+	{"fr", "fra", "french"},
+	{"he", "heb", "hebrew"},
+	{"hi", "hin", "hindi"},
+	{"hr", "hrv", "croatian"},
+	{"hu", "hun", "hungarian"},
+	{"id", "ind", "indonesian"},
+	{"it", "ita", "italian"},
+	{"ja", "jpn", "japanese"},
+	{"ko", "kor", "korean"},
+	{"ms", "msa", "malay"},
+	{"nl", "nld", "dutch"},
+	{"no", "nor", "norwegian"},
+	{"pl", "pol", "polish"},
+	{"pt", "por", "portuguese"},
+	{"ro", "ron", "romanian"},
+	{"ru", "rus", "russian"},
+	{"sr", "srp", "serbian"},
+	{"sv", "swe", "swedish"},
+	{"th", "tha", "thai"},
+	{"tr", "tur", "turkish"},
+	{"uk", "ukr", "ukrainian"},
+	{"vi", "vie", "vietnamese"},
+	{"zh", "zho", "chinese"},
+}
+
+// LookupLanguage identifies the language of a subtitle label such as
+// "en", "eng", "en-US", "English" or "Portuguese (Brazil)"
+func LookupLanguage(label string) (Language, bool) {
+	label = strings.ToLower(strings.TrimSpace(label))
+	if label == "" {
+		return Language{}, false
+	}
+
+	// Region tags: en-US, pt_BR
+	code := label
+	if i := strings.IndexAny(code, "-_"); i > 0 {
+		code = code[:i]
+	}
+
+	for _, lang := range languages {
+		if code == lang.ISO1 || code == lang.ISO2 {
+			return lang, true
+		}
+	}
+
+	// Provider labels: "English", "English - SDH", "Spanish (Latin America)"
+	for _, lang := range languages {
+		rest, found := strings.CutPrefix(label, lang.Name)
+		if found && (rest == "" || !unicode.IsLetter(rune(rest[0]))) {
+			return lang, true
+		}
+	}
+
+	return Language{}, false
+}
+
+// MatchesLanguage reports whether a subtitle labelled label is in the
+// language wanted, comparing by language when both are recognised
+func MatchesLanguage(label, wanted string) bool {
+	have, known := LookupLanguage(label)
+	want, ok := LookupLanguage(wanted)
+	return (ok && known && have.ISO1 == want.ISO1) || strings.EqualFold(label, wanted)
+}
@@ -0,0 +1,333 @@
+// Package opensubtitles searches and downloads subtitles from the
+// OpenSubtitles REST API
+package opensubtitles
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/subtitles"
+)
+
+const (
+	defaultBaseURL = "https://api.opensubtitles.com/api/v1"
+	defaultTimeout = 15 * time.Second
+	userAgent      = "greg v1"
+	// requestDelay keeps us under the API's limit of 5 requests per second
+	requestDelay = 250 * time.Millisecond
+	// maxRetryWait caps how long a rate-limited request waits before retrying
+	// once; longer waits are reported as ErrRateLimited instead
+	maxRetryWait = 10 * time.Second
+	// maxSubtitleSize bounds a downloaded subtitle file
+	maxSubtitleSize = 10 << 20
+)
+
+var (
+	// ErrUnauthorized is returned when the API key or the login is rejected
+	ErrUnauthorized = errors.New("opensubtitles: unauthorized")
+
+	// ErrRateLimited is returned when the API asks us to back off for longer
+	// than we are willing to wait
+	ErrRateLimited = errors.New("opensubtitles: rate limited")
+
+	// ErrQuotaExceeded is returned when the account's daily download quota
+	// is used up
+	ErrQuotaExceeded = errors.New("opensubtitles: download quota exceeded")
+)
+
+// Client is a subtitles.SubtitleProvider backed by OpenSubtitles. Searching
+// only needs an API key; downloads work anonymously within a small daily
+// quota, which logging in with a username and password raises.
+type Client struct {
+	apiKey   string
+	username string
+	password string
+	baseURL  string
+	client   *http.Client
+
+	// token is the login token, fetched on the first download
+	tokenMu sync.Mutex
+	token   string
+}
+
+// New creates an OpenSubtitles client. username and password are optional.
+func New(apiKey, username, password string) *Client {
+	return &Client{
+		apiKey:   apiKey,
+		username: username,
+		password: password,
+		baseURL:  defaultBaseURL,
+		client: &http.Client{
+			Timeout:   defaultTimeout,
+			Transport: providers.NewPacedTransport(providers.SharedTransport{}, requestDelay),
+		},
+	}
+}
+
+// Name returns "opensubtitles"
+func (c *Client) Name() string {
+	return "opensubtitles"
+}
+
+type searchResponse struct {
+	Data []struct {
+		Attributes struct {
+			Language string `json:"language"`
+			Release  string `json:"release"`
+			Files    []struct {
+				FileID   int    `json:"file_id"`
+				FileName string `json:"file_name"`
+			} `json:"files"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+type loginResponse struct {
+	Token string `json:"token"`
+}
+
+type downloadResponse struct {
+	Link      string `json:"link"`
+	FileName  string `json:"file_name"`
+	ResetTime string `json:"reset_time"`
+}
+
+// Search returns subtitles for the movie or show with the given IMDb ID in
+// lang, most downloaded first
+func (c *Client) Search(ctx context.Context, imdbID string, lang string) ([]subtitles.Subtitle, error) {
+	params := url.Values{"imdb_id": {imdbNumber(imdbID)}}
+	return c.search(ctx, params, lang)
+}
+
+// SearchEpisode returns subtitles for one episode of the show with the given
+// IMDb ID
+func (c *Client) SearchEpisode(ctx context.Context, imdbID string, season, episode int, lang string) ([]subtitles.Subtitle, error) {
+	params := url.Values{
+		"parent_imdb_id": {imdbNumber(imdbID)},
+		"season_number":  {strconv.Itoa(season)},
+		"episode_number": {strconv.Itoa(episode)},
+	}
+	return c.search(ctx, params, lang)
+}
+
+func (c *Client) search(ctx context.Context, params url.Values, lang string) ([]subtitles.Subtitle, error) {
+	if lang != "" {
+		params.Set("languages", strings.ToLower(lang))
+	}
+	params.Set("order_by", "download_count")
+
+	var resp searchResponse
+	if err := c.do(ctx, http.MethodGet, "/subtitles?"+params.Encode(), nil, "", &resp); err != nil {
+		return nil, err
+	}
+
+	var subs []subtitles.Subtitle
+	for _, item := range resp.Data {
+		attrs := item.Attributes
+		if len(attrs.Files) == 0 {
+			continue
+		}
+		file := attrs.Files[0]
+		name := attrs.Release
+		if name == "" {
+			name = file.FileName
+		}
+		subs = append(subs, subtitles.Subtitle{
+			ID:       strconv.Itoa(file.FileID),
+			Language: attrs.Language,
+			Name:     name,
+			Format:   "srt",
+		})
+	}
+	return subs, nil
+}
+
+// Download fetches the subtitle file. OpenSubtitles hands out a temporary
+// link per file, which counts against the download quota.
+func (c *Client) Download(ctx context.Context, sub subtitles.Subtitle) ([]byte, error) {
+	fileID, err := strconv.Atoi(sub.ID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OpenSubtitles file ID %q", sub.ID)
+	}
+
+	body, err := json.Marshal(map[string]int{"file_id": fileID})
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := c.login(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp downloadResponse
+	err = c.do(ctx, http.MethodPost, "/download", body, token, &resp)
+	if errors.Is(err, ErrUnauthorized) && token != "" {
+		// The token may have expired; log in again once
+		c.clearToken()
+		if token, err = c.login(ctx); err == nil {
+			err = c.do(ctx, http.MethodPost, "/download", body, token, &resp)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Link == "" {
+		return nil, fmt.Errorf("OpenSubtitles returned no download link for file %d", fileID)
+	}
+
+	return c.fetchFile(ctx, resp.Link)
+}
+
+// login returns the login token, logging in first if credentials are set and
+// no token is cached. Without credentials it returns an empty token.
+func (c *Client) login(ctx context.Context) (string, error) {
+	if c.username == "" || c.password == "" {
+		return "", nil
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.token != "" {
+		return c.token, nil
+	}
+
+	body, err := json.Marshal(map[string]string{"username": c.username, "password": c.password})
+	if err != nil {
+		return "", err
+	}
+
+	var resp loginResponse
+	if err := c.do(ctx, http.MethodPost, "/login", body, "", &resp); err != nil {
+		return "", fmt.Errorf("OpenSubtitles login failed: %w", err)
+	}
+	if resp.Token == "" {
+		return "", fmt.Errorf("OpenSubtitles login returned no token: %w", ErrUnauthorized)
+	}
+	c.token = resp.Token
+	return c.token, nil
+}
+
+func (c *Client) clearToken() {
+	c.tokenMu.Lock()
+	c.token = ""
+	c.tokenMu.Unlock()
+}
+
+// do calls an API endpoint and decodes the JSON response into out. A 429
+// response is retried once if the requested wait is short.
+func (c *Client) do(ctx context.Context, method, endpoint string, body []byte, token string, out interface{}) error {
+	for attempt := 0; ; attempt++ {
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, reader)
+		if err != nil {
+			return fmt.Errorf("failed to create OpenSubtitles request: %w", err)
+		}
+		req.Header.Set("Api-Key", c.apiKey)
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Accept", "application/json")
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to query OpenSubtitles: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			wait := providers.RetryAfter(resp, time.Second)
+			_ = resp.Body.Close()
+			if attempt > 0 || wait > maxRetryWait {
+				return fmt.Errorf("%w: retry after %s", ErrRateLimited, wait.Round(time.Second))
+			}
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		err = decodeResponse(resp, path.Base(strings.SplitN(endpoint, "?", 2)[0]), out)
+		_ = resp.Body.Close()
+		return err
+	}
+}
+
+// decodeResponse maps error statuses to the package errors and decodes a
+// successful response into out
+func decodeResponse(resp *http.Response, endpoint string, out interface{}) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotAcceptable:
+		// The download endpoint answers 406 once the quota is used up
+		var quota downloadResponse
+		_ = json.NewDecoder(resp.Body).Decode(&quota)
+		if quota.ResetTime != "" {
+			return fmt.Errorf("%w, resets in %s", ErrQuotaExceeded, quota.ResetTime)
+		}
+		return ErrQuotaExceeded
+	default:
+		return fmt.Errorf("OpenSubtitles %s returned status %d", endpoint, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse OpenSubtitles response: %w", err)
+	}
+	return nil
+}
+
+// fetchFile downloads a subtitle from the temporary link the API handed out
+func (c *Client) fetchFile(ctx context.Context, link string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subtitle request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download subtitle: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("subtitle download returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSubtitleSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subtitle: %w", err)
+	}
+	return data, nil
+}
+
+// imdbNumber strips the "tt" prefix and leading zeros the API doesn't accept
+func imdbNumber(imdbID string) string {
+	id := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(imdbID)), "tt")
+	if trimmed := strings.TrimLeft(id, "0"); trimmed != "" {
+		return trimmed
+	}
+	return id
+}
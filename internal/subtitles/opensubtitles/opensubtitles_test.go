@@ -0,0 +1,131 @@
+package opensubtitles
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/subtitles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, mux *http.ServeMux, username, password string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c := New("secret", username, password)
+	c.baseURL = srv.URL
+	c.client = srv.Client()
+	return c
+}
+
+func TestSearch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/subtitles", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("Api-Key"))
+		assert.NotEmpty(t, r.Header.Get("User-Agent"))
+		q := r.URL.Query()
+		assert.Equal(t, "de", q.Get("languages"))
+		if q.Get("parent_imdb_id") != "" {
+			assert.Equal(t, "5753856", q.Get("parent_imdb_id"))
+			assert.Equal(t, "1", q.Get("season_number"))
+			assert.Equal(t, "2", q.Get("episode_number"))
+		} else {
+			assert.Equal(t, "1375666", q.Get("imdb_id"))
+		}
+		_, _ = w.Write([]byte(`{"data":[
+			{"attributes":{"language":"de","release":"Inception.2010.1080p","files":[{"file_id":123,"file_name":"inception.srt"}]}},
+			{"attributes":{"language":"de","release":"","files":[]}}
+		]}`))
+	})
+	c := newTestClient(t, mux, "", "")
+
+	subs, err := c.Search(context.Background(), "tt1375666", "de")
+	require.NoError(t, err)
+	assert.Equal(t, []subtitles.Subtitle{{ID: "123", Language: "de", Name: "Inception.2010.1080p", Format: "srt"}}, subs)
+
+	subs, err = c.SearchEpisode(context.Background(), "tt5753856", 1, 2, "de")
+	require.NoError(t, err)
+	assert.Len(t, subs, 1)
+}
+
+func TestDownload(t *testing.T) {
+	var logins int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "jonas", body["username"])
+		_, _ = w.Write([]byte(`{"token":"tok"}`))
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		var body map[string]int
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["file_id"] == 999 {
+			w.WriteHeader(http.StatusNotAcceptable)
+			_, _ = w.Write([]byte(`{"remaining":0,"reset_time":"5 hours"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"link":"http://` + r.Host + `/file/123.srt"}`))
+	})
+	mux.HandleFunc("/file/123.srt", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("1\n00:00:01,000 --> 00:00:02,000\nHallo\n"))
+	})
+	c := newTestClient(t, mux, "jonas", "winden")
+
+	t.Run("logs in once and fetches the file", func(t *testing.T) {
+		for range 2 {
+			data, err := c.Download(context.Background(), subtitles.Subtitle{ID: "123"})
+			require.NoError(t, err)
+			assert.Contains(t, string(data), "Hallo")
+		}
+		assert.Equal(t, 1, logins)
+	})
+
+	t.Run("quota exhausted", func(t *testing.T) {
+		_, err := c.Download(context.Background(), subtitles.Subtitle{ID: "999"})
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		assert.ErrorContains(t, err, "5 hours")
+	})
+}
+
+func TestRateLimitAndAuth(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/subtitles", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Query().Get("imdb_id") {
+		case "1":
+			// Short wait, answered on the retry
+			if calls == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		case "2":
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	c := newTestClient(t, mux, "", "")
+	ctx := context.Background()
+
+	_, err := c.Search(ctx, "tt1", "en")
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	_, err = c.Search(ctx, "tt2", "en")
+	assert.ErrorIs(t, err, ErrRateLimited)
+
+	_, err = c.Search(ctx, "tt3", "en")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
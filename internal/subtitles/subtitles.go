@@ -0,0 +1,132 @@
+// Package subtitles fetches subtitles from external sources for streams
+// whose provider didn't supply one in the wanted language.
+package subtitles
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+// Subtitle is a subtitle file offered by an external source
+type Subtitle struct {
+	ID       string // Source-specific file ID passed back to Download
+	Language string // ISO 639-1 code
+	Name     string // Release or file name
+	Format   string // File extension such as "srt" or "vtt"
+}
+
+// SubtitleProvider searches and downloads subtitles from an external source
+type SubtitleProvider interface {
+	Name() string
+	// Search returns subtitles for the title with the given IMDb ID in lang,
+	// best match first
+	Search(ctx context.Context, imdbID string, lang string) ([]Subtitle, error)
+	Download(ctx context.Context, sub Subtitle) ([]byte, error)
+}
+
+// EpisodeSearcher is an interface for subtitle providers that can search a
+// single episode of a show by the show's IMDb ID
+type EpisodeSearcher interface {
+	SearchEpisode(ctx context.Context, imdbID string, season, episode int, lang string) ([]Subtitle, error)
+}
+
+// Noop is the SubtitleProvider used when no external source is configured.
+// It never finds anything.
+type Noop struct{}
+
+// Name returns "none"
+func (Noop) Name() string { return "none" }
+
+// Search returns no subtitles
+func (Noop) Search(ctx context.Context, imdbID string, lang string) ([]Subtitle, error) {
+	return nil, nil
+}
+
+// Download always fails since Search never returns anything
+func (Noop) Download(ctx context.Context, sub Subtitle) ([]byte, error) {
+	return nil, fmt.Errorf("no subtitle source configured")
+}
+
+// Query identifies the title external subtitles are searched for
+type Query struct {
+	IMDBID  string // tt-prefixed IMDb ID of the movie or show
+	Season  int    // Zero for movies
+	Episode int    // Zero for movies
+}
+
+// HasLanguage reports whether any of subs is in lang
+func HasLanguage(subs []providers.Subtitle, lang string) bool {
+	for _, sub := range subs {
+		if MatchesLanguage(sub.Language, lang) {
+			return true
+		}
+	}
+	return false
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Ensure adds an external subtitle in lang to stream when none of the
+// stream's own subtitles is in that language. The file is saved under dir
+// and prepended to stream.Subtitles. It reports whether a subtitle was
+// added; a stream that already has the language, a query without an IMDb ID
+// or a search without results is not an error.
+func Ensure(ctx context.Context, p SubtitleProvider, stream *providers.StreamURL, q Query, lang, dir string) (bool, error) {
+	if p == nil || stream == nil || q.IMDBID == "" || lang == "" {
+		return false, nil
+	}
+	if _, ok := p.(Noop); ok || HasLanguage(stream.Subtitles, lang) {
+		return false, nil
+	}
+
+	code := lang
+	if l, ok := LookupLanguage(lang); ok {
+		code = l.ISO1
+	}
+
+	var found []Subtitle
+	var err error
+	if searcher, ok := p.(EpisodeSearcher); ok && q.Season > 0 && q.Episode > 0 {
+		found, err = searcher.SearchEpisode(ctx, q.IMDBID, q.Season, q.Episode, code)
+	} else {
+		found, err = p.Search(ctx, q.IMDBID, code)
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s subtitle search: %w", p.Name(), err)
+	}
+	if len(found) == 0 {
+		return false, nil
+	}
+
+	sub := found[0]
+	data, err := p.Download(ctx, sub)
+	if err != nil {
+		return false, fmt.Errorf("%s subtitle download: %w", p.Name(), err)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create subtitle directory: %w", err)
+	}
+	format := sub.Format
+	if format == "" {
+		format = "srt"
+	}
+	name := unsafeFileChars.ReplaceAllString(fmt.Sprintf("%s-%s-%s", p.Name(), sub.ID, code), "_")
+	path := filepath.Join(dir, name+"."+strings.ToLower(format))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, fmt.Errorf("failed to save subtitle: %w", err)
+	}
+
+	stream.Subtitles = append([]providers.Subtitle{{
+		Language: code,
+		URL:      path,
+		Format:   format,
+	}}, stream.Subtitles...)
+	return true, nil
+}
@@ -0,0 +1,108 @@
+package subtitles
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSource returns fixed subtitles and records what was searched
+type fakeSource struct {
+	found    []Subtitle
+	err      error
+	searches []string
+	episodes []string
+}
+
+func (f *fakeSource) Name() string { return "fake" }
+
+func (f *fakeSource) Search(ctx context.Context, imdbID string, lang string) ([]Subtitle, error) {
+	f.searches = append(f.searches, imdbID+":"+lang)
+	return f.found, f.err
+}
+
+func (f *fakeSource) Download(ctx context.Context, sub Subtitle) ([]byte, error) {
+	return []byte("1\n00:00:01,000 --> 00:00:02,000\nHallo\n"), nil
+}
+
+// fakeEpisodeSource also implements EpisodeSearcher
+type fakeEpisodeSource struct {
+	fakeSource
+}
+
+func (f *fakeEpisodeSource) SearchEpisode(ctx context.Context, imdbID string, season, episode int, lang string) ([]Subtitle, error) {
+	f.episodes = append(f.episodes, imdbID)
+	return f.found, f.err
+}
+
+func TestMatchesLanguage(t *testing.T) {
+	assert.True(t, MatchesLanguage("English", "en"))
+	assert.True(t, MatchesLanguage("en-US", "eng"))
+	assert.True(t, MatchesLanguage("Portuguese (Brazil)", "pt"))
+	assert.False(t, MatchesLanguage("Slovenian", "en"))
+	assert.True(t, MatchesLanguage("Klingon", "klingon"))
+}
+
+func TestEnsure(t *testing.T) {
+	ctx := context.Background()
+	query := Query{IMDBID: "tt1375666"}
+
+	t.Run("adds a subtitle when the language is missing", func(t *testing.T) {
+		src := &fakeSource{found: []Subtitle{{ID: "42", Language: "de", Format: "srt"}}}
+		stream := &providers.StreamURL{Subtitles: []providers.Subtitle{{Language: "English", URL: "en.vtt"}}}
+
+		added, err := Ensure(ctx, src, stream, query, "German", t.TempDir())
+		require.NoError(t, err)
+		assert.True(t, added)
+		assert.Equal(t, []string{"tt1375666:de"}, src.searches)
+
+		require.Len(t, stream.Subtitles, 2)
+		assert.Equal(t, "de", stream.Subtitles[0].Language)
+		data, err := os.ReadFile(stream.Subtitles[0].URL)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "Hallo")
+	})
+
+	t.Run("keeps streams that already have the language", func(t *testing.T) {
+		src := &fakeSource{found: []Subtitle{{ID: "42"}}}
+		stream := &providers.StreamURL{Subtitles: []providers.Subtitle{{Language: "English"}}}
+
+		added, err := Ensure(ctx, src, stream, query, "en", t.TempDir())
+		require.NoError(t, err)
+		assert.False(t, added)
+		assert.Empty(t, src.searches)
+	})
+
+	t.Run("searches episodes by season and number", func(t *testing.T) {
+		src := &fakeEpisodeSource{fakeSource{found: []Subtitle{{ID: "7"}}}}
+		stream := &providers.StreamURL{}
+
+		added, err := Ensure(ctx, src, stream, Query{IMDBID: "tt5753856", Season: 1, Episode: 2}, "en", t.TempDir())
+		require.NoError(t, err)
+		assert.True(t, added)
+		assert.Equal(t, []string{"tt5753856"}, src.episodes)
+		assert.Empty(t, src.searches)
+	})
+
+	t.Run("nothing to do without an IMDb ID or a source", func(t *testing.T) {
+		stream := &providers.StreamURL{}
+		added, err := Ensure(ctx, &fakeSource{}, stream, Query{}, "en", t.TempDir())
+		require.NoError(t, err)
+		assert.False(t, added)
+
+		added, err = Ensure(ctx, Noop{}, stream, query, "en", t.TempDir())
+		require.NoError(t, err)
+		assert.False(t, added)
+	})
+
+	t.Run("reports search failures", func(t *testing.T) {
+		src := &fakeSource{err: errors.New("quota exceeded")}
+		_, err := Ensure(ctx, src, &providers.StreamURL{}, query, "en", t.TempDir())
+		assert.ErrorContains(t, err, "quota exceeded")
+	})
+}
@@ -0,0 +1,240 @@
+// Package tmdb looks up movie and TV metadata on The Movie Database, used to
+// fill in details that a scraped page is missing
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/providers"
+)
+
+const (
+	defaultBaseURL  = "https://api.themoviedb.org/3"
+	posterBaseURL   = "https://image.tmdb.org/t/p/w500"
+	defaultTimeout  = 10 * time.Second
+	movieSearchPath = "/search/movie"
+	tvSearchPath    = "/search/tv"
+)
+
+// Client queries the TMDB API with an API key
+type Client struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+
+	// genres maps genre IDs to names, loaded once per media kind
+	genresMu sync.Mutex
+	genres   map[string]map[int]string
+
+	// lookups caches search results, misses included, so the same title is
+	// only searched for once
+	lookupsMu sync.Mutex
+	lookups   map[lookupKey]*providers.MediaDetails
+}
+
+// lookupKey identifies a LookupMetadata call
+type lookupKey struct {
+	kind  string
+	title string
+	year  int
+}
+
+// New creates a TMDB client for apiKey
+func New(apiKey string) *Client {
+	return &Client{
+		apiKey:  apiKey,
+		baseURL: defaultBaseURL,
+		client:  &http.Client{Timeout: defaultTimeout},
+		genres:  make(map[string]map[int]string),
+		lookups: make(map[lookupKey]*providers.MediaDetails),
+	}
+}
+
+type searchResult struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"`
+	Name         string `json:"name"`
+	Overview     string `json:"overview"`
+	PosterPath   string `json:"poster_path"`
+	ReleaseDate  string `json:"release_date"`
+	FirstAirDate string `json:"first_air_date"`
+	GenreIDs     []int  `json:"genre_ids"`
+}
+
+type searchResponse struct {
+	Results []searchResult `json:"results"`
+}
+
+type externalIDsResponse struct {
+	IMDBID string `json:"imdb_id"`
+}
+
+type genreResponse struct {
+	Genres []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"genres"`
+}
+
+// LookupMetadata searches TMDB for title and returns the top match, with its
+// IMDb ID when TMDB knows it, or nil if nothing matched. TV types search TV
+// shows; everything else searches movies. Results are cached by type, title
+// and year; failed searches are not.
+func (c *Client) LookupMetadata(ctx context.Context, title string, mediaType providers.MediaType, year int) (*providers.MediaDetails, error) {
+	kind := "movie"
+	if mediaType == providers.MediaTypeTV {
+		kind = "tv"
+	}
+	key := lookupKey{kind: kind, title: strings.ToLower(strings.TrimSpace(title)), year: year}
+
+	c.lookupsMu.Lock()
+	cached, ok := c.lookups[key]
+	c.lookupsMu.Unlock()
+	if ok {
+		return cloneDetails(cached, mediaType), nil
+	}
+
+	media, err := c.search(ctx, kind, title, mediaType, year)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lookupsMu.Lock()
+	c.lookups[key] = media
+	c.lookupsMu.Unlock()
+	return cloneDetails(media, mediaType), nil
+}
+
+// cloneDetails copies a cached result so callers can't modify the cache
+func cloneDetails(m *providers.MediaDetails, mediaType providers.MediaType) *providers.MediaDetails {
+	if m == nil {
+		return nil
+	}
+	clone := *m
+	clone.Type = mediaType
+	clone.Genres = slices.Clone(m.Genres)
+	return &clone
+}
+
+// search runs a TMDB search for kind ("movie" or "tv") and maps the top result
+func (c *Client) search(ctx context.Context, kind, title string, mediaType providers.MediaType, year int) (*providers.MediaDetails, error) {
+	path, yearParam := movieSearchPath, "year"
+	if kind == "tv" {
+		path, yearParam = tvSearchPath, "first_air_date_year"
+	}
+
+	params := url.Values{"query": {title}}
+	if year != 0 {
+		params.Set(yearParam, strconv.Itoa(year))
+	}
+
+	var resp searchResponse
+	if err := c.get(ctx, path, params, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Results) == 0 {
+		return nil, nil
+	}
+	top := resp.Results[0]
+
+	media := &providers.MediaDetails{Media: providers.Media{
+		Title:    top.Title,
+		Type:     mediaType,
+		Synopsis: top.Overview,
+	}}
+	if media.Title == "" {
+		media.Title = top.Name
+	}
+	if top.PosterPath != "" {
+		media.PosterURL = posterBaseURL + top.PosterPath
+	}
+
+	date := top.ReleaseDate
+	if date == "" {
+		date = top.FirstAirDate
+	}
+	if len(date) >= 4 {
+		media.Year, _ = strconv.Atoi(date[:4])
+	}
+
+	if len(top.GenreIDs) > 0 {
+		// Genre names are a nice-to-have; a failed lookup doesn't spoil the rest
+		if names, err := c.genreNames(ctx, kind); err == nil {
+			for _, id := range top.GenreIDs {
+				if name, ok := names[id]; ok {
+					media.Genres = append(media.Genres, name)
+				}
+			}
+		}
+	}
+
+	if top.ID != 0 {
+		// Like genres, the IMDb ID is a nice-to-have
+		var ids externalIDsResponse
+		if err := c.get(ctx, fmt.Sprintf("/%s/%d/external_ids", kind, top.ID), nil, &ids); err == nil {
+			media.IMDBID = ids.IMDBID
+		}
+	}
+
+	return media, nil
+}
+
+// genreNames returns the genre ID to name table for kind ("movie" or "tv")
+func (c *Client) genreNames(ctx context.Context, kind string) (map[int]string, error) {
+	c.genresMu.Lock()
+	defer c.genresMu.Unlock()
+
+	if names, ok := c.genres[kind]; ok {
+		return names, nil
+	}
+
+	var resp genreResponse
+	if err := c.get(ctx, "/genre/"+kind+"/list", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	names := make(map[int]string, len(resp.Genres))
+	for _, genre := range resp.Genres {
+		names[genre.ID] = genre.Name
+	}
+	c.genres[kind] = names
+	return names, nil
+}
+
+// get calls a TMDB endpoint and decodes the JSON response into out
+func (c *Client) get(ctx context.Context, path string, params url.Values, out interface{}) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("api_key", c.apiKey)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create TMDB request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query TMDB: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("TMDB %s returned status %d", path, resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse TMDB response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package tmdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupMetadata(t *testing.T) {
+	var genreCalls, searchCalls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search/tv", func(w http.ResponseWriter, r *http.Request) {
+		searchCalls++
+		assert.Equal(t, "secret", r.URL.Query().Get("api_key"))
+		assert.Equal(t, "Dark", r.URL.Query().Get("query"))
+		assert.Equal(t, "2017", r.URL.Query().Get("first_air_date_year"))
+		_, _ = w.Write([]byte(`{"results":[{"id":70523,"name":"Dark","overview":"Time travel in Winden.","poster_path":"/dark.jpg","first_air_date":"2017-12-01","genre_ids":[18,9648]}]}`))
+	})
+	mux.HandleFunc("/search/movie", func(w http.ResponseWriter, r *http.Request) {
+		searchCalls++
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	})
+	mux.HandleFunc("/tv/70523/external_ids", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"imdb_id":"tt5753856"}`))
+	})
+	mux.HandleFunc("/genre/tv/list", func(w http.ResponseWriter, r *http.Request) {
+		genreCalls++
+		_, _ = w.Write([]byte(`{"genres":[{"id":18,"name":"Drama"},{"id":9648,"name":"Mystery"}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := New("secret")
+	c.baseURL = srv.URL
+	c.client = srv.Client()
+
+	t.Run("maps the top TV result", func(t *testing.T) {
+		media, err := c.LookupMetadata(context.Background(), "Dark", providers.MediaTypeTV, 2017)
+		require.NoError(t, err)
+		require.NotNil(t, media)
+		assert.Equal(t, "Dark", media.Title)
+		assert.Equal(t, "Time travel in Winden.", media.Synopsis)
+		assert.Equal(t, posterBaseURL+"/dark.jpg", media.PosterURL)
+		assert.Equal(t, 2017, media.Year)
+		assert.Equal(t, []string{"Drama", "Mystery"}, media.Genres)
+		assert.Equal(t, "tt5753856", media.IMDBID)
+	})
+
+	t.Run("results are cached", func(t *testing.T) {
+		media, err := c.LookupMetadata(context.Background(), "dark ", providers.MediaTypeTV, 2017)
+		require.NoError(t, err)
+		require.NotNil(t, media)
+		media.Genres[0] = "Changed"
+
+		media, err = c.LookupMetadata(context.Background(), "Dark", providers.MediaTypeTV, 2017)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Drama", "Mystery"}, media.Genres)
+		assert.Equal(t, 1, searchCalls)
+		assert.Equal(t, 1, genreCalls)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		media, err := c.LookupMetadata(context.Background(), "Nothing", providers.MediaTypeMovie, 0)
+		require.NoError(t, err)
+		assert.Nil(t, media)
+
+		media, err = c.LookupMetadata(context.Background(), "Nothing", providers.MediaTypeMovie, 0)
+		require.NoError(t, err)
+		assert.Nil(t, media)
+		assert.Equal(t, 2, searchCalls)
+	})
+}
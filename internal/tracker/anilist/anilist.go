@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -789,10 +790,14 @@ func (c *Client) query(ctx context.Context, query string, variables map[string]i
 				Status  int    `json:"status"`
 			} `json:"errors"`
 		}
+		apiErr := &tracker.APIError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
 		if err := json.Unmarshal(body, &errorResponse); err == nil && len(errorResponse.Errors) > 0 {
-			return fmt.Errorf("AniList API error: %s", errorResponse.Errors[0].Message)
+			apiErr.Message = errorResponse.Errors[0].Message
 		}
-		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return apiErr
 	}
 
 	if err := json.Unmarshal(body, result); err != nil {
@@ -801,3 +806,12 @@ func (c *Client) query(ctx context.Context, query string, variables map[string]i
 
 	return nil
 }
+
+// parseRetryAfter parses a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
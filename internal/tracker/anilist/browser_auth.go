@@ -3,10 +3,14 @@ package anilist
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/pkg/browser"
@@ -16,6 +20,22 @@ import (
 const (
 	// OAuth URL for AniList (this is fixed and won't change)
 	browserAuthOAuthURL = "https://anilist.co/api/v2/oauth"
+
+	// browserAuthTimeout bounds how long we wait for the user to approve access
+	browserAuthTimeout = 5 * time.Minute
+)
+
+var (
+	// ErrAuthTimeout is returned when the OAuth callback never arrives
+	ErrAuthTimeout = errors.New("authentication timed out")
+	// ErrPortInUse is returned when the callback server cannot bind server_port
+	ErrPortInUse = errors.New("callback port already in use")
+	// ErrPortMismatch is returned when redirect_uri points at a different
+	// port than server_port, so the callback would never reach the server
+	ErrPortMismatch = errors.New("redirect_uri port does not match server_port")
+
+	// openBrowser is a variable to allow mocking in tests
+	openBrowser = browser.OpenURL
 )
 
 // BrowserAuthConfig holds configuration for browser-based OAuth authentication
@@ -33,24 +53,50 @@ type BrowserAuthResult struct {
 	UserID   int
 }
 
+// Authenticate runs the browser OAuth flow and returns the raw access token.
+// The token is persisted through saveToken before it is returned.
+func Authenticate(ctx context.Context, authConfig BrowserAuthConfig, saveToken func(*oauth2.Token) error) (string, error) {
+	token, err := AuthenticateWithBrowser(ctx, authConfig, saveToken)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
 // AuthenticateWithBrowser performs OAuth authentication using the browser.
-// It starts a local server to handle the OAuth callback and opens the browser
-// for the user to authenticate with AniList.
+// It starts a local server on ServerPort to handle the OAuth callback at the
+// path of RedirectURI and opens the browser for the user to authenticate with
+// AniList.
 func AuthenticateWithBrowser(ctx context.Context, authConfig BrowserAuthConfig, saveToken func(*oauth2.Token) error) (*oauth2.Token, error) {
-	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, browserAuthTimeout)
 	defer cancel()
 
+	callbackPath, err := redirectPath(authConfig.RedirectURI, authConfig.ServerPort)
+	if err != nil {
+		return nil, err
+	}
+
+	// Bind before opening the browser so a busy port fails fast
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", authConfig.ServerPort))
+	if err != nil {
+		if errors.Is(err, syscall.EADDRINUSE) {
+			return nil, fmt.Errorf("%w: port %d (free it, or change tracker.anilist.server_port and the port in tracker.anilist.redirect_uri together; the built-in client redirects to %s, so other ports need manual login)",
+				ErrPortInUse, authConfig.ServerPort, AuthBrowserRedirectURI)
+		}
+		return nil, fmt.Errorf("failed to start callback server: %w", err)
+	}
+
 	// Start local server to handle OAuth callback
 	callbackCh := make(chan string, 1)
 	errCh := make(chan error, 1)
 	mux := http.NewServeMux()
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", authConfig.ServerPort),
-		Handler: mux,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
 	}
 
 	// Handle OAuth callback - authorization code comes in query params
-	mux.HandleFunc("/oauth/callback", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(callbackPath, func(w http.ResponseWriter, r *http.Request) {
 		code := r.URL.Query().Get("code")
 		errorParam := r.URL.Query().Get("error")
 
@@ -73,7 +119,7 @@ func AuthenticateWithBrowser(ctx context.Context, authConfig BrowserAuthConfig,
 </body>
 </html>`, errorParam)
 			_, _ = fmt.Fprint(w, html)
-			errCh <- fmt.Errorf("oauth error: %s", errorParam)
+			sendErr(errCh, fmt.Errorf("oauth error: %s", errorParam))
 			return
 		}
 
@@ -94,18 +140,21 @@ func AuthenticateWithBrowser(ctx context.Context, authConfig BrowserAuthConfig,
 </body>
 </html>`
 			_, _ = fmt.Fprint(w, html)
-			errCh <- fmt.Errorf("no authorization code received")
+			sendErr(errCh, fmt.Errorf("no authorization code received"))
 			return
 		}
 
 		// Exchange authorization code for access token in background
 		go func() {
-			token, err := exchangeCodeForToken(code, authConfig)
+			token, err := exchangeCodeForToken(ctx, code, authConfig)
 			if err != nil {
-				errCh <- err
+				sendErr(errCh, err)
 				return
 			}
-			callbackCh <- token
+			select {
+			case callbackCh <- token:
+			default:
+			}
 		}()
 
 		// Show success page immediately
@@ -128,14 +177,15 @@ func AuthenticateWithBrowser(ctx context.Context, authConfig BrowserAuthConfig,
 
 	// Start server in background
 	go func() {
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errCh <- fmt.Errorf("failed to start server: %w", err)
+		if err := srv.Serve(listener); err != nil && err != http.ErrServerClosed {
+			sendErr(errCh, fmt.Errorf("callback server failed: %w", err))
 		}
 	}()
-	defer func() { _ = srv.Shutdown(ctx) }()
-
-	// Give server a moment to start
-	time.Sleep(100 * time.Millisecond)
+	defer func() {
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer shutdownCancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
 
 	// Open browser for authentication using Authorization Code Grant flow
 	authURL := fmt.Sprintf("%s/authorize?client_id=%s&redirect_uri=%s&response_type=code",
@@ -146,7 +196,7 @@ func AuthenticateWithBrowser(ctx context.Context, authConfig BrowserAuthConfig,
 	fmt.Println("Opening browser for AniList authentication...")
 	fmt.Printf("If the browser doesn't open automatically, visit: %s\n", authURL)
 
-	if err := browser.OpenURL(authURL); err != nil {
+	if err := openBrowser(authURL); err != nil {
 		fmt.Printf("Failed to open browser automatically: %v\n", err)
 		fmt.Println("Please copy and paste the URL above into your browser")
 	}
@@ -158,7 +208,10 @@ func AuthenticateWithBrowser(ctx context.Context, authConfig BrowserAuthConfig,
 	case err := <-errCh:
 		return nil, fmt.Errorf("authentication failed: %w", err)
 	case <-ctx.Done():
-		return nil, fmt.Errorf("authentication timeout after 5 minutes")
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%w: no callback received on %s", ErrAuthTimeout, authConfig.RedirectURI)
+		}
+		return nil, ctx.Err()
 	}
 
 	// Create token object
@@ -180,6 +233,39 @@ func AuthenticateWithBrowser(ctx context.Context, authConfig BrowserAuthConfig,
 	return token, nil
 }
 
+// redirectPath returns the callback path the local server should listen on.
+// The redirect has to reach that server, so its port must be serverPort.
+func redirectPath(redirectURI string, serverPort int) (string, error) {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect uri %q: %w", redirectURI, err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	if port != strconv.Itoa(serverPort) {
+		return "", fmt.Errorf("%w: %s redirects to port %s but server_port is %d", ErrPortMismatch, redirectURI, port, serverPort)
+	}
+
+	if u.Path == "" {
+		return "/", nil
+	}
+	return u.Path, nil
+}
+
+// sendErr reports err without blocking if an error is already pending
+func sendErr(errCh chan<- error, err error) {
+	select {
+	case errCh <- err:
+	default:
+	}
+}
+
 // ExtractTokenFromInput extracts the access token from various input formats
 // Handles: raw token, URL with token, or token with extra parameters
 func ExtractTokenFromInput(input string) string {
@@ -214,8 +300,7 @@ func ExtractTokenFromInput(input string) string {
 }
 
 // exchangeCodeForToken exchanges an authorization code for an access token
-func exchangeCodeForToken(code string, authConfig BrowserAuthConfig) (string, error) {
-	tokenURL := fmt.Sprintf("%s/token", browserAuthOAuthURL)
+func exchangeCodeForToken(ctx context.Context, code string, authConfig BrowserAuthConfig) (string, error) {
 	data := url.Values{
 		"grant_type":    {"authorization_code"},
 		"client_id":     {authConfig.ClientID},
@@ -224,7 +309,14 @@ func exchangeCodeForToken(code string, authConfig BrowserAuthConfig) (string, er
 		"code":          {code},
 	}
 
-	resp, err := http.PostForm(tokenURL, data)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, strings.NewReader(data.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to exchange code for token: %w", err)
 	}
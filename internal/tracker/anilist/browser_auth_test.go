@@ -0,0 +1,164 @@
+package anilist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+	return port
+}
+
+func stubBrowser(t *testing.T, fn func(string) error) {
+	t.Helper()
+	orig := openBrowser
+	openBrowser = fn
+	t.Cleanup(func() { openBrowser = orig })
+}
+
+func TestAuthenticateCapturesCodeAtRedirectURI(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		if got := r.PostForm.Get("code"); got != "abc123" {
+			t.Errorf("expected code abc123, got %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprint(w, `{"access_token":"tok-xyz","token_type":"Bearer","expires_in":31536000}`)
+	}))
+	defer tokenServer.Close()
+
+	origEndpoint := tokenEndpoint
+	tokenEndpoint = tokenServer.URL
+	defer func() { tokenEndpoint = origEndpoint }()
+
+	port := freePort(t)
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d/custom/callback", port)
+
+	stubBrowser(t, func(string) error {
+		go func() {
+			resp, err := http.Get(redirectURI + "?code=abc123")
+			if err != nil {
+				t.Errorf("callback request failed: %v", err)
+				return
+			}
+			_ = resp.Body.Close()
+		}()
+		return nil
+	})
+
+	var saved *oauth2.Token
+	token, err := Authenticate(context.Background(), BrowserAuthConfig{
+		ClientID:     "id",
+		ClientSecret: "secret",
+		RedirectURI:  redirectURI,
+		ServerPort:   port,
+	}, func(tok *oauth2.Token) error {
+		saved = tok
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if token != "tok-xyz" {
+		t.Errorf("expected token tok-xyz, got %q", token)
+	}
+	if saved == nil || saved.AccessToken != "tok-xyz" {
+		t.Error("token was not persisted")
+	}
+}
+
+func TestAuthenticatePortInUse(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer func() { _ = l.Close() }()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	stubBrowser(t, func(string) error {
+		t.Error("browser should not open when the port is busy")
+		return nil
+	})
+
+	_, err = Authenticate(context.Background(), BrowserAuthConfig{
+		RedirectURI: fmt.Sprintf("http://localhost:%d/oauth/callback", port),
+		ServerPort:  port,
+	}, nil)
+	if !errors.Is(err, ErrPortInUse) {
+		t.Fatalf("expected ErrPortInUse, got %v", err)
+	}
+}
+
+func TestAuthenticateTimeout(t *testing.T) {
+	stubBrowser(t, func(string) error { return nil })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	port := freePort(t)
+	_, err := Authenticate(ctx, BrowserAuthConfig{
+		RedirectURI: fmt.Sprintf("http://localhost:%d/oauth/callback", port),
+		ServerPort:  port,
+	}, nil)
+	if !errors.Is(err, ErrAuthTimeout) {
+		t.Fatalf("expected ErrAuthTimeout, got %v", err)
+	}
+}
+
+func TestAuthenticatePortMismatch(t *testing.T) {
+	stubBrowser(t, func(string) error {
+		t.Error("browser should not open when the redirect misses the server")
+		return nil
+	})
+
+	port := freePort(t)
+	_, err := Authenticate(context.Background(), BrowserAuthConfig{
+		RedirectURI: fmt.Sprintf("http://localhost:%d/oauth/callback", port+1),
+		ServerPort:  port,
+	}, nil)
+	if !errors.Is(err, ErrPortMismatch) {
+		t.Fatalf("expected ErrPortMismatch, got %v", err)
+	}
+}
+
+func TestRedirectPath(t *testing.T) {
+	tests := []struct {
+		uri     string
+		port    int
+		want    string
+		wantErr bool
+	}{
+		{"http://localhost:8000/oauth/callback", 8000, "/oauth/callback", false},
+		{"http://localhost/cb", 80, "/cb", false},
+		{"https://localhost", 443, "/", false},
+		{"http://localhost:8000/oauth/callback", 9000, "", true},
+		{"http://localhost/cb", 8000, "", true},
+	}
+	for _, tt := range tests {
+		got, err := redirectPath(tt.uri, tt.port)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("redirectPath(%q, %d) error = %v, wantErr %v", tt.uri, tt.port, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("redirectPath(%q, %d) = %q, want %q", tt.uri, tt.port, got, tt.want)
+		}
+	}
+}
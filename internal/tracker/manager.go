@@ -3,9 +3,13 @@ package tracker
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
+	"time"
 
+	"github.com/justchokingaround/greg/internal/clock"
 	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/database"
 	"github.com/justchokingaround/greg/internal/providers"
 	"gorm.io/gorm"
 )
@@ -15,14 +19,21 @@ type Manager struct {
 	anilist Tracker
 	cfg     *config.Config
 	db      *gorm.DB
+	logger  *slog.Logger
+	clock   clock.Clock
 	mu      sync.RWMutex
+
+	// Sync loop state
+	lastSync time.Time
+	dryRun   bool
 }
 
 // NewManager creates a new tracker manager
 func NewManager(cfg *config.Config, db *gorm.DB) *Manager {
 	return &Manager{
-		cfg: cfg,
-		db:  db,
+		cfg:   cfg,
+		db:    db,
+		clock: clock.Real{},
 	}
 }
 
@@ -33,6 +44,20 @@ func (m *Manager) SetAniListClient(client Tracker) {
 	m.anilist = client
 }
 
+// SetLogger sets the logger used by the background sync loop
+func (m *Manager) SetLogger(logger *slog.Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logger = logger
+}
+
+// SetClock replaces the clock used for sync timestamps and the sync_interval debounce
+func (m *Manager) SetClock(c clock.Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = c
+}
+
 // GetAniList returns the AniList tracker
 func (m *Manager) GetAniList() Tracker {
 	m.mu.RLock()
@@ -77,62 +102,19 @@ func (m *Manager) UpdateProgress(ctx context.Context, mediaID string, episode in
 
 // queueSync adds an item to the sync queue
 func (m *Manager) queueSync(mediaID string, episode int, progress float64) error {
-	// Insert into sync_queue table
-	return m.db.Exec(`
-		INSERT INTO sync_queue (media_id, episode, progress, synced, created_at)
-		VALUES (?, ?, ?, false, CURRENT_TIMESTAMP)
-		ON CONFLICT(media_id, episode) DO UPDATE SET
-			progress = excluded.progress,
-			synced = false
-	`, mediaID, episode, progress).Error
+	return m.db.Create(&database.SyncQueue{
+		MediaID:   mediaID,
+		Episode:   episode,
+		Progress:  progress,
+		Status:    StatusWatching.String(),
+		CreatedAt: time.Now(),
+	}).Error
 }
 
-// ProcessSyncQueue processes pending sync items
+// ProcessSyncQueue pushes all pending sync items immediately
 func (m *Manager) ProcessSyncQueue(ctx context.Context) error {
-	m.mu.RLock()
-	anilist := m.anilist
-	m.mu.RUnlock()
-
-	if anilist == nil || !m.cfg.Tracker.AniList.Enabled {
-		return nil
-	}
-
-	// Get pending sync items
-	var items []struct {
-		ID       uint
-		MediaID  string
-		Episode  int
-		Progress float64
-	}
-
-	if err := m.db.Raw(`
-		SELECT id, media_id, episode, progress
-		FROM sync_queue
-		WHERE synced = false
-		ORDER BY created_at ASC
-		LIMIT 100
-	`).Scan(&items).Error; err != nil {
-		return fmt.Errorf("failed to get sync queue: %w", err)
-	}
-
-	for _, item := range items {
-		if err := anilist.UpdateProgress(ctx, item.MediaID, item.Episode, item.Progress); err != nil {
-			// Mark as failed but continue
-			continue
-		}
-
-		// Mark as synced
-		if err := m.db.Exec(`
-			UPDATE sync_queue
-			SET synced = true, synced_at = CURRENT_TIMESTAMP
-			WHERE id = ?
-		`, item.ID).Error; err != nil {
-			// Log but continue
-			continue
-		}
-	}
-
-	return nil
+	_, err := m.Flush(ctx, true)
+	return err
 }
 
 // SearchMedia searches for media on enabled trackers
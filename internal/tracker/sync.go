@@ -0,0 +1,294 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/clock"
+	"github.com/justchokingaround/greg/internal/database"
+)
+
+const (
+	// syncMaxRetries is how many times a single update is retried on transient failures
+	syncMaxRetries = 3
+	// maxRetryAfter caps how long we honor a server-provided Retry-After
+	maxRetryAfter = time.Minute
+)
+
+var (
+	// syncRetryDelay is the base backoff between retries when the API gives no hint.
+	// It is a variable to allow shortening in tests.
+	syncRetryDelay = 2 * time.Second
+)
+
+// APIError is returned by trackers when the remote API rejects a request
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // Server-provided backoff, zero if absent
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("AniList API error: %s", e.Message)
+	}
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// Temporary reports whether the request may succeed if retried
+func (e *APIError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// SyncUpdate is a coalesced progress update pushed to a tracker
+type SyncUpdate struct {
+	MediaID  string
+	Episode  int
+	Complete bool // Mark the entry completed after updating progress
+	queueIDs []uint
+}
+
+// QueueProgress records a watched episode for the next sync batch.
+// Updates for the same media are coalesced so only the furthest episode is pushed.
+func (m *Manager) QueueProgress(ctx context.Context, mediaID string, episode int, lastEpisode bool) error {
+	if !m.cfg.Tracker.AniList.Enabled || !m.cfg.Tracker.AniList.AutoSync {
+		return nil
+	}
+
+	status := StatusWatching.String()
+	if lastEpisode {
+		status = StatusCompleted.String()
+	}
+
+	var anilistID *int
+	if id, err := strconv.Atoi(mediaID); err == nil {
+		anilistID = &id
+	}
+
+	return m.db.WithContext(ctx).Create(&database.SyncQueue{
+		MediaID:   mediaID,
+		AniListID: anilistID,
+		Episode:   episode,
+		Progress:  1.0,
+		Status:    status,
+		CreatedAt: m.now(),
+	}).Error
+}
+
+// SyncEpisode records a watched episode on AniList. With auto_sync on it is
+// queued and pushed with the next batch; with auto_sync off it is pushed
+// straight away, marking the series completed after its last episode when
+// auto_complete is on.
+func (m *Manager) SyncEpisode(ctx context.Context, mediaID string, episode int, lastEpisode bool) error {
+	if !m.cfg.Tracker.AniList.Enabled {
+		return nil
+	}
+
+	if m.cfg.Tracker.AniList.AutoSync {
+		if err := m.QueueProgress(ctx, mediaID, episode, lastEpisode); err != nil {
+			return fmt.Errorf("failed to queue anilist sync: %w", err)
+		}
+		_, err := m.Flush(ctx, false)
+		return err
+	}
+
+	m.mu.RLock()
+	anilist := m.anilist
+	m.mu.RUnlock()
+	if anilist == nil {
+		return nil
+	}
+
+	return m.pushUpdate(ctx, anilist, SyncUpdate{
+		MediaID:  mediaID,
+		Episode:  episode,
+		Complete: lastEpisode && m.cfg.Tracker.AniList.AutoComplete,
+	})
+}
+
+// SetSyncDryRun toggles dry-run mode. In dry-run, Flush reports the updates it
+// would push without calling the tracker or marking queue entries as synced.
+func (m *Manager) SetSyncDryRun(dryRun bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dryRun = dryRun
+}
+
+// RunSync flushes the sync queue every sync_interval until ctx is cancelled,
+// then performs a final flush so nothing queued during the session is lost.
+func (m *Manager) RunSync(ctx context.Context) {
+	interval := m.cfg.Tracker.AniList.SyncInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := m.Flush(ctx, false); err != nil && ctx.Err() == nil {
+				m.logf("anilist sync failed: %v", err)
+			}
+		case <-ctx.Done():
+			finalCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if _, err := m.Flush(finalCtx, true); err != nil {
+				m.logf("final anilist sync failed: %v", err)
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// Flush pushes pending queue entries to AniList. Unless force is set, it is a
+// no-op when the previous push happened less than sync_interval ago.
+// It returns the updates that were pushed (or would be, in dry-run mode).
+func (m *Manager) Flush(ctx context.Context, force bool) ([]SyncUpdate, error) {
+	m.mu.Lock()
+	anilist := m.anilist
+	dryRun := m.dryRun
+	clk := m.clock
+	if !force && !m.lastSync.IsZero() && clock.Since(clk, m.lastSync) < m.cfg.Tracker.AniList.SyncInterval {
+		m.mu.Unlock()
+		return nil, nil
+	}
+	m.mu.Unlock()
+
+	if anilist == nil || !m.cfg.Tracker.AniList.Enabled {
+		return nil, nil
+	}
+
+	updates, err := m.pendingUpdates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || len(updates) == 0 {
+		return updates, nil
+	}
+
+	var errs []error
+	pushed := make([]SyncUpdate, 0, len(updates))
+	for _, update := range updates {
+		if err := m.pushUpdate(ctx, anilist, update); err != nil {
+			errs = append(errs, fmt.Errorf("media %s: %w", update.MediaID, err))
+			continue
+		}
+		now := clk.Now()
+		if err := m.db.WithContext(ctx).Model(&database.SyncQueue{}).
+			Where("id IN ?", update.queueIDs).
+			Updates(map[string]any{"synced": true, "synced_at": now}).Error; err != nil {
+			errs = append(errs, fmt.Errorf("failed to mark media %s synced: %w", update.MediaID, err))
+			continue
+		}
+		pushed = append(pushed, update)
+	}
+
+	m.mu.Lock()
+	m.lastSync = clk.Now()
+	m.mu.Unlock()
+
+	return pushed, errors.Join(errs...)
+}
+
+// pendingUpdates loads unsynced queue entries and coalesces them per media
+func (m *Manager) pendingUpdates(ctx context.Context) ([]SyncUpdate, error) {
+	var items []database.SyncQueue
+	if err := m.db.WithContext(ctx).
+		Where("synced = ?", false).
+		Order("created_at ASC").
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get sync queue: %w", err)
+	}
+
+	byMedia := make(map[string]*SyncUpdate)
+	var order []string
+	for _, item := range items {
+		update, ok := byMedia[item.MediaID]
+		if !ok {
+			update = &SyncUpdate{MediaID: item.MediaID}
+			byMedia[item.MediaID] = update
+			order = append(order, item.MediaID)
+		}
+		update.queueIDs = append(update.queueIDs, item.ID)
+		if item.Episode > update.Episode {
+			update.Episode = item.Episode
+		}
+		if m.cfg.Tracker.AniList.AutoComplete && item.Status == StatusCompleted.String() {
+			update.Complete = true
+		}
+	}
+
+	updates := make([]SyncUpdate, 0, len(order))
+	for _, id := range order {
+		updates = append(updates, *byMedia[id])
+	}
+	return updates, nil
+}
+
+// pushUpdate sends one coalesced update, retrying transient failures
+func (m *Manager) pushUpdate(ctx context.Context, t Tracker, update SyncUpdate) error {
+	if err := withRetry(ctx, func() error {
+		return t.UpdateProgress(ctx, update.MediaID, update.Episode, 1.0)
+	}); err != nil {
+		return err
+	}
+	if update.Complete {
+		return withRetry(ctx, func() error {
+			return t.UpdateStatus(ctx, update.MediaID, StatusCompleted)
+		})
+	}
+	return nil
+}
+
+// withRetry runs fn, backing off and retrying on rate limits and transient errors
+func withRetry(ctx context.Context, fn func() error) error {
+	var err error
+	for attempt := 0; attempt <= syncMaxRetries; attempt++ {
+		if err = fn(); err == nil || !isTransient(err) || attempt == syncMaxRetries {
+			return err
+		}
+
+		delay := syncRetryDelay << attempt
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			delay = min(apiErr.RetryAfter, maxRetryAfter)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// isTransient reports whether err is worth retrying
+func isTransient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Temporary()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// now returns the current time from the manager's clock
+func (m *Manager) now() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.clock.Now()
+}
+
+func (m *Manager) logf(format string, args ...any) {
+	if m.logger != nil {
+		m.logger.Warn(fmt.Sprintf(format, args...))
+	}
+}
@@ -0,0 +1,270 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/internal/clock"
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/database"
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// fakeTracker records progress and status updates
+type fakeTracker struct {
+	mu       sync.Mutex
+	progress []string
+	statuses map[string]WatchStatus
+	failures []error // returned by UpdateProgress in order before succeeding
+}
+
+func (f *fakeTracker) Authenticate(ctx context.Context) error { return nil }
+func (f *fakeTracker) IsAuthenticated() bool                  { return true }
+func (f *fakeTracker) Logout() error                          { return nil }
+func (f *fakeTracker) GetUserLibrary(ctx context.Context, mediaType providers.MediaType) ([]TrackedMedia, error) {
+	return nil, nil
+}
+func (f *fakeTracker) SearchMedia(ctx context.Context, query string, mediaType providers.MediaType) ([]TrackedMedia, error) {
+	return nil, nil
+}
+func (f *fakeTracker) UpdateProgress(ctx context.Context, mediaID string, episode int, progress float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.failures) > 0 {
+		err := f.failures[0]
+		f.failures = f.failures[1:]
+		return err
+	}
+	f.progress = append(f.progress, fmt.Sprintf("%s:%d", mediaID, episode))
+	return nil
+}
+func (f *fakeTracker) GetProgress(ctx context.Context, mediaID string) (*Progress, error) {
+	return nil, nil
+}
+func (f *fakeTracker) UpdateStatus(ctx context.Context, mediaID string, status WatchStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.statuses == nil {
+		f.statuses = make(map[string]WatchStatus)
+	}
+	f.statuses[mediaID] = status
+	return nil
+}
+func (f *fakeTracker) UpdateScore(ctx context.Context, mediaID string, score float64) error {
+	return nil
+}
+func (f *fakeTracker) UpdateDates(ctx context.Context, mediaID string, startDate, endDate *time.Time) error {
+	return nil
+}
+func (f *fakeTracker) SyncHistory(ctx context.Context) error                     { return nil }
+func (f *fakeTracker) DeleteFromList(ctx context.Context, mediaListID int) error { return nil }
+
+func newTestManager(t *testing.T, ft *fakeTracker) (*Manager, *gorm.DB) {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.Migrate(db))
+
+	cfg := &config.Config{}
+	cfg.Tracker.AniList.Enabled = true
+	cfg.Tracker.AniList.AutoSync = true
+	cfg.Tracker.AniList.AutoComplete = true
+	cfg.Tracker.AniList.SyncInterval = time.Hour
+
+	mgr := NewManager(cfg, db)
+	mgr.SetAniListClient(ft)
+	return mgr, db
+}
+
+func TestFlushCoalescesAndDebounces(t *testing.T) {
+	ft := &fakeTracker{}
+	mgr, db := newTestManager(t, ft)
+	ctx := context.Background()
+
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 3, false))
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 5, false))
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 4, false))
+	require.NoError(t, mgr.QueueProgress(ctx, "99", 1, false))
+
+	pushed, err := mgr.Flush(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, pushed, 2)
+	assert.Equal(t, []string{"21:5", "99:1"}, ft.progress)
+
+	var pending int64
+	require.NoError(t, db.Model(&database.SyncQueue{}).Where("synced = ?", false).Count(&pending).Error)
+	assert.Zero(t, pending)
+
+	// A second flush within sync_interval is skipped
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 6, false))
+	pushed, err = mgr.Flush(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, pushed)
+	assert.Len(t, ft.progress, 2)
+
+	// Forcing bypasses the debounce
+	pushed, err = mgr.Flush(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, pushed, 1)
+	assert.Equal(t, "21:6", ft.progress[2])
+}
+
+func TestFlushDebounceFollowsClock(t *testing.T) {
+	ft := &fakeTracker{}
+	mgr, _ := newTestManager(t, ft)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	mgr.SetClock(fake)
+	ctx := context.Background()
+
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 1, false))
+	_, err := mgr.Flush(ctx, false)
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 2, false))
+	fake.Advance(59 * time.Minute)
+	pushed, err := mgr.Flush(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, pushed)
+
+	fake.Advance(time.Minute)
+	pushed, err = mgr.Flush(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, pushed, 1)
+	assert.Equal(t, []string{"21:1", "21:2"}, ft.progress)
+}
+
+func TestFlushAutoComplete(t *testing.T) {
+	ft := &fakeTracker{}
+	mgr, _ := newTestManager(t, ft)
+	ctx := context.Background()
+
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 7, false))
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 8, true))
+
+	_, err := mgr.Flush(ctx, true)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCompleted, ft.statuses["21"])
+
+	// Without auto_complete the status is left alone
+	ft2 := &fakeTracker{}
+	mgr2, _ := newTestManager(t, ft2)
+	mgr2.cfg.Tracker.AniList.AutoComplete = false
+	require.NoError(t, mgr2.QueueProgress(ctx, "21", 8, true))
+	_, err = mgr2.Flush(ctx, true)
+	require.NoError(t, err)
+	assert.Empty(t, ft2.statuses)
+}
+
+func TestFlushRetriesTransientErrors(t *testing.T) {
+	orig := syncRetryDelay
+	syncRetryDelay = time.Millisecond
+	defer func() { syncRetryDelay = orig }()
+
+	ft := &fakeTracker{failures: []error{
+		&APIError{StatusCode: http.StatusTooManyRequests},
+		&APIError{StatusCode: http.StatusBadGateway},
+	}}
+	mgr, _ := newTestManager(t, ft)
+	ctx := context.Background()
+
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 2, false))
+	pushed, err := mgr.Flush(ctx, true)
+	require.NoError(t, err)
+	assert.Len(t, pushed, 1)
+	assert.Equal(t, []string{"21:2"}, ft.progress)
+}
+
+func TestFlushKeepsQueueOnPermanentError(t *testing.T) {
+	ft := &fakeTracker{failures: []error{&APIError{StatusCode: http.StatusBadRequest, Message: "invalid media"}}}
+	mgr, db := newTestManager(t, ft)
+	ctx := context.Background()
+
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 2, false))
+	pushed, err := mgr.Flush(ctx, true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid media")
+	assert.Empty(t, pushed)
+
+	var pending int64
+	require.NoError(t, db.Model(&database.SyncQueue{}).Where("synced = ?", false).Count(&pending).Error)
+	assert.Equal(t, int64(1), pending)
+}
+
+func TestFlushDryRun(t *testing.T) {
+	ft := &fakeTracker{}
+	mgr, db := newTestManager(t, ft)
+	mgr.SetSyncDryRun(true)
+	ctx := context.Background()
+
+	require.NoError(t, mgr.QueueProgress(ctx, "21", 12, true))
+	planned, err := mgr.Flush(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, planned, 1)
+	assert.Equal(t, 12, planned[0].Episode)
+	assert.True(t, planned[0].Complete)
+
+	assert.Empty(t, ft.progress)
+	var pending int64
+	require.NoError(t, db.Model(&database.SyncQueue{}).Where("synced = ?", false).Count(&pending).Error)
+	assert.Equal(t, int64(1), pending)
+}
+
+func TestQueueProgressRequiresAutoSync(t *testing.T) {
+	mgr, db := newTestManager(t, &fakeTracker{})
+	mgr.cfg.Tracker.AniList.AutoSync = false
+
+	require.NoError(t, mgr.QueueProgress(context.Background(), "21", 1, false))
+
+	var count int64
+	require.NoError(t, db.Model(&database.SyncQueue{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestSyncEpisode(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("auto_sync queues and flushes", func(t *testing.T) {
+		ft := &fakeTracker{}
+		mgr, db := newTestManager(t, ft)
+
+		require.NoError(t, mgr.SyncEpisode(ctx, "21", 3, false))
+		assert.Equal(t, []string{"21:3"}, ft.progress)
+
+		var synced int64
+		require.NoError(t, db.Model(&database.SyncQueue{}).Where("synced = ?", true).Count(&synced).Error)
+		assert.Equal(t, int64(1), synced)
+	})
+
+	t.Run("without auto_sync progress is pushed straight away", func(t *testing.T) {
+		ft := &fakeTracker{}
+		mgr, db := newTestManager(t, ft)
+		mgr.cfg.Tracker.AniList.AutoSync = false
+
+		require.NoError(t, mgr.SyncEpisode(ctx, "21", 3, false))
+		require.NoError(t, mgr.SyncEpisode(ctx, "21", 4, true))
+		assert.Equal(t, []string{"21:3", "21:4"}, ft.progress)
+		assert.Equal(t, StatusCompleted, ft.statuses["21"])
+
+		var count int64
+		require.NoError(t, db.Model(&database.SyncQueue{}).Count(&count).Error)
+		assert.Zero(t, count, "nothing is queued without auto_sync")
+	})
+
+	t.Run("disabled tracker sends nothing", func(t *testing.T) {
+		ft := &fakeTracker{}
+		mgr, _ := newTestManager(t, ft)
+		mgr.cfg.Tracker.AniList.Enabled = false
+
+		require.NoError(t, mgr.SyncEpisode(ctx, "21", 3, false))
+		assert.Empty(t, ft.progress)
+	})
+}
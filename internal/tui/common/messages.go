@@ -218,6 +218,15 @@ type ResumePlaybackMsg struct {
 // PlaybackAutoReturnMsg is sent after a delay to automatically return from playback completion
 type PlaybackAutoReturnMsg struct{}
 
+// NextEpisodeResolvedMsg carries the episode to autoplay after the current
+// one ended. Episode is nil at the end of the series. Episodes is the new
+// season's episode list when the next episode starts a new season.
+type NextEpisodeResolvedMsg struct {
+	Episode  *providers.Episode
+	Episodes []providers.Episode
+	Err      error
+}
+
 // MangaPagesLoadedMsg is a message when manga pages are loaded successfully.
 type MangaPagesLoadedMsg struct {
 	Pages []string
@@ -301,9 +310,10 @@ type RequestDetailsMsg struct {
 
 // DetailsLoadedMsg is a message when details for a media item are loaded
 type DetailsLoadedMsg struct {
-	Media providers.Media
-	Index int
-	Err   error
+	Media    providers.Media
+	Index    int
+	Err      error
+	Mismatch error // Wraps providers.ErrTitleMismatch when the details describe a different title
 }
 
 // SearchProviderMsg is a message to search a specific provider
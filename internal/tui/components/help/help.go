@@ -115,6 +115,12 @@ var allShortcuts = []Shortcut{
 	{Key: "ctrl+r", Description: "Refresh list", Context: []HelpContext{DownloadsContext}},
 	{Key: "/", Description: "Filter downloads", Context: []HelpContext{DownloadsContext}},
 
+	// Provider status context
+	{Key: "c", Description: "Clear selected provider cache", Context: []HelpContext{ProviderStatusContext}},
+	{Key: "C", Description: "Clear all caches", Context: []HelpContext{ProviderStatusContext}},
+	{Key: "d", Description: "Disable/enable selected provider for this session", Context: []HelpContext{ProviderStatusContext}},
+	{Key: "D", Description: "Disable/enable selected provider and save to config", Context: []HelpContext{ProviderStatusContext}},
+
 	// Settings context (for future use)
 	{Key: "s", Description: "Save settings", Context: []HelpContext{SettingsContext}},
 	{Key: "r", Description: "Reset to defaults", Context: []HelpContext{SettingsContext}},
@@ -401,6 +407,8 @@ func (m Model) getContextName() string {
 		return "Settings"
 	case HistoryContext:
 		return "History"
+	case ProviderStatusContext:
+		return "Provider Status"
 	default:
 		return ""
 	}
@@ -1,9 +1,8 @@
 package manga
 
 import (
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"strconv"
@@ -17,6 +16,7 @@ import (
 
 	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/database"
+	"github.com/justchokingaround/greg/internal/providers"
 	"github.com/justchokingaround/greg/internal/tui/common"
 )
 
@@ -579,25 +579,25 @@ func (m *Model) renderPage() tea.Cmd {
 	}
 
 	return func() tea.Msg {
-		// Create a temp file
-		tmpFile, err := os.CreateTemp("", "greg-manga-*.jpg")
+		// Fetched through the shared client, which serves revisited pages from
+		// the image cache when it is enabled
+		data, _, err := providers.FetchImage(context.Background(), url)
 		if err != nil {
-			return PageRenderedMsg{Err: fmt.Errorf("failed to create temp file: %w", err)}
+			return PageRenderedMsg{Err: err}
 		}
-		defer func() { _ = os.Remove(tmpFile.Name()) }()
 
-		// Download the image
-		resp, err := http.Get(url)
+		tmpFile, err := os.CreateTemp("", "greg-manga-*.img")
 		if err != nil {
-			return PageRenderedMsg{Err: fmt.Errorf("failed to download image: %w", err)}
+			return PageRenderedMsg{Err: fmt.Errorf("failed to create temp file: %w", err)}
 		}
-		defer func() { _ = resp.Body.Close() }()
+		defer func() { _ = os.Remove(tmpFile.Name()) }()
 
-		_, err = io.Copy(tmpFile, resp.Body)
-		if err != nil {
+		if _, err := tmpFile.Write(data); err != nil {
+			_ = tmpFile.Close()
 			return PageRenderedMsg{Err: fmt.Errorf("failed to save image: %w", err)}
 		}
 		_ = tmpFile.Close()
+		imagePath := tmpFile.Name()
 
 		// Run chafa
 		// We set --size to the available area
@@ -621,7 +621,7 @@ func (m *Model) renderPage() tea.Cmd {
 		// "symbols" or others will use default (no -f flag or specific one if needed, but chafa defaults to symbols)
 
 		// Add file path
-		args = append(args, tmpFile.Name())
+		args = append(args, imagePath)
 
 		cmd := exec.Command("chafa", args...)
 		output, err := cmd.Output()
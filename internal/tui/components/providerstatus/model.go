@@ -8,6 +8,7 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/providers"
 	"github.com/justchokingaround/greg/internal/tui/common"
 	"github.com/justchokingaround/greg/internal/tui/styles"
@@ -18,6 +19,10 @@ type Model struct {
 	err           error
 	showingDetail bool
 	selectedItem  *item
+	notice        string
+
+	// cfg receives provider enable/disable changes that are saved
+	cfg *config.Config
 }
 
 type item struct {
@@ -30,6 +35,9 @@ func (i item) Title() string {
 
 func (i item) Description() string {
 	status := i.status.Status
+	if i.status.Disabled {
+		return fmt.Sprintf("⏸ Disabled (%s)", status)
+	}
 	if i.status.Healthy {
 		return fmt.Sprintf("✅ %s", status)
 	}
@@ -38,13 +46,15 @@ func (i item) Description() string {
 
 func (i item) FilterValue() string { return i.status.ProviderName }
 
-func New() Model {
+// New creates the provider status view. cfg may be nil, in which case
+// providers can only be disabled for the session.
+func New(cfg *config.Config) Model {
 	l := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
 	l.Title = "Provider Health Status"
 	l.SetShowStatusBar(false)
 	l.SetFilteringEnabled(false)
 	l.Styles.Title = lipgloss.NewStyle().Bold(true)
-	return Model{list: l}
+	return Model{list: l, cfg: cfg}
 }
 
 func (m Model) Init() tea.Cmd {
@@ -70,11 +80,43 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 		}
+		if !m.showingDetail {
+			switch msg.String() {
+			case "c":
+				// Clear the selected provider's cache
+				if i, ok := m.list.SelectedItem().(item); ok {
+					return m, clearProviderCache(i.status.ProviderName)
+				}
+			case "C":
+				// Clear every provider's cache and the on-disk cache
+				return m, clearAllCaches
+			case "d", "D":
+				// Switch the selected provider off or back on; D also saves
+				// the change to the config
+				if i, ok := m.list.SelectedItem().(item); ok {
+					return m, toggleProvider(i.status.ProviderName, m.cfg, msg.String() == "D")
+				}
+			}
+		}
 		if (msg.Type == tea.KeyEscape || msg.String() == "q") && m.showingDetail {
 			m.showingDetail = false
 			m.selectedItem = nil
 			return m, nil
 		}
+	case cacheClearedMsg:
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("Failed to clear cache: %v", msg.err)
+		} else {
+			m.notice = msg.notice
+		}
+		return m, nil
+	case providerToggledMsg:
+		if msg.err != nil {
+			m.notice = msg.err.Error()
+		} else {
+			m.notice = msg.notice
+		}
+		return m, fetchStatuses
 	case common.TickMsg:
 		return m, fetchStatuses
 	case common.ProviderStatusesMsg:
@@ -100,6 +142,9 @@ func (m Model) View() string {
 	if m.showingDetail {
 		return m.renderDetail()
 	}
+	if m.notice != "" {
+		return m.list.View() + "\n" + m.notice
+	}
 	return m.list.View()
 }
 
@@ -111,6 +156,11 @@ func (m Model) renderDetail() string {
 	var b strings.Builder
 	b.WriteString(fmt.Sprintf("Provider: %s\n\n", m.selectedItem.status.ProviderName))
 	b.WriteString(fmt.Sprintf("Status: %s\n", m.selectedItem.status.Status))
+	if p, err := providers.Get(m.selectedItem.status.ProviderName); err == nil {
+		if baseURL := providers.BaseURLOf(p); baseURL != "" {
+			b.WriteString(fmt.Sprintf("Base URL: %s\n", baseURL))
+		}
+	}
 	b.WriteString(fmt.Sprintf("Duration: %s\n\n", r.Duration))
 	b.WriteString("Curl Command:\n")
 	b.WriteString(r.CurlCommand)
@@ -125,3 +175,57 @@ func fetchStatuses() tea.Msg {
 	}
 	return common.ProviderStatusesMsg(statuses)
 }
+
+// cacheClearedMsg reports the outcome of a cache clear action
+type cacheClearedMsg struct {
+	notice string
+	err    error
+}
+
+func clearProviderCache(name string) tea.Cmd {
+	return func() tea.Msg {
+		if err := providers.GetRegistry().ClearProviderCache(name); err != nil {
+			return cacheClearedMsg{err: err}
+		}
+		return cacheClearedMsg{notice: fmt.Sprintf("Cleared %s cache", name)}
+	}
+}
+
+func clearAllCaches() tea.Msg {
+	if err := providers.ClearCache(); err != nil {
+		return cacheClearedMsg{err: err}
+	}
+	return cacheClearedMsg{notice: "Cleared all provider caches"}
+}
+
+// providerToggledMsg reports the outcome of disabling or enabling a provider
+type providerToggledMsg struct {
+	notice string
+	err    error
+}
+
+// toggleProvider disables an enabled provider or enables a disabled one for
+// the session, and with persist also writes the change to cfg
+func toggleProvider(name string, cfg *config.Config, persist bool) tea.Cmd {
+	return func() tea.Msg {
+		enable := providers.IsDisabled(name)
+		toggle, verb := providers.Disable, "Disabled"
+		if enable {
+			toggle, verb = providers.Enable, "Enabled"
+		}
+		if err := toggle(name); err != nil {
+			return providerToggledMsg{err: fmt.Errorf("failed to toggle %s: %w", name, err)}
+		}
+		if !persist {
+			return providerToggledMsg{notice: fmt.Sprintf("%s %s for this session", verb, name)}
+		}
+
+		if cfg == nil || !cfg.Providers.SetEnabled(name, enable) {
+			return providerToggledMsg{err: fmt.Errorf("%s %s for this session, but it can't be saved to the config", verb, name)}
+		}
+		if err := cfg.Save(); err != nil {
+			return providerToggledMsg{err: fmt.Errorf("%s %s for this session, but saving the config failed: %w", verb, name, err)}
+		}
+		return providerToggledMsg{notice: fmt.Sprintf("%s %s and saved to config", verb, name)}
+	}
+}
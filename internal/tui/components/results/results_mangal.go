@@ -92,6 +92,9 @@ func (m *MangalModel) UpdateMediaItem(index int, media providers.Media) {
 		if media.PosterURL != "" {
 			existing.PosterURL = media.PosterURL
 		}
+		if media.SourceQuality != "" {
+			existing.SourceQuality = media.SourceQuality
+		}
 
 		m.results[index] = existing
 	}
@@ -808,6 +811,9 @@ func (m MangalModel) renderMediaItem(media providers.Media, selected bool) strin
 	if media.Type != "" {
 		metaParts = append(metaParts, string(media.Type))
 	}
+	if media.SourceQuality != "" {
+		metaParts = append(metaParts, media.SourceQuality)
+	}
 	if media.Rating > 0 {
 		metaParts = append(metaParts, fmt.Sprintf("★ %.1f", media.Rating))
 	}
@@ -1046,6 +1052,9 @@ func (m MangalModel) renderInfoDialog(media providers.Media) string {
 	if media.Type != "" {
 		metaParts = append(metaParts, fmt.Sprintf("Type: %s", string(media.Type)))
 	}
+	if media.SourceQuality != "" {
+		metaParts = append(metaParts, fmt.Sprintf("Quality: %s", media.SourceQuality))
+	}
 	if media.Rating > 0 {
 		metaParts = append(metaParts, fmt.Sprintf("Rating: ★ %.1f", media.Rating))
 	}
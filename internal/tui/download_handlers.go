@@ -88,7 +88,8 @@ func (a *App) resolveAndDownloadMovie(mediaID string, title string) tea.Cmd {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		stream, err := provider.GetStreamURL(ctx, episodeID, providers.Quality1080p)
+		quality := a.streamQuality(provider.Name())
+		stream, err := provider.GetStreamURL(ctx, episodeID, quality)
 		if err != nil {
 			return nil
 		}
@@ -100,7 +101,7 @@ func (a *App) resolveAndDownloadMovie(mediaID string, title string) tea.Cmd {
 			MediaType:  providers.MediaTypeMovie,
 			Episode:    0, // Movies don't have episodes
 			Season:     0,
-			Quality:    providers.Quality1080p,
+			Quality:    quality,
 			Provider:   provider.Name(),
 			StreamURL:  stream.URL,
 			StreamType: stream.Type,
@@ -130,7 +131,7 @@ func (a *App) resolveAndDownloadMovie(mediaID string, title string) tea.Cmd {
 		return common.DownloadAddedMsg{
 			Title:    title,
 			Episode:  0, // 0 to suppress "Episode 1" in notification
-			Quality:  string(providers.Quality1080p),
+			Quality:  string(quality),
 			Location: outputPath,
 		}
 	}
@@ -157,7 +158,8 @@ func (a *App) startDownload(episodeID string, episodeNumber int, episodeTitle st
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		stream, err := provider.GetStreamURL(ctx, episodeID, providers.Quality1080p)
+		quality := a.streamQuality(provider.Name())
+		stream, err := provider.GetStreamURL(ctx, episodeID, quality)
 		if err != nil {
 			a.logger.Error("failed to get stream URL for download", "error", err)
 			return nil
@@ -176,7 +178,7 @@ func (a *App) startDownload(episodeID string, episodeNumber int, episodeTitle st
 			MediaType:  a.selectedMedia.Type,
 			Episode:    episodeNumber,
 			Season:     0, // TODO: Add season support
-			Quality:    providers.Quality1080p,
+			Quality:    quality,
 			Provider:   provider.Name(),
 			StreamURL:  stream.URL,
 			StreamType: stream.Type,
@@ -212,7 +214,7 @@ func (a *App) startDownload(episodeID string, episodeNumber int, episodeTitle st
 		return common.DownloadAddedMsg{
 			Title:    a.selectedMedia.Title,
 			Episode:  episodeNumber,
-			Quality:  string(providers.Quality1080p),
+			Quality:  string(quality),
 			Location: outputPath,
 		}
 	}
@@ -247,7 +249,7 @@ func (a *App) downloadMangaChapters(provider providers.MangaProvider, episodes [
 		}
 
 		// Generate output path
-		sanitizedTitle := sanitizeFilename(a.selectedMedia.Title)
+		sanitizedTitle := a.sanitizeFilename(a.selectedMedia.Title)
 		filename := fmt.Sprintf("%s - Chapter %d.cbz", sanitizedTitle, ep.Number)
 		outputPath := filepath.Join(a.getDownloadPath(), "manga", sanitizedTitle, filename)
 
@@ -300,20 +302,13 @@ func (a *App) downloadMangaChapters(provider providers.MangaProvider, episodes [
 	}
 }
 
-// sanitizeFilename removes invalid characters from filenames
-func sanitizeFilename(name string) string {
-	replacer := strings.NewReplacer(
-		"/", "-",
-		"\\", "-",
-		":", "-",
-		"*", "",
-		"?", "",
-		"\"", "",
-		"<", "",
-		">", "",
-		"|", "",
-	)
-	return replacer.Replace(name)
+// sanitizeFilename makes a title safe to use as a file or directory name,
+// the same way the download manager names video downloads
+func (a *App) sanitizeFilename(name string) string {
+	if appCfg, ok := a.cfg.(*config.Config); ok {
+		return downloader.SanitizerFor(&appCfg.Downloads).Sanitize(name)
+	}
+	return downloader.NewSanitizer("").Sanitize(name)
 }
 
 // getDownloadPath returns the download path from config or default
@@ -432,11 +427,12 @@ func (a *App) handleBatchDownloadMsg(msg common.BatchDownloadMsg) (*App, tea.Cmd
 				}
 
 				// Get stream URL with retry
+				quality := a.streamQuality(provider.Name())
 				var stream *providers.StreamURL
 				var err error
 				for attempt := 1; attempt <= 2; attempt++ {
 					ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
-					stream, err = provider.GetStreamURL(ctx, ep.EpisodeID, providers.Quality1080p)
+					stream, err = provider.GetStreamURL(ctx, ep.EpisodeID, quality)
 					cancel()
 
 					if err == nil {
@@ -464,7 +460,7 @@ func (a *App) handleBatchDownloadMsg(msg common.BatchDownloadMsg) (*App, tea.Cmd
 					MediaType:  a.selectedMedia.Type,
 					Episode:    ep.Number,
 					Season:     0,
-					Quality:    providers.Quality1080p,
+					Quality:    quality,
 					Provider:   provider.Name(),
 					StreamURL:  stream.URL,
 					StreamType: stream.Type,
@@ -474,15 +470,16 @@ func (a *App) handleBatchDownloadMsg(msg common.BatchDownloadMsg) (*App, tea.Cmd
 					EmbedSubs:  true,
 				}
 
-				// Add to queue
-				if err := a.downloadMgr.AddToQueue(context.Background(), task); err != nil {
+				// Add to queue; the manager downloads at most downloads.concurrent at once
+				id, err := a.downloadMgr.Enqueue(context.Background(), task)
+				if err != nil {
 					a.logger.Error("failed to add to download queue", "episode", ep.Number, "error", err)
 					// Check if it's a duplicate
 					if strings.Contains(err.Error(), "already in queue") {
 						a.logger.Info("skipping duplicate episode", "episode", ep.Number)
 					}
 				} else {
-					a.logger.Info("added to download queue", "episode", ep.Number)
+					a.logger.Info("added to download queue", "episode", ep.Number, "task_id", id)
 					successCount++
 				}
 
@@ -10,6 +10,7 @@ import (
 	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/database"
 	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/providers/utils"
 	"github.com/justchokingaround/greg/internal/tracker/mapping"
 	"github.com/justchokingaround/greg/internal/tui/common"
 )
@@ -114,6 +115,9 @@ func (a *App) handleSearchResultsMsg(msg common.SearchResultsMsg) (*App, tea.Cmd
 			limit = len(mediaResults)
 		}
 
+		// Results that already have a synopsis aren't requested above, so warm
+		// their info cache to make opening them instant too
+		var warm []providers.Media
 		for i := 0; i < limit; i++ {
 			if mediaResults[i].Synopsis == "" {
 				idx := i
@@ -124,13 +128,33 @@ func (a *App) handleSearchResultsMsg(msg common.SearchResultsMsg) (*App, tea.Cmd
 						Index:   idx,
 					}
 				})
+			} else {
+				warm = append(warm, mediaResults[i])
 			}
 		}
+		if len(warm) > 0 {
+			cmds = append(cmds, a.prefetchDetails(warm))
+		}
 	}
 
 	return a, tea.Batch(cmds...)
 }
 
+// prefetchDetails loads the details of results in the background so opening
+// them doesn't wait on the site
+func (a *App) prefetchDetails(media []providers.Media) tea.Cmd {
+	providerName := a.providerName
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := providers.PrefetchDetails(ctx, providerName, media); err != nil {
+			a.debugLog("Prefetching details from %s: %v", providerName, err)
+		}
+		return nil
+	}
+}
+
 // handleGenerateMediaDebugInfoMsg handles media debug info generation
 func (a *App) handleGenerateMediaDebugInfoMsg(msg common.GenerateMediaDebugInfoMsg) (*App, tea.Cmd) {
 	// Save current state to return to
@@ -862,6 +886,8 @@ func (a *App) performSearch(query string) tea.Cmd {
 		a.searchQueries = make(map[providers.MediaType]string)
 	}
 	a.searchQueries[a.currentMediaType] = query
+	a.requestID = providers.NewRequestID()
+	requestID := a.requestID
 
 	return func() tea.Msg {
 		provider, ok := a.providers[a.currentMediaType]
@@ -870,7 +896,7 @@ func (a *App) performSearch(query string) tea.Cmd {
 		}
 
 		// Add timeout to prevent hanging indefinitely
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(providers.WithRequestID(context.Background(), requestID), 30*time.Second)
 		defer cancel()
 
 		results, err := provider.Search(ctx, query)
@@ -906,6 +932,10 @@ func (a *App) performSearch(query string) tea.Cmd {
 		}
 		results = filteredResults
 
+		if a.safeSearch() {
+			results = providers.FilterAdult(results)
+		}
+
 		// Enrich results with detailed information (genres, rating, synopsis)
 		// We no longer fetch details immediately to avoid API rate limits.
 		// Instead, details are fetched on-demand as the user scrolls (lazy loading).
@@ -920,8 +950,17 @@ func (a *App) performSearch(query string) tea.Cmd {
 	}
 }
 
+// safeSearch reports whether adult titles should be hidden from search results
+func (a *App) safeSearch() bool {
+	cfg, ok := a.cfg.(*config.Config)
+	return !ok || cfg.UI.SafeSearch
+}
+
 // searchSpecificProvider searches using a specific provider
 func (a *App) searchSpecificProvider(providerName string, query string) tea.Cmd {
+	a.requestID = providers.NewRequestID()
+	requestID := a.requestID
+
 	return func() tea.Msg {
 		a.debugLog("searchSpecificProvider: provider=%s, query=%s, currentMediaType=%s", providerName, query, a.currentMediaType)
 
@@ -931,7 +970,7 @@ func (a *App) searchSpecificProvider(providerName string, query string) tea.Cmd
 			return common.SearchResultsMsg{Err: fmt.Errorf("provider not found: %s", providerName)}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		ctx, cancel := context.WithTimeout(providers.WithRequestID(context.Background(), requestID), 30*time.Second)
 		defer cancel()
 
 		results, err := provider.Search(ctx, query)
@@ -969,6 +1008,10 @@ func (a *App) searchSpecificProvider(providerName string, query string) tea.Cmd
 		}
 		results = filteredResults
 
+		if a.safeSearch() {
+			results = providers.FilterAdult(results)
+		}
+
 		a.debugLog("searchSpecificProvider: After filtering, %d results for type %s", len(results), a.currentMediaType)
 
 		var interfaceResults []interface{}
@@ -980,8 +1023,15 @@ func (a *App) searchSpecificProvider(providerName string, query string) tea.Cmd
 	}
 }
 
-// fetchMediaDetails fetches detailed media information
+// fetchMediaDetails fetches detailed media information and checks it against
+// the search result it was requested for
 func (a *App) fetchMediaDetails(mediaID string, index int) tea.Cmd {
+	var result providers.Media
+	if items := a.results.GetItems(); index >= 0 && index < len(items) && items[index].ID == mediaID {
+		result = items[index]
+	}
+	requestID := a.requestID
+
 	return func() tea.Msg {
 		// Acquire semaphore to limit concurrent fetches
 		a.detailsSem <- struct{}{}
@@ -992,7 +1042,7 @@ func (a *App) fetchMediaDetails(mediaID string, index int) tea.Cmd {
 			return common.DetailsLoadedMsg{Err: fmt.Errorf("no provider available"), Index: index}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(providers.WithRequestID(context.Background(), requestID), 10*time.Second)
 		defer cancel()
 
 		details, err := provider.GetMediaDetails(ctx, mediaID)
@@ -1001,17 +1051,19 @@ func (a *App) fetchMediaDetails(mediaID string, index int) tea.Cmd {
 		}
 
 		return common.DetailsLoadedMsg{
-			Media: details.Media,
-			Index: index,
+			Media:    details.Media,
+			Index:    index,
+			Mismatch: utils.CheckTitleMatch(result, details),
 		}
 	}
 }
 
 // getSeasons retrieves seasons for the given media ID
 func (a *App) getSeasons(mediaID string) tea.Cmd {
+	ctx := providers.WithRequestID(context.Background(), a.requestID)
 	return func() tea.Msg {
 		provider := a.providers[a.currentMediaType]
-		seasons, err := provider.GetSeasons(context.Background(), mediaID)
+		seasons, err := provider.GetSeasons(ctx, mediaID)
 		if err != nil {
 			return common.SeasonsLoadedMsg{Error: err}
 		}
@@ -1030,9 +1082,10 @@ func (a *App) getSeasons(mediaID string) tea.Cmd {
 
 // getEpisodes retrieves episodes for the given season ID
 func (a *App) getEpisodes(seasonID string) tea.Cmd {
+	ctx := providers.WithRequestID(context.Background(), a.requestID)
 	return func() tea.Msg {
 		provider := a.providers[a.currentMediaType]
-		episodes, err := provider.GetEpisodes(context.Background(), seasonID)
+		episodes, err := provider.GetEpisodes(ctx, seasonID)
 		if err != nil {
 			return common.EpisodesLoadedMsg{Error: err}
 		}
@@ -1058,6 +1111,26 @@ func (a *App) handleDetailsLoadedMsg(msg common.DetailsLoadedMsg) (tea.Model, te
 		a.debugLog("Failed to fetch details for %s: %v", msg.Media.ID, msg.Err)
 		return a, nil
 	}
+	if msg.Mismatch != nil {
+		// Keep the card's own title and year so the list still shows what was
+		// searched for, and warn before the user picks it
+		a.debugLog("Details for %s don't match the search result: %v", msg.Media.ID, msg.Mismatch)
+		a.statusMsg = fmt.Sprintf("⚠ %v", msg.Mismatch)
+		a.statusMsgTime = time.Now()
+		msg.Media.Title = ""
+		msg.Media.Year = 0
+		msg.Media.Status = ""
+	}
+	if a.safeSearch() && providers.IsAdult(msg.Media.Genres...) {
+		// Search results are only filtered on the genres the search page
+		// lists, so warn about titles whose details show more
+		title := msg.Media.Title
+		if items := a.results.GetItems(); msg.Index >= 0 && msg.Index < len(items) {
+			title = items[msg.Index].Title
+		}
+		a.statusMsg = fmt.Sprintf("⚠ %s is marked as adult content", title)
+		a.statusMsgTime = time.Now()
+	}
 	a.results.UpdateMediaItem(msg.Index, msg.Media)
 	return a, nil
 }
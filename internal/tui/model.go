@@ -20,6 +20,7 @@ import (
 	"github.com/justchokingaround/greg/internal/player"
 	"github.com/justchokingaround/greg/internal/player/mpv"
 	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/subtitles"
 	"github.com/justchokingaround/greg/internal/tracker"
 	"github.com/justchokingaround/greg/internal/tracker/mapping"
 	"github.com/justchokingaround/greg/internal/tui/common"
@@ -131,6 +132,7 @@ type App struct {
 	lastProgress            *player.PlaybackProgress // Store last known progress
 	playbackCompletionMsg   string                   // Message to show after playback ends
 	episodeCompleted        bool                     // Whether the last episode was completed (>= 85%)
+	resolvingNextEpisode    bool                     // Looking up the episode to autoplay (player.autoplay_next)
 	launchStartTime         time.Time                // When player launch started (for timeout)
 	lastPlayedEpisodeNumber int                      // Episode number to position cursor on after playback
 
@@ -142,6 +144,10 @@ type App struct {
 	// Search queries per media type
 	searchQueries map[providers.MediaType]string
 
+	// requestID tags the provider requests made from the latest search
+	// onwards, so the flow can be followed in the debug log
+	requestID string
+
 	// For debug links mode
 	inDebugLinksMode bool
 	debugInfo        *DebugInfo
@@ -204,6 +210,9 @@ type App struct {
 	audioPreference    string               // "dub", "sub", or "" (use DB/config)
 	selectedAudioTrack *int                 // User-selected audio track index from selector (nil if not set)
 	pendingStream      *providers.StreamURL // Stream waiting for audio selection
+
+	// External subtitle source used when a stream lacks the preferred language
+	subtitleSource subtitles.SubtitleProvider
 }
 
 func NewApp(providerMap map[providers.MediaType]providers.Provider, db *gorm.DB, cfg interface{}, logger *slog.Logger, audioPreference string) *App {
@@ -353,7 +362,7 @@ func NewApp(providerMap map[providers.MediaType]providers.Provider, db *gorm.DB,
 		mangaComponent:          manga.New(appConfig, db),
 		mangaInfoComponent:      mangainfo.New(nil),
 		mangaDownloadComponent:  mangadownload.New(),
-		providerStatusComponent: providerstatus.New(),
+		providerStatusComponent: providerstatus.New(appConfig),
 		historyService:          historyService,
 		helpComponent:           help.New(),
 		spinner:                 s,
@@ -371,6 +380,7 @@ func NewApp(providerMap map[providers.MediaType]providers.Provider, db *gorm.DB,
 		clipboardSvc:            clipboardSvc,
 		msgChan:                 make(chan tea.Msg, 100),
 		audioPreference:         audioPreference,
+		subtitleSource:          newSubtitleSource(appConfig),
 	}
 
 	// Set parent for manga info component
@@ -753,6 +763,9 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case common.PlaybackAutoReturnMsg:
 		return a.handlePlaybackAutoReturnMsg(msg)
 
+	case common.NextEpisodeResolvedMsg:
+		return a.handleNextEpisodeResolvedMsg(msg)
+
 	case common.ShowAudioSelectorMsg:
 		// Show audio selector when no matching track found
 		selector := audioselect.New(msg.Tracks, msg.AniListID)
@@ -5,7 +5,9 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
@@ -16,9 +18,13 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/justchokingaround/greg/internal/audio"
+	"github.com/justchokingaround/greg/internal/config"
 	"github.com/justchokingaround/greg/internal/database"
+	historyservice "github.com/justchokingaround/greg/internal/history"
 	"github.com/justchokingaround/greg/internal/player"
 	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/subtitles"
+	"github.com/justchokingaround/greg/internal/subtitles/opensubtitles"
 	"github.com/justchokingaround/greg/internal/tracker"
 	"github.com/justchokingaround/greg/internal/tracker/mapping"
 	"github.com/justchokingaround/greg/internal/tui/common"
@@ -88,6 +94,11 @@ func (a *App) handlePlaybackProgressMsg(msg common.PlaybackProgressMsg) tea.Cmd
 		return nil
 	}
 
+	// Playback already ended and the next episode is being looked up
+	if a.resolvingNextEpisode {
+		return nil
+	}
+
 	if msg.Err != nil {
 		errMsg := msg.Err.Error()
 		a.debugLog("handlePlaybackProgressMsg: GetProgress error: %v", msg.Err)
@@ -142,6 +153,10 @@ func (a *App) handlePlaybackProgressMsg(msg common.PlaybackProgressMsg) tea.Cmd
 	if msg.Progress.EOF {
 		a.debugLog("handlePlaybackProgressMsg: EOF reached, ending playback")
 		a.syncProgressOnEnd(a.lastProgress)
+		if a.autoplayNext() && a.currentEpisodeNumber > 0 {
+			a.resolvingNextEpisode = true
+			return a.resolveNextEpisode()
+		}
 		return func() tea.Msg {
 			return createPlaybackEndedMsg(a.lastProgress)
 		}
@@ -151,6 +166,80 @@ func (a *App) handlePlaybackProgressMsg(msg common.PlaybackProgressMsg) tea.Cmd
 	return nil
 }
 
+// autoplayNext reports whether player.autoplay_next is enabled
+func (a *App) autoplayNext() bool {
+	cfg, ok := a.cfg.(*config.Config)
+	return ok && cfg.Player.AutoplayNext
+}
+
+// resolveNextEpisode looks up the episode after the one that just played
+// to the end
+func (a *App) resolveNextEpisode() tea.Cmd {
+	provider, ok := a.providers[a.currentMediaType]
+	if !ok {
+		return func() tea.Msg { return common.NextEpisodeResolvedMsg{} }
+	}
+
+	mediaID := a.selectedMedia.ID
+	current := providers.Episode{
+		ID:     a.currentEpisodeID,
+		Number: a.currentEpisodeNumber,
+		Season: a.currentSeasonNumber,
+	}
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		next, err := providers.NextEpisode(ctx, provider, mediaID, current)
+		if err != nil || next == nil {
+			return common.NextEpisodeResolvedMsg{Err: err}
+		}
+
+		msg := common.NextEpisodeResolvedMsg{Episode: next}
+		if next.Season != current.Season {
+			msg.Episodes, msg.Err = providers.SeasonEpisodes(ctx, provider, mediaID, next.Season)
+		}
+		return msg
+	}
+}
+
+// handleNextEpisodeResolvedMsg plays the resolved next episode, or ends
+// playback as usual at the end of the series
+func (a *App) handleNextEpisodeResolvedMsg(msg common.NextEpisodeResolvedMsg) (*App, tea.Cmd) {
+	a.resolvingNextEpisode = false
+	if a.state != playingView {
+		return a, nil
+	}
+
+	if msg.Err != nil {
+		a.logger.Warn("failed to resolve next episode", "error", msg.Err)
+	}
+	if msg.Err != nil || msg.Episode == nil {
+		progress := a.lastProgress
+		return a, func() tea.Msg {
+			return createPlaybackEndedMsg(progress)
+		}
+	}
+
+	next := *msg.Episode
+	a.debugLog("handleNextEpisodeResolvedMsg: autoplaying S%d E%d (%s)", next.Season, next.Number, next.ID)
+	if len(msg.Episodes) > 0 {
+		a.episodes = msg.Episodes
+		a.episodesComponent.SetEpisodes(a.episodes)
+	}
+	a.currentSeasonNumber = next.Season
+	a.lastProgress = nil
+
+	return a, func() tea.Msg {
+		return common.EpisodeSelectedMsg{
+			EpisodeID: next.ID,
+			Number:    next.Number,
+			Title:     next.Title,
+		}
+	}
+}
+
 // autoReturnAfterDelay returns a command that sends PlaybackAutoReturnMsg after a delay
 func (a *App) autoReturnAfterDelay(delay time.Duration) tea.Cmd {
 	return func() tea.Msg {
@@ -159,25 +248,81 @@ func (a *App) autoReturnAfterDelay(delay time.Duration) tea.Cmd {
 	}
 }
 
-// selectBestSubtitle selects the best subtitle from available options, preferring English
-func selectBestSubtitle(subtitles []providers.Subtitle) *providers.Subtitle {
-	if len(subtitles) == 0 {
+// selectBestSubtitle selects the best subtitle from available options,
+// preferring lang, then English, then the first one
+func selectBestSubtitle(subs []providers.Subtitle, lang string) *providers.Subtitle {
+	if len(subs) == 0 {
 		return nil
 	}
 
-	// Try to find English subtitle (various language codes)
-	englishCodes := []string{"en", "eng", "english", "en-US", "en-GB"}
-	for _, sub := range subtitles {
-		subLang := strings.ToLower(sub.Language)
-		for _, code := range englishCodes {
-			if strings.Contains(subLang, strings.ToLower(code)) {
-				return &sub
+	for _, wanted := range []string{lang, "en"} {
+		for i := range subs {
+			if wanted != "" && subtitles.MatchesLanguage(subs[i].Language, wanted) {
+				return &subs[i]
 			}
 		}
 	}
 
-	// If no English subtitle found, return the first one
-	return &subtitles[0]
+	return &subs[0]
+}
+
+// subtitleSlang returns the mpv --slang list matching lang
+func subtitleSlang(lang string) string {
+	if l, ok := subtitles.LookupLanguage(lang); ok {
+		return strings.Join([]string{l.ISO1, l.ISO2, l.Name}, ",")
+	}
+	return lang
+}
+
+// subtitleLanguage returns player.subtitle_language, defaulting to English
+func (a *App) subtitleLanguage() string {
+	if cfg, ok := a.cfg.(*config.Config); ok && cfg.Player.SubtitleLang != "" {
+		return cfg.Player.SubtitleLang
+	}
+	return "en"
+}
+
+// newSubtitleSource returns the external subtitle source for cfg: OpenSubtitles
+// when an API key is set, otherwise a no-op that leaves streams with only
+// their provider's subtitles
+func newSubtitleSource(cfg *config.Config) subtitles.SubtitleProvider {
+	if cfg == nil || cfg.Player.OpenSubtitlesAPIKey == "" {
+		return subtitles.Noop{}
+	}
+	return opensubtitles.New(cfg.Player.OpenSubtitlesAPIKey, cfg.Player.OpenSubtitlesUsername, cfg.Player.OpenSubtitlesPassword)
+}
+
+// addExternalSubtitles fetches a subtitle in player.subtitle_language from the
+// external subtitle source when auto_subtitles is on and the stream has none
+// in that language. The title is looked up by the IMDb ID the provider's
+// details carry, so providers without one are skipped. Failures only mean
+// playing without the subtitle, so they are logged rather than returned.
+func (a *App) addExternalSubtitles(ctx context.Context, provider providers.Provider, stream *providers.StreamURL, season, episode int) {
+	cfg, ok := a.cfg.(*config.Config)
+	if !ok || !cfg.Player.AutoSubtitles || a.subtitleSource == nil {
+		return
+	}
+	if _, noop := a.subtitleSource.(subtitles.Noop); noop {
+		return
+	}
+	lang := a.subtitleLanguage()
+	if subtitles.HasLanguage(stream.Subtitles, lang) {
+		return
+	}
+
+	details, err := provider.GetMediaDetails(ctx, a.selectedMedia.ID)
+	if err != nil || details.IMDBID == "" {
+		a.debugLog("addExternalSubtitles: no IMDb ID for %s (err=%v)", a.selectedMedia.ID, err)
+		return
+	}
+
+	query := subtitles.Query{IMDBID: details.IMDBID, Season: season, Episode: episode}
+	added, err := subtitles.Ensure(ctx, a.subtitleSource, stream, query, lang, filepath.Join(cfg.Cache.Path, "subtitles"))
+	if err != nil {
+		a.logger.Warn("failed to fetch external subtitles", "source", a.subtitleSource.Name(), "imdb_id", details.IMDBID, "error", err)
+		return
+	}
+	a.debugLog("addExternalSubtitles: source=%s imdb=%s lang=%s added=%v", a.subtitleSource.Name(), details.IMDBID, lang, added)
 }
 
 // syncProgressOnEnd syncs playback progress to AniList when playback ends
@@ -225,6 +370,8 @@ func (a *App) syncProgressOnEnd(progress *player.PlaybackProgress) {
 			a.err = fmt.Errorf("failed to save progress to database: %v", err)
 		}
 
+		a.markWatchedIfPassed(progress, anilistID, providerName, seasonNumber, episodeNumber)
+
 		if completed && episodeNumber > 0 {
 			nextEpisode := a.findNextEpisode(episodeNumber, seasonNumber)
 			if nextEpisode != nil {
@@ -276,31 +423,22 @@ func (a *App) syncProgressOnEnd(progress *player.PlaybackProgress) {
 
 	a.debugLog("syncProgressOnEnd: All checks passed, starting AniList sync...")
 
-	// Sync to AniList in the background
+	// With auto_sync the episode is queued and the flush is debounced by
+	// sync_interval, so rapid episode changes coalesce; without it the
+	// progress is pushed straight away
+	mediaID := fmt.Sprintf("%d", a.currentAniListID)
+	episode := a.currentEpisodeNumber
+	lastEpisode := a.isLastEpisode
 	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		// Use the AniList ID
-		mediaID := fmt.Sprintf("%d", a.currentAniListID)
-
-		a.debugLog("AniList Sync: Calling UpdateProgress(mediaID=%s, episode=%d, progress=1.0)",
-			mediaID, a.currentEpisodeNumber)
+		a.debugLog("AniList Sync: Syncing progress (mediaID=%s, episode=%d, last=%v)",
+			mediaID, episode, lastEpisode)
 
-		// Update progress (100% = episode completed)
-		if err := mgr.UpdateProgress(ctx, mediaID, a.currentEpisodeNumber, 1.0); err != nil {
-			// Set error for display
+		if err := mgr.SyncEpisode(ctx, mediaID, episode, lastEpisode); err != nil {
 			a.logger.Error("AniList sync failed", "error", err)
 			a.err = fmt.Errorf("failed to sync to anilist: %v", err)
-		} else {
-			a.logger.Info("AniList sync completed successfully")
-		}
-
-		// Check if this is the last episode
-		if a.isLastEpisode {
-			// TODO: Trigger score prompt dialog
-			// This will be implemented in the score dialog component
-			_ = a.isLastEpisode // acknowledge
 		}
 	}()
 }
@@ -373,6 +511,58 @@ func (a *App) checkResumePosition(anilistID int, episode int) (int, error) {
 	return history.ProgressSeconds, nil
 }
 
+// markWatchedIfPassed marks the episode watched once playback passed
+// tracker.anilist.sync_threshold of its duration
+func (a *App) markWatchedIfPassed(progress *player.PlaybackProgress, anilistIDPtr *int, providerName string, season, episode int) {
+	if a.historyService == nil {
+		return
+	}
+
+	threshold := 0.85
+	if cfg, ok := a.cfg.(*config.Config); ok {
+		threshold = cfg.Tracker.AniList.SyncThreshold
+	}
+	if !historyservice.PassedThreshold(progress.CurrentTime, progress.Duration, threshold) {
+		return
+	}
+
+	ref := historyservice.EpisodeRef{Season: season, Episode: episode}
+	if err := a.historyService.MarkWatched(context.Background(), providerName, a.historyMediaID(anilistIDPtr), ref); err != nil {
+		a.logger.Warn("failed to mark episode watched", "error", err)
+	}
+}
+
+// streamQuality returns the quality to request from the named provider,
+// honoring its default_quality before the global player.quality
+func (a *App) streamQuality(providerName string) providers.Quality {
+	cfg, _ := a.cfg.(*config.Config)
+	return providers.SelectQuality("", providerName, cfg)
+}
+
+// getStream fetches an episode's stream at the configured quality, with
+// redirect wrappers and tracking parameters removed from its URL. Requests
+// are tagged with the current search's request ID unless ctx has one.
+func (a *App) getStream(ctx context.Context, provider providers.Provider, episodeID string) (*providers.StreamURL, error) {
+	if providers.RequestID(ctx) == "" && a.requestID != "" {
+		ctx = providers.WithRequestID(ctx, a.requestID)
+	}
+	stream, err := provider.GetStreamURL(ctx, episodeID, a.streamQuality(provider.Name()))
+	if err != nil {
+		return nil, err
+	}
+	stream.URL = providers.CleanStreamURL(stream.URL)
+	a.debugLog("Got stream URL: %s", providers.RedactedStreamURL(stream.URL))
+	return stream, nil
+}
+
+// historyMediaID returns the media ID history records are stored under
+func (a *App) historyMediaID(anilistIDPtr *int) string {
+	if anilistIDPtr != nil {
+		return fmt.Sprintf("anilist:%d", *anilistIDPtr)
+	}
+	return a.selectedMedia.ID
+}
+
 // savePlaybackProgress saves progress to the database
 // Supports both AniList content (with anilistID) and direct provider content (anilistID = nil)
 func (a *App) savePlaybackProgress(anilistIDPtr *int, providerName string, episode int, progressSeconds int, totalSeconds int, completed bool) error {
@@ -400,16 +590,14 @@ func (a *App) savePlaybackProgress(anilistIDPtr *int, providerName string, episo
 	}
 
 	// Determine MediaID, MediaTitle, MediaType based on content source
-	var mediaID string
+	mediaID := a.historyMediaID(anilistIDPtr)
 	var mediaTitle string
 	var mediaType string
 
 	if hasAniListID {
-		mediaID = fmt.Sprintf("anilist:%d", anilistID)
 		mediaTitle = a.currentAniListMedia.Title
 		mediaType = "anime"
 	} else {
-		mediaID = a.selectedMedia.ID
 		mediaTitle = a.selectedMedia.Title
 
 		switch a.selectedMedia.Type {
@@ -878,6 +1066,16 @@ func (a *App) handlePlaybackEndedMsg(msg common.PlaybackEndedMsg) (*App, tea.Cmd
 func (a *App) handlePlaybackErrorMsg(msg common.PlaybackErrorMsg) (*App, tea.Cmd) {
 	if msg.Error != nil {
 		a.err = msg.Error
+		switch {
+		case errors.Is(msg.Error, providers.ErrDRMProtected):
+			a.err = fmt.Errorf("this stream is DRM protected and can't be played, try a different server or provider: %w", msg.Error)
+		case errors.Is(msg.Error, providers.ErrExtractorBroken):
+			a.err = fmt.Errorf("the video extractor is currently broken on every server, try a different provider or check for an update: %w", msg.Error)
+		case errors.Is(msg.Error, providers.ErrThrottled):
+			a.err = fmt.Errorf("the provider is rate limiting us, wait a bit before trying again: %w", msg.Error)
+		case errors.Is(msg.Error, providers.ErrNoEpisodes):
+			a.err = fmt.Errorf("the provider has nothing to watch for this title, the ID may be wrong or outdated, try searching for it again: %w", msg.Error)
+		}
 		a.state = errorView
 		return a, nil
 	}
@@ -1001,12 +1199,12 @@ func (a *App) playMovieDirectly(mediaID string) tea.Cmd {
 		defer cancel()
 
 		a.debugLog("Calling GetStreamURL for episodeID=%s", episodeID)
-		stream, err := provider.GetStreamURL(ctx, episodeID, providers.Quality1080p)
+		stream, err := a.getStream(ctx, provider, episodeID)
 		if err != nil {
 			a.debugLog("ERROR: GetStreamURL failed: %v", err)
 			return common.PlaybackErrorMsg{Error: fmt.Errorf("failed to get stream URL: %w", err)}
 		}
-		a.debugLog("Got stream URL: %s", stream.URL)
+		a.addExternalSubtitles(ctx, provider, stream, 0, 0)
 
 		// Check if debug mode is enabled
 		if a.isDebugMode() {
@@ -1057,10 +1255,10 @@ func (a *App) playMovieDirectly(mediaID string) tea.Cmd {
 			AudioTrack: audioTrackIndex,
 		}
 
-		// Add subtitle if available, preferring English
-		if subtitle := selectBestSubtitle(stream.Subtitles); subtitle != nil {
+		// Add subtitle if available, preferring player.subtitle_language
+		if subtitle := selectBestSubtitle(stream.Subtitles, a.subtitleLanguage()); subtitle != nil {
 			options.SubtitleURL = subtitle.URL
-			options.SubtitleLang = "en,eng,english"
+			options.SubtitleLang = subtitleSlang(a.subtitleLanguage())
 		}
 
 		if err := a.player.Play(context.Background(), stream.URL, options); err != nil {
@@ -1098,10 +1296,11 @@ func (a *App) startPlayback(episodeID string, episodeNumber int, episodeTitle st
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		stream, err := provider.GetStreamURL(ctx, episodeID, providers.Quality1080p)
+		stream, err := a.getStream(ctx, provider, episodeID)
 		if err != nil {
 			return common.PlaybackErrorMsg{Error: fmt.Errorf("failed to get stream URL: %w", err)}
 		}
+		a.addExternalSubtitles(ctx, provider, stream, a.currentSeasonNumber, episodeNumber)
 
 		// Check if debug mode is enabled
 		if a.isDebugMode() {
@@ -1179,10 +1378,10 @@ func (a *App) startPlayback(episodeID string, episodeNumber int, episodeTitle st
 			}
 		}
 
-		// Add subtitle if available, preferring English
-		if subtitle := selectBestSubtitle(stream.Subtitles); subtitle != nil {
+		// Add subtitle if available, preferring player.subtitle_language
+		if subtitle := selectBestSubtitle(stream.Subtitles, a.subtitleLanguage()); subtitle != nil {
 			options.SubtitleURL = subtitle.URL
-			options.SubtitleLang = "en,eng,english"
+			options.SubtitleLang = subtitleSlang(a.subtitleLanguage())
 		}
 
 		// Play the stream with MPV (now async - returns immediately)
@@ -1234,10 +1433,10 @@ func (a *App) continuePlaybackWithAudioTrack(audioTrackIndex int) tea.Cmd {
 			}
 		}
 
-		// Add subtitle if available, preferring English
-		if subtitle := selectBestSubtitle(stream.Subtitles); subtitle != nil {
+		// Add subtitle if available, preferring player.subtitle_language
+		if subtitle := selectBestSubtitle(stream.Subtitles, a.subtitleLanguage()); subtitle != nil {
 			options.SubtitleURL = subtitle.URL
-			options.SubtitleLang = "en,eng,english"
+			options.SubtitleLang = subtitleSlang(a.subtitleLanguage())
 		}
 
 		// Play the stream with MPV (now async - returns immediately)
@@ -1434,7 +1633,7 @@ func (a *App) resumePlaybackFromHistory(msg common.ResumePlaybackMsg) tea.Cmd {
 			}
 
 			// Now get the stream URL using the episode ID
-			stream, err := provider.GetStreamURL(ctx, movieEpisodeID, providers.Quality1080p)
+			stream, err := a.getStream(ctx, provider, movieEpisodeID)
 			if err != nil {
 				// If getting stream fails and we haven't tried searching yet, try that
 				if hasMovieMethod && movieEpisodeID == actualMediaID {
@@ -1449,7 +1648,7 @@ func (a *App) resumePlaybackFromHistory(msg common.ResumePlaybackMsg) tea.Cmd {
 						// Try to get episode ID and stream with new media ID
 						movieEpisodeID, err = episodeIDGetter.GetMovieEpisodeID(ctx, actualMediaID)
 						if err == nil {
-							stream, err = provider.GetStreamURL(ctx, movieEpisodeID, providers.Quality1080p)
+							stream, err = a.getStream(ctx, provider, movieEpisodeID)
 						}
 					}
 				}
@@ -1458,6 +1657,7 @@ func (a *App) resumePlaybackFromHistory(msg common.ResumePlaybackMsg) tea.Cmd {
 					return common.PlaybackErrorMsg{Error: fmt.Errorf("failed to get stream URL: %w", err)}
 				}
 			}
+			a.addExternalSubtitles(ctx, provider, stream, 0, 0)
 
 			// If this is AniList content, fetch the full media details for proper tracking
 			if isAniListMedia {
@@ -1498,10 +1698,10 @@ func (a *App) resumePlaybackFromHistory(msg common.ResumePlaybackMsg) tea.Cmd {
 				AudioTrack: audioTrackIndex,
 			}
 
-			// Add subtitle if available, preferring English
-			if subtitle := selectBestSubtitle(stream.Subtitles); subtitle != nil {
+			// Add subtitle if available, preferring player.subtitle_language
+			if subtitle := selectBestSubtitle(stream.Subtitles, a.subtitleLanguage()); subtitle != nil {
 				playOpts.SubtitleURL = subtitle.URL
-				playOpts.SubtitleLang = "en,eng,english"
+				playOpts.SubtitleLang = subtitleSlang(a.subtitleLanguage())
 			}
 
 			if err := a.player.Play(context.Background(), stream.URL, playOpts); err != nil {
@@ -1616,10 +1816,11 @@ func (a *App) resumePlaybackFromHistory(msg common.ResumePlaybackMsg) tea.Cmd {
 		}
 
 		// Get stream URL
-		stream, err := provider.GetStreamURL(ctx, episodeID, providers.Quality1080p)
+		stream, err := a.getStream(ctx, provider, episodeID)
 		if err != nil {
 			return common.PlaybackErrorMsg{Error: fmt.Errorf("failed to get stream URL: %w", err)}
 		}
+		a.addExternalSubtitles(ctx, provider, stream, msg.Season, msg.Episode)
 
 		// If this is AniList content, fetch the full media details for proper tracking
 		if isAniListMedia {
@@ -1662,10 +1863,10 @@ func (a *App) resumePlaybackFromHistory(msg common.ResumePlaybackMsg) tea.Cmd {
 			AudioTrack: audioTrackIndex,
 		}
 
-		// Add subtitle if available, preferring English
-		if subtitle := selectBestSubtitle(stream.Subtitles); subtitle != nil {
+		// Add subtitle if available, preferring player.subtitle_language
+		if subtitle := selectBestSubtitle(stream.Subtitles, a.subtitleLanguage()); subtitle != nil {
 			playOpts.SubtitleURL = subtitle.URL
-			playOpts.SubtitleLang = "en,eng,english"
+			playOpts.SubtitleLang = subtitleSlang(a.subtitleLanguage())
 		}
 
 		if err := a.player.Play(context.Background(), stream.URL, playOpts); err != nil {
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/justchokingaround/greg/internal/config"
+	"github.com/justchokingaround/greg/internal/providers"
+	"github.com/justchokingaround/greg/internal/subtitles"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// detailsProvider answers GetMediaDetails with fixed details. No other
+// Provider method is called by the code under test.
+type detailsProvider struct {
+	providers.Provider
+	details *providers.MediaDetails
+}
+
+func (p detailsProvider) GetMediaDetails(ctx context.Context, id string) (*providers.MediaDetails, error) {
+	return p.details, nil
+}
+
+// recordingSubtitles is a subtitle source that records the IMDb IDs it is
+// searched for
+type recordingSubtitles struct {
+	searched []string
+}
+
+func (s *recordingSubtitles) Name() string { return "test" }
+
+func (s *recordingSubtitles) Search(ctx context.Context, imdbID string, lang string) ([]subtitles.Subtitle, error) {
+	s.searched = append(s.searched, imdbID)
+	return []subtitles.Subtitle{{ID: "1", Language: lang, Format: "srt"}}, nil
+}
+
+func (s *recordingSubtitles) Download(ctx context.Context, sub subtitles.Subtitle) ([]byte, error) {
+	return []byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n"), nil
+}
+
+func TestAddExternalSubtitles(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Player.AutoSubtitles = true
+	cfg.Player.SubtitleLang = "en"
+	cfg.Cache.Path = t.TempDir()
+
+	newApp := func(source subtitles.SubtitleProvider) *App {
+		return &App{
+			cfg:            cfg,
+			logger:         slog.Default(),
+			subtitleSource: source,
+			selectedMedia:  providers.Media{ID: "movie/free-inception-hd-19764"},
+		}
+	}
+
+	t.Run("details with an IMDb ID fetch a subtitle", func(t *testing.T) {
+		source := &recordingSubtitles{}
+		provider := detailsProvider{details: &providers.MediaDetails{IMDBID: "tt1375666"}}
+		stream := &providers.StreamURL{}
+
+		newApp(source).addExternalSubtitles(context.Background(), provider, stream, 0, 0)
+
+		assert.Equal(t, []string{"tt1375666"}, source.searched)
+		require.Len(t, stream.Subtitles, 1)
+		assert.Equal(t, "en", stream.Subtitles[0].Language)
+		_, err := os.Stat(stream.Subtitles[0].URL)
+		assert.NoError(t, err)
+	})
+
+	t.Run("details without an IMDb ID are skipped", func(t *testing.T) {
+		source := &recordingSubtitles{}
+		stream := &providers.StreamURL{}
+
+		newApp(source).addExternalSubtitles(context.Background(), detailsProvider{details: &providers.MediaDetails{}}, stream, 0, 0)
+
+		assert.Empty(t, source.searched)
+		assert.Empty(t, stream.Subtitles)
+	})
+}
@@ -44,7 +44,7 @@ func (a *App) generateWatchPartyURL(episodeID string, episodeNumber int, episode
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		stream, err := provider.GetStreamURL(ctx, episodeID, providers.Quality1080p)
+		stream, err := a.getStream(ctx, provider, episodeID)
 		if err != nil {
 			return common.WatchPartyMsg{
 				URL: "",
@@ -105,9 +105,9 @@ func (a *App) generateWatchPartyURL(episodeID string, episodeNumber int, episode
 					Err: fmt.Errorf("failed to generate proxied URL with proxy %s: %w", finalProxyURL, err),
 				}
 			}
-			a.logger.Debug("proxy applied to video URL", "original", stream.URL, "proxied", videoURL, "proxy", finalProxyURL)
+			a.logger.Debug("proxy applied to video URL", "original", providers.RedactedStreamURL(stream.URL), "proxied", providers.RedactedStreamURL(videoURL), "proxy", finalProxyURL)
 		} else {
-			a.logger.Debug("no proxy configured, using original URL", "url", stream.URL)
+			a.logger.Debug("no proxy configured, using original URL", "url", providers.RedactedStreamURL(stream.URL))
 		}
 
 		// Generate WatchParty URL
@@ -173,7 +173,7 @@ func (a *App) generateWatchPartyURLWithProvider(provider providers.Provider, epi
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
 
-		stream, err := provider.GetStreamURL(ctx, episodeID, providers.Quality1080p)
+		stream, err := a.getStream(ctx, provider, episodeID)
 		if err != nil {
 			return common.WatchPartyMsg{
 				URL: "",
@@ -234,9 +234,9 @@ func (a *App) generateWatchPartyURLWithProvider(provider providers.Provider, epi
 					Err: fmt.Errorf("failed to generate proxied URL with proxy %s: %w", finalProxyURL, err),
 				}
 			}
-			a.logger.Debug("proxy applied to video URL", "original", stream.URL, "proxied", videoURL, "proxy", finalProxyURL)
+			a.logger.Debug("proxy applied to video URL", "original", providers.RedactedStreamURL(stream.URL), "proxied", providers.RedactedStreamURL(videoURL), "proxy", finalProxyURL)
 		} else {
-			a.logger.Debug("no proxy configured, using original URL", "url", stream.URL)
+			a.logger.Debug("no proxy configured, using original URL", "url", providers.RedactedStreamURL(stream.URL))
 		}
 
 		// Generate WatchParty URL
@@ -0,0 +1,159 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+// resultCacheTTL is how long an extraction result is reused. Extracted
+// stream URLs are signed and expire, so this only covers extractions that
+// happen close together, such as two providers hitting the same embed.
+const resultCacheTTL = 2 * time.Minute
+
+// ResultCache remembers extraction results by key for a short time and
+// coalesces concurrent extractions of the same key into one call. Only
+// successful results are cached. It is safe for concurrent use.
+type ResultCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	calls   map[string]*inflight
+}
+
+type cacheEntry struct {
+	sources *types.VideoSources
+	expires time.Time
+}
+
+// inflight is an extraction other callers of the same key wait on
+type inflight struct {
+	done    chan struct{}
+	sources *types.VideoSources
+	err     error
+}
+
+// NewResultCache creates a cache that keeps results for ttl
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]cacheEntry),
+		calls:   make(map[string]*inflight),
+	}
+}
+
+// Do returns the cached result for key, waits for an extraction of key that
+// is already running, or runs extract itself. Callers get their own copy of
+// the result. If the extraction a caller waited on was cancelled by its
+// initiator's context, the caller retries with its own context.
+func (c *ResultCache) Do(ctx context.Context, key string, extract func(context.Context) (*types.VideoSources, error)) (*types.VideoSources, error) {
+	for {
+		c.mu.Lock()
+		if entry, ok := c.entries[key]; ok {
+			if c.now().Before(entry.expires) {
+				c.mu.Unlock()
+				return cloneSources(entry.sources), nil
+			}
+			delete(c.entries, key)
+		}
+
+		if call, ok := c.calls[key]; ok {
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			if isCancellation(call.err) && ctx.Err() == nil {
+				continue
+			}
+			return cloneSources(call.sources), call.err
+		}
+
+		call := &inflight{done: make(chan struct{})}
+		c.calls[key] = call
+		c.mu.Unlock()
+
+		c.run(ctx, key, call, extract)
+		return cloneSources(call.sources), call.err
+	}
+}
+
+// errExtractPanicked is what callers waiting on an extraction get when it
+// panicked instead of returning
+var errExtractPanicked = errors.New("extraction panicked")
+
+// run runs extract for call and then publishes the result. The cleanup is
+// deferred so a panicking extractor doesn't leave waiters hanging on
+// call.done.
+func (c *ResultCache) run(ctx context.Context, key string, call *inflight, extract func(context.Context) (*types.VideoSources, error)) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		if call.err == nil && call.sources != nil {
+			c.sweep()
+			c.entries[key] = cacheEntry{sources: call.sources, expires: c.now().Add(c.ttl)}
+		}
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.err = errExtractPanicked
+	call.sources, call.err = extract(ctx)
+}
+
+// Clear drops every cached result. Running extractions are unaffected.
+func (c *ResultCache) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]cacheEntry)
+	c.mu.Unlock()
+}
+
+// sweep drops expired entries so the cache doesn't grow over a long
+// session. The caller must hold c.mu.
+func (c *ResultCache) sweep() {
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// isCancellation reports whether err came from a cancelled or expired
+// context rather than from the extraction itself
+func isCancellation(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// cloneSources copies the source and subtitle lists so callers can modify
+// their result without affecting the cached one
+func cloneSources(sources *types.VideoSources) *types.VideoSources {
+	if sources == nil {
+		return nil
+	}
+	clone := *sources
+	clone.Sources = append([]types.Source(nil), sources.Sources...)
+	clone.Subtitles = append([]types.Subtitle(nil), sources.Subtitles...)
+	return &clone
+}
+
+// sharedResults is the cache Extract uses across all providers
+var sharedResults = NewResultCache(resultCacheTTL)
+
+// Extract extracts embedURL with the extractor for serverName. Results are
+// shared across providers: an embed that was extracted recently, or is
+// being extracted right now, is not extracted again.
+func Extract(ctx context.Context, serverName, embedURL string) (*types.VideoSources, error) {
+	key := ExtractorName(serverName)
+	extractor := extractorForKey(key)
+	return sharedResults.Do(ctx, key+" "+embedURL, func(ctx context.Context) (*types.VideoSources, error) {
+		return extractor.Extract(ctx, embedURL)
+	})
+}
@@ -0,0 +1,199 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+func TestResultCacheCoalescesConcurrentCalls(t *testing.T) {
+	cache := NewResultCache(time.Minute)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	extract := func(ctx context.Context) (*types.VideoSources, error) {
+		calls.Add(1)
+		<-release
+		return &types.VideoSources{Sources: []types.Source{{URL: "https://cdn.example/master.m3u8"}}}, nil
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	results := make([]*types.VideoSources, callers)
+	errs := make([]error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = cache.Do(context.Background(), "embed", extract)
+		}()
+	}
+
+	// Let every caller reach the cache before the extraction finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("extract ran %d times, want 1", got)
+	}
+	for i := range callers {
+		if errs[i] != nil {
+			t.Fatalf("caller %d: %v", i, errs[i])
+		}
+		if len(results[i].Sources) != 1 || results[i].Sources[0].URL != "https://cdn.example/master.m3u8" {
+			t.Fatalf("caller %d got %+v", i, results[i])
+		}
+	}
+
+	// Callers get copies, so changing one result doesn't leak into the cache
+	results[0].Sources[0].URL = "changed"
+	cached, err := cache.Do(context.Background(), "embed", extract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cached.Sources[0].URL != "https://cdn.example/master.m3u8" {
+		t.Errorf("cached result was modified: %q", cached.Sources[0].URL)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("cached call ran extract again (%d calls)", got)
+	}
+}
+
+func TestResultCacheExpiryAndErrors(t *testing.T) {
+	cache := NewResultCache(time.Minute)
+	now := time.Unix(0, 0)
+	cache.now = func() time.Time { return now }
+
+	var calls int
+	failing := true
+	extract := func(ctx context.Context) (*types.VideoSources, error) {
+		calls++
+		if failing {
+			return nil, errors.New("embed down")
+		}
+		return &types.VideoSources{}, nil
+	}
+
+	// Failures aren't cached
+	for range 2 {
+		if _, err := cache.Do(context.Background(), "embed", extract); err == nil {
+			t.Fatal("expected error")
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+
+	failing = false
+	_, _ = cache.Do(context.Background(), "embed", extract)
+	_, _ = cache.Do(context.Background(), "embed", extract)
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3 (second success served from cache)", calls)
+	}
+
+	now = now.Add(time.Minute)
+	_, _ = cache.Do(context.Background(), "embed", extract)
+	if calls != 4 {
+		t.Fatalf("calls = %d, want 4 after expiry", calls)
+	}
+
+	cache.Clear()
+	_, _ = cache.Do(context.Background(), "embed", extract)
+	if calls != 5 {
+		t.Fatalf("calls = %d, want 5 after Clear", calls)
+	}
+}
+
+func TestResultCacheRetriesAfterLeaderCancelled(t *testing.T) {
+	cache := NewResultCache(time.Minute)
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	var calls atomic.Int32
+	extract := func(ctx context.Context) (*types.VideoSources, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return &types.VideoSources{Sources: []types.Source{{URL: "ok"}}}, nil
+	}
+
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := cache.Do(leaderCtx, "embed", extract)
+		leaderErr <- err
+	}()
+	<-started
+
+	followerDone := make(chan *types.VideoSources, 1)
+	go func() {
+		sources, err := cache.Do(context.Background(), "embed", extract)
+		if err != nil {
+			t.Errorf("follower: %v", err)
+		}
+		followerDone <- sources
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("leader error = %v, want context.Canceled", err)
+	}
+	if sources := <-followerDone; sources == nil || sources.Sources[0].URL != "ok" {
+		t.Errorf("follower got %+v, want its own extraction", sources)
+	}
+}
+
+func TestResultCachePanickingExtraction(t *testing.T) {
+	cache := NewResultCache(time.Minute)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	leaderDone := make(chan any, 1)
+	go func() {
+		defer func() { leaderDone <- recover() }()
+		_, _ = cache.Do(context.Background(), "embed", func(ctx context.Context) (*types.VideoSources, error) {
+			close(started)
+			<-release
+			panic("extractor bug")
+		})
+	}()
+	<-started
+
+	followerErr := make(chan error, 1)
+	go func() {
+		_, err := cache.Do(context.Background(), "embed", func(ctx context.Context) (*types.VideoSources, error) {
+			return &types.VideoSources{}, nil
+		})
+		followerErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	if r := <-leaderDone; r != "extractor bug" {
+		t.Errorf("leader recovered %v, want the extractor's panic", r)
+	}
+	select {
+	case err := <-followerErr:
+		if !errors.Is(err, errExtractPanicked) {
+			t.Errorf("follower error = %v, want %v", err, errExtractPanicked)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("follower still waiting on the panicked extraction")
+	}
+
+	// The key isn't left marked as in flight
+	if _, err := cache.Do(context.Background(), "embed", func(ctx context.Context) (*types.VideoSources, error) {
+		return &types.VideoSources{}, nil
+	}); err != nil {
+		t.Errorf("Do() after panic error = %v", err)
+	}
+}
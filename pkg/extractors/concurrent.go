@@ -0,0 +1,160 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+// ErrExtractorBroken is returned by ExtractFirst when every server failed
+// inside the same extractor, which points at the extractor (e.g. a megacloud
+// key rotation) rather than the provider or the episode
+var ErrExtractorBroken = errors.New("extractor broken")
+
+// ExtractorError marks an error raised by an extractor's Extract, as opposed
+// to fetching the embed URL or checking the extracted sources
+type ExtractorError struct {
+	Extractor string // Extractor key, e.g. "megacloud"
+	Err       error
+}
+
+func (e *ExtractorError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExtractorError) Unwrap() error {
+	return e.Err
+}
+
+// NewExtractorError wraps err as a failure of the extractor serverName maps to
+func NewExtractorError(serverName string, err error) error {
+	return &ExtractorError{Extractor: ExtractorName(serverName), Err: err}
+}
+
+// ServerExtractFunc extracts video sources from a single episode server
+type ServerExtractFunc func(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error)
+
+// serverResult is the outcome of extracting from the server at index
+type serverResult struct {
+	index   int
+	sources *types.VideoSources
+	err     error
+}
+
+// ExtractFirst extracts from servers concurrently, running at most
+// concurrency extractions at a time, and returns the first non-empty result.
+// Servers are started in slice order, so earlier servers are preferred; when
+// several succeed at the same time the earliest one wins. Remaining
+// extractions are cancelled as soon as a result is chosen.
+//
+// If no server yields sources, the last error is returned, or an empty
+// VideoSources if every server succeeded without sources. ErrDRMProtected
+// takes precedence over other errors, since trying another server or
+// provider is the only way forward. When every server failed inside the same
+// extractor, the error wraps ErrExtractorBroken and names that extractor.
+func ExtractFirst(ctx context.Context, servers []types.EpisodeServer, concurrency int, extract ServerExtractFunc) (*types.VideoSources, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan serverResult, len(servers))
+	sem := make(chan struct{}, concurrency)
+
+	go func() {
+		for i, server := range servers {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+
+			go func(index int, server types.EpisodeServer) {
+				defer func() { <-sem }()
+
+				sources, err := extract(ctx, server)
+				results <- serverResult{index: index, sources: sources, err: err}
+			}(i, server)
+		}
+	}()
+
+	var lastErr, drmErr error
+	var errs []error
+	for received := 0; received < len(servers); received++ {
+		var res serverResult
+		select {
+		case res = <-results:
+		case <-ctx.Done():
+			if lastErr == nil {
+				lastErr = ctx.Err()
+			}
+			return nil, lastErr
+		}
+
+		if res.err != nil {
+			lastErr = res.err
+			errs = append(errs, res.err)
+			if errors.Is(res.err, ErrDRMProtected) {
+				drmErr = res.err
+			}
+			continue
+		}
+		if res.sources == nil || len(res.sources.Sources) == 0 {
+			continue
+		}
+
+		// Prefer an earlier server if it finished at the same time
+		best := res
+		for drained := false; !drained; {
+			select {
+			case other := <-results:
+				received++
+				if other.err == nil && other.sources != nil && len(other.sources.Sources) > 0 && other.index < best.index {
+					best = other
+				}
+			default:
+				drained = true
+			}
+		}
+
+		cancel()
+		return best.sources, nil
+	}
+
+	if drmErr != nil {
+		return nil, drmErr
+	}
+	if len(errs) == len(servers) {
+		if name, ok := sameExtractor(errs); ok {
+			return nil, fmt.Errorf("%w: %s failed on all %d servers: %v", ErrExtractorBroken, name, len(servers), lastErr)
+		}
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+
+	return &types.VideoSources{
+		Sources:   []types.Source{},
+		Subtitles: []types.Subtitle{},
+	}, nil
+}
+
+// sameExtractor reports whether every error came from the same extractor
+func sameExtractor(errs []error) (string, bool) {
+	var name string
+	for _, err := range errs {
+		var extErr *ExtractorError
+		if !errors.As(err, &extErr) {
+			return "", false
+		}
+		if name != "" && extErr.Extractor != name {
+			return "", false
+		}
+		name = extErr.Extractor
+	}
+	return name, name != ""
+}
@@ -0,0 +1,206 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+// mockServer describes how a fake server behaves during extraction
+type mockServer struct {
+	delay time.Duration
+	url   string
+	err   error
+}
+
+func mockExtract(behaviour map[string]mockServer) ServerExtractFunc {
+	return func(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
+		b := behaviour[server.Name]
+		select {
+		case <-time.After(b.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if b.err != nil {
+			return nil, b.err
+		}
+		sources := &types.VideoSources{}
+		if b.url != "" {
+			sources.Sources = []types.Source{{URL: b.url}}
+		}
+		return sources, nil
+	}
+}
+
+func TestExtractFirst(t *testing.T) {
+	servers := []types.EpisodeServer{{Name: "slow"}, {Name: "fast"}, {Name: "medium"}}
+
+	t.Run("fast server wins over slow failing server", func(t *testing.T) {
+		extract := mockExtract(map[string]mockServer{
+			"slow":   {delay: 2 * time.Second, err: errors.New("timeout")},
+			"fast":   {delay: 10 * time.Millisecond, url: "fast.m3u8"},
+			"medium": {delay: 200 * time.Millisecond, url: "medium.m3u8"},
+		})
+
+		start := time.Now()
+		sources, err := ExtractFirst(context.Background(), servers, 3, extract)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := sources.Sources[0].URL; got != "fast.m3u8" {
+			t.Errorf("expected fast.m3u8, got %s", got)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("expected early return, took %s", elapsed)
+		}
+	})
+
+	t.Run("earlier server wins ties", func(t *testing.T) {
+		extract := mockExtract(map[string]mockServer{
+			"slow":   {url: "slow.m3u8"},
+			"fast":   {url: "fast.m3u8"},
+			"medium": {url: "medium.m3u8"},
+		})
+
+		// With a concurrency of one, servers run strictly in priority order
+		sources, err := ExtractFirst(context.Background(), servers, 1, extract)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := sources.Sources[0].URL; got != "slow.m3u8" {
+			t.Errorf("expected slow.m3u8, got %s", got)
+		}
+	})
+
+	t.Run("skips servers with empty sources", func(t *testing.T) {
+		extract := mockExtract(map[string]mockServer{
+			"slow":   {delay: 50 * time.Millisecond, url: "slow.m3u8"},
+			"fast":   {},
+			"medium": {err: errors.New("dead")},
+		})
+
+		sources, err := ExtractFirst(context.Background(), servers, 3, extract)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := sources.Sources[0].URL; got != "slow.m3u8" {
+			t.Errorf("expected slow.m3u8, got %s", got)
+		}
+	})
+
+	t.Run("returns error when all servers fail", func(t *testing.T) {
+		extract := mockExtract(map[string]mockServer{
+			"slow":   {err: errors.New("dead")},
+			"fast":   {err: errors.New("dead")},
+			"medium": {err: errors.New("dead")},
+		})
+
+		if _, err := ExtractFirst(context.Background(), servers, 2, extract); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("reports DRM over other failures", func(t *testing.T) {
+		extract := mockExtract(map[string]mockServer{
+			"slow":   {err: errors.New("dead")},
+			"fast":   {err: fmt.Errorf("server fast: %w", ErrDRMProtected)},
+			"medium": {delay: 20 * time.Millisecond, err: errors.New("dead")},
+		})
+
+		if _, err := ExtractFirst(context.Background(), servers, 3, extract); !errors.Is(err, ErrDRMProtected) {
+			t.Errorf("expected ErrDRMProtected, got %v", err)
+		}
+	})
+
+	t.Run("skips DRM servers when another works", func(t *testing.T) {
+		extract := mockExtract(map[string]mockServer{
+			"slow":   {err: ErrDRMProtected},
+			"fast":   {delay: 20 * time.Millisecond, url: "fast.m3u8"},
+			"medium": {err: ErrDRMProtected},
+		})
+
+		sources, err := ExtractFirst(context.Background(), servers, 3, extract)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := sources.Sources[0].URL; got != "fast.m3u8" {
+			t.Errorf("expected fast.m3u8, got %s", got)
+		}
+	})
+
+	t.Run("returns empty sources when no server has any", func(t *testing.T) {
+		extract := mockExtract(map[string]mockServer{})
+
+		sources, err := ExtractFirst(context.Background(), servers, 2, extract)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(sources.Sources) != 0 {
+			t.Errorf("expected no sources, got %d", len(sources.Sources))
+		}
+	})
+
+	t.Run("stops at the context deadline", func(t *testing.T) {
+		extract := mockExtract(map[string]mockServer{
+			"slow":   {delay: time.Second, url: "slow.m3u8"},
+			"fast":   {delay: time.Second, url: "fast.m3u8"},
+			"medium": {delay: time.Second, url: "medium.m3u8"},
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		if _, err := ExtractFirst(ctx, servers, 3, extract); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	})
+}
+
+func TestExtractFirstSameBrokenExtractor(t *testing.T) {
+	servers := []types.EpisodeServer{{Name: "UpCloud"}, {Name: "Vidcloud"}}
+
+	t.Run("all servers fail in the same extractor", func(t *testing.T) {
+		extract := func(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
+			return nil, NewExtractorError(server.Name, errors.New("decryption failed"))
+		}
+
+		_, err := ExtractFirst(context.Background(), servers, 2, extract)
+		if !errors.Is(err, ErrExtractorBroken) {
+			t.Fatalf("expected ErrExtractorBroken, got %v", err)
+		}
+		if !strings.Contains(err.Error(), KeyVidCloud) {
+			t.Errorf("expected error to name %s, got %v", KeyVidCloud, err)
+		}
+	})
+
+	t.Run("different extractors are not summarized", func(t *testing.T) {
+		mixed := []types.EpisodeServer{{Name: "HD-1"}, {Name: "Vidcloud"}}
+		extract := func(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
+			return nil, NewExtractorError(server.Name, errors.New("decryption failed"))
+		}
+
+		_, err := ExtractFirst(context.Background(), mixed, 2, extract)
+		if err == nil || errors.Is(err, ErrExtractorBroken) {
+			t.Fatalf("expected plain error, got %v", err)
+		}
+	})
+
+	t.Run("non-extractor failure is not summarized", func(t *testing.T) {
+		extract := func(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
+			if server.Name == "UpCloud" {
+				return nil, fmt.Errorf("failed to fetch embed URL: %w", errors.New("503"))
+			}
+			return nil, NewExtractorError(server.Name, errors.New("decryption failed"))
+		}
+
+		_, err := ExtractFirst(context.Background(), servers, 2, extract)
+		if err == nil || errors.Is(err, ErrExtractorBroken) {
+			t.Fatalf("expected plain error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package extractors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+// ErrDRMProtected is returned when a server only offers a DRM-protected
+// (Widevine, PlayReady or FairPlay) stream, which can't be played. Another
+// server may still have an unprotected copy.
+var ErrDRMProtected = errors.New("stream is DRM protected")
+
+// maxPlaylistSize bounds how much of a playlist is read when checking for DRM
+const maxPlaylistSize = 512 * 1024
+
+// drmKeyMethods are #EXT-X-KEY methods only used with DRM systems.
+// AES-128 is plain HLS encryption and can be decrypted with the key URI.
+var drmKeyMethods = []string{"SAMPLE-AES", "SAMPLE-AES-CTR", "SAMPLE-AES-CENC"}
+
+// drmKeyFormats identify DRM systems in KEYFORMAT attributes and manifests
+var drmKeyFormats = []string{
+	"com.widevine",
+	"edef8ba9-79d6-4ace-a3c8-27dcd51d21ed", // Widevine system ID
+	"com.microsoft.playready",
+	"9a04f079-9840-4286-ab92-e65be0885f95", // PlayReady system ID
+	"com.apple.streamingkeydelivery",       // FairPlay
+}
+
+// DetectDRM reports whether an HLS playlist or DASH manifest carries DRM
+// markers: a SAMPLE-AES key, a DRM key format, or PSSH data
+func DetectDRM(playlist []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(playlist))
+	scanner.Buffer(make([]byte, 64*1024), maxPlaylistSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if isDRMLine(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDRMLine checks a single playlist or manifest line for DRM markers
+func isDRMLine(line string) bool {
+	lower := strings.ToLower(line)
+
+	// DASH manifests carry the PSSH box or a ContentProtection element naming
+	// the DRM system
+	if strings.Contains(lower, "<cenc:pssh") || strings.Contains(lower, "<mspr:pro") {
+		return true
+	}
+	if strings.Contains(lower, "<contentprotection") {
+		return containsAny(lower, drmKeyFormats)
+	}
+
+	if !strings.HasPrefix(line, "#EXT-X-KEY:") && !strings.HasPrefix(line, "#EXT-X-SESSION-KEY:") {
+		return false
+	}
+
+	attrs := strings.ToUpper(line[strings.IndexByte(line, ':')+1:])
+	for _, method := range drmKeyMethods {
+		if strings.HasPrefix(attrs, "METHOD="+method+",") || strings.Contains(attrs, ",METHOD="+method+",") ||
+			strings.HasSuffix(attrs, "METHOD="+method) {
+			return true
+		}
+	}
+	return containsAny(lower, drmKeyFormats)
+}
+
+// containsAny reports whether s contains any of substrs
+func containsAny(s string, substrs []string) bool {
+	for _, sub := range substrs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckDRM fetches the HLS playlist of the first M3U8 source and, for a
+// master playlist, its first variant, returning ErrDRMProtected if either
+// carries DRM markers. Sources that can't be fetched are not treated as DRM;
+// the player will report those failures itself.
+func CheckDRM(ctx context.Context, client *http.Client, sources *types.VideoSources) error {
+	if sources == nil {
+		return nil
+	}
+
+	for _, source := range sources.Sources {
+		if !source.IsM3U8 {
+			continue
+		}
+
+		playlist, err := fetchPlaylist(ctx, client, source.URL, source.Referer)
+		if err != nil {
+			return nil
+		}
+		if DetectDRM(playlist) {
+			return fmt.Errorf("%s: %w", source.URL, ErrDRMProtected)
+		}
+
+		// Key tags live in media playlists, so check one variant of a master
+		variant := firstVariant(playlist, source.URL)
+		if variant == "" {
+			return nil
+		}
+		media, err := fetchPlaylist(ctx, client, variant, source.Referer)
+		if err != nil {
+			return nil
+		}
+		if DetectDRM(media) {
+			return fmt.Errorf("%s: %w", source.URL, ErrDRMProtected)
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// fetchPlaylist downloads the start of a playlist
+func fetchPlaylist(ctx context.Context, client *http.Client, playlistURL, referer string) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", playlistURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("playlist returned status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxPlaylistSize))
+}
+
+// firstVariant returns the absolute URL of the first variant stream of a
+// master playlist, or "" if playlist is a media playlist
+func firstVariant(playlist []byte, playlistURL string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(playlist))
+	scanner.Buffer(make([]byte, 64*1024), maxPlaylistSize)
+
+	nextIsVariant := false
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#EXT-X-STREAM-INF") {
+			nextIsVariant = true
+			continue
+		}
+		if !nextIsVariant || line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		base, err := url.Parse(playlistURL)
+		if err != nil {
+			return line
+		}
+		ref, err := url.Parse(line)
+		if err != nil {
+			return line
+		}
+		return base.ResolveReference(ref).String()
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+func TestDetectDRM(t *testing.T) {
+	tests := []struct {
+		name     string
+		playlist string
+		want     bool
+	}{
+		{
+			name:     "plain media playlist",
+			playlist: "#EXTM3U\n#EXTINF:10,\nseg0.ts\n#EXT-X-ENDLIST\n",
+		},
+		{
+			name:     "AES-128 is not DRM",
+			playlist: "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\"\n#EXTINF:10,\nseg0.ts\n",
+		},
+		{
+			name:     "SAMPLE-AES with license URI",
+			playlist: "#EXTM3U\n#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"skd://license.example.com/abc\",KEYFORMATVERSIONS=\"1\"\n#EXTINF:10,\nseg0.ts\n",
+			want:     true,
+		},
+		{
+			name:     "Widevine key format",
+			playlist: "#EXTM3U\n#EXT-X-KEY:METHOD=SAMPLE-AES-CTR,URI=\"data:text/plain;base64,AAAAW3Bzc2g=\",KEYFORMAT=\"urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed\"\n",
+			want:     true,
+		},
+		{
+			name:     "session key in master playlist",
+			playlist: "#EXTM3U\n#EXT-X-SESSION-KEY:METHOD=SAMPLE-AES,URI=\"skd://key\",KEYFORMAT=\"com.apple.streamingkeydelivery\"\n#EXT-X-STREAM-INF:BANDWIDTH=1\nv.m3u8\n",
+			want:     true,
+		},
+		{
+			name:     "DASH PSSH",
+			playlist: "<MPD><Period><AdaptationSet><ContentProtection schemeIdUri=\"urn:uuid:edef8ba9-79d6-4ace-a3c8-27dcd51d21ed\">\n<cenc:pssh>AAAA</cenc:pssh>\n",
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectDRM([]byte(tt.playlist)); got != tt.want {
+				t.Errorf("DetectDRM() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDRM(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plain/master.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\n720/index.m3u8\n"))
+	})
+	mux.HandleFunc("/plain/720/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#EXTM3U\n#EXTINF:10,\nseg0.ts\n"))
+	})
+	mux.HandleFunc("/drm/master.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#EXTM3U\n#EXT-X-STREAM-INF:BANDWIDTH=1\n720/index.m3u8\n"))
+	})
+	mux.HandleFunc("/drm/720/index.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Referer") != "https://embed.example/" {
+			http.Error(w, "missing referer", http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("#EXTM3U\n#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"skd://license\"\n#EXTINF:10,\nseg0.ts\n"))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	check := func(path string) error {
+		return CheckDRM(context.Background(), server.Client(), &types.VideoSources{
+			Sources: []types.Source{{URL: server.URL + path, IsM3U8: true, Referer: "https://embed.example/"}},
+		})
+	}
+
+	if err := check("/plain/master.m3u8"); err != nil {
+		t.Errorf("expected no error for plain stream, got %v", err)
+	}
+	if err := check("/drm/master.m3u8"); !errors.Is(err, ErrDRMProtected) {
+		t.Errorf("expected ErrDRMProtected, got %v", err)
+	}
+	if err := check("/missing.m3u8"); err != nil {
+		t.Errorf("unreachable playlists should not be reported as DRM, got %v", err)
+	}
+}
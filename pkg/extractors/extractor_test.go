@@ -1,6 +1,7 @@
 package extractors
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -68,3 +69,35 @@ func TestNewVidCloudExtractor(t *testing.T) {
 		t.Error("HTTP Client is nil")
 	}
 }
+
+func TestGetExtractorAliases(t *testing.T) {
+	defer func() { _ = SetAliases(nil) }()
+
+	err := SetAliases(map[string]string{
+		"Server 1": "MegaCloud",
+		"HD-1":     "vidcloud",
+		"Server 2": "nosuchextractor",
+	})
+	if err == nil || !strings.Contains(err.Error(), "server 2 -> nosuchextractor") {
+		t.Errorf("SetAliases() error = %v, want unknown key reported", err)
+	}
+
+	if _, ok := GetExtractor("server 1").(*MegaCloudExtractor); !ok {
+		t.Errorf("aliased server 1 = %T, want *MegaCloudExtractor", GetExtractor("server 1"))
+	}
+	// Aliases win over the built-in name matching
+	if _, ok := GetExtractor("HD-1").(*VidCloudExtractor); !ok {
+		t.Errorf("aliased HD-1 = %T, want *VidCloudExtractor", GetExtractor("HD-1"))
+	}
+	// Invalid aliases are dropped and fall back to the default lookup
+	if _, ok := GetExtractor("Server 2").(*VidCloudExtractor); !ok {
+		t.Errorf("Server 2 = %T, want default *VidCloudExtractor", GetExtractor("Server 2"))
+	}
+
+	if err := SetAliases(nil); err != nil {
+		t.Fatalf("SetAliases(nil) error = %v", err)
+	}
+	if _, ok := GetExtractor("HD-1").(*MegaCloudExtractor); !ok {
+		t.Errorf("HD-1 after reset = %T, want *MegaCloudExtractor", GetExtractor("HD-1"))
+	}
+}
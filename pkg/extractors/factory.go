@@ -1,28 +1,93 @@
 package extractors
 
 import (
+	"fmt"
+	"sort"
 	"strings"
+	"sync"
 )
 
+// Extractor keys that server names can be aliased to
+const (
+	KeyMegaCloud = "megacloud"
+	KeyVidCloud  = "vidcloud"
+)
+
+// serverAliases maps lowercased server names to extractor keys. It is
+// consulted before the built-in name matching so a renamed server can be
+// pointed at the right extractor from config.
+var serverAliases = struct {
+	sync.RWMutex
+	m map[string]string
+}{}
+
+// SetAliases replaces the server name alias table. Names and keys are
+// matched case-insensitively. Aliases to unknown extractor keys are dropped
+// and reported in the returned error; the valid ones still take effect.
+func SetAliases(aliases map[string]string) error {
+	table := make(map[string]string, len(aliases))
+	var unknown []string
+	for name, key := range aliases {
+		name = strings.ToLower(strings.TrimSpace(name))
+		key = strings.ToLower(strings.TrimSpace(key))
+		if name == "" {
+			continue
+		}
+		if extractorForKey(key) == nil {
+			unknown = append(unknown, fmt.Sprintf("%s -> %s", name, key))
+			continue
+		}
+		table[name] = key
+	}
+
+	serverAliases.Lock()
+	serverAliases.m = table
+	serverAliases.Unlock()
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown extractor keys in aliases (want %s or %s): %s",
+			KeyMegaCloud, KeyVidCloud, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// extractorForKey returns the extractor for a known key, or nil
+func extractorForKey(key string) Extractor {
+	switch key {
+	case KeyMegaCloud:
+		return NewMegaCloudExtractor()
+	case KeyVidCloud:
+		return NewVidCloudExtractor()
+	}
+	return nil
+}
+
 // GetExtractor returns an appropriate extractor based on the server name or URL
 func GetExtractor(serverName string) Extractor {
+	return extractorForKey(ExtractorName(serverName))
+}
+
+// ExtractorName returns the key of the extractor used for serverName,
+// honoring the alias table before the built-in name matching
+func ExtractorName(serverName string) string {
 	serverLower := strings.ToLower(serverName)
 
+	serverAliases.RLock()
+	key, ok := serverAliases.m[strings.TrimSpace(serverLower)]
+	serverAliases.RUnlock()
+	if ok {
+		return key
+	}
+
 	// HD-1, HD-2, HD-3 servers from hianime use megacloud.blog
 	// These need the MegaCloud extractor (not dec.eatmynerds.live)
 	if strings.Contains(serverLower, "hd-") {
-		return NewMegaCloudExtractor()
-	}
-
-	// All other servers use videostr.net/streameeeeee.site embeds
-	// and work best with dec.eatmynerds.live (VidCloud extractor)
-	if strings.Contains(serverLower, "vidcloud") ||
-		strings.Contains(serverLower, "upcloud") ||
-		strings.Contains(serverLower, "akcloud") ||
-		strings.Contains(serverLower, "megacloud") {
-		return NewVidCloudExtractor()
+		return KeyMegaCloud
 	}
 
-	// Default to VidCloud extractor
-	return NewVidCloudExtractor()
+	// All other servers (vidcloud, upcloud, akcloud, megacloud) use
+	// videostr.net/streameeeeee.site embeds and work best with
+	// dec.eatmynerds.live (VidCloud extractor), which is also the default
+	return KeyVidCloud
 }
@@ -0,0 +1,160 @@
+package extractors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultMegaCloudKeys are the crawlr.cc provider keys used when no key
+// source is configured or it cannot be reached
+var defaultMegaCloudKeys = []string{"9D7F1B3E8"}
+
+// keySourceTTL is how long a fetched key list is used before refetching
+const keySourceTTL = time.Hour
+
+// keySourceBackoff is how long a key source that failed to answer is left
+// alone, so a dead source isn't fetched again on every extraction
+const keySourceBackoff = time.Minute
+
+// keySource caches the megacloud key list fetched from a remote URL so key
+// rotations don't need a new binary
+type keySource struct {
+	mu      sync.Mutex
+	url     string
+	keys    []string
+	fetched time.Time
+	failed  time.Time // When the last fetch failed
+	client  *http.Client
+
+	// flight shares one fetch between extractions that need the keys at once
+	flight singleflight.Group
+}
+
+var megaCloudKeySource = &keySource{
+	client: &http.Client{Timeout: 10 * time.Second},
+}
+
+// SetKeySource sets the URL the megacloud key list is fetched from. The URL
+// must serve a JSON array of keys, newest first. An empty URL goes back to
+// the baked-in keys.
+func SetKeySource(url string) {
+	megaCloudKeySource.mu.Lock()
+	defer megaCloudKeySource.mu.Unlock()
+
+	if megaCloudKeySource.url == url {
+		return
+	}
+	megaCloudKeySource.url = url
+	megaCloudKeySource.keys = nil
+	megaCloudKeySource.fetched = time.Time{}
+	megaCloudKeySource.failed = time.Time{}
+}
+
+// Keys returns the current key list. A fetched list is cached for
+// keySourceTTL; if refreshing fails the last fetched list is kept, and the
+// baked-in keys are used when nothing was ever fetched. After a failed fetch
+// the source isn't asked again for keySourceBackoff. The fetch runs without
+// holding the lock and is shared by concurrent callers; a caller whose ctx
+// ends stops waiting for it and gets the fallback keys.
+func (k *keySource) Keys(ctx context.Context) []string {
+	k.mu.Lock()
+	url := k.url
+	if url == "" {
+		k.mu.Unlock()
+		return defaultMegaCloudKeys
+	}
+	if (len(k.keys) > 0 && time.Since(k.fetched) < keySourceTTL) || time.Since(k.failed) < keySourceBackoff {
+		defer k.mu.Unlock()
+		return k.fallback()
+	}
+	k.mu.Unlock()
+
+	ch := k.flight.DoChan(url, func() (interface{}, error) {
+		keys, err := k.fetch(context.WithoutCancel(ctx), url)
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		if k.url != url {
+			// The source changed while this fetch ran
+			return keys, err
+		}
+		if err != nil {
+			k.failed = time.Now()
+			return nil, err
+		}
+		k.keys = keys
+		k.fetched = time.Now()
+		k.failed = time.Time{}
+		return keys, nil
+	})
+
+	select {
+	case res := <-ch:
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		if res.Err == nil && k.url == url {
+			return res.Val.([]string)
+		}
+		return k.fallback()
+	case <-ctx.Done():
+		k.mu.Lock()
+		defer k.mu.Unlock()
+		return k.fallback()
+	}
+}
+
+// fallback returns the last fetched keys, or the baked-in ones if there are
+// none. The caller must hold k.mu.
+func (k *keySource) fallback() []string {
+	if len(k.keys) > 0 {
+		return k.keys
+	}
+	return defaultMegaCloudKeys
+}
+
+// fetch downloads and validates the key list
+func (k *keySource) fetch(ctx context.Context, url string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating key source request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed fetching key source: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("key source returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading key source: %w", err)
+	}
+
+	var raw []string
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed parsing key source: %w", err)
+	}
+
+	var keys []string
+	for _, key := range raw {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("key source has no keys")
+	}
+	return keys, nil
+}
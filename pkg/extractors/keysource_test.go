@@ -0,0 +1,155 @@
+package extractors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKeySourceDefaults(t *testing.T) {
+	k := &keySource{client: http.DefaultClient}
+	if got := k.Keys(context.Background()); !reflect.DeepEqual(got, defaultMegaCloudKeys) {
+		t.Errorf("Keys() = %v, want baked-in %v", got, defaultMegaCloudKeys)
+	}
+}
+
+func TestKeySourceCachesFetchedKeys(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		_, _ = w.Write([]byte(`["NEWKEY", "", "OLDKEY"]`))
+	}))
+	defer srv.Close()
+
+	k := &keySource{url: srv.URL, client: srv.Client()}
+	want := []string{"NEWKEY", "OLDKEY"}
+	for i := 0; i < 2; i++ {
+		if got := k.Keys(context.Background()); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Keys() = %v, want %v", got, want)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("key source fetched %d times, want 1", hits)
+	}
+}
+
+func TestKeySourceKeepsLastKeysOnFailure(t *testing.T) {
+	var fail atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`["NEWKEY"]`))
+	}))
+	defer srv.Close()
+
+	k := &keySource{url: srv.URL, client: srv.Client()}
+	k.Keys(context.Background())
+
+	// Expire the cache and make the refresh fail
+	fail.Store(true)
+	k.fetched = time.Now().Add(-2 * keySourceTTL)
+	if got := k.Keys(context.Background()); !reflect.DeepEqual(got, []string{"NEWKEY"}) {
+		t.Errorf("Keys() = %v, want last fetched keys", got)
+	}
+
+	// A source that never answered falls back to the baked-in keys
+	k = &keySource{url: srv.URL, client: srv.Client()}
+	if got := k.Keys(context.Background()); !reflect.DeepEqual(got, defaultMegaCloudKeys) {
+		t.Errorf("Keys() = %v, want baked-in %v", got, defaultMegaCloudKeys)
+	}
+}
+
+func TestKeySourceBacksOffAfterFailure(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	k := &keySource{url: srv.URL, client: srv.Client()}
+	for i := 0; i < 3; i++ {
+		if got := k.Keys(context.Background()); !reflect.DeepEqual(got, defaultMegaCloudKeys) {
+			t.Fatalf("Keys() = %v, want baked-in %v", got, defaultMegaCloudKeys)
+		}
+	}
+	if hits != 1 {
+		t.Errorf("failing key source fetched %d times, want 1", hits)
+	}
+
+	k.failed = time.Now().Add(-2 * keySourceBackoff)
+	k.Keys(context.Background())
+	if hits != 2 {
+		t.Errorf("key source fetched %d times after the backoff, want 2", hits)
+	}
+}
+
+func TestKeySourceSlowFetch(t *testing.T) {
+	release := make(chan struct{})
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		<-release
+		_, _ = w.Write([]byte(`["NEWKEY"]`))
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	k := &keySource{url: srv.URL, client: srv.Client()}
+
+	// Callers give up on a slow source with their own context instead of
+	// queueing behind it, and share the one fetch that's running
+	start := time.Now()
+	done := make(chan []string, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			done <- k.Keys(ctx)
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		if got := <-done; !reflect.DeepEqual(got, defaultMegaCloudKeys) {
+			t.Errorf("Keys() = %v, want baked-in %v", got, defaultMegaCloudKeys)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Keys() returned after %v, want callers to stop waiting with ctx", elapsed)
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("key source fetched %d times, want 1", got)
+	}
+}
+
+func TestMegaCloudTriesNextKey(t *testing.T) {
+	crawlr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/NEWKEY") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"sources":[{"url":"https://cdn.example/master.m3u8"}]}`))
+	}))
+	defer crawlr.Close()
+
+	keys := &keySource{
+		url:     "unused",
+		keys:    []string{"OLDKEY", "NEWKEY"},
+		fetched: time.Now(),
+	}
+	m := &MegaCloudExtractor{client: crawlr.Client(), baseURL: crawlr.URL, keys: keys}
+
+	sources, err := m.Extract(context.Background(), "https://megacloud.blog/embed-2/e-1/abc")
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if len(sources.Sources) != 1 || sources.Sources[0].URL != "https://cdn.example/master.m3u8" {
+		t.Errorf("Extract() sources = %+v", sources.Sources)
+	}
+}
@@ -0,0 +1,78 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+// ErrDeadSource is returned when a server extracted stream URLs that don't
+// respond. The server is unusable even though extraction succeeded, so the
+// next server should be tried.
+var ErrDeadSource = errors.New("extracted source is not reachable")
+
+// livenessTimeout bounds how long a single source may take to respond
+const livenessTimeout = 10 * time.Second
+
+// maxLivenessRead is how much of a source body is read before giving up on it;
+// enough to know the host is really serving it
+const maxLivenessRead = 4 * 1024
+
+// CheckAlive requests each source in turn, with its referer, until one
+// answers with a 2xx status, returning ErrDeadSource if none does. Only the
+// start of each body is read.
+func CheckAlive(ctx context.Context, client *http.Client, sources *types.VideoSources) error {
+	if sources == nil || len(sources.Sources) == 0 {
+		return nil
+	}
+
+	var lastErr error
+	for _, source := range sources.Sources {
+		err := probeSource(ctx, client, source)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("%w: %v", ErrDeadSource, lastErr)
+}
+
+// probeSource GETs the start of a single source
+func probeSource(ctx context.Context, client *http.Client, source types.Source) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, livenessTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", source.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+	if source.Referer != "" {
+		req.Header.Set("Referer", source.Referer)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned status %d", source.URL, resp.StatusCode)
+	}
+
+	_, err = io.Copy(io.Discard, io.LimitReader(resp.Body, maxLivenessRead))
+	return err
+}
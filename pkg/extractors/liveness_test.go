@@ -0,0 +1,86 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+func TestCheckAlive(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/live.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Referer") != "https://embed.example/" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte("#EXTM3U\n"))
+	})
+	mux.HandleFunc("/dead.m3u8", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	live := types.Source{URL: srv.URL + "/live.m3u8", IsM3U8: true, Referer: "https://embed.example/"}
+	dead := types.Source{URL: srv.URL + "/dead.m3u8", IsM3U8: true}
+
+	tests := []struct {
+		name    string
+		sources *types.VideoSources
+		wantErr bool
+	}{
+		{name: "no sources", sources: &types.VideoSources{}},
+		{name: "live source", sources: &types.VideoSources{Sources: []types.Source{live}}},
+		{name: "dead then live", sources: &types.VideoSources{Sources: []types.Source{dead, live}}},
+		{name: "only dead", sources: &types.VideoSources{Sources: []types.Source{dead}}, wantErr: true},
+		{
+			name:    "missing referer",
+			sources: &types.VideoSources{Sources: []types.Source{{URL: live.URL, IsM3U8: true}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckAlive(context.Background(), srv.Client(), tt.sources)
+			if tt.wantErr != errors.Is(err, ErrDeadSource) {
+				t.Errorf("CheckAlive() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractFirstSkipsDeadSources(t *testing.T) {
+	dead := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer dead.Close()
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("#EXTM3U\n"))
+	}))
+	defer live.Close()
+
+	servers := []types.EpisodeServer{
+		{Name: "megacloud", URL: dead.URL + "/master.m3u8"},
+		{Name: "vidcloud", URL: live.URL + "/master.m3u8"},
+	}
+	extract := func(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
+		sources := &types.VideoSources{Sources: []types.Source{{URL: server.URL, IsM3U8: true}}}
+		if err := CheckAlive(ctx, http.DefaultClient, sources); err != nil {
+			return nil, err
+		}
+		return sources, nil
+	}
+
+	got, err := ExtractFirst(context.Background(), servers, 1, extract)
+	if err != nil {
+		t.Fatalf("ExtractFirst() error = %v", err)
+	}
+	if got.Sources[0].URL != servers[1].URL {
+		t.Errorf("ExtractFirst() picked %s, want the vidcloud source", got.Sources[0].URL)
+	}
+}
@@ -1,6 +1,7 @@
 package extractors
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,7 +15,9 @@ import (
 
 // MegaCloudExtractor handles extraction from MegaCloud/VidCloud/UpCloud servers using crawlr.cc
 type MegaCloudExtractor struct {
-	client *http.Client
+	client  *http.Client
+	baseURL string
+	keys    *keySource
 }
 
 // crawlrResponse represents the JSON response from crawlr.cc
@@ -45,21 +48,37 @@ func NewMegaCloudExtractor() *MegaCloudExtractor {
 		client: &http.Client{
 			Timeout: 30 * time.Second, // Increase timeout for crawlr.cc
 		},
+		baseURL: "https://crawlr.cc",
+		keys:    megaCloudKeySource,
 	}
 }
 
 // Extract extracts video sources and subtitles from a MegaCloud embed URL using crawlr.cc
-// This uses the external crawlr.cc service with provider ID mapping
-func (m *MegaCloudExtractor) Extract(targetURL string) (*types.VideoSources, error) {
-	// Get the provider ID for megacloud
-	// Hardcoded here to avoid dependency on config package
-	providerID := "9D7F1B3E8" // megacloud ID
+// This uses the external crawlr.cc service with provider ID mapping. Each
+// key from the key source is tried in turn so a rotated key falls through
+// to the next one.
+func (m *MegaCloudExtractor) Extract(ctx context.Context, targetURL string) (*types.VideoSources, error) {
+	var lastErr error
+	for _, key := range m.keys.Keys(ctx) {
+		sources, err := m.extractWithKey(ctx, key, targetURL)
+		if err == nil {
+			return sources, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
 
+// extractWithKey asks crawlr.cc for the sources of targetURL using one provider key
+func (m *MegaCloudExtractor) extractWithKey(ctx context.Context, providerID, targetURL string) (*types.VideoSources, error) {
 	// Construct the crawlr.cc URL
-	crawlrURL := fmt.Sprintf("https://crawlr.cc/%s?url=%s", providerID, url.QueryEscape(targetURL))
+	crawlrURL := fmt.Sprintf("%s/%s?url=%s", m.baseURL, providerID, url.QueryEscape(targetURL))
 
 	// Make request to crawlr.cc
-	req, err := http.NewRequest("GET", crawlrURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", crawlrURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating crawlr request: %w", err)
 	}
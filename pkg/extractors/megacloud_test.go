@@ -1,6 +1,7 @@
 package extractors
 
 import (
+	"context"
 	"strings"
 	"testing"
 )
@@ -15,7 +16,7 @@ func TestMegaCloudExtractor(t *testing.T) {
 	// Example embed URL (replace with a real one for testing)
 	embedURL := "https://megacloud.tv/embed-2/e-1/example-id"
 
-	data, err := extractor.Extract(embedURL)
+	data, err := extractor.Extract(context.Background(), embedURL)
 	if err != nil {
 		t.Fatalf("Failed to extract: %v", err)
 	}
@@ -55,7 +56,7 @@ func TestMegaCloudInvalidURL(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := extractor.Extract(tc.url)
+			_, err := extractor.Extract(context.Background(), tc.url)
 			if err == nil {
 				t.Error("Expected error for invalid URL, got nil")
 			}
@@ -0,0 +1,66 @@
+package extractors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+// ServerProbe is the outcome of trying to extract from a single server
+type ServerProbe struct {
+	Server    types.EpisodeServer
+	Available bool          // Extraction yielded at least one source
+	Latency   time.Duration // How long the attempt took
+	Err       error         // Why the server is unavailable, if known
+}
+
+// ProbeServers extracts from every server concurrently, running at most
+// concurrency extractions at a time, each bounded by timeout. Unlike
+// ExtractFirst it doesn't stop at the first working server, so the caller
+// learns which servers work before picking one. Results are in server order.
+func ProbeServers(ctx context.Context, servers []types.EpisodeServer, concurrency int, timeout time.Duration, extract ServerExtractFunc) []ServerProbe {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	probes := make([]ServerProbe, len(servers))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		probes[i].Server = server
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			probes[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			probeCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			sources, err := extract(probeCtx, server)
+			probes[i].Latency = time.Since(start)
+			switch {
+			case err != nil:
+				probes[i].Err = err
+			case sources == nil || len(sources.Sources) == 0:
+				probes[i].Err = fmt.Errorf("server %s: no sources extracted", server.Name)
+			default:
+				probes[i].Available = true
+			}
+		}()
+	}
+	wg.Wait()
+
+	return probes
+}
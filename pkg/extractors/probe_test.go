@@ -0,0 +1,73 @@
+package extractors
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+func TestProbeServers(t *testing.T) {
+	servers := []types.EpisodeServer{{Name: "slow"}, {Name: "broken"}, {Name: "empty"}, {Name: "fast"}, {Name: "hung"}}
+	extract := mockExtract(map[string]mockServer{
+		"slow":   {delay: 30 * time.Millisecond, url: "https://slow.example/master.m3u8"},
+		"broken": {err: errors.New("embed gone")},
+		"empty":  {},
+		"fast":   {url: "https://fast.example/master.m3u8"},
+		"hung":   {delay: time.Minute},
+	})
+
+	probes := ProbeServers(context.Background(), servers, 2, 100*time.Millisecond, extract)
+
+	if len(probes) != len(servers) {
+		t.Fatalf("got %d probes, want %d", len(probes), len(servers))
+	}
+	for i, probe := range probes {
+		if probe.Server.Name != servers[i].Name {
+			t.Errorf("probe %d is for %q, want %q", i, probe.Server.Name, servers[i].Name)
+		}
+	}
+
+	want := map[string]bool{"slow": true, "broken": false, "empty": false, "fast": true, "hung": false}
+	for _, probe := range probes {
+		if probe.Available != want[probe.Server.Name] {
+			t.Errorf("%s: available = %v, want %v (err %v)", probe.Server.Name, probe.Available, want[probe.Server.Name], probe.Err)
+		}
+		if !probe.Available && probe.Err == nil {
+			t.Errorf("%s: unavailable without an error", probe.Server.Name)
+		}
+	}
+
+	if probes[0].Latency < 30*time.Millisecond {
+		t.Errorf("slow latency = %v, want at least 30ms", probes[0].Latency)
+	}
+	if !errors.Is(probes[4].Err, context.DeadlineExceeded) {
+		t.Errorf("hung err = %v, want deadline exceeded", probes[4].Err)
+	}
+}
+
+func TestProbeServersBoundsConcurrency(t *testing.T) {
+	servers := make([]types.EpisodeServer, 6)
+	var running, peak atomic.Int32
+	extract := func(ctx context.Context, server types.EpisodeServer) (*types.VideoSources, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return &types.VideoSources{Sources: []types.Source{{URL: "ok"}}}, nil
+	}
+
+	ProbeServers(context.Background(), servers, 2, time.Second, extract)
+
+	if got := peak.Load(); got > 2 {
+		t.Errorf("peak concurrency = %d, want at most 2", got)
+	}
+}
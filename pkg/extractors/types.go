@@ -1,8 +1,13 @@
 package extractors
 
-import "github.com/justchokingaround/greg/pkg/types"
+import (
+	"context"
 
-// Extractor is the interface that all extractors must implement
+	"github.com/justchokingaround/greg/pkg/types"
+)
+
+// Extractor is the interface that all extractors must implement.
+// Implementations must abort their requests when ctx is cancelled.
 type Extractor interface {
-	Extract(url string) (*types.VideoSources, error)
+	Extract(ctx context.Context, url string) (*types.VideoSources, error)
 }
@@ -1,6 +1,7 @@
 package extractors
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -27,7 +28,7 @@ func NewVidCloudExtractor() *VidCloudExtractor {
 }
 
 // Extract extracts video sources from VidCloud/UpCloud/AkCloud URLs using dec.eatmynerds.live
-func (v *VidCloudExtractor) Extract(sourceURL string) (*types.VideoSources, error) {
+func (v *VidCloudExtractor) Extract(ctx context.Context, sourceURL string) (*types.VideoSources, error) {
 	// Extract referer from sourceURL
 	parsedURL, err := url.Parse(sourceURL)
 	referer := ""
@@ -39,7 +40,7 @@ func (v *VidCloudExtractor) Extract(sourceURL string) (*types.VideoSources, erro
 	decURL := fmt.Sprintf("https://dec.eatmynerds.live/?url=%s", url.QueryEscape(sourceURL))
 
 	// Make request to dec.eatmynerds.live
-	req, err := http.NewRequest("GET", decURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", decURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed creating dec request: %w", err)
 	}
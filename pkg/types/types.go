@@ -27,6 +27,7 @@ type SearchResult struct {
 	URL         string `json:"url,omitempty"`
 	ReleaseDate string `json:"releaseDate,omitempty"`
 	Type        string `json:"type,omitempty"`
+	Quality     string `json:"quality,omitempty"`
 }
 
 type SearchResults struct {
@@ -89,11 +90,23 @@ type MovieInfo struct {
 	Description             string    `json:"description,omitempty"`
 	Genres                  []string  `json:"genres,omitempty"`
 	ReleaseDate             string    `json:"releaseDate,omitempty"`
-	Rating                  string    `json:"rating,omitempty"`
+	Rating                  string    `json:"rating,omitempty"`      // As shown on the site, e.g. "7.5" or "N/A"
+	RatingFloat             float64   `json:"ratingFloat,omitempty"` // Rating parsed to 0-10, zero when missing or invalid
+	Collection              string    `json:"collection,omitempty"`  // Franchise or collection label, if the site shows one
 	Type                    string    `json:"type,omitempty"`
 	LastSeason              int       `json:"lastSeason,omitempty"`
 	TotalEpisodesLastSeason int       `json:"totalEpisodesLastSeason,omitempty"`
 	Episodes                []Episode `json:"episodes,omitempty"`
+	Seasons                 []Season  `json:"seasons,omitempty"`
+}
+
+// Season is a season of a show as numbered by the provider. Episode.Season
+// refers to Number.
+type Season struct {
+	ID        string `json:"id"`
+	Number    int    `json:"number"`
+	Title     string `json:"title,omitempty"`
+	PosterURL string `json:"poster_url,omitempty"` // Season artwork, if the provider lists any
 }
 
 type MangaChapter struct {